@@ -0,0 +1,105 @@
+package bnk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+import (
+	"util"
+	"wwise"
+)
+
+// ErrNoSections is returned when a SoundBank has no sections at all, as an
+// empty file does. A SoundBank with sections, but no wems, is opened.
+var ErrNoSections = errors.New("There are no sections within this file.")
+
+// ErrNoDataIndex is returned when the DATA section of a SoundBank is not
+// preceded by a DIDX section, without which its wems cannot be located.
+var ErrNoDataIndex = errors.New("The DATA section is not preceded by a DIDX " +
+	"section")
+
+// A SectionIdError is returned when a section is created from the header of a
+// section of another type.
+type SectionIdError struct {
+	// The identifier of the section being created, and that of the header.
+	Expected [4]byte
+	Actual   [4]byte
+}
+
+func (e *SectionIdError) Error() string {
+	return fmt.Sprintf("Expected %s header but got: %s", e.Expected, e.Actual)
+}
+
+// A RepeatedWemError is returned when the DIDX section of a SoundBank indexes
+// the same wem id more than once.
+type RepeatedWemError struct {
+	WemId uint32
+}
+
+func (e *RepeatedWemError) Error() string {
+	return fmt.Sprintf("%d is an illegal repeated wem ID in the DIDX", e.WemId)
+}
+
+// A DataLengthError is returned when a File with StrictDataLength writes a
+// DATA section whose length differs from the length computed from its wems.
+type DataLengthError struct {
+	// The length of the section computed from its wems, and the number of bytes
+	// that were written for it, excluding its header.
+	Expected int64
+	Written  int64
+}
+
+func (e *DataLengthError) Error() string {
+	return fmt.Sprintf("The DATA section should be %d bytes long, but %d bytes "+
+		"were written for it", e.Expected, e.Written)
+}
+
+// A LoopError is returned when the loop of a wem cannot be changed, as it is
+// not held by a Sound object that this package can edit.
+type LoopError struct {
+	// The index of the wem, and why its loop cannot be changed.
+	Index  int
+	Reason string
+	// The error that the reason is taken from, such as a *wwise.VersionError,
+	// or nil if there is none.
+	Err error
+}
+
+func (e *LoopError) Error() string {
+	return fmt.Sprintf("The loop of the wem at index %d cannot be changed: %s",
+		e.Index, e.Reason)
+}
+
+func (e *LoopError) Unwrap() error {
+	return e.Err
+}
+
+// checkSectionId returns a *SectionIdError if hdr is not the header of a
+// section with the identifier id.
+func checkSectionId(hdr *SectionHeader, id [4]byte) error {
+	if hdr.Identifier != id {
+		return &SectionIdError{id, hdr.Identifier}
+	}
+	return nil
+}
+
+// checkSectionLength returns a *wwise.BoundsError if the data of the section
+// with header hdr, which r is seeked to the start of, extends past the end of
+// r. The length is not checked if r does not report its size.
+func checkSectionLength(r io.Reader, hdr *SectionHeader) error {
+	sr, ok := r.(util.ReadSeekerAt)
+	if !ok {
+		return nil
+	}
+	start, err := sr.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if end := start + int64(hdr.Length); end > sr.Size() {
+		return &wwise.BoundsError{fmt.Sprintf("The %s section", hdr.Identifier),
+			end, sr.Size()}
+	}
+	return nil
+}
@@ -2,12 +2,11 @@
 package bnk
 
 import (
+	"context"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
 	"math"
-	"os"
 	"strings"
 )
 
@@ -25,13 +24,24 @@ const InfiniteLoops = 0
 // A File represents an open Wwise SoundBank.
 type File struct {
 	closer io.Closer
+	// The index of the first wem with each id.
+	indexOf map[uint32]int
 	// The list of sections in this SoundBank, in the order that they are expected
-	// to be found in the file.
+	// to be found in the file, followed by any trailing data.
 	sections          []Section
 	BankHeaderSection *BankHeaderSection
 	IndexSection      *DataIndexSection
 	DataSection       *DataSection
 	ObjectSection     *ObjectHierarchySection
+	// The number of bytes that the wems following a replaced wem are aligned to.
+	// This is 16, as required by SoundBanks, unless the wems of the SoundBank
+	// were written with a smaller alignment, which is then kept. It may be set to
+	// a larger multiple of 16 for games that expect it.
+	WemAlignment int64
+	// If true, WriteTo recomputes the length of the DATA section from the wems
+	// that are written, rather than trusting the length kept as wems are
+	// replaced, and fails if the bytes written for the section differ from it.
+	StrictDataLength bool
 }
 
 // LoopValue describes the loop parameters of a given audio object.
@@ -46,92 +56,232 @@ type LoopValue struct {
 // NewFile creates a new File for access Wwise SoundBank files. The file is
 // expected to start at position 0 in the io.ReaderAt.
 func NewFile(r io.ReaderAt) (*File, error) {
+	return newFile(r, nil)
+}
+
+// newFile is like NewFile, but reports its progress to m as each section, and
+// each object of the HIRC section, is parsed.
+func newFile(r io.ReaderAt, m *wwise.OpenMonitor) (*File, error) {
 	bnk := new(File)
 
-	sr := util.NewResettingReader(r, 0, math.MaxInt64)
+	sr := util.NewResettingReader(r, 0, util.SizeOf(r))
+	if err := m.Parsed(0); err != nil {
+		return nil, err
+	}
 	for {
+		hdrStart, _ := sr.Seek(0, io.SeekCurrent)
 		hdr := new(SectionHeader)
 		err := binary.Read(sr, binary.LittleEndian, hdr)
+		if err == io.EOF {
+			break
+		}
+		if len(bnk.sections) > 0 && (err == io.ErrUnexpectedEOF ||
+			err == nil && !isSectionHeader(hdr, hdrStart, sr.Size())) {
+			// The bytes after the last section do not begin another one, so they are
+			// kept as they are.
+			sr.Seek(hdrStart, io.SeekStart)
+			bnk.sections = append(bnk.sections, NewTrailingData(sr))
+			break
+		}
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, err
+			return nil, wwise.WrapParseError(err, "Section header", hdrStart)
 		}
+		start, _ := sr.Seek(0, io.SeekCurrent)
 
+		// Errors are reported with the section, and the offset of its header.
+		part := identifierName(hdr.Identifier) + " section"
 		switch id := hdr.Identifier; id {
 		case bkhdHeaderId:
 			sec, err := hdr.NewBankHeaderSection(sr)
 			if err != nil {
-				return nil, err
+				return nil, wwise.WrapParseError(err, part, hdrStart)
 			}
 			bnk.BankHeaderSection = sec
 			bnk.sections = append(bnk.sections, sec)
 		case didxHeaderId:
 			sec, err := hdr.NewDataIndexSection(sr)
 			if err != nil {
-				return nil, err
+				return nil, wwise.WrapParseError(err, part, hdrStart)
 			}
 			bnk.IndexSection = sec
 			bnk.sections = append(bnk.sections, sec)
 		case dataHeaderId:
 			sec, err := hdr.NewDataSection(sr, bnk.IndexSection)
 			if err != nil {
-				return nil, err
+				return nil, wwise.WrapParseError(err, part, hdrStart)
 			}
 			bnk.DataSection = sec
 			bnk.sections = append(bnk.sections, sec)
 		case hircHeaderId:
-			sec, err := hdr.NewObjectHierarchySection(sr)
+			sec, err := hdr.newObjectHierarchySection(sr, m)
 			if err != nil {
-				return nil, err
+				return nil, wwise.WrapParseError(err, part, hdrStart)
 			}
 			bnk.ObjectSection = sec
 			bnk.sections = append(bnk.sections, sec)
 		default:
 			sec, err := hdr.NewUnknownSection(sr)
 			if err != nil {
-				return nil, err
+				return nil, wwise.WrapParseError(err, part, hdrStart)
 			}
 			bnk.sections = append(bnk.sections, sec)
 		}
+		// Continue from the end of the section given by its length, whether or not
+		// parsing it read all of it.
+		pos, _ := sr.Seek(start+int64(hdr.Length), io.SeekStart)
+		if err := m.SectionParsed(pos); err != nil {
+			return nil, err
+		}
 	}
 
-	if bnk.DataSection == nil || len(bnk.Wems()) == 0 {
-		return nil, errors.New("There are no wems stored within this file.")
+	if len(bnk.sections) == 0 {
+		return nil, ErrNoSections
 	}
+	bnk.indexOf = wwise.IndexWems(bnk.Wems())
+	bnk.WemAlignment = sourceAlignment(bnk.Wems())
 
 	return bnk, nil
 }
 
+// sourceAlignment returns the alignment that wems were written with: the
+// largest power of two, up to wemAlignmentBytes, that divides the offset of
+// every wem with data. Nearly every SoundBank is aligned to wemAlignmentBytes,
+// but those that are not keep their own alignment, so that a replacement is
+// padded as the wems around it are.
+func sourceAlignment(wems []*wwise.Wem) int64 {
+	alignment := int64(wemAlignmentBytes)
+	for _, wem := range wems {
+		if wem.Descriptor.Length == 0 {
+			continue
+		}
+		for int64(wem.Descriptor.Offset)%alignment != 0 {
+			alignment /= 2
+		}
+	}
+	return alignment
+}
+
+// isSectionHeader returns true if hdr, read from offset start of a SoundBank of
+// size bytes, plausibly begins a section: either it has the identifier of a
+// section that this package decodes, or its identifier is made of upper case
+// letters and digits, as those of Wwise are, and the section ends within the
+// SoundBank.
+func isSectionHeader(hdr *SectionHeader, start, size int64) bool {
+	switch hdr.Identifier {
+	case bkhdHeaderId, didxHeaderId, dataHeaderId, hircHeaderId:
+		return true
+	}
+	for _, c := range hdr.Identifier {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return start+SECTION_HEADER_BYTES+int64(hdr.Length) <= size
+}
+
+// The suggestion made when the wems of a SoundBank would not fit within the
+// 32 bit offsets and lengths of its DIDX and DATA sections.
+const overflowHint = "stream the largest wems from a File Package rather " +
+	"than embedding them, or split the wems across several SoundBanks"
+
 // WriteTo writes the full contents of this File to the Writer specified by w.
+// It returns a *wwise.OverflowError, and writes nothing, if the wems would not
+// fit within the 32 bit offsets and lengths of the SoundBank. With
+// StrictDataLength, it returns a *DataLengthError if the DATA section is not
+// written with the length computed from its wems.
 func (bnk *File) WriteTo(w io.Writer) (written int64, err error) {
+	data := bnk.DataSection
+	if data == nil {
+		// Without a DATA section, there are no offsets to overflow.
+		return bnk.writeSections(w, nil, 0)
+	}
+	end := data.end
+	if data.region == nil {
+		if err := wwise.CheckOffsets(bnk, overflowHint); err != nil {
+			return 0, err
+		}
+		end = wwise.DataEnd(bnk)
+	}
+	if bnk.StrictDataLength {
+		if end, err = data.layoutLength(); err != nil {
+			return 0, err
+		}
+	}
+	if end > math.MaxUint32 {
+		return 0, &wwise.OverflowError{"The length of the DATA section", end,
+			overflowHint}
+	}
+	if bnk.StrictDataLength {
+		data.Header.Length = uint32(end)
+	}
+	return bnk.writeSections(w, data, end)
+}
+
+// writeSections writes every section to w. With StrictDataLength, it returns a
+// *DataLengthError if data, which may be nil, is not written with the length
+// end.
+func (bnk *File) writeSections(w io.Writer, data *DataSection,
+	end int64) (written int64, err error) {
 	for _, s := range bnk.sections {
 		n, err := s.WriteTo(w)
 		if err != nil {
 			return written, err
 		}
 		written += n
+		if bnk.StrictDataLength && data != nil && s == Section(data) &&
+			n != SECTION_HEADER_BYTES+end {
+			return written, &DataLengthError{end, n - SECTION_HEADER_BYTES}
+		}
 	}
 	return
 }
 
-// Open opens the File at the specified path using os.Open and prepares it for
-// use as a Wwise SoundBank file.
+// Open opens the File at the specified path using util.OpenMapped and prepares
+// it for use as a Wwise SoundBank file.
 func Open(path string) (*File, error) {
-	f, err := os.Open(path)
+	return OpenContext(context.Background(), path, nil)
+}
+
+// OpenContext is like Open, but calls progress, if it is not nil, as each
+// section of the SoundBank, and each object of its HIRC section, is parsed.
+// Parsing stops once ctx is done, and the error of ctx is returned.
+func OpenContext(ctx context.Context, path string,
+	progress wwise.OpenProgressFunc) (*File, error) {
+	f, err := util.OpenMapped(path)
 	if err != nil {
 		return nil, err
 	}
-	bnk, err := NewFile(f)
+	cache := util.NewIndexCache(path, f)
+	bnk, err := newFile(cache, wwise.NewOpenMonitor(ctx, f.Size(), progress))
 	if err != nil {
 		f.Close()
 		return nil, err
 	}
+	// The cache is only a convenience, so failing to write it is not an error.
+	cache.Save()
 	bnk.closer = f
 	return bnk, nil
 }
 
+// An OpenResult is the outcome of opening a SoundBank with OpenAsync.
+type OpenResult struct {
+	File *File
+	Err  error
+}
+
+// OpenAsync opens the SoundBank at path in a new goroutine, as OpenContext
+// does, and returns a channel that receives the result once it is opened.
+// progress is called from that goroutine.
+func OpenAsync(ctx context.Context, path string,
+	progress wwise.OpenProgressFunc) <-chan OpenResult {
+	c := make(chan OpenResult, 1)
+	go func() {
+		bnk, err := OpenContext(ctx, path, progress)
+		c <- OpenResult{bnk, err}
+	}()
+	return c
+}
+
 // Close closes the File
 // If the File was created using NewFile directly instead of Open,
 // Close has no effect.
@@ -151,8 +301,40 @@ func (bnk *File) Wems() []*wwise.Wem {
 	return bnk.DataSection.Wems
 }
 
+// MarkModified marks the BKHD, DIDX and HIRC sections of this File as
+// modified, so that they are encoded from their fields when the File is
+// written, rather than copied from the bytes they were read from. Call it after
+// changing their fields, or the descriptors of wems, directly.
+func (bnk *File) MarkModified() {
+	if bnk.BankHeaderSection != nil {
+		bnk.BankHeaderSection.MarkModified()
+	}
+	if bnk.IndexSection != nil {
+		bnk.IndexSection.MarkModified()
+	}
+	if bnk.ObjectSection != nil {
+		bnk.ObjectSection.MarkModified()
+	}
+}
+
 func (bnk *File) ReplaceWems(rs ...*wwise.ReplacementWem) {
-	surplus := wwise.ReplaceWems(bnk, wemAlignmentBytes, rs...)
+	if bnk.DataSection == nil {
+		// There are no wems to replace.
+		return
+	}
+	if bnk.DataSection.region != nil {
+		// The wems cannot be moved, as they are not stored one after another.
+		rs = wwise.SortReplacements(rs)
+		bnk.DataSection.replaceAppended(bnk.WemAlignment, rs...)
+		bnk.IndexSection.MarkModified()
+		return
+	}
+	surplus := wwise.ReplaceWems(bnk, bnk.WemAlignment, rs...)
+	if bnk.IndexSection != nil {
+		// The lengths and offsets of the wems are changed through their
+		// descriptors, which are shared with the index.
+		bnk.IndexSection.MarkModified()
+	}
 
 	if surplus != 0 {
 		// Update the length of the DATA header to account for the change in size.
@@ -161,9 +343,33 @@ func (bnk *File) ReplaceWems(rs ...*wwise.ReplacementWem) {
 }
 
 func (bnk *File) DataStart() uint32 {
+	if bnk.DataSection == nil {
+		return 0
+	}
 	return bnk.DataSection.DataStart
 }
 
+func (bnk *File) IndexOf(id uint32) int {
+	if i, ok := bnk.indexOf[id]; ok {
+		return i
+	}
+	return -1
+}
+
+func (bnk *File) WemById(id uint32) *wwise.Wem {
+	if i := bnk.IndexOf(id); i >= 0 {
+		return bnk.Wems()[i]
+	}
+	return nil
+}
+
+func (bnk *File) DescriptorById(id uint32) *wwise.WemDescriptor {
+	if wem := bnk.WemById(id); wem != nil {
+		return wem.Descriptor
+	}
+	return nil
+}
+
 // LoopOf returns the loop value of the wem stored in this SoundBank at index i.
 // Returns a default LoopValue{false, 0} if the index is invalid.
 func (bnk *File) LoopOf(i int) LoopValue {
@@ -181,42 +387,37 @@ func (bnk *File) LoopOf(i int) LoopValue {
 	if bnk.ObjectSection == nil {
 		return value
 	}
+	bnk.ObjectSection.decode()
 
 	times, ok := bnk.ObjectSection.loopOf[desc.WemId]
 	return LoopValue{ok, times}
 }
 
 // ReplaceLoopOf replaces the loop value of the wem stored in this SoundBank at
-// index i with the new value. This method is idempotent.
-func (bnk *File) ReplaceLoopOf(i int, loop LoopValue) {
-	if bnk.DataSection == nil {
-		return
-	}
-
-	wems := bnk.DataSection.Wems
-	if i < 0 || i >= len(wems) {
-		return
+// index i with the new value. This method is idempotent. A *LoopError is
+// returned, and nothing is changed, if the loop of the wem cannot be changed,
+// as described by CheckLoop.
+func (bnk *File) ReplaceLoopOf(i int, loop LoopValue) error {
+	object, err := bnk.loopObject(i)
+	if err != nil {
+		return err
 	}
-
 	desc := bnk.DataSection.Wems[i].Descriptor
-	if bnk.ObjectSection == nil {
-		return
-	}
 
 	oldValue, oldLoops := bnk.ObjectSection.loopOf[desc.WemId]
 	// Return if the loop values aren't changing.
 	if oldLoops == false && loop.Loops == false || ((oldLoops == loop.Loops) &&
 		oldValue == loop.Value) {
-		return
-	}
-
-	// The HIRC object that maps to the target wem.
-	object, ok := bnk.ObjectSection.wemToObject[desc.WemId]
-	if !ok {
-		return
+		return nil
 	}
 	// The sound structure that maps to the target wem.
 	ss := object.Structure
+	if loop.Loops && !oldLoops && ss.ParameterCount == math.MaxUint8 {
+		return &LoopError{i, "its Sound object already has as many parameters " +
+			"as it can hold", nil}
+	}
+	object.MarkModified()
+	bnk.ObjectSection.MarkModified()
 
 	if loop.Loops == false {
 		// We are removing looping from an audio object that already has a loop.
@@ -233,7 +434,7 @@ func (bnk *File) ReplaceLoopOf(i int, loop LoopValue) {
 				object.Descriptor.Length -= lengthDecrease
 
 				delete(bnk.ObjectSection.loopOf, desc.WemId)
-				return
+				return nil
 			}
 		}
 	} else {
@@ -245,7 +446,7 @@ func (bnk *File) ReplaceLoopOf(i int, loop LoopValue) {
 				if paramType == parameterLoopType {
 					ss.ParameterValues[i] = lbs
 					bnk.ObjectSection.loopOf[desc.WemId] = loop.Value
-					return
+					return nil
 				}
 			}
 		} else { // oldLoops == false
@@ -261,6 +462,7 @@ func (bnk *File) ReplaceLoopOf(i int, loop LoopValue) {
 			object.Descriptor.Length += lengthIncrease
 		}
 	}
+	return nil
 }
 
 func (bnk *File) String() string {
@@ -278,7 +480,7 @@ func (bnk *File) String() string {
 	fmt.Fprint(b, title)
 	fmt.Fprintln(b, strings.Repeat("-", len(title)-1))
 
-	for i, wem := range bnk.DataSection.Wems {
+	for i, wem := range bnk.Wems() {
 		desc := wem.Descriptor
 		l := bnk.LoopOf(i)
 		loop := -1
@@ -4,9 +4,20 @@ package bnk
 // Large system tests for the bnk package.
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 import (
@@ -19,6 +30,9 @@ const (
 
 	simpleSoundBank  = "simple.bnk"
 	complexSoundBank = "complex.bnk"
+	// The complex SoundBank with its first wem replaced by that of the simple
+	// SoundBank.
+	replacedLargerSoundBank = "0_replaced_with_larger.bnk"
 
 	loopNoneSoundBank     = "loop_none.bnk"
 	loop2SoundBank        = "loop_2.bnk"
@@ -114,7 +128,13 @@ func TestReplaceLoopOfCases(t *testing.T) {
 			t.Error(err)
 			t.FailNow()
 		}
-		bnk.ReplaceLoopOf(0, c.loopChange)
+		if !bnk.CanLoop(0) {
+			t.Errorf("Expected the loop of the first wem of %s to be editable",
+				c.input)
+		}
+		if err := bnk.ReplaceLoopOf(0, c.loopChange); err != nil {
+			t.Error(err)
+		}
 
 		expect, err := os.Open(filepath.Join(testDir, c.expected))
 		if err != nil {
@@ -126,6 +146,23 @@ func TestReplaceLoopOfCases(t *testing.T) {
 	}
 }
 
+func TestReplaceWemsWithLargerAlignment(t *testing.T) {
+	const alignment = 2048
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bnk.WemAlignment = alignment
+	bnk.ReplaceWems(&wwise.ReplacementWem{util.NewConstantReader(100), 0, 100})
+	reread := rereadFile(t, bnk)
+
+	offset := reread.Wems()[1].Descriptor.Offset
+	if offset%alignment != 0 {
+		t.Errorf("The wem following the replacement has an offset of 0x%X, "+
+			"which is not byte aligned by %d", offset, alignment)
+	}
+}
+
 func rereadFile(t *testing.T, org *File) *File {
 	orgBytes := new(bytes.Buffer)
 	_, err := org.WriteTo(orgBytes)
@@ -187,3 +224,1544 @@ func assertReplacedFileCorrectness(t *testing.T, bnkPath string,
 	}
 	return
 }
+
+func TestHierarchyReachesEveryWem(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	reached := make(map[uint32]bool)
+	var visit func(n *ObjectNode)
+	visit = func(n *ObjectNode) {
+		if n.IsSound() {
+			reached[n.WemId] = true
+		}
+		for _, child := range n.Children {
+			visit(child)
+		}
+	}
+	for _, root := range bnk.Hierarchy() {
+		if root.Type != eventObjectId {
+			continue
+		}
+		visit(root)
+	}
+
+	for i, wem := range bnk.Wems() {
+		if !reached[wem.Descriptor.WemId] {
+			t.Errorf("The wem at index %d with id %d is not reachable from any "+
+				"event", i, wem.Descriptor.WemId)
+		}
+	}
+}
+
+func TestWemFormat(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	format, err := bnk.Wems()[0].Format()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if format.Codec != wwise.CodecVorbis || format.Channels != 2 ||
+		format.SampleRate != 44100 {
+		t.Errorf("Expected a 2 channel, 44100 Hz Vorbis wem but got a %d "+
+			"channel, %d Hz %s wem", format.Channels, format.SampleRate,
+			format.CodecName())
+	}
+	if format.Samples() != 267264 {
+		t.Errorf("Expected 267264 samples but got %d", format.Samples())
+	}
+
+	if ms := format.Mismatches(format); len(ms) != 0 {
+		t.Errorf("Expected a format to match itself, got %v", ms)
+	}
+	mono := *format
+	mono.Channels = 1
+	if ms := format.Mismatches(&mono); len(ms) != 1 {
+		t.Errorf("Expected a single mismatch in channels, got %v", ms)
+	}
+}
+
+func TestObjectTypeCounts(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	counts := bnk.ObjectTypeCounts()
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	if total != int(bnk.ObjectSection.ObjectCount) {
+		t.Errorf("Expected %d objects in total, found %d",
+			bnk.ObjectSection.ObjectCount, total)
+	}
+	if counts[soundObjectId] != len(bnk.Wems()) {
+		t.Errorf("Expected %d sound objects, found %d", len(bnk.Wems()),
+			counts[soundObjectId])
+	}
+	if n := bnk.LoopableWemCount(); n != len(bnk.Wems()) {
+		t.Errorf("Expected %d loopable wems, found %d", len(bnk.Wems()), n)
+	}
+}
+
+func TestObjectsAreDecodedWhenQueried(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+
+	if len(bnk.ObjectSection.wemToObject) != 0 {
+		t.Error("Expected the HIRC objects to not be decoded when opened")
+	}
+	bnk.LoopOf(0)
+	if n := len(bnk.ObjectSection.wemToObject); n != len(bnk.Wems()) {
+		t.Errorf("Expected %d decoded sound objects, found %d", len(bnk.Wems()),
+			n)
+	}
+}
+
+func TestConcurrentLoopOfDecodesOnce(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, loop2SoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+
+	// The objects are decoded by whichever goroutine first reads a loop, while
+	// the others wait for it to finish.
+	const readers = 8
+	loops := make(chan LoopValue, readers)
+	for i := 0; i < readers; i++ {
+		go func() { loops <- bnk.LoopOf(0) }()
+	}
+	for i := 0; i < readers; i++ {
+		if loop := <-loops; loop != (LoopValue{true, 2}) {
+			t.Errorf("Expected the first wem to loop twice, got %+v", loop)
+		}
+	}
+}
+
+func TestTestedSoundBanksHaveNoWarnings(t *testing.T) {
+	for _, name := range []string{simpleSoundBank, complexSoundBank} {
+		bnk, err := Open(filepath.Join(testDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ws := bnk.Warnings(); len(ws) != 0 {
+			t.Errorf("Expected no warnings for %s, got %v", name, ws)
+		}
+		bnk.Close()
+	}
+}
+
+func TestDiffWemsFindsReplacedWem(t *testing.T) {
+	old, err := Open(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	updated, err := Open(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer updated.Close()
+
+	d, err := wwise.DiffWems(old, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Empty() {
+		t.Errorf("Expected no differences between identical files, got %+v", d)
+	}
+
+	updated.ReplaceWems(&wwise.ReplacementWem{util.NewConstantReader(100), 0,
+		100})
+	d, err = wwise.DiffWems(old, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := old.Wems()[0].Descriptor.WemId
+	if len(d.Changed) != 1 || d.Changed[0] != id || len(d.Added) != 0 ||
+		len(d.Removed) != 0 {
+		t.Errorf("Expected only wem %d to have changed, got %+v", id, d)
+	}
+}
+
+func TestMatchReplacementsById(t *testing.T) {
+	simple, err := Open(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer simple.Close()
+	complex, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer complex.Close()
+
+	last := len(complex.Wems()) - 1
+	rs := []*wwise.ReplacementWem{
+		{util.NewConstantReader(100), last, 100},
+	}
+	matched, missing := wwise.MatchReplacements(complex, complex, rs)
+	if len(matched) != 1 || matched[0].WemIndex != last || len(missing) != 0 {
+		t.Errorf("Expected the replacement to match wem %d, got %d matched and "+
+			"%v missing", last, len(matched), missing)
+	}
+	id := complex.Wems()[last].Descriptor.WemId
+	matched, missing = wwise.MatchReplacements(complex, simple, rs)
+	if len(matched) != 0 || len(missing) != 1 || missing[0] != id {
+		t.Errorf("Expected wem %d to be missing, got %d matched and %v missing",
+			id, len(matched), missing)
+	}
+}
+
+func TestWriteToFileIsEqual(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+	last := len(bnk.Wems()) - 1
+	bnk.ReplaceWems(
+		&wwise.ReplacementWem{util.NewConstantReader(100), 0, 100},
+		&wwise.ReplacementWem{util.NewConstantReader(5000), last, 5000})
+	want := new(bytes.Buffer)
+	if _, err := bnk.WriteTo(want); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files can be written to at any position, so their wems are written
+	// concurrently.
+	f, err := ioutil.TempFile("", "wwiseutil-*.bnk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	for _, withProgress := range []bool{false, true} {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		var n int64
+		if withProgress {
+			n, err = wwise.WriteWithProgress(bnk, f, func(done, total int64) bool {
+				return true
+			})
+		} else {
+			n, err = bnk.WriteTo(f)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(want.Len()) || !bytes.Equal(got, want.Bytes()) {
+			t.Errorf("Writing to a file (with progress: %t) did not produce the "+
+				"same SoundBank as writing to a buffer", withProgress)
+		}
+	}
+}
+
+// BenchmarkCopyWems compares copying every wem of a SoundBank with io.Copy,
+// which allocates a buffer for each wem, to util.Copy, which shares pooled
+// buffers.
+func BenchmarkCopyWems(b *testing.B) {
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer bnk.Close()
+	// Hide the ReadFrom method of ioutil.Discard, so that the copy buffer is
+	// used.
+	w := struct{ io.Writer }{ioutil.Discard}
+
+	copies := []struct {
+		name string
+		copy func(io.Writer, io.Reader) (int64, error)
+	}{
+		{"io.Copy", io.Copy},
+		{"util.Copy", util.Copy},
+	}
+	for _, c := range copies {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, wem := range bnk.Wems() {
+					if _, err := c.copy(w, wem); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWriteTo(b *testing.B) {
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer bnk.Close()
+	w := struct{ io.Writer }{ioutil.Discard}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := bnk.WriteTo(w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestConcurrentWritesAreEqual(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+	want := new(bytes.Buffer)
+	if _, err := bnk.WriteTo(want); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every goroutine reads the same wems and objects, each with its own
+	// readers.
+	const writers = 4
+	got := make([]*bytes.Buffer, writers)
+	errs := make(chan error, writers)
+	for i := range got {
+		got[i] = new(bytes.Buffer)
+		go func(buf *bytes.Buffer) {
+			_, err := bnk.WriteTo(buf)
+			errs <- err
+		}(got[i])
+	}
+	for range got {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, buf := range got {
+		if !bytes.Equal(buf.Bytes(), want.Bytes()) {
+			t.Errorf("Write %d did not produce the same SoundBank", i)
+		}
+	}
+}
+
+func BenchmarkNewDataIndexSection(b *testing.B) {
+	// Some SoundBanks index tens of thousands of wems.
+	const count = 50000
+	data := new(bytes.Buffer)
+	for i := uint32(0); i < count; i++ {
+		desc := wwise.WemDescriptor{i + 1, i * 16, 16}
+		binary.Write(data, binary.LittleEndian, &desc)
+	}
+	hdr := &SectionHeader{didxHeaderId, uint32(data.Len())}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := hdr.NewDataIndexSection(bytes.NewReader(data.Bytes()))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLargeReplacementIsStreamed(t *testing.T) {
+	util.SkipIfShort(t)
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+
+	// A replacement of several gigabytes, which is never held in memory.
+	const size = 3 << 30
+	bnk.ReplaceWems(&wwise.ReplacementWem{util.NewConstantReader(size), 0, size})
+	wwise.AssertWriteIsStreamed(t, bnk, size)
+}
+
+func TestUnmodifiedSectionsAreCopied(t *testing.T) {
+	path := filepath.Join(testDir, complexSoundBank)
+	original, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bnk, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+
+	// Until a section is marked as modified, it is copied from the file rather
+	// than encoded from its fields.
+	id := bnk.BankHeaderSection.Descriptor.BankId
+	bnk.BankHeaderSection.Descriptor.BankId = id + 1
+	got := new(bytes.Buffer)
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), original) {
+		t.Error("Expected an unmodified SoundBank to be written unchanged")
+	}
+
+	bnk.BankHeaderSection.MarkModified()
+	got.Reset()
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	written, err := NewFile(bytes.NewReader(got.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.BankHeaderSection.Descriptor.BankId != id+1 {
+		t.Errorf("Expected bank id %d once the BKHD was marked as modified, "+
+			"got %d", id+1, written.BankHeaderSection.Descriptor.BankId)
+	}
+
+	// A wem descriptor is shared with the DIDX section, which is written once
+	// the File is marked as modified.
+	desc := bnk.Wems()[0].Descriptor
+	desc.WemId++
+	bnk.MarkModified()
+	got.Reset()
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	written, err = NewFile(bytes.NewReader(got.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wemId := written.Wems()[0].Descriptor.WemId; wemId != desc.WemId {
+		t.Errorf("Expected wem id %d once the File was marked as modified, got "+
+			"%d", desc.WemId, wemId)
+	}
+}
+
+func TestOpenContextReportsProgress(t *testing.T) {
+	path := filepath.Join(testDir, complexSoundBank)
+	var last wwise.OpenProgress
+	bnk, err := OpenContext(context.Background(), path,
+		func(p wwise.OpenProgress) {
+			if p.Parsed < last.Parsed || p.Sections < last.Sections {
+				t.Errorf("Progress went back from %+v to %+v", last, p)
+			}
+			last = p
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+	if last.Parsed != last.Size || last.Sections != len(bnk.Sections()) {
+		t.Errorf("Expected %d bytes and %d sections to be parsed, got %+v",
+			last.Size, len(bnk.Sections()), last)
+	}
+
+	// Parsing stops once the context is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	res := <-OpenAsync(ctx, path, func(p wwise.OpenProgress) {
+		if p.Sections == 1 {
+			cancel()
+		}
+	})
+	if res.Err != context.Canceled || res.File != nil {
+		t.Errorf("Expected opening to be cancelled, got %v", res.Err)
+	}
+}
+
+func TestReplacementPastFourGiBIsRejected(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+
+	// The DATA section would be longer than its 32 bit length can hold.
+	const size = 1<<32 - 16
+	bnk.ReplaceWems(&wwise.ReplacementWem{util.NewConstantReader(size), 0, size})
+	_, err = bnk.WriteTo(ioutil.Discard)
+	if oe, ok := err.(*wwise.OverflowError); !ok || oe.Value <= math.MaxUint32 ||
+		oe.Hint == "" {
+		t.Errorf("Expected an OverflowError for a DATA section longer than 4 GiB, "+
+			"got %v", err)
+	}
+}
+
+func TestOpenAllOpensEveryContainer(t *testing.T) {
+	names := []string{simpleSoundBank, complexSoundBank, "loop_2.bnk",
+		"loop_none.bnk"}
+	var paths []string
+	for _, name := range names {
+		paths = append(paths, filepath.Join(testDir, name))
+	}
+	open := func(ctx context.Context, path string) (wwise.Container, error) {
+		return OpenContext(ctx, path, nil)
+	}
+
+	var mu sync.Mutex
+	wemCounts := make([]int, len(paths))
+	wwise.OpenAll(context.Background(), paths, 2, open, func(i int,
+		ctn wwise.Container, err error) {
+		if err != nil {
+			t.Errorf("Could not open %s: %s", paths[i], err)
+			return
+		}
+		defer ctn.Close()
+		mu.Lock()
+		wemCounts[i] = len(ctn.Wems())
+		mu.Unlock()
+	})
+	for i, path := range paths {
+		bnk, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bnk.Wems()) != wemCounts[i] {
+			t.Errorf("Expected %s to be opened with %d wems, got %d", path,
+				len(bnk.Wems()), wemCounts[i])
+		}
+		bnk.Close()
+	}
+
+	// Once the context is done, the remaining containers fail to open.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	failed := 0
+	wwise.OpenAll(ctx, paths, 0, open, func(i int, ctn wwise.Container,
+		err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err == context.Canceled && ctn == nil {
+			failed++
+		}
+	})
+	if failed != len(paths) {
+		t.Errorf("Expected %d containers to be cancelled, got %d", len(paths),
+			failed)
+	}
+}
+
+func TestWemsCanBeFoundById(t *testing.T) {
+	ctn, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctn.Close()
+	wwise.AssertLookupsMatchWems(t, ctn)
+}
+
+func TestMalformedSectionsReturnErrors(t *testing.T) {
+	hdr := &SectionHeader{didxHeaderId, 0}
+	_, err := hdr.NewBankHeaderSection(util.NewResettingReader(
+		bytes.NewReader(nil), 0, 0))
+	if _, ok := err.(*SectionIdError); !ok {
+		t.Errorf("Expected a *SectionIdError but got: %v", err)
+	}
+
+	data := new(bytes.Buffer)
+	for i := uint32(0); i < 2; i++ {
+		desc := wwise.WemDescriptor{1, i * 16, 16}
+		binary.Write(data, binary.LittleEndian, &desc)
+	}
+	hdr = &SectionHeader{didxHeaderId, uint32(data.Len())}
+	_, err = hdr.NewDataIndexSection(data)
+	if rerr, ok := err.(*RepeatedWemError); !ok || rerr.WemId != 1 {
+		t.Errorf("Expected a *RepeatedWemError for wem 1 but got: %v", err)
+	}
+
+	// A DATA section without a DIDX section before it.
+	bank := new(bytes.Buffer)
+	binary.Write(bank, binary.LittleEndian, &SectionHeader{dataHeaderId, 0})
+	_, err = NewFile(bytes.NewReader(bank.Bytes()))
+	if perr, ok := err.(*wwise.ParseError); !ok || perr.Err != ErrNoDataIndex {
+		t.Errorf("Expected %v but got: %v", ErrNoDataIndex, err)
+	}
+
+	// A file with no sections.
+	if _, err := NewFile(bytes.NewReader(nil)); err != ErrNoSections {
+		t.Errorf("Expected %v but got: %v", ErrNoSections, err)
+	}
+}
+
+// FuzzNewFile checks that no SoundBank, however malformed, makes the parser
+// panic, or read or write more than the SoundBank holds. The corpus in
+// testdata/fuzz holds SoundBanks crafted to trip each of the bounds checks.
+func FuzzNewFile(f *testing.F) {
+	data, err := ioutil.ReadFile(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bnk, err := NewFile(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		bnk.Hierarchy()
+		bnk.Warnings()
+		n, err := bnk.WriteTo(ioutil.Discard)
+		if err == nil && n > int64(len(data)) {
+			t.Errorf("Wrote %d bytes of a %d byte SoundBank", n, len(data))
+		}
+	})
+}
+
+func TestSectionLengthMismatchesAreKept(t *testing.T) {
+	section := func(id [4]byte, data []byte) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, &SectionHeader{id, uint32(len(data))})
+		b.Write(data)
+		return b.Bytes()
+	}
+	le := func(vs ...uint32) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+	// A DIDX section with 2 bytes after its last entry, and a HIRC section with
+	// 3 bytes after its last object.
+	didx := append(le(1, 0, 4, 2, 4, 4), 0xAA, 0xBB)
+	hirc := append(le(1), 0x01)
+	hirc = append(append(hirc, le(4, 7)...), 0xCC, 0xDD, 0xEE)
+	var data []byte
+	data = append(data, section(bkhdHeaderId, le(134, 1))...)
+	data = append(data, section(didxHeaderId, didx)...)
+	data = append(data, section(dataHeaderId, []byte("RIFFWAVE"))...)
+	data = append(data, section(hircHeaderId, hirc)...)
+
+	bnk, err := NewFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LengthMismatch{{"DIDX", 26, 24}, {"HIRC", 16, 13}}
+	if got := bnk.LengthMismatches(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected mismatches %v but got: %v", want, got)
+	}
+	if len(bnk.Wems()) != 2 {
+		t.Fatalf("Expected 2 wems but got %d", len(bnk.Wems()))
+	}
+
+	// The unparsed bytes are written back even once the DIDX section is encoded
+	// from its fields.
+	bnk.IndexSection.MarkModified()
+	bnk.ObjectSection.MarkModified()
+	got := new(bytes.Buffer)
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Error("The written SoundBank differs from the one that was read")
+	}
+}
+
+func TestVerifyFindsCorruptSave(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+	bnk.ReplaceWems(&wwise.ReplacementWem{util.NewConstantReader(100), 0, 100})
+
+	f, err := ioutil.TempFile("", "wwiseutil-*.bnk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = bnk.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := func(ctx context.Context, path string) (wwise.Container, error) {
+		return OpenContext(ctx, path, nil)
+	}
+	ctx := context.Background()
+	if err := wwise.Verify(ctx, bnk, f.Name(), open, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the first byte of the replaced wem.
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[int64(bnk.DataStart())+int64(bnk.Wems()[0].Descriptor.Offset)] ^= 0xFF
+	if err := ioutil.WriteFile(f.Name(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = wwise.Verify(ctx, bnk, f.Name(), open, nil)
+	if _, ok := err.(*wwise.VerifyError); !ok {
+		t.Errorf("Expected a *wwise.VerifyError but got: %v", err)
+	}
+}
+
+// A failingReader is a replacement that cannot be read.
+type failingReader struct{}
+
+func (failingReader) ReadAt(p []byte, off int64) (int, error) {
+	return 0, errors.New("The replacement could not be read")
+}
+
+func TestFailedSaveKeepsExistingFile(t *testing.T) {
+	original, err := ioutil.ReadFile(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "wwiseutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, simpleSoundBank)
+	if err := ioutil.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bnk, err := NewFile(bytes.NewReader(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bnk.ReplaceWems(&wwise.ReplacementWem{failingReader{}, 0, 100})
+	err = util.WriteFileAtomic(path, func(f *os.File) error {
+		_, err := bnk.WriteTo(f)
+		return err
+	})
+	if err == nil {
+		t.Fatal("Expected the save to fail")
+	}
+
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Error("The failed save changed the existing file")
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 1 {
+		t.Errorf("Expected only the existing file to remain, but found %d files",
+			len(fis))
+	}
+}
+
+func TestOverwrittenSourceIsBackedUp(t *testing.T) {
+	original, err := ioutil.ReadFile(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "wwiseutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, simpleSoundBank)
+	if err := ioutil.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other.bnk")
+	if util.NeedsBackup(path, other) {
+		t.Error("A file outside of game data should only be backed up when it " +
+			"is the source")
+	}
+	if !util.NeedsBackup(path, path) {
+		t.Fatal("The source should be backed up before it is overwritten")
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, want := range []string{
+		path + ".20200102-030405.bak",
+		path + ".20200102-030405-2.bak",
+	} {
+		backup, err := util.BackupFile(path, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backup != want {
+			t.Errorf("Expected the backup %s but got %s", want, backup)
+		}
+		got, err := ioutil.ReadFile(backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, original) {
+			t.Error("The backup differs from the original file")
+		}
+	}
+
+	game := filepath.Join(dir, "nativePC", "sound", simpleSoundBank)
+	if err := os.MkdirAll(filepath.Dir(game), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(game, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !util.NeedsBackup(game, other) {
+		t.Error("A file within game data should be backed up before it is " +
+			"overwritten")
+	}
+}
+
+func TestTrailingDataIsKept(t *testing.T) {
+	original, err := ioutil.ReadFile(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	footers := map[string][]byte{
+		"shorter than a header": []byte("END!!"),
+		"not an identifier":     []byte("made by a tool, v2"),
+		"past the end":          []byte("FOOT\xff\xff\x00\x00"),
+	}
+	for name, footer := range footers {
+		data := append(append([]byte(nil), original...), footer...)
+		bnk, err := NewFile(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if n := bnk.TrailingDataSize(); n != int64(len(footer)) {
+			t.Errorf("%s: Expected %d trailing bytes but got %d", name, len(footer),
+				n)
+		}
+		if len(bnk.Warnings()) == 0 {
+			t.Errorf("%s: Expected a warning about the trailing data", name)
+		}
+
+		got := new(bytes.Buffer)
+		if _, err := bnk.WriteTo(got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), data) {
+			t.Errorf("%s: The written SoundBank differs from the one that was read",
+				name)
+		}
+		bnk.ReplaceWems(&wwise.ReplacementWem{util.NewConstantReader(100), 0,
+			100})
+		got.Reset()
+		if _, err := bnk.WriteTo(got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(got.Bytes(), footer) {
+			t.Errorf("%s: The trailing data was not written after a replacement",
+				name)
+		}
+	}
+}
+
+func TestParseErrorsHaveOffsets(t *testing.T) {
+	section := func(id [4]byte, data []byte) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, &SectionHeader{id, uint32(len(data))})
+		b.Write(data)
+		return b.Bytes()
+	}
+	le := func(vs ...uint32) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+	// A HIRC section whose second object, at offset 0x49, claims to be longer
+	// than the section.
+	hirc := append(le(2), 0x01)
+	hirc = append(append(hirc, le(4, 7)...), 0x01)
+	hirc = append(hirc, le(100, 8)...)
+	var data []byte
+	data = append(data, section(bkhdHeaderId, le(134, 1))...)
+	data = append(data, section(didxHeaderId, le(1, 0, 8))...)
+	data = append(data, section(dataHeaderId, []byte("RIFFWAVE"))...)
+	data = append(data, section(hircHeaderId, hirc)...)
+
+	_, err := NewFile(bytes.NewReader(data))
+	perr, ok := err.(*wwise.ParseError)
+	if !ok {
+		t.Fatalf("Expected a *wwise.ParseError but got: %v", err)
+	}
+	if perr.Part != "HIRC object 1" || perr.Offset != 0x49 {
+		t.Errorf("Expected the error to be at HIRC object 1 at 0x49 but got: %v",
+			err)
+	}
+	if _, ok := perr.Err.(*wwise.BoundsError); !ok {
+		t.Errorf("Expected a *wwise.BoundsError but got: %v", perr.Err)
+	}
+
+	// A SoundBank cut off within its DIDX section.
+	_, err = NewFile(bytes.NewReader(data[:30]))
+	want := "DIDX section at 0x10: "
+	if err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("Expected an error starting with \"%s\" but got: %v", want, err)
+	}
+}
+
+func TestPlaceholderWems(t *testing.T) {
+	section := func(id [4]byte, data []byte) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, &SectionHeader{id, uint32(len(data))})
+		b.Write(data)
+		return b.Bytes()
+	}
+	le := func(vs ...uint32) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+	// A placeholder with an offset of 0 between two wems, and one after the last
+	// wem that is already in order.
+	didx := le(1, 0, 8, 2, 0, 0, 3, 16, 8, 4, 24, 0)
+	wems := []byte("AAAAAAAA\x00\x00\x00\x00\x00\x00\x00\x00BBBBBBBB")
+	var data []byte
+	data = append(data, section(bkhdHeaderId, le(132, 1))...)
+	data = append(data, section(didxHeaderId, didx)...)
+	data = append(data, section(dataHeaderId, wems)...)
+
+	bnk, err := NewFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []wwise.WemDescriptor{{1, 0, 8}, {2, 16, 0}, {3, 16, 8}, {4, 24, 0}}
+	for i, wem := range bnk.Wems() {
+		if *wem.Descriptor != want[i] {
+			t.Errorf("Expected wem %d to be %v but got %v", i, want[i],
+				*wem.Descriptor)
+		}
+	}
+	if len(bnk.Warnings()) != 1 {
+		t.Errorf("Expected a warning about the moved placeholder but got: %v",
+			bnk.Warnings())
+	}
+	if _, err := bnk.Wems()[1].Format(); err != wwise.ErrEmptyWem {
+		t.Errorf("Expected %v but got: %v", wwise.ErrEmptyWem, err)
+	}
+	got := new(bytes.Buffer)
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Error("The written SoundBank differs from the one that was read")
+	}
+
+	// Give the placeholder data, and empty the first wem, whose offset is aligned
+	// so that it needs no padding.
+	bnk.ReplaceWems(
+		&wwise.ReplacementWem{bytes.NewReader([]byte("CCCCC")), 1, 5},
+		&wwise.ReplacementWem{bytes.NewReader(nil), 0, 0})
+	got.Reset()
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	written, err := NewFile(bytes.NewReader(got.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []wwise.WemDescriptor{{1, 0, 0}, {2, 0, 5}, {3, 16, 8}, {4, 24, 0}}
+	contents := []string{"", "CCCCC", "BBBBBBBB", ""}
+	for i, wem := range written.Wems() {
+		if *wem.Descriptor != want[i] {
+			t.Errorf("Expected written wem %d to be %v but got %v", i, want[i],
+				*wem.Descriptor)
+		}
+		b, err := ioutil.ReadAll(wem.NewReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != contents[i] {
+			t.Errorf("Expected written wem %d to hold %q but got %q", i,
+				contents[i], b)
+		}
+	}
+}
+
+func TestOverlappingWemsAreKept(t *testing.T) {
+	section := func(id [4]byte, data []byte) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, &SectionHeader{id, uint32(len(data))})
+		b.Write(data)
+		return b.Bytes()
+	}
+	le := func(vs ...uint32) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+	// The second wem shares its first 8 bytes with the first.
+	didx := le(1, 0, 16, 2, 8, 16, 3, 24, 8)
+	wems := []byte("AAAAAAAAABABABABBBBBBBBBCCCCCCCC")
+	var data []byte
+	data = append(data, section(bkhdHeaderId, le(132, 1))...)
+	data = append(data, section(didxHeaderId, didx)...)
+	data = append(data, section(dataHeaderId, wems)...)
+
+	bnk, err := NewFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Overlap{{1, 0}}
+	if got := bnk.Overlaps(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected overlaps %v but got: %v", want, got)
+	}
+	if len(bnk.Warnings()) != 1 {
+		t.Errorf("Expected a warning about the overlap but got: %v",
+			bnk.Warnings())
+	}
+	got := new(bytes.Buffer)
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Error("The written SoundBank differs from the one that was read")
+	}
+
+	// Replacing the second wem leaves the data of the first as it was, and
+	// writes the replacement once, after the other wems, padded to the 8 bytes
+	// that the wems are aligned to.
+	bnk.ReplaceWems(&wwise.ReplacementWem{bytes.NewReader([]byte("XXXX")), 1,
+		4})
+	got.Reset()
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != len(data)+8 {
+		t.Errorf("Expected %d bytes to be written but got %d", len(data)+8,
+			got.Len())
+	}
+	written, err := NewFile(bytes.NewReader(got.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := []string{"AAAAAAAAABABABAB", "XXXX", "CCCCCCCC"}
+	for i, wem := range written.Wems() {
+		b, err := ioutil.ReadAll(wem.NewReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != contents[i] {
+			t.Errorf("Expected written wem %d to hold %q but got %q", i,
+				contents[i], b)
+		}
+	}
+}
+
+func TestUnalignedSoundBankKeepsItsAlignment(t *testing.T) {
+	section := func(id [4]byte, data []byte) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, &SectionHeader{id, uint32(len(data))})
+		b.Write(data)
+		return b.Bytes()
+	}
+	le := func(vs ...uint32) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+	// Wems aligned to 4 bytes, rather than the 16 that SoundBanks require.
+	didx := le(1, 0, 6, 2, 8, 4, 3, 12, 3)
+	wems := []byte("AAAAAA\x00\x00BBBBCCC")
+	var data []byte
+	data = append(data, section(bkhdHeaderId, le(132, 1))...)
+	data = append(data, section(didxHeaderId, didx)...)
+	data = append(data, section(dataHeaderId, wems)...)
+
+	bnk, err := NewFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bnk.WemAlignment != 4 {
+		t.Errorf("Expected an alignment of 4 but got %d", bnk.WemAlignment)
+	}
+	got := new(bytes.Buffer)
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Error("The written SoundBank differs from the one that was read")
+	}
+
+	// A shorter replacement is padded to the alignment of the SoundBank, so the
+	// wem that follows it keeps its offset.
+	bnk.ReplaceWems(&wwise.ReplacementWem{bytes.NewReader([]byte("DD")), 1, 2})
+	got.Reset()
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	written, err := NewFile(bytes.NewReader(got.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []wwise.WemDescriptor{{1, 0, 6}, {2, 8, 2}, {3, 12, 3}}
+	for i, wem := range written.Wems() {
+		if *wem.Descriptor != want[i] {
+			t.Errorf("Expected written wem %d to be %v but got %v", i, want[i],
+				*wem.Descriptor)
+		}
+	}
+}
+
+func TestHierarchyProblems(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, loopNoneSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+	bnk.ReplaceLoopOf(0, LoopValue{true, 3})
+	if problems := bnk.HierarchyProblems(); len(problems) != 0 {
+		t.Errorf("Expected no problems after editing a loop but got: %v",
+			problems)
+	}
+	bnk.ObjectSection.ObjectCount++
+	bnk.ObjectSection.Header.Length++
+	if problems := bnk.HierarchyProblems(); len(problems) != 2 {
+		t.Errorf("Expected problems with the object count and section length "+
+			"but got: %v", problems)
+	}
+
+	section := func(id [4]byte, data []byte) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, &SectionHeader{id, uint32(len(data))})
+		b.Write(data)
+		return b.Bytes()
+	}
+	le := func(vs ...uint32) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+	// An event whose only action is not in the SoundBank.
+	hirc := le(1)
+	hirc = append(hirc, eventObjectId)
+	hirc = append(hirc, le(9, 100)...)
+	hirc = append(hirc, 1)
+	hirc = append(hirc, le(200)...)
+	var data []byte
+	data = append(data, section(bkhdHeaderId, le(132, 1))...)
+	data = append(data, section(didxHeaderId, le(1, 0, 4))...)
+	data = append(data, section(dataHeaderId, []byte("AAAA"))...)
+	data = append(data, section(hircHeaderId, hirc)...)
+	bnk, err = NewFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HierarchyProblem{{100, "Event 100 plays the action 200, which " +
+		"is not in this SoundBank"}}
+	if got := bnk.HierarchyProblems(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected problems %v but got: %v", want, got)
+	}
+	if got := bnk.Warnings(); len(got) != 1 ||
+		got[0] != (wwise.Warning{wwise.SeverityProblem, wwise.WarningHierarchy,
+			want[0].Description}) {
+		t.Errorf("Expected the problem to be warned of but got: %v", got)
+	}
+}
+
+func TestStrictDataLength(t *testing.T) {
+	for _, name := range []string{simpleSoundBank, complexSoundBank} {
+		bnk, err := Open(filepath.Join(testDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer bnk.Close()
+		bnk.StrictDataLength = true
+		original, err := ioutil.ReadFile(filepath.Join(testDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := new(bytes.Buffer)
+		if _, err := bnk.WriteTo(got); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !bytes.Equal(got.Bytes(), original) {
+			t.Errorf("%s: the written SoundBank differs from the one that was read",
+				name)
+		}
+		bnk.ReplaceWems(&wwise.ReplacementWem{bytes.NewReader([]byte("XXXXX")),
+			0, 5})
+		if _, err := bnk.WriteTo(ioutil.Discard); err != nil {
+			t.Errorf("%s: expected the replaced SoundBank to be written, got: %s",
+				name, err)
+		}
+		if got := int64(bnk.DataSection.Header.Length); got != wwise.DataEnd(bnk) {
+			t.Errorf("%s: expected a DATA length of %d but got %d", name,
+				wwise.DataEnd(bnk), got)
+		}
+
+		// A replacement that holds fewer bytes than its length is caught once
+		// the section is written.
+		bnk.ReplaceWems(&wwise.ReplacementWem{bytes.NewReader([]byte("XXXX")),
+			0, 16})
+		_, err = bnk.WriteTo(ioutil.Discard)
+		if e, ok := err.(*DataLengthError); !ok || e.Expected-e.Written != 12 {
+			t.Errorf("%s: expected a DataLengthError 12 bytes short, got %v",
+				name, err)
+		}
+
+		// A descriptor that disagrees with the layout of the wems is rejected
+		// before anything is written.
+		bnk.Wems()[0].Descriptor.Offset += 16
+		n, err := bnk.WriteTo(ioutil.Discard)
+		if err == nil || n != 0 {
+			t.Errorf("%s: expected a misplaced wem to be rejected, wrote %d bytes",
+				name, n)
+		}
+	}
+}
+
+func TestLoopsOfUnsupportedWemsAreNotChanged(t *testing.T) {
+	bnk, err := Open(filepath.Join(testDir, loopNoneSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bnk.Close()
+	original, err := ioutil.ReadFile(filepath.Join(testDir, loopNoneSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range []int{-1, len(bnk.Wems())} {
+		if _, ok := bnk.ReplaceLoopOf(index, LoopValue{true, 2}).(*LoopError); !ok {
+			t.Errorf("Expected a LoopError for index %d", index)
+		}
+	}
+	// The parameters of Sound objects may be laid out differently in versions
+	// that have not been tested.
+	bnk.BankHeaderSection.Descriptor.Version = 134
+	if bnk.CanLoop(0) {
+		t.Error("Expected loops not to be editable in an untested version")
+	}
+	err = bnk.ReplaceLoopOf(0, LoopValue{true, 2})
+	if e, ok := err.(*LoopError); !ok || e.Index != 0 {
+		t.Errorf("Expected a LoopError for an untested version but got: %v", err)
+	}
+	var verr *wwise.VersionError
+	if !errors.As(err, &verr) || verr.Version != 134 {
+		t.Errorf("Expected a VersionError for version 134 but got: %v", err)
+	}
+	if err := bnk.CheckVersion(wwise.EditWems); err != nil {
+		t.Errorf("Expected wems to be replaceable in any version but got: %v", err)
+	}
+	bnk.BankHeaderSection.Descriptor.Version = 132
+	bnk.ObjectSection.decode()
+	bnk.ObjectSection.wemToObject = make(map[uint32]*SfxVoiceSoundObject)
+	if err := bnk.CheckLoop(0); err == nil {
+		t.Error("Expected an error for a wem that no Sound object plays")
+	}
+
+	got := new(bytes.Buffer)
+	if _, err := bnk.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), original) {
+		t.Error("Expected the SoundBank to be unchanged")
+	}
+}
+
+func TestWritesAreReproducible(t *testing.T) {
+	simple, err := Open(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer simple.Close()
+	wem, err := ioutil.ReadAll(simple.Wems()[0].NewReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := ioutil.ReadFile(filepath.Join(testDir,
+		replacedLargerSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replacement := &wwise.ReplacementWem{bytes.NewReader(wem), 0,
+		int64(len(wem))}
+	earlier := &wwise.ReplacementWem{bytes.NewReader([]byte("XXXX")), 0, 4}
+	// However the replacement is given, and however the SoundBank is written,
+	// the same bytes are written each time.
+	for _, rs := range [][]*wwise.ReplacementWem{
+		{replacement},
+		{earlier, replacement},
+	} {
+		bnk, err := Open(filepath.Join(testDir, complexSoundBank))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer bnk.Close()
+		bnk.ReplaceWems(rs...)
+		for i := 0; i < 2; i++ {
+			got := new(bytes.Buffer)
+			if _, err := bnk.WriteTo(got); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), golden) {
+				t.Errorf("Write %d of %d replacement(s) to a buffer differs from %s",
+					i+1, len(rs), replacedLargerSoundBank)
+			}
+
+			f, err := ioutil.TempFile("", "wwiseutil-*.bnk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			_, err = bnk.WriteTo(f)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b, err := ioutil.ReadFile(f.Name()); err != nil ||
+				!bytes.Equal(b, golden) {
+				t.Errorf("Write %d of %d replacement(s) to a file differs from %s",
+					i+1, len(rs), replacedLargerSoundBank)
+			}
+		}
+	}
+}
+
+func TestSoundBanksWithoutWems(t *testing.T) {
+	section := func(id [4]byte, data []byte) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, &SectionHeader{id, uint32(len(data))})
+		b.Write(data)
+		return b.Bytes()
+	}
+	bank := func(sections ...[]byte) []byte {
+		return bytes.Join(sections, nil)
+	}
+	bkhd := section(bkhdHeaderId, []byte{132, 0, 0, 0, 1, 0, 0, 0})
+	banks := map[string][]byte{
+		"header only": bank(bkhd),
+		"empty DIDX and DATA": bank(bkhd, section(didxHeaderId, nil),
+			section(dataHeaderId, nil)),
+		"DATA without indexed wems": bank(bkhd, section(didxHeaderId, nil),
+			section(dataHeaderId, []byte("RIFF"))),
+		"HIRC without objects": bank(bkhd, section(hircHeaderId, make([]byte, 4))),
+		"empty HIRC":           bank(bkhd, section(hircHeaderId, nil)),
+	}
+	for name, data := range banks {
+		t.Run(name, func(t *testing.T) {
+			bnk, err := NewFile(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bnk.Wems()) != 0 {
+				t.Errorf("Expected no wems but got %d", len(bnk.Wems()))
+			}
+			if problems := bnk.HierarchyProblems(); len(problems) != 0 {
+				t.Errorf("Expected no hierarchy problems but got: %v", problems)
+			}
+			_ = bnk.String()
+			bnk.ReplaceWems()
+
+			// The SoundBank is written back as it was read, even once its sections
+			// are encoded from their fields.
+			for _, strict := range []bool{false, true} {
+				bnk.StrictDataLength = strict
+				if bnk.IndexSection != nil {
+					bnk.IndexSection.MarkModified()
+				}
+				if bnk.ObjectSection != nil {
+					bnk.ObjectSection.MarkModified()
+				}
+				got := new(bytes.Buffer)
+				if _, err := bnk.WriteTo(got); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got.Bytes(), data) {
+					t.Errorf("The written SoundBank differs from the one that was read "+
+						"with StrictDataLength %t", strict)
+				}
+			}
+		})
+	}
+}
+
+func TestStructureRoundTrip(t *testing.T) {
+	util.SkipIfShort(t)
+
+	section := func(id [4]byte, data []byte) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, &SectionHeader{id, uint32(len(data))})
+		b.Write(data)
+		return b.Bytes()
+	}
+	le := func(vs ...uint32) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+	bkhd := section(bkhdHeaderId, le(132, 1, 7))
+	banks := map[string][]byte{
+		"placeholders": bytes.Join([][]byte{bkhd,
+			section(didxHeaderId, le(1, 0, 8, 2, 0, 0, 3, 16, 8, 4, 24, 0)),
+			section(dataHeaderId,
+				[]byte("AAAAAAAA\x00\x00\x00\x00\x00\x00\x00\x00BBBBBBBB"))}, nil),
+		"overlapping wems": bytes.Join([][]byte{bkhd,
+			section(didxHeaderId, le(1, 0, 16, 2, 8, 16, 3, 24, 8)),
+			section(dataHeaderId, []byte("AAAAAAAAABABABABBBBBBBBBCCCCCCCC"))},
+			nil),
+		"padding that is not zero": bytes.Join([][]byte{bkhd,
+			section(didxHeaderId, le(1, 0, 5, 2, 8, 3)),
+			section(dataHeaderId, []byte("AAAAAxyzBBB"))}, nil),
+		"unknown sections and trailing data": bytes.Join([][]byte{bkhd,
+			section(didxHeaderId, nil), section(dataHeaderId, []byte("RIFF")),
+			section(hircHeaderId, nil), section([4]byte{'S', 'T', 'I', 'D'},
+				[]byte("names")), []byte("footer")}, nil),
+	}
+	names := []string{simpleSoundBank, complexSoundBank, replacedLargerSoundBank,
+		loopNoneSoundBank, loop2SoundBank, loop23SoundBank, loopInfinitySoundBank}
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(testDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		banks[name] = data
+	}
+
+	for name, data := range banks {
+		t.Run(name, func(t *testing.T) {
+			bnk, err := NewFile(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "bank.json")
+			if err := bnk.ExportStructure(path); err != nil {
+				t.Fatal(err)
+			}
+			s, err := ReadStructure(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := new(bytes.Buffer)
+			if _, err := s.WriteTo(got); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), data) {
+				t.Error("The SoundBank written from its structure differs from the " +
+					"one that was exported")
+			}
+		})
+	}
+}
+
+func TestStructureDecodesObjects(t *testing.T) {
+	b, err := Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	path := filepath.Join(t.TempDir(), "complex.json")
+	if err := b.ExportStructure(path); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ReadStructure(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Wems) != len(b.Wems()) {
+		t.Errorf("Expected %d wems but got %d", len(b.Wems()), len(s.Wems))
+	}
+	for i, wem := range s.Wems {
+		if wem.File != fmt.Sprintf("complex_media/%d.wem", wem.Id) {
+			t.Errorf("Unexpected file %q for wem %d", wem.File, i+1)
+			break
+		}
+	}
+	counts := make(map[string]int)
+	for _, obj := range s.section(hircHeaderId).Objects {
+		switch {
+		case obj.Sound != nil:
+			counts["sound"]++
+		case obj.Event != nil:
+			counts["event"]++
+		case obj.Action != nil:
+			counts["action"]++
+		}
+	}
+	types := b.ObjectTypeCounts()
+	expected := map[string]int{"sound": types[soundObjectId],
+		"event": types[eventObjectId], "action": types[actionObjectId]}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected decoded objects %v but got %v", expected, counts)
+	}
+}
+
+func TestStructureEdits(t *testing.T) {
+	util.SkipIfShort(t)
+
+	// Adding a loop parameter to the Sound gives the SoundBank that loops twice.
+	b, err := Open(filepath.Join(testDir, loopNoneSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	path := filepath.Join(t.TempDir(), "loop.json")
+	if err := b.ExportStructure(path); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ReadStructure(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range s.section(hircHeaderId).Objects {
+		if obj.Sound != nil {
+			obj.Sound.Parameters = append(obj.Sound.Parameters,
+				&StructureParameter{parameterLoopType, 2})
+		}
+	}
+	assertStructureWrites(t, s, loop2SoundBank)
+
+	// Replacing the file of the first wem gives the SoundBank with it replaced,
+	// with the wems that follow it moved.
+	b, err = Open(filepath.Join(testDir, complexSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	path = filepath.Join(t.TempDir(), "complex.json")
+	if err := b.ExportStructure(path); err != nil {
+		t.Fatal(err)
+	}
+	s, err = ReadStructure(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simple, err := Open(filepath.Join(testDir, simpleSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer simple.Close()
+	_, err = wwise.ExportWem(simple.Wems()[0], s.file(s.Wems[0].File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStructureWrites(t, s, replacedLargerSoundBank)
+}
+
+// assertStructureWrites checks that s is written as the SoundBank name.
+func assertStructureWrites(t *testing.T, s *Structure, name string) {
+	t.Helper()
+	expected, err := ioutil.ReadFile(filepath.Join(testDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(bytes.Buffer)
+	if _, err := s.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), expected) {
+		t.Errorf("The edited structure is not written as %s", name)
+	}
+}
@@ -0,0 +1,303 @@
+// Package bnk implements access to the Wwise SoundBank file format.
+package bnk
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+import (
+	"util"
+)
+
+// The identifiers of the HIRC object types.
+const (
+	stateObjectId           = 0x01
+	actionObjectId          = 0x03
+	eventObjectId           = 0x04
+	randomSequenceObjectId  = 0x05
+	switchContainerObjectId = 0x06
+	actorMixerObjectId      = 0x07
+	audioBusObjectId        = 0x08
+	blendContainerObjectId  = 0x09
+	musicSegmentObjectId    = 0x0A
+	musicTrackObjectId      = 0x0B
+	musicSwitchObjectId     = 0x0C
+	musicPlaylistObjectId   = 0x0D
+	attenuationObjectId     = 0x0E
+	dialogueEventObjectId   = 0x0F
+	effectObjectId          = 0x12
+	auxiliaryBusObjectId    = 0x14
+)
+
+// The last SoundBank version that stores the action count of an event as a
+// 32-bit integer. Later versions store it as a variable length integer.
+const lastFixedActionCountVersion = 122
+
+// The offset of the direct parent id within the 10 bytes following the effect
+// container of a node's base parameters.
+const parentIdOffset = 5
+
+var objectTypeNames = map[byte]string{
+	stateObjectId:           "State",
+	soundObjectId:           "Sound",
+	actionObjectId:          "Action",
+	eventObjectId:           "Event",
+	randomSequenceObjectId:  "Random/Sequence Container",
+	switchContainerObjectId: "Switch Container",
+	actorMixerObjectId:      "Actor-Mixer",
+	audioBusObjectId:        "Audio Bus",
+	blendContainerObjectId:  "Blend Container",
+	musicSegmentObjectId:    "Music Segment",
+	musicTrackObjectId:      "Music Track",
+	musicSwitchObjectId:     "Music Switch Container",
+	musicPlaylistObjectId:   "Music Playlist Container",
+	attenuationObjectId:     "Attenuation",
+	dialogueEventObjectId:   "Dialogue Event",
+	effectObjectId:          "Effect",
+	auxiliaryBusObjectId:    "Auxiliary Bus",
+}
+
+// An ObjectNode is a single HIRC object within the decoded object hierarchy of
+// a SoundBank.
+type ObjectNode struct {
+	Id   uint32
+	Type byte
+	// The id of the wem played by this object. This is only valid if this is a
+	// Sound object.
+	WemId uint32
+	// The objects below this one in the hierarchy. For events, these are its
+	// actions; for actions, this is its target; and for containers, these are
+	// the objects contained within it.
+	Children []*ObjectNode
+}
+
+// TypeName returns a human readable name for the type of this object.
+func (n *ObjectNode) TypeName() string {
+	return ObjectTypeName(n.Type)
+}
+
+// IsSound returns true if this object is a Sound object that plays a wem.
+func (n *ObjectNode) IsSound() bool {
+	return n.Type == soundObjectId
+}
+
+// ObjectTypeName returns a human readable name for a HIRC object type.
+func ObjectTypeName(t byte) string {
+	if name, ok := objectTypeNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// Hierarchy decodes the objects of this SoundBank into a tree. Events, along
+// with their actions and the containers and sounds they target, are returned
+// first. Any remaining objects that have no parent within this SoundBank and
+// are not referenced by an action follow, in the order they appear in the
+// file.
+func (bnk *File) Hierarchy() []*ObjectNode {
+	if bnk.ObjectSection == nil {
+		return nil
+	}
+	bnk.ObjectSection.decode()
+	version := uint32(0)
+	if bnk.BankHeaderSection != nil {
+		version = bnk.BankHeaderSection.Descriptor.Version
+	}
+	return bnk.ObjectSection.hierarchy(version)
+}
+
+// EventsOfWems returns a mapping from wem id to the ids of the events that
+// play the wem, in the order that the events appear in the file.
+func (bnk *File) EventsOfWems() map[uint32][]uint32 {
+	eventsOf := make(map[uint32][]uint32)
+	var visit func(event uint32, n *ObjectNode)
+	visit = func(event uint32, n *ObjectNode) {
+		if n.IsSound() {
+			events := eventsOf[n.WemId]
+			if len(events) == 0 || events[len(events)-1] != event {
+				eventsOf[n.WemId] = append(events, event)
+			}
+		}
+		for _, child := range n.Children {
+			visit(event, child)
+		}
+	}
+	for _, root := range bnk.Hierarchy() {
+		if root.Type == eventObjectId {
+			visit(root.Id, root)
+		}
+	}
+	return eventsOf
+}
+
+// The references between the objects of a HIRC section, by the id of the
+// object that makes them. A parent id of 0 means that the object has no parent.
+type objectLinks struct {
+	// The objects, by id and in the order they appear in the section.
+	nodes map[uint32]*ObjectNode
+	order []*ObjectNode
+	// The direct parent of each Sound and container, the actions of each event,
+	// and the target of each action.
+	parentOf  map[uint32]uint32
+	actionsOf map[uint32][]uint32
+	targetOf  map[uint32]uint32
+}
+
+// links decodes the references between the objects of this section. The
+// children of the returned nodes are not set.
+func (hrc *ObjectHierarchySection) links(version uint32) *objectLinks {
+	nodes := make(map[uint32]*ObjectNode)
+	var order []*ObjectNode
+	parentOf := make(map[uint32]uint32)
+	actionsOf := make(map[uint32][]uint32)
+	targetOf := make(map[uint32]uint32)
+
+	for _, obj := range hrc.objects {
+		switch obj := obj.(type) {
+		case *SfxVoiceSoundObject:
+			node := &ObjectNode{Id: obj.Descriptor.ObjectId, Type: soundObjectId,
+				WemId: obj.WemDescriptor.WemId}
+			nodes[node.Id], order = node, append(order, node)
+			parentOf[node.Id] =
+				binary.LittleEndian.Uint32(obj.Structure.Unknown[parentIdOffset:])
+		case *UnknownObject:
+			desc := obj.Descriptor
+			node := &ObjectNode{Id: desc.ObjectId, Type: desc.Type}
+			nodes[node.Id], order = node, append(order, node)
+			data, err := obj.data()
+			if err != nil {
+				continue
+			}
+			switch desc.Type {
+			case eventObjectId:
+				if ids, err := decodeEventActions(data, version); err == nil {
+					actionsOf[node.Id] = ids
+				}
+			case actionObjectId:
+				// An action begins with a 2 byte action type, followed by the id of
+				// the object it targets.
+				if len(data) >= 6 {
+					targetOf[node.Id] = binary.LittleEndian.Uint32(data[2:])
+				}
+			case randomSequenceObjectId, switchContainerObjectId,
+				actorMixerObjectId, blendContainerObjectId:
+				if parent, err := decodeParentId(data); err == nil {
+					parentOf[node.Id] = parent
+				}
+			}
+		}
+	}
+	return &objectLinks{nodes, order, parentOf, actionsOf, targetOf}
+}
+
+func (hrc *ObjectHierarchySection) hierarchy(version uint32) []*ObjectNode {
+	l := hrc.links(version)
+	nodes, order := l.nodes, l.order
+	parentOf, actionsOf, targetOf := l.parentOf, l.actionsOf, l.targetOf
+
+	referenced := make(map[uint32]bool)
+	for _, node := range order {
+		if parent, ok := nodes[parentOf[node.Id]]; ok && parent != node {
+			parent.Children = append(parent.Children, node)
+			referenced[node.Id] = true
+		}
+	}
+
+	var roots []*ObjectNode
+	for _, node := range order {
+		if node.Type != eventObjectId {
+			continue
+		}
+		for _, id := range actionsOf[node.Id] {
+			action, ok := nodes[id]
+			if !ok {
+				continue
+			}
+			// Actions are shared by reference; copy them so that each event owns
+			// its own subtree.
+			action = &ObjectNode{Id: action.Id, Type: action.Type}
+			if target, ok := nodes[targetOf[id]]; ok {
+				action.Children = append(action.Children, target)
+				referenced[target.Id] = true
+			}
+			node.Children = append(node.Children, action)
+			referenced[id] = true
+		}
+		roots = append(roots, node)
+		referenced[node.Id] = true
+	}
+
+	var unreferenced []*ObjectNode
+	for _, node := range order {
+		if !referenced[node.Id] {
+			unreferenced = append(unreferenced, node)
+		}
+	}
+	return append(roots, unreferenced...)
+}
+
+// decodeEventActions returns the ids of the actions triggered by an event,
+// given the data of the event following its object id.
+func decodeEventActions(data []byte, version uint32) ([]uint32, error) {
+	var count uint64
+	offset := 0
+	if version <= lastFixedActionCountVersion {
+		if len(data) < 4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		count, offset = uint64(binary.LittleEndian.Uint32(data)), 4
+	} else {
+		var n int
+		count, n = binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("invalid event action count")
+		}
+		offset = n
+	}
+
+	// The count is compared against the number of ids that fit in the data, as
+	// multiplying a corrupt count may overflow.
+	if count > uint64(len(data)-offset)/4 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	ids := make([]uint32, count)
+	for i := range ids {
+		ids[i] = binary.LittleEndian.Uint32(data[offset+i*4:])
+	}
+	return ids, nil
+}
+
+// decodeParentId returns the id of the direct parent of a container, given the
+// data of the container following its object id.
+func decodeParentId(data []byte) (uint32, error) {
+	// Skip the override parent effects flag, then the effect container.
+	offset := OVERRIDE_EFFECTS_BYTES
+	if len(data) < offset+1 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	count := int(data[offset])
+	offset++
+	if count > 0 {
+		offset += 1 + count*EFFECT_BYTES
+	}
+	if len(data) < offset+parentIdOffset+4 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return binary.LittleEndian.Uint32(data[offset+parentIdOffset:]), nil
+}
+
+// data returns the data of this UnknownObject, following its object id.
+func (unknown *UnknownObject) data() ([]byte, error) {
+	r, ok := unknown.Reader.(util.ReadSeekerAt)
+	if !ok {
+		return nil, errors.New("object data does not support random access")
+	}
+	b := make([]byte, r.Size())
+	_, err := r.ReadAt(b, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return b, nil
+}
@@ -3,6 +3,7 @@ package bnk
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
 )
 
@@ -32,6 +33,10 @@ const soundObjectId = 0x02
 // The wem is embedded in this sound file.
 const streamSettingEmbedded = 0x00
 
+// errObjectTooShort is returned when the fields of an object extend past the
+// length given by its descriptor.
+var errObjectTooShort = errors.New("The object is shorter than its fields")
+
 // Object represents a single object within the HIRC section.
 type Object interface {
 	io.WriterTo
@@ -48,6 +53,8 @@ type ObjectDescriptor struct {
 // An SfxVoiceSoundObject represents a Voice/SFX Sound object within the HIRC
 // section.
 type SfxVoiceSoundObject struct {
+	// The data of this object, following its descriptor, as it was read.
+	original
 	Descriptor *ObjectDescriptor
 
 	Unknown       *[5]byte
@@ -117,6 +124,9 @@ func (desc *ObjectDescriptor) NewSfxVoiceSoundObject(sr util.ReadSeekerAt) (*Sfx
 	// The descriptor length includes the Object ID, which has already been
 	// written. Remove this from the remaining length.
 	dataLength := int64(desc.Length) - OBJECT_DESCRIPTOR_ID_BYTES
+	if dataLength < 0 {
+		return nil, errObjectTooShort
+	}
 	unknown := new([5]byte)
 	err := binary.Read(sr, binary.LittleEndian, unknown)
 	if err != nil {
@@ -137,13 +147,18 @@ func (desc *ObjectDescriptor) NewSfxVoiceSoundObject(sr util.ReadSeekerAt) (*Sfx
 
 	ssOffset, _ := sr.Seek(0, io.SeekCurrent)
 	remaining := dataLength - (ssOffset - startOffset)
+	if remaining < 0 {
+		return nil, errObjectTooShort
+	}
 
 	ss, err := NewSoundStructure(sr, remaining)
 	if err != nil {
 		return nil, err
 	}
 
-	return &SfxVoiceSoundObject{desc, unknown, wd, soundType, ss}, nil
+	source := util.NewResettingReader(sr, startOffset, dataLength)
+	return &SfxVoiceSoundObject{original{source: source}, desc, unknown, wd,
+		soundType, ss}, nil
 }
 
 // WriteTo writes the full contents of this SfxVoiceSoundObject to the Writer
@@ -155,6 +170,9 @@ func (sound *SfxVoiceSoundObject) WriteTo(w io.Writer) (written int64, err error
 	}
 	written = OBJECT_DESCRIPTOR_BYTES
 
+	if n, ok, err := sound.writeOriginal(w); ok {
+		return written + n, err
+	}
 	err = binary.Write(w, binary.LittleEndian, sound.Unknown)
 	if err != nil {
 		return
@@ -204,7 +222,7 @@ func (unknown *UnknownObject) WriteTo(w io.Writer) (written int64, err error) {
 	}
 	written = int64(OBJECT_DESCRIPTOR_BYTES)
 
-	n, err := io.Copy(w, unknown.Reader)
+	n, err := util.Copy(w, util.NewIndependentReader(unknown.Reader))
 	if err != nil {
 		return written, err
 	}
@@ -274,6 +292,9 @@ func NewSoundStructure(sr util.ReadSeekerAt, length int64) (*SoundStructure, err
 	// it.
 	currOffset, _ := sr.Seek(0, io.SeekCurrent)
 	remaining := length - (currOffset - startOffset)
+	if remaining < 0 {
+		return nil, errObjectTooShort
+	}
 	r := util.NewResettingReader(sr, currOffset, remaining)
 	sr.Seek(remaining, io.SeekCurrent)
 	return &SoundStructure{override, ctr, unknown, count, types, values,
@@ -317,7 +338,7 @@ func (ss *SoundStructure) WriteTo(w io.Writer) (written int64, err error) {
 	}
 	written += int64(ss.ParameterCount) * PARAMETER_VALUE_BYTES
 
-	n, err = io.Copy(w, ss.RemainingReader)
+	n, err = util.Copy(w, util.NewIndependentReader(ss.RemainingReader))
 	if err != nil {
 		return written, err
 	}
@@ -0,0 +1,405 @@
+package bnk
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+import (
+	"util"
+	"wwise"
+)
+
+// The SoundBank versions that this package has been verified against.
+var testedVersions = []uint32{120, 132}
+
+// A SectionInfo describes a single section of a SoundBank.
+type SectionInfo struct {
+	Identifier string
+	// The length in bytes of the section, excluding its header.
+	Length uint32
+}
+
+// Sections returns a description of every section of this SoundBank, in the
+// order they appear in the file.
+func (bnk *File) Sections() []SectionInfo {
+	var infos []SectionInfo
+	for _, s := range bnk.sections {
+		var hdr *SectionHeader
+		switch s := s.(type) {
+		case *BankHeaderSection:
+			hdr = s.Header
+		case *DataIndexSection:
+			hdr = s.Header
+		case *DataSection:
+			hdr = s.Header
+		case *ObjectHierarchySection:
+			hdr = s.Header
+		case *UnknownSection:
+			hdr = s.Header
+		default:
+			continue
+		}
+		infos = append(infos, SectionInfo{string(hdr.Identifier[:]), hdr.Length})
+	}
+	return infos
+}
+
+// A LengthMismatch describes a section whose length, as declared by its header,
+// is longer than the content that was parsed from it, which is common in
+// SoundBanks made by tools other than Wwise. Such sections are read leniently:
+// the sections that follow are found from the declared length, and the bytes
+// that were not parsed are kept, and written back after the parsed content.
+type LengthMismatch struct {
+	Identifier string
+	// The length in bytes of the section given by its header, and the number of
+	// those bytes that were parsed.
+	Declared uint32
+	Parsed   uint32
+}
+
+// LengthMismatches returns a description of every section of this SoundBank
+// whose declared length disagrees with its content, in the order they appear
+// in the file.
+func (bnk *File) LengthMismatches() []LengthMismatch {
+	var mismatches []LengthMismatch
+	for _, s := range bnk.sections {
+		var hdr *SectionHeader
+		var trailing util.ReadSeekerAt
+		switch s := s.(type) {
+		case *DataIndexSection:
+			hdr, trailing = s.Header, s.trailing
+		case *DataSection:
+			hdr, trailing = s.Header, s.trailing
+		case *ObjectHierarchySection:
+			hdr, trailing = s.Header, s.trailing
+		}
+		if trailing == nil {
+			continue
+		}
+		mismatches = append(mismatches, LengthMismatch{
+			string(hdr.Identifier[:]), hdr.Length,
+			hdr.Length - uint32(trailing.Size())})
+	}
+	return mismatches
+}
+
+// An Overlap describes a wem whose data overlaps that of another wem, as in
+// some repacked SoundBanks that share data between wems. The DATA section of
+// such a SoundBank is written back as it was read, and replacements are placed
+// after it, so that replacing one of the wems leaves the other unchanged.
+type Overlap struct {
+	// The index of the wem, and of the wem that it overlaps.
+	Index int
+	Other int
+}
+
+// Overlaps returns a description of every wem of this SoundBank whose data
+// overlaps that of another wem, in the order of their indexes.
+func (bnk *File) Overlaps() []Overlap {
+	if bnk.DataSection == nil {
+		return nil
+	}
+	return bnk.DataSection.overlaps
+}
+
+// A HierarchyProblem describes an inconsistency within the HIRC section of a
+// SoundBank, such as a length that disagrees with the objects it covers, or an
+// object that refers to another that is not in the SoundBank. SoundBanks
+// edited by other tools may have such problems, which are otherwise only found
+// once the game fails to load the SoundBank.
+type HierarchyProblem struct {
+	// The id of the object with the problem, or 0 if the problem is with the
+	// section as a whole.
+	ObjectId    uint32
+	Description string
+}
+
+// HierarchyProblems checks the HIRC section of this SoundBank, as it would be
+// written, returning a description of each problem found in the order of the
+// objects. It checks that the object count and the section length match the
+// objects, that the length of each object matches its contents, and that the
+// parent of each Sound and container, and the actions of each event, are
+// within this SoundBank. The targets of actions are not checked, as they may be
+// in other SoundBanks.
+func (bnk *File) HierarchyProblems() []HierarchyProblem {
+	hrc := bnk.ObjectSection
+	if hrc == nil {
+		return nil
+	}
+	hrc.decode()
+	var problems []HierarchyProblem
+	if int(hrc.ObjectCount) != len(hrc.objects) {
+		problems = append(problems, HierarchyProblem{0, fmt.Sprintf("The HIRC "+
+			"section declares %d objects, but holds %d", hrc.ObjectCount,
+			len(hrc.objects))})
+	}
+
+	length := int64(OBJECT_COUNT_BYTES)
+	if hrc.empty {
+		length = 0
+	}
+	for _, obj := range hrc.objects {
+		var desc *ObjectDescriptor
+		switch obj := obj.(type) {
+		case *SfxVoiceSoundObject:
+			desc = obj.Descriptor
+		case *UnknownObject:
+			desc = obj.Descriptor
+		}
+		n, err := obj.WriteTo(ioutil.Discard)
+		if err != nil {
+			problems = append(problems, HierarchyProblem{desc.ObjectId,
+				fmt.Sprintf("Object %d could not be written: %s", desc.ObjectId,
+					err)})
+		}
+		declared := int64(desc.Length) + OBJECT_DESCRIPTOR_BYTES -
+			OBJECT_DESCRIPTOR_ID_BYTES
+		if err == nil && n != declared {
+			problems = append(problems, HierarchyProblem{desc.ObjectId,
+				fmt.Sprintf("Object %d declares %d bytes, but holds %d",
+					desc.ObjectId, declared, n)})
+		}
+		length += n
+	}
+	if hrc.trailing != nil {
+		length += hrc.trailing.Size()
+	}
+	if length != int64(hrc.Header.Length) {
+		problems = append(problems, HierarchyProblem{0, fmt.Sprintf("The HIRC "+
+			"section declares %d bytes, but its objects take up %d",
+			hrc.Header.Length, length)})
+	}
+
+	version := uint32(0)
+	if bnk.BankHeaderSection != nil {
+		version = bnk.BankHeaderSection.Descriptor.Version
+	}
+	l := hrc.links(version)
+	for _, node := range l.order {
+		if parent := l.parentOf[node.Id]; parent != 0 && l.nodes[parent] == nil {
+			problems = append(problems, HierarchyProblem{node.Id, fmt.Sprintf(
+				"%s %d has the parent %d, which is not in this SoundBank",
+				node.TypeName(), node.Id, parent)})
+		}
+		for _, action := range l.actionsOf[node.Id] {
+			if l.nodes[action] == nil {
+				problems = append(problems, HierarchyProblem{node.Id, fmt.Sprintf(
+					"Event %d plays the action %d, which is not in this SoundBank",
+					node.Id, action)})
+			}
+		}
+	}
+	return problems
+}
+
+// UnknownSections returns the sections of this SoundBank that this package does
+// not decode, in the order they appear in the file.
+func (bnk *File) UnknownSections() []*UnknownSection {
+	var unknown []*UnknownSection
+	for _, s := range bnk.sections {
+		if s, ok := s.(*UnknownSection); ok {
+			unknown = append(unknown, s)
+		}
+	}
+	return unknown
+}
+
+// TrailingDataSize returns the number of bytes that follow the last section of
+// this SoundBank without forming a section themselves, which are kept and
+// written back unchanged.
+func (bnk *File) TrailingDataSize() int64 {
+	for _, s := range bnk.sections {
+		if td, ok := s.(*TrailingData); ok {
+			return td.Size()
+		}
+	}
+	return 0
+}
+
+// ObjectTypeCounts returns the number of HIRC objects of each type within this
+// SoundBank.
+func (bnk *File) ObjectTypeCounts() map[byte]int {
+	counts := make(map[byte]int)
+	if bnk.ObjectSection == nil {
+		return counts
+	}
+	for _, obj := range bnk.ObjectSection.objects {
+		switch obj := obj.(type) {
+		case *SfxVoiceSoundObject:
+			counts[obj.Descriptor.Type]++
+		case *UnknownObject:
+			counts[obj.Descriptor.Type]++
+		}
+	}
+	return counts
+}
+
+// IsTestedVersion returns true if SoundBanks of the specified version are
+// known to be read and written correctly by this package.
+func IsTestedVersion(version uint32) bool {
+	for _, v := range testedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns the version of this SoundBank given by its BKHD section, or 0
+// if it has none.
+func (bnk *File) Version() uint32 {
+	if bnk.BankHeaderSection == nil {
+		return 0
+	}
+	return bnk.BankHeaderSection.Descriptor.Version
+}
+
+// CheckVersion returns a *wwise.VersionError if this SoundBank does not support
+// edit. Wems can be replaced in every version, as the DIDX and DATA sections
+// are laid out the same in each, but loops are only edited in tested versions,
+// as the layout of the Sound objects that hold them differs between versions.
+func (bnk *File) CheckVersion(edit string) error {
+	if edit == wwise.EditLoops && !IsTestedVersion(bnk.Version()) {
+		return &wwise.VersionError{"SoundBank", bnk.Version(), edit,
+			testedVersions}
+	}
+	return nil
+}
+
+// LoopableWemCount returns the number of wems of this SoundBank whose loop can
+// be changed, as they are played by a sound object within this SoundBank.
+func (bnk *File) LoopableWemCount() int {
+	count := 0
+	for i := range bnk.Wems() {
+		if bnk.CanLoop(i) {
+			count++
+		}
+	}
+	return count
+}
+
+// CanLoop returns true if the loop of the wem at index i can be changed, as it
+// is played by a sound object within this SoundBank.
+func (bnk *File) CanLoop(i int) bool {
+	return bnk.CheckLoop(i) == nil
+}
+
+// CheckLoop returns a *LoopError describing why the loop of the wem at index i
+// cannot be changed, or nil if it can. Loops are only changed for wems played
+// by a decoded Sound object within this SoundBank, and only in tested versions,
+// as the loop is a parameter of the Sound object whose layout differs between
+// versions; the *LoopError then wraps the *wwise.VersionError of CheckVersion.
+func (bnk *File) CheckLoop(i int) error {
+	_, err := bnk.loopObject(i)
+	return err
+}
+
+// loopObject returns the Sound object that plays the wem at index i, whose
+// parameters hold the loop of the wem, or a *LoopError if there is none that
+// can be edited.
+func (bnk *File) loopObject(i int) (*SfxVoiceSoundObject, error) {
+	wems := bnk.Wems()
+	if i < 0 || i >= len(wems) {
+		return nil, &LoopError{i, "there is no such wem", nil}
+	}
+	if bnk.ObjectSection == nil {
+		return nil, &LoopError{i, "the SoundBank has no HIRC section", nil}
+	}
+	if err := bnk.CheckVersion(wwise.EditLoops); err != nil {
+		return nil, &LoopError{i, err.Error(), err}
+	}
+	bnk.ObjectSection.decode()
+	object, ok := bnk.ObjectSection.wemToObject[wems[i].Descriptor.WemId]
+	if !ok {
+		return nil, &LoopError{i, "it is not played by a Sound object within " +
+			"this SoundBank", nil}
+	}
+	return object, nil
+}
+
+// Warnings returns a Warning for each part of this SoundBank that this package
+// does not fully understand, read leniently, or found to be inconsistent. The
+// SoundBank can still be read and written, but any edits to it should be
+// verified in game.
+func (bnk *File) Warnings() []wwise.Warning {
+	var warnings []wwise.Warning
+	warn := func(severity wwise.Severity, kind string, format string,
+		args ...interface{}) {
+		warnings = append(warnings,
+			wwise.Warning{severity, kind, fmt.Sprintf(format, args...)})
+	}
+	if bnk.BankHeaderSection != nil {
+		version := bnk.BankHeaderSection.Descriptor.Version
+		if !IsTestedVersion(version) {
+			warn(wwise.SeverityCaution, wwise.WarningUntestedVersion,
+				"SoundBank version %d has not been tested", version)
+		}
+	}
+	for _, s := range bnk.UnknownSections() {
+		warn(wwise.SeverityNotice, wwise.WarningUnknownSection, "The %s section "+
+			"(%d bytes) is not decoded, and is written back unchanged",
+			s.Header.Identifier[:], s.Header.Length)
+	}
+	for _, m := range bnk.LengthMismatches() {
+		warn(wwise.SeverityCaution, wwise.WarningLengthMismatch, "The %s section "+
+			"declares %d bytes, but only %d could be parsed; the other %d are "+
+			"written back unchanged", m.Identifier, m.Declared, m.Parsed,
+			m.Declared-m.Parsed)
+	}
+	if n := bnk.TrailingDataSize(); n > 0 {
+		warn(wwise.SeverityNotice, wwise.WarningTrailingData, "%d byte(s) follow "+
+			"the last section without forming a section, and are written back "+
+			"unchanged", n)
+	}
+
+	if bnk.DataSection != nil && bnk.DataSection.moved > 0 {
+		warn(wwise.SeverityCaution, wwise.WarningMovedPlaceholder, "%d empty "+
+			"wem(s) had offsets out of order, and were moved to the wem that "+
+			"follows them", bnk.DataSection.moved)
+	}
+
+	for _, o := range bnk.Overlaps() {
+		warn(wwise.SeverityNotice, wwise.WarningOverlap, "The wem at index %d "+
+			"overlaps the wem at index %d; replacements are written after the "+
+			"other wems", o.Index, o.Other)
+	}
+	if bnk.DataSection != nil && bnk.DataSection.region != nil &&
+		len(bnk.Overlaps()) == 0 {
+		warn(wwise.SeverityNotice, wwise.WarningOutOfOrder, "The wems are not "+
+			"stored in the order of the DIDX section; replacements are written "+
+			"after the other wems")
+	}
+
+	seen := make(map[uint32]bool)
+	for i, wem := range bnk.Wems() {
+		id := wem.Descriptor.WemId
+		if seen[id] {
+			warn(wwise.SeverityCaution, wwise.WarningRepeatedId, "The wem at index "+
+				"%d has the same id, %d, as an earlier wem", i, id)
+		}
+		seen[id] = true
+	}
+
+	unknown := 0
+	for t, count := range bnk.ObjectTypeCounts() {
+		if _, ok := objectTypeNames[t]; !ok {
+			unknown += count
+		}
+	}
+	if unknown > 0 {
+		warn(wwise.SeverityNotice, wwise.WarningUnknownObject, "%d HIRC "+
+			"object(s) have an unknown type", unknown)
+	}
+	if bnk.ObjectSection != nil {
+		bnk.ObjectSection.decode()
+		if n := bnk.ObjectSection.undecoded; n > 0 {
+			warn(wwise.SeverityCaution, wwise.WarningUndecodedObject, "%d Sound "+
+				"object(s) could not be decoded, and are written back unchanged", n)
+		}
+	}
+	for _, p := range bnk.HierarchyProblems() {
+		warn(wwise.SeverityProblem, wwise.WarningHierarchy, "%s", p.Description)
+	}
+	return warnings
+}
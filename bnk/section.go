@@ -2,10 +2,14 @@
 package bnk
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 import (
@@ -39,6 +43,18 @@ var dataHeaderId = [4]byte{'D', 'A', 'T', 'A'}
 // The identifier for the start of the HIRC section.
 var hircHeaderId = [4]byte{'H', 'I', 'R', 'C'}
 
+// identifierName returns the identifier of a section as text, quoting it if it
+// is not made of printable characters, as the identifiers of corrupt sections
+// may not be.
+func identifierName(id [4]byte) string {
+	for _, c := range id {
+		if c < ' ' || c > '~' {
+			return strconv.Quote(string(id[:]))
+		}
+	}
+	return string(id[:])
+}
+
 // Section represents a single section of a Wwise SoundBank.
 type Section interface {
 	io.WriterTo
@@ -51,8 +67,55 @@ type SectionHeader struct {
 	Length     uint32
 }
 
+// An original records the bytes that a section or object was read from. Until
+// it is marked as modified, the section or object is written by copying these
+// bytes rather than encoding its fields again, so that the unmodified parts of
+// a SoundBank are written back exactly as they were read.
+type original struct {
+	source   util.ReadSeekerAt
+	modified bool
+}
+
+// sectionOriginal returns the original of the section with header hdr, whose
+// data r is seeked to the start of. The section has no original, and is always
+// encoded, if r does not support random access.
+func sectionOriginal(r io.Reader, hdr *SectionHeader) original {
+	sr, ok := r.(util.ReadSeekerAt)
+	if !ok {
+		return original{}
+	}
+	dataOffset, err := sr.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return original{}
+	}
+	return original{source: util.NewResettingReader(sr,
+		dataOffset-SECTION_HEADER_BYTES,
+		SECTION_HEADER_BYTES+int64(hdr.Length))}
+}
+
+// MarkModified records that the section or object has been changed, so that
+// it is encoded from its fields when it is written. The methods of File mark
+// what they change themselves. A change made directly to the fields of a
+// section or object, or to a wem descriptor that the DIDX section shares, is
+// not written until this is called; File.MarkModified marks every section at
+// once.
+func (o *original) MarkModified() {
+	o.modified = true
+}
+
+// writeOriginal copies the original bytes to w, if there are any and they have
+// not been modified. It returns false if the fields must be encoded instead.
+func (o *original) writeOriginal(w io.Writer) (int64, bool, error) {
+	if o.source == nil || o.modified {
+		return 0, false, nil
+	}
+	n, err := util.Copy(w, util.NewIndependentReader(o.source))
+	return n, true, err
+}
+
 // A BankHeaderSection represents the BKHD section of a SoundBank file.
 type BankHeaderSection struct {
+	original
 	Header          *SectionHeader
 	Descriptor      BankDescriptor
 	RemainingReader io.Reader
@@ -66,6 +129,7 @@ type BankDescriptor struct {
 
 // A DataIndexSection represents the DIDX section of a SoundBank file.
 type DataIndexSection struct {
+	original
 	Header *SectionHeader
 	// The count of wems in this SoundBank.
 	WemCount int
@@ -73,6 +137,9 @@ type DataIndexSection struct {
 	WemIds []uint32
 	// A mapping from wem ID to its descriptor.
 	DescriptorMap map[uint32]*wwise.WemDescriptor
+	// The bytes at the end of the section that do not form a whole entry, or nil
+	// if there are none. They are written back after the entries.
+	trailing util.ReadSeekerAt
 }
 
 // A DataIndexSection represents the DATA section of a SoundBank file.
@@ -82,20 +149,53 @@ type DataSection struct {
 	// This is the location where wem entries are stored.
 	DataStart uint32
 	Wems      []*wwise.Wem
+	// The number of placeholders, wems with no data, that were moved from an
+	// offset outside of the order of the wems.
+	moved int
+	// The wems whose data overlaps that of another wem.
+	overlaps []Overlap
+	// If the wems are not stored one after another, in the order of the index,
+	// the data of the section as it was read. It is then written back as it is,
+	// so that no wem is moved and no data is written twice, and the wems that
+	// are replaced are appended after it, in the order they were replaced.
+	region   util.ReadSeekerAt
+	appended []*wwise.Wem
+	// The padding that aligns the first appended wem, and the length of the
+	// section including the appended wems.
+	regionPadding int64
+	end           int64
+	// The data of the section if the DIDX section indexes no wems, or nil if it
+	// indexes some or the section is empty. It is written back as it is.
+	trailing util.ReadSeekerAt
 }
 
 // A ObjectHierarchySection represents the HIRC section of a SoundBank file,
 // which contains all wwise metadata objects defining the behavior and
 // properties of wems.
 type ObjectHierarchySection struct {
+	original
 	Header      *SectionHeader
 	ObjectCount uint32
-	objects     []Object
+	// The objects of this section. Until the section is decoded, every object is
+	// an UnknownObject that records only its descriptor and the location of its
+	// data.
+	objects []Object
+	// Whether the section is empty, without even an object count, as some tools
+	// write it for SoundBanks with no objects. It is then written back empty.
+	empty bool
+	// Decodes the Sound objects of this section once, however many goroutines
+	// first read the hierarchy or the loops at the same time, and the number
+	// that could not be decoded, which are left as UnknownObjects.
+	decodeOnce sync.Once
+	undecoded  int
 	// A convenience field for accessing the loop parameters of every wem. It maps
 	// the wem id of the loop in question to the loop value, where 0 represents
 	// infinity.
 	loopOf      map[uint32]uint32
 	wemToObject map[uint32]*SfxVoiceSoundObject
+	// The bytes at the end of the section that follow its last object, or nil if
+	// there are none. They are written back after the objects.
+	trailing util.ReadSeekerAt
 }
 
 // An UnknownSection represents an unknown section in a SoundBank file.
@@ -105,14 +205,31 @@ type UnknownSection struct {
 	Reader io.Reader
 }
 
+// TrailingData represents bytes that follow the last section of a SoundBank
+// file, but do not form a section themselves, such as a footer appended by
+// another tool. They are written back unchanged after the last section.
+type TrailingData struct {
+	// A reader to read the trailing bytes.
+	Reader util.ReadSeekerAt
+}
+
 // NewBankHeaderSection creates a new BankHeaderSection, reading from sr, which
 // must be seeked to the start of the BKHD section data.
-// It is an error to call this method on a non-BKHD header.
+// A *SectionIdError is returned if hdr is not a BKHD header, and a
+// *wwise.BoundsError if the section extends past the end of sr.
 func (hdr *SectionHeader) NewBankHeaderSection(sr util.ReadSeekerAt) (*BankHeaderSection, error) {
-	if hdr.Identifier != bkhdHeaderId {
-		panic(fmt.Sprintf("Expected BKHD header but got: %s", hdr.Identifier))
+	if err := checkSectionId(hdr, bkhdHeaderId); err != nil {
+		return nil, err
+	}
+	if err := checkSectionLength(sr, hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Length < BKHD_SECTION_BYTES {
+		return nil, fmt.Errorf("The BKHD section is %d bytes long, shorter than "+
+			"its %d byte descriptor", hdr.Length, BKHD_SECTION_BYTES)
 	}
 	sec := new(BankHeaderSection)
+	sec.original = sectionOriginal(sr, hdr)
 	sec.Header = hdr
 	desc := BankDescriptor{}
 	err := binary.Read(sr, binary.LittleEndian, &desc)
@@ -132,6 +249,9 @@ func (hdr *SectionHeader) NewBankHeaderSection(sr util.ReadSeekerAt) (*BankHeade
 // WriteTo writes the full contents of this BankHeaderSection to the Writer
 // specified by w.
 func (hdr *BankHeaderSection) WriteTo(w io.Writer) (written int64, err error) {
+	if n, ok, err := hdr.writeOriginal(w); ok {
+		return n, err
+	}
 	err = binary.Write(w, binary.LittleEndian, hdr.Header)
 	if err != nil {
 		return
@@ -142,7 +262,7 @@ func (hdr *BankHeaderSection) WriteTo(w io.Writer) (written int64, err error) {
 		return
 	}
 	written += int64(BKHD_SECTION_BYTES)
-	n, err := io.Copy(w, hdr.RemainingReader)
+	n, err := util.Copy(w, util.NewIndependentReader(hdr.RemainingReader))
 	if err != nil {
 		return
 	}
@@ -158,27 +278,48 @@ func (hdr *BankHeaderSection) String() string {
 
 // NewDataIndexSection creates a new DataIndexSection, reading from r, which must
 // be seeked to the start of the DIDX section data.
-// It is an error to call this method on a non-DIDX header.
+// A *SectionIdError is returned if hdr is not a DIDX header, and a
+// *wwise.BoundsError if the section extends past the end of r.
 func (hdr *SectionHeader) NewDataIndexSection(r io.Reader) (*DataIndexSection, error) {
-	if hdr.Identifier != didxHeaderId {
-		panic(fmt.Sprintf("Expected DIDX header but got: %s", hdr.Identifier))
+	if err := checkSectionId(hdr, didxHeaderId); err != nil {
+		return nil, err
+	}
+	if err := checkSectionLength(r, hdr); err != nil {
+		return nil, err
 	}
+	source := sectionOriginal(r, hdr)
 	wemCount := int(hdr.Length / DIDX_ENTRY_BYTES)
-	sec := DataIndexSection{hdr, wemCount, make([]uint32, 0),
-		make(map[uint32]*wwise.WemDescriptor)}
-	for i := 0; i < wemCount; i++ {
-		var desc wwise.WemDescriptor
-		err := binary.Read(r, binary.LittleEndian, &desc)
-		if err != nil {
-			return nil, err
-		}
+	// Read the whole index at once, and decode it into preallocated
+	// descriptors; some SoundBanks index tens of thousands of wems.
+	data, err := util.ReadFull(r, int64(wemCount*DIDX_ENTRY_BYTES))
+	if err != nil {
+		return nil, err
+	}
+	descs := make([]wwise.WemDescriptor, wemCount)
+	sec := DataIndexSection{source, hdr, wemCount,
+		make([]uint32, 0, wemCount),
+		make(map[uint32]*wwise.WemDescriptor, wemCount), nil}
+	for i := range descs {
+		entry := data[i*DIDX_ENTRY_BYTES:]
+		desc := &descs[i]
+		desc.WemId = binary.LittleEndian.Uint32(entry)
+		desc.Offset = binary.LittleEndian.Uint32(entry[4:])
+		desc.Length = binary.LittleEndian.Uint32(entry[8:])
 
 		if _, ok := sec.DescriptorMap[desc.WemId]; ok {
-			panic(fmt.Sprintf(
-				"%d is an illegal repeated wem ID in the DIDX", desc.WemId))
+			return nil, &RepeatedWemError{desc.WemId}
 		}
 		sec.WemIds = append(sec.WemIds, desc.WemId)
-		sec.DescriptorMap[desc.WemId] = &desc
+		sec.DescriptorMap[desc.WemId] = desc
+	}
+	// A length that is not a multiple of the size of an entry is common in
+	// SoundBanks made by other tools; the bytes that remain are kept as they are.
+	if extra := int64(hdr.Length) - int64(len(data)); extra > 0 {
+		trailing, err := util.ReadFull(r, extra)
+		if err != nil {
+			return nil, err
+		}
+		sec.trailing = bytes.NewReader(trailing)
 	}
 
 	return &sec, nil
@@ -187,6 +328,9 @@ func (hdr *SectionHeader) NewDataIndexSection(r io.Reader) (*DataIndexSection, e
 // WriteTo writes the full contents of this DataIndexSection to the Writer
 // specified by w.
 func (idx *DataIndexSection) WriteTo(w io.Writer) (written int64, err error) {
+	if n, ok, err := idx.writeOriginal(w); ok {
+		return n, err
+	}
 	err = binary.Write(w, binary.LittleEndian, idx.Header)
 	if err != nil {
 		return
@@ -201,14 +345,15 @@ func (idx *DataIndexSection) WriteTo(w io.Writer) (written int64, err error) {
 		}
 		written += int64(DIDX_ENTRY_BYTES)
 	}
-	return written, nil
+	n, err := writeTrailing(w, idx.trailing)
+	return written + n, err
 }
 
 func (idx *DataIndexSection) String() string {
 	b := new(strings.Builder)
-	total := uint32(0)
+	total := int64(0)
 	for _, desc := range idx.DescriptorMap {
-		total += desc.Length
+		total += int64(desc.Length)
 	}
 	fmt.Fprintf(b, "%s: len(%d) wem_count(%d)\n", idx.Header.Identifier,
 		idx.Header.Length, idx.WemCount)
@@ -219,17 +364,38 @@ func (idx *DataIndexSection) String() string {
 // NewDataSection creates a new DataSection, reading from sr, which must be
 // seeked to the start of the DATA section data. idx specifies how each wem
 // should be indexed from, given the current sr offset.
-// It is an error to call this method on a non-DATA header.
+// A *SectionIdError is returned if hdr is not a DATA header, and ErrNoDataIndex
+// if idx is nil. A *wwise.BoundsError is returned if the section extends past
+// the end of sr, or idx places a wem past the end of the section.
+// Wems may overlap, or be stored out of the order of idx; the section is then
+// written back as it was read, with any replacements after it.
 func (hdr *SectionHeader) NewDataSection(sr util.ReadSeekerAt,
 	idx *DataIndexSection) (*DataSection, error) {
-	if hdr.Identifier != dataHeaderId {
-		panic(fmt.Sprintf("Expected DATA header but got: %s", hdr.Identifier))
+	if err := checkSectionId(hdr, dataHeaderId); err != nil {
+		return nil, err
+	}
+	if err := checkSectionLength(sr, hdr); err != nil {
+		return nil, err
+	}
+	if idx == nil {
+		return nil, ErrNoDataIndex
 	}
 	dataOffset, _ := sr.Seek(0, io.SeekCurrent)
 
-	sec := DataSection{hdr, uint32(dataOffset), make([]*wwise.Wem, 0)}
+	sec := DataSection{Header: hdr, DataStart: uint32(dataOffset),
+		Wems: make([]*wwise.Wem, 0)}
+	descs := make([]*wwise.WemDescriptor, len(idx.WemIds))
+	for i, id := range idx.WemIds {
+		descs[i] = idx.DescriptorMap[id]
+	}
+	sec.moved = wwise.PlacePlaceholders(descs, 0, int64(hdr.Length))
+	sec.overlaps = findOverlaps(descs)
 	for i, id := range idx.WemIds {
 		desc := idx.DescriptorMap[id]
+		if end := int64(desc.Offset) + int64(desc.Length); end > int64(hdr.Length) {
+			return nil, &wwise.BoundsError{fmt.Sprintf("Wem %d", desc.WemId),
+				dataOffset + end, dataOffset + int64(hdr.Length)}
+		}
 		wemStartOffset := dataOffset + int64(desc.Offset)
 		wemReader := util.NewResettingReader(sr, wemStartOffset, int64(desc.Length))
 
@@ -249,6 +415,14 @@ func (hdr *SectionHeader) NewDataSection(sr util.ReadSeekerAt,
 				nextOffset = dataOffset + int64(nextDesc.Offset)
 			}
 			remaining := nextOffset - wemEndOffset
+			if remaining < 0 {
+				// The next wem overlaps this one, or is stored before it, so the wems
+				// cannot be written one after another.
+				remaining = 0
+				sec.region = util.NewResettingReader(sr, dataOffset,
+					int64(hdr.Length))
+				sec.end = int64(hdr.Length)
+			}
 			// Pass a Reader over the remaining section if we have remaining bytes to
 			// read, or an empty Reader if remaining is 0 (no bytes will be read).
 			padding = util.NewResettingReader(sr, wemEndOffset, remaining)
@@ -257,11 +431,84 @@ func (hdr *SectionHeader) NewDataSection(sr util.ReadSeekerAt,
 		wem := wwise.Wem{wemReader, desc, padding}
 		sec.Wems = append(sec.Wems, &wem)
 	}
+	if len(sec.Wems) == 0 && hdr.Length > 0 {
+		// There is no wem for the data to be the padding of.
+		sec.trailing = util.NewResettingReader(sr, dataOffset, int64(hdr.Length))
+	}
 
 	sr.Seek(int64(hdr.Length), io.SeekCurrent)
 	return &sec, nil
 }
 
+// findOverlaps returns an Overlap for each of descs whose data overlaps that of
+// one before it in the order of their offsets.
+func findOverlaps(descs []*wwise.WemDescriptor) []Overlap {
+	order := make([]int, len(descs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return descs[order[i]].Offset < descs[order[j]].Offset
+	})
+	var overlaps []Overlap
+	furthest, end := -1, int64(0)
+	for _, i := range order {
+		desc := descs[i]
+		if desc.Length == 0 {
+			continue
+		}
+		if furthest >= 0 && int64(desc.Offset) < end {
+			overlaps = append(overlaps, Overlap{i, furthest})
+		}
+		if e := int64(desc.Offset) + int64(desc.Length); e > end {
+			furthest, end = i, e
+		}
+	}
+	sort.Slice(overlaps, func(i, j int) bool {
+		return overlaps[i].Index < overlaps[j].Index
+	})
+	return overlaps
+}
+
+// replaceAppended replaces the wems of a DATA section that is written back as
+// it was read, placing each replacement after the data of the section, and
+// after any wems that were replaced before it. The wems that follow each
+// replacement are aligned to alignment bytes.
+func (data *DataSection) replaceAppended(alignment int64,
+	rs ...*wwise.ReplacementWem) {
+	for _, r := range rs {
+		wem := data.Wems[r.WemIndex]
+		wem.Reader = util.NewResettingReader(r.Wem, 0, r.Length)
+		wem.Descriptor.Length = uint32(r.Length)
+		appended := false
+		for _, a := range data.appended {
+			appended = appended || a == wem
+		}
+		if !appended {
+			data.appended = append(data.appended, wem)
+		}
+	}
+
+	pad := func(off int64) int64 {
+		if alignment == 0 {
+			return 0
+		}
+		return (alignment - off%alignment) % alignment
+	}
+	off := data.region.Size()
+	data.regionPadding = pad(off)
+	off += data.regionPadding
+	for _, wem := range data.appended {
+		wem.Descriptor.Offset = uint32(off)
+		off += int64(wem.Descriptor.Length)
+		padding := pad(off)
+		wem.Padding = util.NewResettingReader(&util.InfiniteReaderAt{0}, 0, padding)
+		off += padding
+	}
+	data.end = off
+	data.Header.Length = uint32(off)
+}
+
 // WriteTo writes the full contents of this DataSection to the Writer specified
 // by w.
 func (data *DataSection) WriteTo(w io.Writer) (written int64, err error) {
@@ -270,20 +517,61 @@ func (data *DataSection) WriteTo(w io.Writer) (written int64, err error) {
 		return
 	}
 	written = int64(SECTION_HEADER_BYTES)
-	for _, wem := range data.Wems {
-		n, err := io.Copy(w, wem)
-		if err != nil {
-			return written, err
+	n, err := wwise.WriteWems(w, data.layout())
+	written += n
+	if err != nil {
+		return
+	}
+	n, err = writeTrailing(w, data.trailing)
+	return written + n, err
+}
+
+// layout returns the wems of this DataSection in the order that they are
+// written. If the section is written back as it was read, its data is returned
+// as a single wem, followed by the wems that were replaced.
+func (data *DataSection) layout() []*wwise.Wem {
+	if data.region == nil {
+		return data.Wems
+	}
+	size := data.region.Size()
+	return append([]*wwise.Wem{{
+		Reader:     data.region,
+		Descriptor: &wwise.WemDescriptor{0, 0, uint32(size)},
+		Padding: util.NewResettingReader(&util.InfiniteReaderAt{0}, 0,
+			data.regionPadding),
+	}}, data.appended...)
+}
+
+// layoutLength returns the length of this DataSection computed from the wems
+// that are written, and their padding, rather than from its header. It returns
+// an error if a wem is not written at the offset, or with the length, given by
+// its descriptor.
+func (data *DataSection) layoutLength() (int64, error) {
+	off := int64(0)
+	for _, wem := range data.layout() {
+		desc := wem.Descriptor
+		if int64(desc.Offset) != off {
+			return 0, fmt.Errorf("Wem %d is indexed at offset %d of the DATA "+
+				"section, but would be written at offset %d", desc.WemId,
+				desc.Offset, off)
 		}
-		written += int64(n)
-		n, err = io.Copy(w, wem.Padding)
-		if err != nil {
-			return written, err
+		size := int64(desc.Length)
+		if s, ok := wem.Reader.(interface{ Size() int64 }); ok {
+			size = s.Size()
+		}
+		if size != int64(desc.Length) {
+			return 0, fmt.Errorf("Wem %d is indexed as %d bytes long, but %d "+
+				"bytes would be written", desc.WemId, desc.Length, size)
+		}
+		off += size
+		if wem.Padding != nil {
+			off += wem.Padding.Size()
 		}
-		written += int64(n)
 	}
-
-	return written, nil
+	if data.trailing != nil {
+		off += data.trailing.Size()
+	}
+	return off, nil
 }
 
 func (data *DataSection) String() string {
@@ -291,62 +579,150 @@ func (data *DataSection) String() string {
 }
 
 // NewObjectHierarchySection creates a new ObjectHierarchySection, reading from
-// sr, which must be seeked to the start of the HIRC section data.
-// It is an error to call this method on a non-HIRC header.
+// sr, which must be seeked to the start of the HIRC section data. Only the
+// descriptor of each object is read; objects are decoded once the hierarchy or
+// the loops of the SoundBank are first accessed.
+// A *SectionIdError is returned if hdr is not a HIRC header, and a
+// *wwise.BoundsError if the section, or one of its objects, extends past the
+// end of sr or of the section.
 func (hdr *SectionHeader) NewObjectHierarchySection(sr util.ReadSeekerAt) (*ObjectHierarchySection, error) {
-	if hdr.Identifier != hircHeaderId {
-		panic(fmt.Sprintf("Expected HIRC header but got: %s", hdr.Identifier))
+	return hdr.newObjectHierarchySection(sr, nil)
+}
+
+// newObjectHierarchySection is like NewObjectHierarchySection, but reports to m
+// as each object is parsed; a HIRC section may have tens of thousands.
+func (hdr *SectionHeader) newObjectHierarchySection(sr util.ReadSeekerAt,
+	m *wwise.OpenMonitor) (*ObjectHierarchySection, error) {
+	if err := checkSectionId(hdr, hircHeaderId); err != nil {
+		return nil, err
+	}
+	if err := checkSectionLength(sr, hdr); err != nil {
+		return nil, err
 	}
 	sec := new(ObjectHierarchySection)
+	sec.original = sectionOriginal(sr, hdr)
 	sec.Header = hdr
 	sec.loopOf = make(map[uint32]uint32)
 	sec.wemToObject = make(map[uint32]*SfxVoiceSoundObject)
 
+	if hdr.Length == 0 {
+		sec.empty = true
+		return sec, nil
+	}
+	start, _ := sr.Seek(0, io.SeekCurrent)
+	end := start + int64(hdr.Length)
 	var count uint32
 	err := binary.Read(sr, binary.LittleEndian, &count)
 	if err != nil {
 		return nil, err
 	}
 	sec.ObjectCount = count
+	// Every object has at least a descriptor, so the count cannot be trusted if
+	// the section is too short to hold that many.
+	descsEnd := start + OBJECT_COUNT_BYTES + int64(count)*OBJECT_DESCRIPTOR_BYTES
+	if descsEnd > end {
+		return nil, &wwise.BoundsError{fmt.Sprintf("The %d objects of the HIRC "+
+			"section", count), descsEnd, end}
+	}
 
 	for i := uint32(0); i < sec.ObjectCount; i++ {
-		desc := new(ObjectDescriptor)
-		err := binary.Read(sr, binary.LittleEndian, desc)
+		objStart, _ := sr.Seek(0, io.SeekCurrent)
+		obj, objEnd, err := newObject(sr, end)
 		if err != nil {
-			return nil, err
+			return nil, wwise.WrapParseError(err, fmt.Sprintf("HIRC object %d", i),
+				objStart)
 		}
-		switch id := desc.Type; id {
-		case soundObjectId:
-			obj, err := desc.NewSfxVoiceSoundObject(sr)
-			if err != nil {
-				return nil, err
-			}
-
-			sec.wemToObject[obj.WemDescriptor.WemId] = obj
-			if obj.Structure.loops {
-				sec.loopOf[obj.WemDescriptor.WemId] = obj.Structure.loopCount
-			}
-			sec.objects = append(sec.objects, obj)
-		default:
-			obj, err := desc.NewUnknownObject(sr)
-			if err != nil {
-				return nil, err
-			}
-			sec.objects = append(sec.objects, obj)
+		sec.objects = append(sec.objects, obj)
+		if err := m.Parsed(objEnd); err != nil {
+			return nil, err
 		}
 	}
+	// The objects may end before the section does, which is common in
+	// SoundBanks made by other tools; the bytes that remain are kept as they are.
+	if pos, _ := sr.Seek(0, io.SeekCurrent); pos < end {
+		sec.trailing = util.NewResettingReader(sr, pos, end-pos)
+		sr.Seek(end, io.SeekStart)
+	}
 
 	return sec, nil
 }
 
+// newObject reads the object that sr is seeked to the start of, returning it
+// and the offset that it ends at. A *wwise.BoundsError is returned if the object
+// extends past end, the end of its section.
+func newObject(sr util.ReadSeekerAt, end int64) (Object, int64, error) {
+	desc := new(ObjectDescriptor)
+	err := binary.Read(sr, binary.LittleEndian, desc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if desc.Length < OBJECT_DESCRIPTOR_ID_BYTES {
+		return nil, 0, fmt.Errorf("Object %d is %d bytes long, shorter than its "+
+			"id", desc.ObjectId, desc.Length)
+	}
+	pos, _ := sr.Seek(0, io.SeekCurrent)
+	objEnd := pos + int64(desc.Length) - OBJECT_DESCRIPTOR_ID_BYTES
+	if objEnd > end {
+		return nil, 0, &wwise.BoundsError{fmt.Sprintf("Object %d", desc.ObjectId),
+			objEnd, end}
+	}
+	obj, err := desc.NewUnknownObject(sr)
+	if err != nil {
+		return nil, 0, err
+	}
+	return obj, objEnd, nil
+}
+
+// decode decodes the Sound objects of this section, if it has not been decoded
+// already. A Sound object that cannot be decoded is left as an UnknownObject,
+// and is written back unchanged. It is safe to call from several goroutines.
+func (hrc *ObjectHierarchySection) decode() {
+	hrc.decodeOnce.Do(hrc.decodeObjects)
+}
+
+// decodeObjects decodes the Sound objects of this section; see decode.
+func (hrc *ObjectHierarchySection) decodeObjects() {
+	for i, obj := range hrc.objects {
+		unknown, ok := obj.(*UnknownObject)
+		if !ok || unknown.Descriptor.Type != soundObjectId {
+			continue
+		}
+		data, ok := unknown.Reader.(util.ReadSeekerAt)
+		if !ok {
+			hrc.undecoded++
+			continue
+		}
+		// Decode from a separate reader, so that the position of the object's own
+		// reader is unaffected.
+		sr := io.NewSectionReader(data, 0, data.Size())
+		sound, err := unknown.Descriptor.NewSfxVoiceSoundObject(sr)
+		if err != nil {
+			hrc.undecoded++
+			continue
+		}
+
+		hrc.wemToObject[sound.WemDescriptor.WemId] = sound
+		if sound.Structure.loops {
+			hrc.loopOf[sound.WemDescriptor.WemId] = sound.Structure.loopCount
+		}
+		hrc.objects[i] = sound
+	}
+}
+
 // WriteTo writes the full contents of this ObjectHierarchySection to the Writer
 // specified by w.
 func (hrc *ObjectHierarchySection) WriteTo(w io.Writer) (written int64, err error) {
+	if n, ok, err := hrc.writeOriginal(w); ok {
+		return n, err
+	}
 	err = binary.Write(w, binary.LittleEndian, hrc.Header)
 	if err != nil {
 		return
 	}
 	written = int64(SECTION_HEADER_BYTES)
+	if hrc.empty {
+		return
+	}
 
 	err = binary.Write(w, binary.LittleEndian, hrc.ObjectCount)
 	if err != nil {
@@ -361,7 +737,16 @@ func (hrc *ObjectHierarchySection) WriteTo(w io.Writer) (written int64, err erro
 		}
 		written += int64(n)
 	}
-	return written, nil
+	n, err := writeTrailing(w, hrc.trailing)
+	return written + n, err
+}
+
+// writeTrailing copies the trailing bytes of a section to w, if it has any.
+func writeTrailing(w io.Writer, trailing util.ReadSeekerAt) (int64, error) {
+	if trailing == nil {
+		return 0, nil
+	}
+	return util.Copy(w, util.NewIndependentReader(trailing))
 }
 
 func (hrc *ObjectHierarchySection) String() string {
@@ -374,7 +759,11 @@ func (hrc *ObjectHierarchySection) String() string {
 
 // NewUnknownSection creates a new UnknownSection, reading from sr, which
 // must be seeked to the start of the unknown section data.
+// A *wwise.BoundsError is returned if the section extends past the end of sr.
 func (hdr *SectionHeader) NewUnknownSection(sr util.ReadSeekerAt) (*UnknownSection, error) {
+	if err := checkSectionLength(sr, hdr); err != nil {
+		return nil, err
+	}
 	// Get the offset into the file where the data portion of this section begins.
 	dataOffset, _ := sr.Seek(0, io.SeekCurrent)
 	r := util.NewResettingReader(sr, dataOffset, int64(hdr.Length))
@@ -391,7 +780,7 @@ func (unknown *UnknownSection) WriteTo(w io.Writer) (written int64, err error) {
 	}
 	written = int64(SECTION_HEADER_BYTES)
 
-	n, err := io.Copy(w, unknown.Reader)
+	n, err := util.Copy(w, util.NewIndependentReader(unknown.Reader))
 	if err != nil {
 		return written, err
 	}
@@ -404,3 +793,26 @@ func (unknown *UnknownSection) String() string {
 	return fmt.Sprintf("%s: len(%d)\n", unknown.Header.Identifier,
 		unknown.Header.Length)
 }
+
+// NewTrailingData creates a new TrailingData of the rest of sr, which must be
+// seeked to the first byte that follows the last section.
+func NewTrailingData(sr util.ReadSeekerAt) *TrailingData {
+	start, _ := sr.Seek(0, io.SeekCurrent)
+	r := util.NewResettingReader(sr, start, sr.Size()-start)
+	sr.Seek(0, io.SeekEnd)
+	return &TrailingData{r}
+}
+
+// Size returns the number of trailing bytes.
+func (td *TrailingData) Size() int64 {
+	return td.Reader.Size()
+}
+
+// WriteTo writes the trailing bytes to the Writer specified by w.
+func (td *TrailingData) WriteTo(w io.Writer) (written int64, err error) {
+	return util.Copy(w, util.NewIndependentReader(td.Reader))
+}
+
+func (td *TrailingData) String() string {
+	return fmt.Sprintf("trailing data: len(%d)\n", td.Size())
+}
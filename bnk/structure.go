@@ -0,0 +1,830 @@
+// Package bnk implements access to the Wwise SoundBank file format.
+package bnk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+import (
+	"util"
+	"wwise"
+)
+
+// The format named by every structure, which distinguishes it from other JSON
+// files.
+const StructureFormat = "wwiseutil SoundBank"
+
+// The version of the structure schema written by ExportStructure. Structures of
+// a later version are rejected by ReadStructure.
+const StructureSchemaVersion = 1
+
+// The name of the file, within the media directory of a structure, that holds
+// the data of a DATA section that is stored whole.
+const structureDataFile = "DATA.bin"
+
+// A Structure describes the complete structure of a SoundBank as JSON: each of
+// its sections, the descriptors of its wems, and its HIRC objects, decoded
+// where this package can decode them. The data of each wem is kept in a
+// separate file, which the structure refers to, so that a SoundBank can be
+// reviewed and edited as text, or generated from a template.
+//
+// Lengths and offsets are not stored where they can be computed; they are
+// computed again when the SoundBank is written, so that editing a wem or an
+// object needs no other change.
+type Structure struct {
+	Format        string `json:"format"`
+	SchemaVersion int    `json:"schema_version"`
+	// The number of bytes that the wems are aligned to, which the padding of
+	// each wem defaults to.
+	WemAlignment int64 `json:"wem_alignment"`
+	// The wems of the SoundBank, in the order of its DIDX section.
+	Wems     []*StructureWem     `json:"wems"`
+	Sections []*StructureSection `json:"sections"`
+	// The bytes that follow the last section, if there are any.
+	Trailing []byte `json:"trailing,omitempty"`
+	// The directory that the files of the structure are relative to.
+	dir string
+}
+
+// A StructureWem describes a single wem of a Structure.
+type StructureWem struct {
+	Id uint32 `json:"id"`
+	// The file holding the data of the wem, relative to the structure and using
+	// forward slashes. It is empty for wems with no data, and if the DATA
+	// section is stored whole.
+	File string `json:"file,omitempty"`
+	// The offset written to the DIDX section, if it is not the offset that the
+	// wem is written at, as for placeholders that are out of order, or the DATA
+	// section is stored whole.
+	Offset *uint32 `json:"offset,omitempty"`
+	// The length of the wem, if it has no file.
+	Length *uint32 `json:"length,omitempty"`
+	// The number of bytes of padding that follow the wem, if it is not padded
+	// to the alignment of the wems. The last wem is not padded by default.
+	Padding *int64 `json:"padding,omitempty"`
+	// The padding that follows the wem, if it is not made of zeros.
+	PaddingData []byte `json:"padding_data,omitempty"`
+}
+
+// A StructureSection describes a single section of a Structure, in the order
+// that it is written.
+type StructureSection struct {
+	// The four character identifier of the section, such as BKHD.
+	Id string `json:"id"`
+	// The version and id of a BKHD section.
+	Version uint32 `json:"version,omitempty"`
+	BankId  uint32 `json:"bank_id,omitempty"`
+	// The file holding the data of a DATA section that is stored whole, as it is
+	// when its wems overlap or are out of order.
+	File string `json:"file,omitempty"`
+	// The objects of a HIRC section, and whether it is empty, without even an
+	// object count.
+	Objects []*StructureObject `json:"objects,omitempty"`
+	Empty   bool               `json:"empty,omitempty"`
+	// The bytes of the section that are not otherwise described: those that
+	// follow the descriptor of a BKHD section, the last entry of a DIDX section,
+	// or the last object of a HIRC section; the data of a DATA section with no
+	// wems; and the whole of any other section.
+	Data []byte `json:"data,omitempty"`
+}
+
+// A StructureObject describes a single HIRC object. Sound, Event and Action
+// objects are decoded; the data of any other object, or of one that cannot be
+// decoded, is kept as it is.
+type StructureObject struct {
+	Type byte `json:"type"`
+	// The name of the type, which is only informative.
+	TypeName string           `json:"type_name,omitempty"`
+	Id       uint32           `json:"id"`
+	Sound    *StructureSound  `json:"sound,omitempty"`
+	Event    *StructureEvent  `json:"event,omitempty"`
+	Action   *StructureAction `json:"action,omitempty"`
+	// The data of an object that is not decoded, following its id.
+	Data []byte `json:"data,omitempty"`
+}
+
+// A StructureSound describes a Sound object, as an SfxVoiceSoundObject does.
+type StructureSound struct {
+	Unknown   [5]byte `json:"unknown"`
+	WemId     uint32  `json:"wem_id"`
+	WemLength uint32  `json:"wem_length"`
+	SoundType byte    `json:"sound_type"`
+
+	OverrideParentEffects byte               `json:"override_parent_effects"`
+	EffectBypass          byte               `json:"effect_bypass,omitempty"`
+	Effects               []*StructureEffect `json:"effects,omitempty"`
+	// The bytes that follow the effects, around the id of the parent of the
+	// Sound.
+	BaseUnknown    [parentIdOffset]byte `json:"base_unknown"`
+	ParentId       uint32               `json:"parent_id"`
+	BaseUnknownEnd byte                 `json:"base_unknown_end"`
+	// The parameters of the Sound. A parameter of type 58 is its loop count,
+	// where 0 loops infinite times.
+	Parameters []*StructureParameter `json:"parameters"`
+	// The bytes that follow the parameters.
+	Remaining []byte `json:"remaining,omitempty"`
+}
+
+// A StructureEffect describes a single effect of a Sound.
+type StructureEffect struct {
+	Index   byte    `json:"index"`
+	Id      uint32  `json:"id"`
+	Padding [2]byte `json:"padding"`
+}
+
+// A StructureParameter describes a single parameter of a Sound.
+type StructureParameter struct {
+	Type  byte   `json:"type"`
+	Value uint32 `json:"value"`
+}
+
+// A StructureEvent describes an Event object.
+type StructureEvent struct {
+	// The ids of the actions that the event triggers.
+	Actions []uint32 `json:"actions"`
+	// The bytes that follow the action ids.
+	Remaining []byte `json:"remaining,omitempty"`
+}
+
+// A StructureAction describes an Action object.
+type StructureAction struct {
+	ActionType uint16 `json:"action_type"`
+	// The id of the object that the action targets.
+	TargetId uint32 `json:"target_id"`
+	// The bytes that follow the target id.
+	Remaining []byte `json:"remaining,omitempty"`
+}
+
+// ExportStructure writes the complete structure of this SoundBank to the JSON
+// file at path, and the data of its wems to a directory beside it, named after
+// the file with "_media" in place of its extension. ReadStructure reads it
+// back; unless it is edited, it is then written as this SoundBank would be.
+func (bnk *File) ExportStructure(path string) error {
+	media := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) +
+		"_media"
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(util.LongPath(filepath.Join(dir, media)), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	s, err := bnk.structure(dir, media)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, func(f *os.File) error {
+		_, err := f.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// structure returns the Structure of this SoundBank, writing the data of its
+// wems to the directory media within dir.
+func (bnk *File) structure(dir, media string) (*Structure, error) {
+	s := &Structure{Format: StructureFormat,
+		SchemaVersion: StructureSchemaVersion, WemAlignment: bnk.WemAlignment}
+	whole := bnk.DataSection == nil || bnk.DataSection.region != nil
+	if bnk.IndexSection != nil {
+		wems, err := bnk.structureWems(dir, media, whole)
+		if err != nil {
+			return nil, err
+		}
+		s.Wems = wems
+	}
+
+	for _, sec := range bnk.sections {
+		var ss *StructureSection
+		var err error
+		switch sec := sec.(type) {
+		case *BankHeaderSection:
+			ss = &StructureSection{Version: sec.Descriptor.Version,
+				BankId: sec.Descriptor.BankId}
+			ss.Data, err = readAll(sec.RemainingReader)
+			ss.Id = string(sec.Header.Identifier[:])
+		case *DataIndexSection:
+			ss = &StructureSection{Id: string(sec.Header.Identifier[:])}
+			ss.Data, err = readAll(sec.trailing)
+		case *DataSection:
+			ss = &StructureSection{Id: string(sec.Header.Identifier[:])}
+			if sec.region != nil {
+				ss.File = path.Join(media, structureDataFile)
+				err = writeWholeData(sec, filepath.Join(dir, media,
+					structureDataFile))
+			} else {
+				ss.Data, err = readAll(sec.trailing)
+			}
+		case *ObjectHierarchySection:
+			ss = &StructureSection{Id: string(sec.Header.Identifier[:]),
+				Empty: sec.empty}
+			ss.Objects, err = sec.structureObjects(bnk.Version())
+			if err == nil {
+				ss.Data, err = readAll(sec.trailing)
+			}
+		case *UnknownSection:
+			ss = &StructureSection{Id: string(sec.Header.Identifier[:])}
+			ss.Data, err = readAll(sec.Reader)
+		case *TrailingData:
+			s.Trailing, err = readAll(sec.Reader)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ss != nil {
+			s.Sections = append(s.Sections, ss)
+		}
+	}
+	return s, nil
+}
+
+// structureWems describes the wems of this SoundBank, writing the data of each
+// to the directory media within dir, unless the DATA section is stored whole.
+func (bnk *File) structureWems(dir, media string,
+	whole bool) ([]*StructureWem, error) {
+	// The entries are read as they are written, as the offsets of placeholders
+	// that were out of order differ from their descriptors until the index is
+	// modified.
+	idx := bnk.IndexSection
+	b := new(bytes.Buffer)
+	if _, err := idx.WriteTo(b); err != nil {
+		return nil, err
+	}
+	entries := b.Bytes()[SECTION_HEADER_BYTES:]
+
+	var wems []*StructureWem
+	off := int64(0)
+	for i, id := range idx.WemIds {
+		entry := entries[i*DIDX_ENTRY_BYTES:]
+		offset := binary.LittleEndian.Uint32(entry[4:])
+		length := binary.LittleEndian.Uint32(entry[8:])
+		sw := &StructureWem{Id: id}
+		wems = append(wems, sw)
+		if whole {
+			sw.Offset, sw.Length = &offset, &length
+			continue
+		}
+
+		wem := bnk.Wems()[i]
+		if length > 0 {
+			name := fmt.Sprintf("%d.wem", id)
+			sw.File = path.Join(media, name)
+			_, err := wwise.ExportWem(wem, filepath.Join(dir, media, name))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if int64(offset) != off {
+			sw.Offset = &offset
+		}
+		padding, err := readAll(wem.Padding)
+		if err != nil {
+			return nil, err
+		}
+		end := off + int64(length)
+		last := i == len(idx.WemIds)-1
+		if !bytes.Equal(padding, make([]byte, len(padding))) {
+			sw.PaddingData = padding
+		} else if n := int64(len(padding)); n !=
+			defaultPadding(end, bnk.WemAlignment, last) {
+			sw.Padding = &n
+		}
+		off = end + int64(len(padding))
+	}
+	return wems, nil
+}
+
+// defaultPadding returns the number of bytes of padding that follow a wem that
+// ends at offset end of the DATA section, unless its structure says otherwise.
+func defaultPadding(end, alignment int64, last bool) int64 {
+	if last || alignment <= 0 {
+		return 0
+	}
+	return (alignment - end%alignment) % alignment
+}
+
+// writeWholeData writes the data of a DATA section that is stored whole to the
+// file at path.
+func writeWholeData(data *DataSection, path string) error {
+	f, err := os.Create(util.LongPath(path))
+	if err != nil {
+		return err
+	}
+	_, err = wwise.WriteWems(f, data.layout())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readAll returns the bytes of r, or nil if r is nil or empty.
+func readAll(r io.Reader) ([]byte, error) {
+	if r == nil {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(util.NewIndependentReader(r))
+	if len(b) == 0 {
+		b = nil
+	}
+	return b, err
+}
+
+// structureObjects describes the objects of this section, decoding the Sound,
+// Event and Action objects. version is the version of the SoundBank.
+func (hrc *ObjectHierarchySection) structureObjects(
+	version uint32) ([]*StructureObject, error) {
+	hrc.decode()
+	var objects []*StructureObject
+	for _, obj := range hrc.objects {
+		switch obj := obj.(type) {
+		case *SfxVoiceSoundObject:
+			desc := obj.Descriptor
+			so := &StructureObject{Type: desc.Type,
+				TypeName: ObjectTypeName(desc.Type), Id: desc.ObjectId}
+			sound, err := structureSound(obj)
+			if err != nil {
+				return nil, err
+			}
+			so.Sound = sound
+			objects = append(objects, so)
+		case *UnknownObject:
+			desc := obj.Descriptor
+			so := &StructureObject{Type: desc.Type,
+				TypeName: ObjectTypeName(desc.Type), Id: desc.ObjectId}
+			data, err := obj.data()
+			if err != nil {
+				return nil, err
+			}
+			so.Event, so.Action = decodeStructureObject(desc.Type, data, version)
+			if so.Event == nil && so.Action == nil && len(data) > 0 {
+				so.Data = data
+			}
+			objects = append(objects, so)
+		}
+	}
+	return objects, nil
+}
+
+// structureSound describes a decoded Sound object.
+func structureSound(sound *SfxVoiceSoundObject) (*StructureSound, error) {
+	ss := sound.Structure
+	s := &StructureSound{Unknown: *sound.Unknown,
+		WemId:                 sound.WemDescriptor.WemId,
+		WemLength:             sound.WemDescriptor.WemLength,
+		SoundType:             sound.Type,
+		OverrideParentEffects: ss.OverrideParentEffects,
+		EffectBypass:          ss.EffectContainer.Bypass,
+		ParentId: binary.LittleEndian.Uint32(
+			ss.Unknown[parentIdOffset:]),
+		BaseUnknownEnd: ss.Unknown[parentIdOffset+4],
+		Parameters:     []*StructureParameter{},
+	}
+	copy(s.BaseUnknown[:], ss.Unknown[:parentIdOffset])
+	for _, e := range ss.EffectContainer.Effects {
+		s.Effects = append(s.Effects, &StructureEffect{e.Index, e.Id, e.Padding})
+	}
+	for i, t := range ss.ParameterTypes {
+		s.Parameters = append(s.Parameters, &StructureParameter{t,
+			binary.LittleEndian.Uint32(ss.ParameterValues[i][:])})
+	}
+	remaining, err := readAll(ss.RemainingReader)
+	if err != nil {
+		return nil, err
+	}
+	s.Remaining = remaining
+	return s, nil
+}
+
+// decodeStructureObject decodes the data of an Event or Action object, which
+// follows its id. It returns nil for both if the object is of another type, or
+// cannot be decoded into a form that is encoded back as the same data.
+func decodeStructureObject(t byte, data []byte,
+	version uint32) (*StructureEvent, *StructureAction) {
+	switch t {
+	case eventObjectId:
+		ids, err := decodeEventActions(data, version)
+		if err != nil {
+			return nil, nil
+		}
+		encoded := encodeEventActions(ids, version)
+		if !bytes.HasPrefix(data, encoded) {
+			return nil, nil
+		}
+		remaining := data[len(encoded):]
+		if len(remaining) == 0 {
+			remaining = nil
+		}
+		return &StructureEvent{ids, remaining}, nil
+	case actionObjectId:
+		if len(data) < 6 {
+			return nil, nil
+		}
+		remaining := data[6:]
+		if len(remaining) == 0 {
+			remaining = nil
+		}
+		return nil, &StructureAction{binary.LittleEndian.Uint16(data),
+			binary.LittleEndian.Uint32(data[2:]), remaining}
+	}
+	return nil, nil
+}
+
+// encodeEventActions encodes the action count and ids of an event, as they are
+// decoded by decodeEventActions.
+func encodeEventActions(ids []uint32, version uint32) []byte {
+	var b []byte
+	if version <= lastFixedActionCountVersion {
+		b = make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, uint32(len(ids)))
+	} else {
+		b = make([]byte, binary.MaxVarintLen64)
+		b = b[:binary.PutUvarint(b, uint64(len(ids)))]
+	}
+	for _, id := range ids {
+		var v [4]byte
+		binary.LittleEndian.PutUint32(v[:], id)
+		b = append(b, v[:]...)
+	}
+	return b
+}
+
+// ReadStructure reads a Structure from the JSON file at path, as written by
+// ExportStructure. The files that it refers to are read once it is written.
+func ReadStructure(path string) (*Structure, error) {
+	f, err := os.Open(util.LongPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := new(Structure)
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(s); err != nil {
+		return nil, err
+	}
+	if s.Format != StructureFormat {
+		return nil, fmt.Errorf("The file is not a SoundBank structure; its "+
+			"format is %q rather than %q", s.Format, StructureFormat)
+	}
+	if s.SchemaVersion < 1 || s.SchemaVersion > StructureSchemaVersion {
+		return nil, fmt.Errorf("Schema version %d is not supported; the "+
+			"supported versions are 1 to %d", s.SchemaVersion,
+			StructureSchemaVersion)
+	}
+	s.dir = filepath.Dir(path)
+	return s, nil
+}
+
+// OpenStructure reads the Structure at path, as ReadStructure does, and
+// returns the SoundBank that it describes. The SoundBank is built in memory.
+func OpenStructure(path string) (*File, error) {
+	s, err := ReadStructure(path)
+	if err != nil {
+		return nil, err
+	}
+	b := new(bytes.Buffer)
+	if _, err := s.WriteTo(b); err != nil {
+		return nil, err
+	}
+	return NewFile(bytes.NewReader(b.Bytes()))
+}
+
+// A structureLayout places the wems of a Structure within its DATA section.
+type structureLayout struct {
+	wems []*layoutWem
+	// The length of the data of the wems, and the file holding it if the DATA
+	// section is stored whole.
+	length int64
+	whole  string
+}
+
+// A layoutWem is a single wem placed by a structureLayout.
+type layoutWem struct {
+	desc    wwise.WemDescriptor
+	file    string
+	padding []byte
+}
+
+// file returns the path of a file named by the structure.
+func (s *Structure) file(name string) string {
+	p := filepath.FromSlash(name)
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.dir, p)
+}
+
+// section returns the first section of the structure with the identifier id,
+// or nil if there is none.
+func (s *Structure) section(id [4]byte) *StructureSection {
+	for _, sec := range s.Sections {
+		if sec.Id == string(id[:]) {
+			return sec
+		}
+	}
+	return nil
+}
+
+// layout places the wems of the structure, reading the size of each of their
+// files.
+func (s *Structure) layout() (*structureLayout, error) {
+	l := new(structureLayout)
+	data := s.section(dataHeaderId)
+	if data != nil && data.File != "" {
+		l.whole = s.file(data.File)
+		info, err := os.Stat(util.LongPath(l.whole))
+		if err != nil {
+			return nil, err
+		}
+		l.length = info.Size()
+	}
+	whole := data == nil || l.whole != ""
+
+	seen := make(map[uint32]bool)
+	off := int64(0)
+	for i, sw := range s.Wems {
+		if seen[sw.Id] {
+			return nil, &RepeatedWemError{sw.Id}
+		}
+		seen[sw.Id] = true
+		lw := &layoutWem{desc: wwise.WemDescriptor{WemId: sw.Id}}
+		l.wems = append(l.wems, lw)
+		if whole {
+			if sw.File != "" || sw.Offset == nil || sw.Length == nil {
+				return nil, fmt.Errorf("Wem %d must have an offset and length, and "+
+					"no file, as the SoundBank has no DATA section or stores it "+
+					"whole", sw.Id)
+			}
+			lw.desc.Offset, lw.desc.Length = *sw.Offset, *sw.Length
+			continue
+		}
+
+		length := int64(0)
+		switch {
+		case sw.File != "" && sw.Length != nil:
+			return nil, fmt.Errorf("Wem %d has both a file and a length", sw.Id)
+		case sw.File != "":
+			lw.file = s.file(sw.File)
+			info, err := os.Stat(util.LongPath(lw.file))
+			if err != nil {
+				return nil, err
+			}
+			length = info.Size()
+		case sw.Length != nil:
+			if *sw.Length != 0 {
+				return nil, fmt.Errorf("Wem %d has a length but no file", sw.Id)
+			}
+		}
+		end := off + length
+		last := i == len(s.Wems)-1
+		switch {
+		case sw.PaddingData != nil:
+			if sw.Padding != nil && *sw.Padding != int64(len(sw.PaddingData)) {
+				return nil, fmt.Errorf("Wem %d has %d bytes of padding data, but a "+
+					"padding of %d", sw.Id, len(sw.PaddingData), *sw.Padding)
+			}
+			lw.padding = sw.PaddingData
+		case sw.Padding != nil:
+			if *sw.Padding < 0 {
+				return nil, fmt.Errorf("Wem %d has a negative padding of %d", sw.Id,
+					*sw.Padding)
+			}
+			lw.padding = make([]byte, *sw.Padding)
+		default:
+			lw.padding = make([]byte, defaultPadding(end, s.WemAlignment, last))
+		}
+		if end > math.MaxUint32 {
+			return nil, &wwise.OverflowError{fmt.Sprintf("The end of wem %d",
+				sw.Id), end, overflowHint}
+		}
+		lw.desc.Offset, lw.desc.Length = uint32(off), uint32(length)
+		if sw.Offset != nil {
+			lw.desc.Offset = *sw.Offset
+		}
+		off = end + int64(len(lw.padding))
+	}
+	if !whole {
+		l.length = off
+	}
+	return l, nil
+}
+
+// WriteTo writes the SoundBank described by this Structure to w, reading the
+// data of its wems from the files that it refers to. Lengths and offsets that
+// the structure does not give are computed from its wems and objects.
+func (s *Structure) WriteTo(w io.Writer) (written int64, err error) {
+	l, err := s.layout()
+	if err != nil {
+		return 0, err
+	}
+	version := uint32(0)
+	if hdr := s.section(bkhdHeaderId); hdr != nil {
+		version = hdr.Version
+	}
+	for _, sec := range s.Sections {
+		n, err := s.writeSection(w, sec, l, version)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	n, err := w.Write(s.Trailing)
+	return written + int64(n), err
+}
+
+// writeSection writes a single section of the structure to w. l places the
+// wems of the structure, and version is the version of the SoundBank.
+func (s *Structure) writeSection(w io.Writer, sec *StructureSection,
+	l *structureLayout, version uint32) (int64, error) {
+	if len(sec.Id) != 4 {
+		return 0, fmt.Errorf("%q is not a four character section identifier",
+			sec.Id)
+	}
+	hdr := &SectionHeader{}
+	copy(hdr.Identifier[:], sec.Id)
+	body := new(bytes.Buffer)
+	var wems []*wwise.Wem
+
+	switch hdr.Identifier {
+	case bkhdHeaderId:
+		binary.Write(body, binary.LittleEndian,
+			BankDescriptor{sec.Version, sec.BankId})
+	case didxHeaderId:
+		for _, lw := range l.wems {
+			binary.Write(body, binary.LittleEndian, &lw.desc)
+		}
+	case dataHeaderId:
+		if l.whole != "" {
+			f, err := util.OpenLazy(util.LongPath(l.whole))
+			if err != nil {
+				return 0, err
+			}
+			if f.Size() != l.length {
+				return 0, fmt.Errorf("The data of the DATA section changed size " +
+					"while the SoundBank was written")
+			}
+			wems = append(wems, &wwise.Wem{
+				Reader:     util.NewResettingReader(f, 0, f.Size()),
+				Descriptor: &wwise.WemDescriptor{Length: uint32(f.Size())}})
+		}
+		for _, lw := range l.wems {
+			if l.whole != "" {
+				break
+			}
+			var r io.Reader = bytes.NewReader(nil)
+			if lw.file != "" {
+				f, err := util.OpenLazy(util.LongPath(lw.file))
+				if err != nil {
+					return 0, err
+				}
+				if f.Size() != int64(lw.desc.Length) {
+					return 0, fmt.Errorf("The file of wem %d changed size while the "+
+						"SoundBank was written", lw.desc.WemId)
+				}
+				r = util.NewResettingReader(f, 0, f.Size())
+			}
+			desc := lw.desc
+			wems = append(wems, &wwise.Wem{Reader: r, Descriptor: &desc,
+				Padding: bytes.NewReader(lw.padding)})
+		}
+	case hircHeaderId:
+		if sec.Empty {
+			if len(sec.Objects) > 0 || len(sec.Data) > 0 {
+				return 0, fmt.Errorf("The HIRC section is empty, but has objects or " +
+					"data")
+			}
+			break
+		}
+		binary.Write(body, binary.LittleEndian, uint32(len(sec.Objects)))
+		for _, obj := range sec.Objects {
+			if err := obj.writeTo(body, version); err != nil {
+				return 0, fmt.Errorf("Object %d: %s", obj.Id, err)
+			}
+		}
+	}
+
+	length := int64(body.Len()) + int64(len(sec.Data))
+	if hdr.Identifier == dataHeaderId {
+		length += l.length
+	}
+	if length > math.MaxUint32 {
+		return 0, &wwise.OverflowError{"The length of the " + sec.Id + " section",
+			length, overflowHint}
+	}
+	hdr.Length = uint32(length)
+	if err := binary.Write(w, binary.LittleEndian, hdr); err != nil {
+		return 0, err
+	}
+	written := int64(SECTION_HEADER_BYTES)
+	n, err := body.WriteTo(w)
+	written += n
+	if err != nil {
+		return written, err
+	}
+	n, err = wwise.WriteWems(w, wems)
+	written += n
+	if err != nil {
+		return written, err
+	}
+	m, err := w.Write(sec.Data)
+	return written + int64(m), err
+}
+
+// writeTo encodes this object to w. version is the version of the SoundBank.
+func (obj *StructureObject) writeTo(w io.Writer, version uint32) error {
+	decoded := 0
+	for _, ok := range []bool{obj.Sound != nil, obj.Event != nil,
+		obj.Action != nil, len(obj.Data) > 0} {
+		if ok {
+			decoded++
+		}
+	}
+	if decoded > 1 {
+		return fmt.Errorf("Only one of sound, event, action and data can be " +
+			"given")
+	}
+
+	data := obj.Data
+	switch {
+	case obj.Sound != nil:
+		sound, err := obj.Sound.object()
+		if err != nil {
+			return err
+		}
+		b := new(bytes.Buffer)
+		if _, err := sound.WriteTo(b); err != nil {
+			return err
+		}
+		data = b.Bytes()[OBJECT_DESCRIPTOR_BYTES:]
+	case obj.Event != nil:
+		data = append(encodeEventActions(obj.Event.Actions, version),
+			obj.Event.Remaining...)
+	case obj.Action != nil:
+		data = make([]byte, 6, 6+len(obj.Action.Remaining))
+		binary.LittleEndian.PutUint16(data, obj.Action.ActionType)
+		binary.LittleEndian.PutUint32(data[2:], obj.Action.TargetId)
+		data = append(data, obj.Action.Remaining...)
+	}
+
+	length := int64(len(data)) + OBJECT_DESCRIPTOR_ID_BYTES
+	if length > math.MaxUint32 {
+		return fmt.Errorf("The object is %d bytes long, longer than its length "+
+			"can hold", length)
+	}
+	desc := &ObjectDescriptor{obj.Type, uint32(length), obj.Id}
+	if err := binary.Write(w, binary.LittleEndian, desc); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// object returns the SfxVoiceSoundObject described by this StructureSound,
+// without its descriptor.
+func (s *StructureSound) object() (*SfxVoiceSoundObject, error) {
+	if len(s.Effects) > math.MaxUint8 || len(s.Parameters) > math.MaxUint8 {
+		return nil, fmt.Errorf("A Sound can have at most %d effects and %d "+
+			"parameters", math.MaxUint8, math.MaxUint8)
+	}
+	if len(s.Effects) == 0 && s.EffectBypass != 0 {
+		return nil, fmt.Errorf("A Sound with no effects cannot bypass them")
+	}
+	effects := &EffectContainer{EffectCount: byte(len(s.Effects)),
+		Bypass: s.EffectBypass}
+	for _, e := range s.Effects {
+		effects.Effects = append(effects.Effects, &Effect{e.Index, e.Id,
+			e.Padding})
+	}
+	base := new([10]byte)
+	copy(base[:], s.BaseUnknown[:])
+	binary.LittleEndian.PutUint32(base[parentIdOffset:], s.ParentId)
+	base[parentIdOffset+4] = s.BaseUnknownEnd
+
+	ss := &SoundStructure{OverrideParentEffects: s.OverrideParentEffects,
+		EffectContainer: effects, Unknown: base,
+		ParameterCount:  byte(len(s.Parameters)),
+		RemainingReader: bytes.NewReader(s.Remaining)}
+	for _, p := range s.Parameters {
+		var v [4]byte
+		binary.LittleEndian.PutUint32(v[:], p.Value)
+		ss.ParameterTypes = append(ss.ParameterTypes, p.Type)
+		ss.ParameterValues = append(ss.ParameterValues, v)
+	}
+	unknown := s.Unknown
+	return &SfxVoiceSoundObject{Descriptor: &ObjectDescriptor{},
+		Unknown:       &unknown,
+		WemDescriptor: OptionalWemDescriptor{s.WemId, s.WemLength},
+		Type:          s.SoundType, Structure: ss}, nil
+}
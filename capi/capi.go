@@ -0,0 +1,177 @@
+// Command capi is a C shared library that exports a flat C API over the bnk and
+// pck packages, so that modding frameworks written in other languages, such as
+// Python, C# or C++, can embed wwiseutil directly. Build it, along with the
+// C header declaring its functions, with:
+//
+//	go build -buildmode=c-shared -o libwwiseutil.so capi
+//
+// Containers are referred to by handles, which are valid until they are closed.
+// Every function that can fail returns NULL on success, or else a description
+// of the failure, which the caller must free with wwise_free. Wem indexes start
+// at 1, as they do elsewhere. The functions may be called from any thread, and
+// calls on different handles run in parallel.
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// cError returns a description of err that the caller frees with wwise_free,
+// or NULL if err is nil.
+func cError(err error) *C.char {
+	if err == nil {
+		return nil
+	}
+	return C.CString(err.Error())
+}
+
+// wwise_open opens the SoundBank or File Package at path, by its extension,
+// and stores its handle in handle.
+//
+//export wwise_open
+func wwise_open(path *C.char, handle *C.int64_t) *C.char {
+	id, err := openContainer(C.GoString(path))
+	if err != nil {
+		return cError(err)
+	}
+	*handle = C.int64_t(id)
+	return nil
+}
+
+// wwise_close closes the container of handle. The handle is no longer valid.
+//
+//export wwise_close
+func wwise_close(handle C.int64_t) {
+	closeContainer(int64(handle))
+}
+
+// wwise_wem_count stores the number of wems within the container of handle in
+// count.
+//
+//export wwise_wem_count
+func wwise_wem_count(handle C.int64_t, count *C.int) *C.char {
+	n, err := wemCount(int64(handle))
+	if err != nil {
+		return cError(err)
+	}
+	*count = C.int(n)
+	return nil
+}
+
+// wwise_wem_info stores the id, offset and length of the wem at index in id,
+// offset and length.
+//
+//export wwise_wem_info
+func wwise_wem_info(handle C.int64_t, index C.int, id *C.uint32_t,
+	offset *C.uint32_t, length *C.uint32_t) *C.char {
+	desc, err := wemDescriptor(int64(handle), int(index))
+	if err != nil {
+		return cError(err)
+	}
+	*id = C.uint32_t(desc.WemId)
+	*offset = C.uint32_t(desc.Offset)
+	*length = C.uint32_t(desc.Length)
+	return nil
+}
+
+// wwise_list stores a JSON array describing every wem of the container of
+// handle in list, which the caller frees with wwise_free. Each element has the
+// index, id, offset and length of a wem, and whether it has been replaced.
+//
+//export wwise_list
+func wwise_list(handle C.int64_t, list **C.char) *C.char {
+	data, err := listWems(int64(handle))
+	if err != nil {
+		return cError(err)
+	}
+	*list = C.CString(string(data))
+	return nil
+}
+
+// wwise_read_wem copies the wem at index into buf, which holds size bytes, and
+// stores the number of bytes copied in read. The wem is cut short if buf is
+// smaller than it; its length is given by wwise_wem_info.
+//
+//export wwise_read_wem
+func wwise_read_wem(handle C.int64_t, index C.int, buf unsafe.Pointer,
+	size C.int64_t, read *C.int64_t) *C.char {
+	var dst []byte
+	if size > 0 {
+		dst = unsafe.Slice((*byte)(buf), int64(size))
+	}
+	n, err := readWem(int64(handle), int(index), dst)
+	*read = C.int64_t(n)
+	return cError(err)
+}
+
+// wwise_extract writes the wem at index, as it is, to the file at path.
+//
+//export wwise_extract
+func wwise_extract(handle C.int64_t, index C.int, path *C.char) *C.char {
+	return cError(extractWem(int64(handle), int(index), C.GoString(path)))
+}
+
+// wwise_extract_all writes every wem of the container of handle, as it is, to a
+// file within dir named by its id, and stores the number of wems written in
+// exported. It stops at the first wem that cannot be written.
+//
+//export wwise_extract_all
+func wwise_extract_all(handle C.int64_t, dir *C.char,
+	exported *C.int) *C.char {
+	n, err := extractAll(int64(handle), C.GoString(dir))
+	*exported = C.int(n)
+	return cError(err)
+}
+
+// wwise_replace stages a replacement of the wem at index with the file at
+// path. The file is read only once the container is saved, and must not change
+// until then.
+//
+//export wwise_replace
+func wwise_replace(handle C.int64_t, index C.int, path *C.char) *C.char {
+	return cError(replaceWithFile(int64(handle), int(index),
+		C.GoString(path)))
+}
+
+// wwise_replace_data stages a replacement of the wem at index with the size
+// bytes at data, which are copied, so that data may be freed once the call
+// returns.
+//
+//export wwise_replace_data
+func wwise_replace_data(handle C.int64_t, index C.int, data unsafe.Pointer,
+	size C.int64_t) *C.char {
+	if size <= 0 {
+		return cError(fmt.Errorf("The replacement wem is empty"))
+	}
+	src := unsafe.Slice((*byte)(data), int64(size))
+	return cError(replaceWithData(int64(handle), int(index), src))
+}
+
+// wwise_save writes the container of handle, with every staged replacement, to
+// the file at path, and stores the number of bytes written in written. An
+// existing file is overwritten, once it is backed up if it is an original file
+// of the game, and the written file is verified. The container cannot be saved
+// over the file it was opened from, as that file is still being read from.
+//
+//export wwise_save
+func wwise_save(handle C.int64_t, path *C.char, written *C.int64_t) *C.char {
+	n, err := save(int64(handle), C.GoString(path))
+	*written = C.int64_t(n)
+	return cError(err)
+}
+
+// wwise_free frees a string returned by another function of this library.
+//
+//export wwise_free
+func wwise_free(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+func main() {}
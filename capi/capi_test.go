@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+import (
+	"bnk"
+)
+
+const loopNoneSoundBank = "../bnk/testdata/loop_none.bnk"
+
+// openTest opens path, failing t if it cannot be opened.
+func openTest(t *testing.T, path string) int64 {
+	t.Helper()
+	id, err := openContainer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestHandleLifetime(t *testing.T) {
+	if _, err := openContainer("missing.txt"); err == nil {
+		t.Error("Expected an unsupported file not to be opened")
+	}
+	a := openTest(t, loopNoneSoundBank)
+	b := openTest(t, loopNoneSoundBank)
+	if a == b {
+		t.Fatalf("Expected distinct handles, got %d twice", a)
+	}
+	if n, err := wemCount(a); err != nil || n == 0 {
+		t.Errorf("Expected the wems of an open handle, got %d: %v", n, err)
+	}
+
+	closeContainer(a)
+	if _, err := wemCount(a); err == nil {
+		t.Error("Expected a closed handle to be rejected")
+	}
+	// Closing a handle twice, or one that was never opened, does nothing.
+	closeContainer(a)
+	closeContainer(-1)
+	if _, err := wemCount(b); err != nil {
+		t.Errorf("Expected closing one handle to leave the other open: %v", err)
+	}
+	closeContainer(b)
+}
+
+func TestConcurrentCloseRejectsWaitingCalls(t *testing.T) {
+	id := openTest(t, loopNoneSoundBank)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each call either finds the container open or reports it as closed.
+			if _, err := wemCount(id); err != nil &&
+				!strings.Contains(err.Error(), "no open container") {
+				t.Error(err)
+			}
+		}()
+	}
+	closeContainer(id)
+	wg.Wait()
+}
+
+func TestWemIndexes(t *testing.T) {
+	id := openTest(t, loopNoneSoundBank)
+	defer closeContainer(id)
+	count, err := wemCount(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range []int{0, -1, count + 1} {
+		if _, err := wemDescriptor(id, index); err == nil {
+			t.Errorf("Expected wem index %d to be rejected", index)
+		}
+		if _, err := readWem(id, index, make([]byte, 1)); err == nil {
+			t.Errorf("Expected reading wem index %d to be rejected", index)
+		}
+		if err := replaceWithData(id, index, []byte{1}); err == nil {
+			t.Errorf("Expected replacing wem index %d to be rejected", index)
+		}
+	}
+	if _, err := wemDescriptor(id, count); err != nil {
+		t.Errorf("Expected the last wem, %d, to be valid: %v", count, err)
+	}
+}
+
+func TestReadWemIsCutShort(t *testing.T) {
+	id := openTest(t, loopNoneSoundBank)
+	defer closeContainer(id)
+	desc, err := wemDescriptor(id, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	whole := make([]byte, desc.Length+16)
+	n, err := readWem(id, 1, whole)
+	if err != nil || n != int(desc.Length) {
+		t.Fatalf("Expected %d bytes, read %d: %v", desc.Length, n, err)
+	}
+
+	part := make([]byte, desc.Length/2)
+	n, err = readWem(id, 1, part)
+	if err != nil || n != len(part) {
+		t.Fatalf("Expected %d bytes, read %d: %v", len(part), n, err)
+	}
+	if !bytes.Equal(part, whole[:len(part)]) {
+		t.Error("Expected a short buffer to hold the start of the wem")
+	}
+	if n, err := readWem(id, 1, nil); n != 0 || err != nil {
+		t.Errorf("Expected an empty buffer to read nothing, read %d: %v", n, err)
+	}
+}
+
+func TestReplaceAndSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "source.bnk")
+	data, err := ioutil.ReadFile(loopNoneSoundBank)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(src, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	id := openTest(t, src)
+	defer closeContainer(id)
+
+	replacement := bytes.Repeat([]byte{0xAB}, 100)
+	if err := replaceWithData(id, 1, replacement); err != nil {
+		t.Fatal(err)
+	}
+	// The replacement is copied, so the caller may reuse its buffer.
+	replacement[0] = 0
+	if err := replaceWithData(id, 1, nil); err == nil {
+		t.Error("Expected an empty replacement to be rejected")
+	}
+	list, err := listWems(id)
+	if err != nil || !strings.Contains(string(list), `"replaced":true`) {
+		t.Errorf("Expected the list to show the replacement: %s %v", list, err)
+	}
+
+	// The file that the container was opened from is still read from.
+	if _, err := save(id, src); err == nil {
+		t.Error("Expected saving over the source file to be rejected")
+	}
+	if got, err := ioutil.ReadFile(src); err != nil || !bytes.Equal(got, data) {
+		t.Error("Expected the source file to be left unchanged")
+	}
+
+	out := filepath.Join(dir, "out.bnk")
+	n, err := save(id, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := bnk.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+	if fi, err := os.Stat(out); err != nil || fi.Size() != n {
+		t.Errorf("Expected %d bytes to be written", n)
+	}
+	wem, err := ioutil.ReadAll(saved.Wems()[0].NewReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wem) != 100 || wem[0] != 0xAB {
+		t.Error("Expected the saved SoundBank to hold a copy of the replacement")
+	}
+}
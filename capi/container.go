@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+)
+
+import (
+	"session"
+	"util"
+	"wwise"
+)
+
+// The functions of this file implement the C API in plain Go, so that they can
+// be tested without cgo; each exported C function converts its arguments and
+// calls one of them.
+
+// A wemInfo describes a single wem, as it is listed by wwise_list.
+type wemInfo struct {
+	Index    int    `json:"index"`
+	Id       uint32 `json:"id"`
+	Offset   uint32 `json:"offset"`
+	Length   uint32 `json:"length"`
+	Replaced bool   `json:"replaced"`
+}
+
+// The open containers, each kept in a session under its handle.
+var sessions = session.NewStore()
+
+// The last handle given to a container.
+var lastHandle int64
+
+// key returns the key that the session of the handle id is kept under.
+func key(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// openContainer opens the SoundBank or File Package at path, by its extension,
+// and returns its handle.
+func openContainer(path string) (int64, error) {
+	ctn, err := session.OpenFile(context.Background(), path)
+	if err != nil {
+		return 0, fmt.Errorf("Could not parse .bnk or .pck file: %s", err)
+	}
+	id := atomic.AddInt64(&lastHandle, 1)
+	sessions.Put(key(id), session.New(ctn, path))
+	return id, nil
+}
+
+// closeContainer closes the container of the handle id, once any call using it
+// is done. The handle is no longer valid.
+func closeContainer(id int64) {
+	sessions.Close(key(id))
+}
+
+// lookup returns the locked session of the handle id.
+func lookup(id int64) (*session.Session, error) {
+	sess := sessions.Lock(key(id))
+	if sess == nil {
+		return nil, fmt.Errorf("There is no open container with handle %d", id)
+	}
+	return sess, nil
+}
+
+// lookupWem returns the locked session of the handle id, and the index,
+// starting at 0, of the wem at index within its container.
+func lookupWem(id int64, index int) (*session.Session, int, error) {
+	sess, err := lookup(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	count := len(sess.Container.Wems())
+	if index < 1 || index > count {
+		sess.Unlock()
+		return nil, 0, fmt.Errorf("%d is not a valid wem index; the valid "+
+			"index range is %d to %d", index, 1, count)
+	}
+	return sess, index - 1, nil
+}
+
+// wemCount returns the number of wems within the container of the handle id.
+func wemCount(id int64) (int, error) {
+	sess, err := lookup(id)
+	if err != nil {
+		return 0, err
+	}
+	defer sess.Unlock()
+	return len(sess.Container.Wems()), nil
+}
+
+// wemDescriptor returns the descriptor of the wem at index.
+func wemDescriptor(id int64, index int) (wwise.WemDescriptor, error) {
+	sess, i, err := lookupWem(id, index)
+	if err != nil {
+		return wwise.WemDescriptor{}, err
+	}
+	defer sess.Unlock()
+	return *sess.Container.Wems()[i].Descriptor, nil
+}
+
+// listWems returns a JSON array describing every wem of the container of the
+// handle id.
+func listWems(id int64) ([]byte, error) {
+	sess, err := lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Unlock()
+	wems := []*wemInfo{}
+	for i, wem := range sess.Container.Wems() {
+		desc := wem.Descriptor
+		wems = append(wems, &wemInfo{i + 1, desc.WemId, desc.Offset, desc.Length,
+			sess.Replaced[i]})
+	}
+	return json.Marshal(wems)
+}
+
+// readWem copies the wem at index into buf, cutting it short if buf is smaller
+// than it, and returns the number of bytes copied.
+func readWem(id int64, index int, buf []byte) (int, error) {
+	sess, i, err := lookupWem(id, index)
+	if err != nil {
+		return 0, err
+	}
+	defer sess.Unlock()
+	wem := sess.Container.Wems()[i]
+	if n := int64(wem.Descriptor.Length); int64(len(buf)) > n {
+		buf = buf[:n]
+	}
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	return io.ReadFull(wem.NewReader(), buf)
+}
+
+// extractWem writes the wem at index, as it is, to the file at path.
+func extractWem(id int64, index int, path string) error {
+	sess, i, err := lookupWem(id, index)
+	if err != nil {
+		return err
+	}
+	defer sess.Unlock()
+	_, err = wwise.ExportWem(sess.Container.Wems()[i], path)
+	return err
+}
+
+// extractAll writes every wem of the container of the handle id, as it is, to
+// a file within dir named by its id, stopping at the first wem that cannot be
+// written. It returns the number of wems written.
+func extractAll(id int64, dir string) (int, error) {
+	sess, err := lookup(id)
+	if err != nil {
+		return 0, err
+	}
+	defer sess.Unlock()
+	if err := os.MkdirAll(util.LongPath(dir), os.ModePerm); err != nil {
+		return 0, err
+	}
+	wems := sess.Container.Wems()
+	paths := make([]string, len(wems))
+	for i, wem := range wems {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d.wem", wem.Descriptor.WemId))
+	}
+	exporter := &wwise.Exporter{StopOnError: true}
+	res, err := exporter.Run(wems, paths, nil)
+	return res.Exported, err
+}
+
+// replaceWithFile stages a replacement of the wem at index with the file at
+// path, which is read only once the container is saved.
+func replaceWithFile(id int64, index int, path string) error {
+	sess, i, err := lookupWem(id, index)
+	if err != nil {
+		return err
+	}
+	defer sess.Unlock()
+	if err := sess.Container.CheckVersion(wwise.EditWems); err != nil {
+		return err
+	}
+	wem, err := util.OpenLazy(path)
+	if err != nil {
+		return err
+	}
+	sess.Replace(i, wem, wem.Size())
+	return nil
+}
+
+// replaceWithData stages a replacement of the wem at index with a copy of
+// data.
+func replaceWithData(id int64, index int, data []byte) error {
+	sess, i, err := lookupWem(id, index)
+	if err != nil {
+		return err
+	}
+	defer sess.Unlock()
+	if err := sess.Container.CheckVersion(wwise.EditWems); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("The replacement wem is empty")
+	}
+	b := append([]byte(nil), data...)
+	sess.Replace(i, bytes.NewReader(b), int64(len(b)))
+	return nil
+}
+
+// save writes the container of the handle id, with every staged replacement,
+// to the file at path, and returns the number of bytes written, as wwise_save
+// describes.
+func save(id int64, path string) (int64, error) {
+	sess, err := lookup(id)
+	if err != nil {
+		return 0, err
+	}
+	defer sess.Unlock()
+	saved, err := sess.Save(context.Background(), path, true)
+	if err != nil {
+		return 0, err
+	}
+	return saved.Bytes, nil
+}
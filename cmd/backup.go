@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+import (
+	"util"
+)
+
+var backupOriginals bool
+
+func init() {
+	const (
+		usage = "Before overwriting the source file, or a file within game data " +
+			"(a nativePC or chunk directory), copies it to a timestamped .bak " +
+			"file beside it. Use -backup=false to overwrite such files without a " +
+			"copy."
+		flagName = "backup"
+	)
+	flag.BoolVar(&backupOriginals, flagName, true, usage)
+}
+
+// backupOutput copies the existing file at path, which is about to be
+// overwritten by a container edited from source, if the backup flag is given
+// and the file is the source or an original file of the game.
+func backupOutput(source, path string) error {
+	if !backupOriginals || path == stdioPath || !util.NeedsBackup(path, source) {
+		return nil
+	}
+	backup, err := util.BackupFile(path, time.Now())
+	if err != nil {
+		return fmt.Errorf("Could not back up \"%s\": %s", path, err)
+	}
+	fmt.Fprintln(messages, "Backed up the original file to:", backup)
+	return nil
+}
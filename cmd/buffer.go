@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+)
+
+import (
+	"util"
+)
+
+// The suffixes that a buffer size may be given with, and the number of bytes
+// that each stands for.
+var bufferSizeSuffixes = []struct {
+	suffix string
+	bytes  int
+}{
+	{"k", 1 << 10},
+	{"kb", 1 << 10},
+	{"m", 1 << 20},
+	{"mb", 1 << 20},
+}
+
+// A bufferSizeFlag sets the size of the buffers used to copy wems.
+type bufferSizeFlag struct{}
+
+func init() {
+	const (
+		usage = "The size of the buffers used to copy wems when exporting or " +
+			"writing containers, such as 256K or 4M. Larger buffers are often " +
+			"faster on hard drives and network shares. Defaults to auto, which " +
+			"chooses a size for each wem from its length."
+		flagName = "buffer-size"
+	)
+	flag.Var(bufferSizeFlag{}, flagName, usage)
+}
+
+func (bufferSizeFlag) String() string {
+	size := util.CopyBufferSize()
+	if size == util.AutoBufferSize {
+		return "auto"
+	}
+	return strconv.Itoa(size)
+}
+
+func (bufferSizeFlag) Set(value string) error {
+	size, err := parseBufferSize(value)
+	if err != nil {
+		return err
+	}
+	util.SetCopyBufferSize(size)
+	return nil
+}
+
+// parseBufferSize parses a buffer size given as "auto", or a number of bytes
+// with an optional K or M suffix.
+func parseBufferSize(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "auto" {
+		return util.AutoBufferSize, nil
+	}
+	multiplier := 1
+	for _, s := range bufferSizeSuffixes {
+		if strings.HasSuffix(value, s.suffix) {
+			value, multiplier = strings.TrimSuffix(value, s.suffix), s.bytes
+			break
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, errors.New("must be auto, or a positive number of bytes " +
+			"with an optional K or M suffix")
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+import (
+	"bnk"
+	"util"
+	"wwise"
+)
+
+const buildCommand = "build"
+
+// A buildConfig describes a mod build: a set of source containers, the edits to
+// make to each, and where to write the results. Relative paths are resolved
+// against the directory containing the config file, so that a build is
+// reproducible from any working directory.
+type buildConfig struct {
+	Containers []*buildContainer `json:"containers"`
+}
+
+// A buildContainer describes the edits to make to a single source container.
+type buildContainer struct {
+	// The path to the source .bnk or .pck.
+	Source string `json:"source"`
+	// The path to write the edited container to. When the mod-layout flag is
+	// used, this is the root directory of the mod instead.
+	Output string `json:"output"`
+	// A directory of replacement wems, named by the index of the wem that they
+	// replace, as used by the replace flag.
+	ReplaceDir string `json:"replace_dir"`
+	// Individual replacement wems.
+	Replacements []*buildReplacement `json:"replacements"`
+	// Loop rules applied to the container, as used by the loops command. These
+	// are only supported for SoundBanks.
+	Loops []*loopRule `json:"loops"`
+}
+
+// A buildReplacement replaces a single wem, selected by either its id or its
+// index, where the first wem is 1.
+type buildReplacement struct {
+	Id    uint32 `json:"id"`
+	Index int    `json:"index"`
+	Wem   string `json:"wem"`
+}
+
+// readBuildConfig reads and validates the build config at path. All relative
+// paths within the config are resolved before it is returned.
+func readBuildConfig(path string) (*buildConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := new(buildConfig)
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Containers) == 0 {
+		return nil, errors.New("no containers are listed")
+	}
+
+	base := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(base, p)
+	}
+	for i, c := range cfg.Containers {
+		if c.Source == "" || c.Output == "" {
+			return nil, fmt.Errorf("container %d: source and output are required",
+				i+1)
+		}
+		c.Source, c.Output = resolve(c.Source), resolve(c.Output)
+		c.ReplaceDir = resolve(c.ReplaceDir)
+		for j, r := range c.Replacements {
+			if (r.Id == 0) == (r.Index == 0) || r.Wem == "" {
+				return nil, fmt.Errorf("container %d, replacement %d: exactly one "+
+					"of id or index, and a wem, are required", i+1, j+1)
+			}
+			r.Wem = resolve(r.Wem)
+		}
+		for j, rule := range c.Loops {
+			if err := rule.prepare(); err != nil {
+				return nil, fmt.Errorf("container %d, loop rule %d: %s", i+1, j+1,
+					err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// runBuild executes every step of the build config in args. Up to jobs
+// containers are built at once.
+func runBuild(args []string) {
+	if len(args) != 1 {
+		usageError("build expects exactly one build config file")
+	}
+	cfg, err := readBuildConfig(args[0])
+	if err != nil {
+		fatal(args[0], exitValidationFailure, "Could not read build config: %s",
+			err)
+	}
+
+	verifyJobs()
+	forEachJob(len(cfg.Containers), func(i int) {
+		buildContainerOutput(cfg.Containers[i])
+	})
+	if dryRun {
+		fmt.Fprintf(messages, "Dry run: %d container(s) would be built\n",
+			len(cfg.Containers))
+		return
+	}
+	fmt.Fprintf(messages, "Built %d container(s)\n", len(cfg.Containers))
+}
+
+func buildContainerOutput(c *buildContainer) {
+	ctn, err := openFile(c.Source)
+	if err != nil {
+		fatal(c.Source, openErrorCode(err), "Could not parse .bnk or .pck file: "+
+			"%s", err)
+	}
+	defer ctn.Close()
+
+	var rs []*wwise.ReplacementWem
+	if c.ReplaceDir != "" {
+		rs = append(rs, readTargetDir(ctn, c.ReplaceDir)...)
+	}
+	for _, r := range c.Replacements {
+		rs = append(rs, c.openReplacement(ctn, r))
+	}
+	// Only the last replacement for each wem is kept, so that individual
+	// replacements override those from a directory.
+	rs = wwise.SortReplacements(rs)
+
+	if len(rs) > 0 {
+		checkVersion(ctn, c.Source, wwise.EditWems)
+	}
+	before := snapshotDescriptors(ctn)
+	ctn.ReplaceWems(rs...)
+
+	if len(c.Loops) > 0 {
+		b, ok := ctn.(*bnk.File)
+		if !ok {
+			fatal(c.Source, exitValidationFailure, "Loop rules are only supported "+
+				"for SoundBank files")
+		}
+		checkVersion(b, c.Source, wwise.EditLoops)
+		applyLoopRules(b, c.Source, c.Loops)
+	}
+
+	out := modOutputPath(c.Source, c.Output)
+	if dryRun {
+		printReplacePlan(ctn, before, rs, out)
+		return
+	}
+	err = os.MkdirAll(filepath.Dir(out), os.ModePerm)
+	if err != nil {
+		fatal(out, exitFailure, "Could not create output directory: %s", err)
+	}
+	total := writeOutput(ctn, c.Source, out)
+	fmt.Fprintf(messages, "Wrote %d bytes to %s\n", total, out)
+}
+
+// openReplacement opens the replacement wem described by r, exiting if it is
+// invalid.
+func (c *buildContainer) openReplacement(ctn wwise.Container,
+	r *buildReplacement) *wwise.ReplacementWem {
+	index := r.Index - 1
+	if r.Id != 0 {
+		index = ctn.IndexOf(r.Id)
+		if index < 0 {
+			fatal(r.Wem, exitValidationFailure, "%s has no wem with id %d",
+				c.Source, r.Id)
+		}
+	}
+	if index < 0 || index >= len(ctn.Wems()) {
+		fatal(r.Wem, exitValidationFailure, "%d is not a valid index; %s's valid "+
+			"index range is %d to %d", r.Index, c.Source, 1, len(ctn.Wems()))
+	}
+
+	f, err := util.OpenLazy(r.Wem)
+	if err != nil {
+		fatal(r.Wem, exitFailure, "Could not open replacement wem: %s", err)
+	}
+	return &wwise.ReplacementWem{Wem: f, WemIndex: index, Length: f.Size()}
+}
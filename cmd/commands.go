@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	openCommand    = "open"
+	exportCommand  = "export"
+	replaceCommand = "replace"
+	loopCommand    = "loop"
+	dumpCommand    = "dump"
+	helpCommand    = "help"
+)
+
+// The flags accepted by every command.
+var commonFlags = []string{"errors-json", "verbose", "v", "buffer-size",
+	"memory-threshold", "index-cache"}
+
+// A command is run by name, as the first argument, rather than by flag. Each
+// command accepts only the flags that apply to it, which may be given before or
+// after its arguments.
+type command struct {
+	name    string
+	aliases []string
+	// A synopsis of the arguments that the command expects.
+	args string
+	// A one line description of what the command does.
+	description string
+	// The names of the flags, registered on the default flag set, that apply to
+	// this command.
+	flags []string
+	// run is given the arguments that remain after parsing flags.
+	run func(args []string)
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{openCommand, []string{shellCommand}, "<file>",
+			"Opens a .bnk or .pck in an interactive shell to list and replace wems.",
+			[]string{"verify", "backup", "strict-data-length"}, runShell},
+		{exportCommand, nil, "<file or directory>",
+			"Writes the wems of a .bnk or .pck, or of every .bnk and .pck within a " +
+				"directory, to the output directory.",
+			[]string{"output", "o", "names", "jobs", "j", "dry-run", "n"},
+			runExport},
+		{convertCommand, nil, "<file>...",
+			"Exports the wems of .bnk or .pck files as playable .ogg or .wav files.",
+			[]string{"output", "o", "format", "cache-size", "names", "jobs", "j",
+				"dry-run", "n"},
+			runConvert},
+		{replaceCommand, nil, "<file>",
+			"Replaces the wems of a .bnk or .pck with those in the target directory.",
+			[]string{"output", "o", "target", "t", "mod-layout", "in-place",
+				"verify", "backup", "strict-data-length", "dry-run", "n"},
+			runReplace},
+		{loopCommand, []string{loopsCommand}, "<file>",
+			"Applies a file of loop rules to the wems of a .bnk.",
+			[]string{"output", "o", "rules", "mod-layout", "in-place", "verify",
+				"backup", "strict-data-length", "dry-run", "n"},
+			runLoops},
+		{dumpCommand, nil, "<file>",
+			"Prints the index, id, offset and length of every wem in a .bnk or .pck.",
+			nil, runDump},
+		{infoCommand, nil, "<file>",
+			"Reports the format of a .bnk or .pck and which operations it supports.",
+			nil, runInfo},
+		{hircCommand, nil, "<file>",
+			"Prints the decoded object hierarchy of a .bnk.",
+			[]string{"names"}, runHirc},
+		{diffCommand, nil, "<old directory> <new directory>",
+			"Reports the wems that changed between two game installs, as JSON to " +
+				"output if it is given.",
+			[]string{"output", "o", "jobs", "j"}, runDiff},
+		{buildCommand, nil, "<config>",
+			"Builds every container described by a JSON mod project config.",
+			[]string{"jobs", "j", "mod-layout", "in-place", "verify", "backup",
+				"strict-data-length", "dry-run", "n"},
+			runBuild},
+		{pckCommand, nil, "extract-bnk|inject-bnk <file.pck> [args]...",
+			"Extracts or injects the SoundBanks embedded within a .pck.",
+			[]string{"output", "o", "mod-layout", "in-place", "verify", "backup",
+				"dry-run", "n"},
+			runPck},
+		{structureCommand, nil, "export|import <file>",
+			"Exports the structure of a .bnk as editable JSON, or builds a .bnk from " +
+				"one.",
+			[]string{"output", "o", "verify", "backup"}, runStructure},
+		{serveCommand, nil, "",
+			"Serves an HTTP API to open containers, list, download, export and " +
+				"replace wems, edit loops, and save the result.",
+			[]string{"addr", "root", "cache-size"}, runServe},
+		{manifestCommand, nil, "<file or directory>...",
+			"Writes a manifest of the sizes and SHA-256s of files to output.",
+			[]string{"output", "o", "jobs", "j", "dry-run", "n"}, runManifest},
+		{verifyCommand, nil, "<manifest>",
+			"Checks files against the sizes and SHA-256s listed by a manifest.",
+			[]string{"jobs", "j"}, runVerify},
+		{helpCommand, nil, "[command]",
+			"Prints help for a command.", nil, runHelp},
+	}
+	flag.Usage = printUsage
+}
+
+// lookupCommand returns the command with the specified name or alias, or nil
+// if there is no such command.
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// flagSet returns a flag set of the flags that apply to this command. The flags
+// share their values with the default flag set.
+func (cmd *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	for _, name := range append(cmd.flags, commonFlags...) {
+		f := flag.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+		// The value may have been parsed already, so restore its default.
+		fs.Lookup(name).DefValue = f.DefValue
+	}
+	fs.Usage = cmd.printUsage
+	return fs
+}
+
+// execute parses the flags within args, then runs this command with the
+// arguments that remain.
+func (cmd *command) execute(args []string) {
+	fs := cmd.flagSet()
+	// Errors found once flags have been parsed are followed by this command's
+	// usage rather than that of the whole program.
+	flag.Usage = cmd.printUsage
+
+	var positional []string
+	for {
+		fs.Parse(args)
+		rest := fs.Args()
+		if len(rest) == 0 {
+			break
+		}
+		if consumed := len(args) - len(rest); consumed > 0 &&
+			args[consumed-1] == "--" {
+			// Everything following a -- terminator is an argument.
+			positional = append(positional, rest...)
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	cmd.run(positional)
+}
+
+func (cmd *command) printUsage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: %s %s [flags] %s\n\n%s\n", programName(), cmd.name,
+		cmd.args, cmd.description)
+	if len(cmd.aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(cmd.aliases, ", "))
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	cmd.flagSet().PrintDefaults()
+}
+
+func printUsage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [args]\n\nCommands:\n",
+		programName())
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s%s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(w, "\nRun \"%s help <command>\" for the flags of a command.\n",
+		programName())
+	fmt.Fprintf(w, "\nThe following flags are also accepted without a command, "+
+		"as in previous versions:\n")
+	flag.PrintDefaults()
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// runHelp prints the usage of the command named in args, or of the whole
+// program if there is none.
+func runHelp(args []string) {
+	if len(args) == 0 {
+		printUsage()
+		return
+	}
+	cmd := lookupCommand(args[0])
+	if cmd == nil {
+		usageError(flagError(args[0] + " is not a command"))
+	}
+	cmd.printUsage()
+}
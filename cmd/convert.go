@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+import (
+	"convert"
+	"util"
+	"wwise"
+)
+
+const convertCommand = "convert"
+
+var convertFormat string
+
+var cacheSize int
+
+func init() {
+	const (
+		usage = "The audio format, either ogg or wav, that the convert command " +
+			"converts wems to."
+		flagName = "format"
+	)
+	flag.StringVar(&convertFormat, flagName, "ogg", usage)
+}
+
+func init() {
+	const (
+		usage = "The size limit, in megabytes, of the cache of converted wems, " +
+			"which is shared with the GUI. Wems found in the cache are copied " +
+			"rather than converted again. 0 disables the cache."
+		flagName = "cache-size"
+	)
+	flag.IntVar(&cacheSize, flagName, 1024, usage)
+}
+
+// A convertJob is a single wem to convert, and the path to write it to.
+type convertJob struct {
+	wem  *wwise.Wem
+	path string
+}
+
+// runConvert exports the wems of the .bnk or .pck files in args to the output
+// directory, converting each of them to the format given by the format flag.
+// When several files are given, the wems of each are written to their own
+// directory within output.
+func runConvert(args []string) {
+	if len(args) == 0 {
+		usageError("convert expects at least one .bnk or .pck file")
+	}
+	to := convert.ParseFormat(convertFormat)
+	switch {
+	case to == convert.UnknownFormat:
+		usageError(flagError(convertFormat + " is not a supported audio format"))
+	case output == "" || output == stdioPath:
+		usageError("output must be a directory")
+	case cacheSize < 0:
+		usageError("cache-size must not be negative")
+	}
+	verifyJobs()
+
+	for _, path := range args {
+		filePath = path
+		verifyInputType()
+	}
+	names := openNames()
+	ctns := openContainers(args)
+	var work []convertJob
+	for i, path := range args {
+		ctn := ctns[i]
+		defer ctn.Close()
+
+		dir := output
+		if len(args) > 1 {
+			dir = filepath.Join(output,
+				strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		}
+		for i, name := range exportPaths(ctn, names) {
+			name = strings.TrimSuffix(name, wemExtension) + to.Extension()
+			work = append(work, convertJob{ctn.Wems()[i], filepath.Join(dir, name)})
+		}
+	}
+	if dryRun {
+		for _, job := range work {
+			fmt.Fprintf(messages, "Would write %s\n", job.path)
+		}
+		fmt.Fprintf(messages, "Dry run: %d wem(s) would be converted to %s\n",
+			len(work), output)
+		return
+	}
+
+	c := convert.NewConverter()
+	if cacheSize > 0 {
+		c.Cache = convert.NewCache(convert.DefaultCacheDir(),
+			int64(cacheSize)<<20)
+	}
+	var mu sync.Mutex
+	count := 0
+	forEachJob(len(work), func(i int) {
+		if convertWem(c, work[i].wem, to, work[i].path) {
+			mu.Lock()
+			count++
+			mu.Unlock()
+		}
+	})
+
+	fmt.Fprintf(messages, "Successfully converted %d wem(s) to %s\n", count,
+		output)
+}
+
+// openContainers opens each of the .bnk or .pck files at paths, several at
+// once, exiting if any of them could not be parsed.
+func openContainers(paths []string) []wwise.Container {
+	open := func(ctx context.Context, path string) (wwise.Container, error) {
+		if path == stdioPath {
+			return newStdinContainer()
+		}
+		return openFileContext(ctx, path)
+	}
+	ctns := make([]wwise.Container, len(paths))
+	errs := make([]error, len(paths))
+	wwise.OpenAll(openContext, paths, jobs, open, func(i int,
+		ctn wwise.Container, err error) {
+		ctns[i], errs[i] = ctn, err
+	})
+	for i, err := range errs {
+		if err != nil {
+			for _, ctn := range ctns {
+				if ctn != nil {
+					ctn.Close()
+				}
+			}
+			fatal(paths[i], openErrorCode(err),
+				"Could not parse .bnk or .pck file: %s", err)
+		}
+	}
+	if verbose {
+		for _, ctn := range ctns {
+			fmt.Fprintln(messages, ctn)
+		}
+	}
+	return ctns
+}
+
+// convertWem converts a single wem to the file at path, returning false and
+// recording an error if it could not be converted.
+func convertWem(c *convert.Converter, wem *wwise.Wem, to convert.Format,
+	path string) bool {
+	format, err := wem.Format()
+	if err != nil {
+		recordError(path, exitValidationFailure, "Could not read the format of "+
+			"wem %d: %s", wem.Descriptor.WemId, err)
+		return false
+	}
+	err = os.MkdirAll(util.LongPath(filepath.Dir(path)), os.ModePerm)
+	if err == nil {
+		err = c.Convert(wem, format, to, path)
+	}
+	if err != nil {
+		recordError(path, exitFailure, "Could not convert wem %d: %s",
+			wem.Descriptor.WemId, err)
+		return false
+	}
+	return true
+}
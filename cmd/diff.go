@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+import (
+	"wwise"
+)
+
+const diffCommand = "diff"
+
+// A diffReport lists the media that changed between two game installs.
+type diffReport struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+	// The paths, relative to each install, of containers that are only present
+	// in the new or old install.
+	AddedContainers   []string         `json:"added_containers,omitempty"`
+	RemovedContainers []string         `json:"removed_containers,omitempty"`
+	Containers        []*containerDiff `json:"containers"`
+}
+
+// A containerDiff lists the wems that changed between two versions of a
+// container. Only containers with changes are reported.
+type containerDiff struct {
+	Path    string   `json:"path"`
+	Added   []uint32 `json:"added,omitempty"`
+	Removed []uint32 `json:"removed,omitempty"`
+	Changed []uint32 `json:"changed,omitempty"`
+}
+
+func (d *containerDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// runDiff compares every container within the two directories in args,
+// matching containers by their path within each directory. A report is printed,
+// and is also written as JSON to output if it is given.
+func runDiff(args []string) {
+	if len(args) != 2 {
+		usageError("diff expects exactly two directories; the old install, then " +
+			"the new one")
+	}
+	verifyJobs()
+	oldDir, newDir := args[0], args[1]
+	oldPaths := relativeContainers(oldDir)
+	newPaths := relativeContainers(newDir)
+
+	report := &diffReport{Old: oldDir, New: newDir}
+	var common []string
+	for rel := range newPaths {
+		if oldPaths[rel] {
+			common = append(common, rel)
+		} else {
+			report.AddedContainers = append(report.AddedContainers,
+				filepath.ToSlash(rel))
+		}
+	}
+	for rel := range oldPaths {
+		if !newPaths[rel] {
+			report.RemovedContainers = append(report.RemovedContainers,
+				filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(common)
+	sort.Strings(report.AddedContainers)
+	sort.Strings(report.RemovedContainers)
+
+	diffs := make([]*containerDiff, len(common))
+	forEachJob(len(common), func(i int) {
+		rel := common[i]
+		d, err := diffContainers(filepath.Join(oldDir, rel),
+			filepath.Join(newDir, rel))
+		if err != nil {
+			recordError(filepath.Join(newDir, rel), openErrorCode(err), "Could not "+
+				"compare %s: %s", rel, err)
+			return
+		}
+		d.Path = filepath.ToSlash(rel)
+		diffs[i] = d
+	})
+	for _, d := range diffs {
+		if d != nil && !d.empty() {
+			report.Containers = append(report.Containers, d)
+		}
+	}
+
+	printDiffReport(report)
+	if output != "" && output != stdioPath {
+		writeDiffReport(report, output)
+	}
+}
+
+// relativeContainers returns the set of paths, relative to dir, of every
+// container within dir.
+func relativeContainers(dir string) map[string]bool {
+	if !isDir(dir) {
+		usageError(flagError(dir + " is not a directory"))
+	}
+	paths, err := findContainers(dir)
+	if err != nil {
+		fatal(dir, exitFailure, "Could not read directory: %s", err)
+	}
+	rels := make(map[string]bool)
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			fatal(path, exitFailure, "Could not read directory: %s", err)
+		}
+		rels[rel] = true
+	}
+	return rels
+}
+
+// diffContainers compares the wems of the containers at oldPath and newPath by
+// their ids and contents.
+func diffContainers(oldPath, newPath string) (*containerDiff, error) {
+	oldCtn, err := openFile(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	defer oldCtn.Close()
+	newCtn, err := openFile(newPath)
+	if err != nil {
+		return nil, err
+	}
+	defer newCtn.Close()
+
+	d, err := wwise.DiffWems(oldCtn, newCtn)
+	if err != nil {
+		return nil, err
+	}
+	return &containerDiff{Added: d.Added, Removed: d.Removed,
+		Changed: d.Changed}, nil
+}
+
+func printDiffReport(r *diffReport) {
+	w := os.Stdout
+	for _, path := range r.AddedContainers {
+		fmt.Fprintf(w, "Added container %s\n", path)
+	}
+	for _, path := range r.RemovedContainers {
+		fmt.Fprintf(w, "Removed container %s\n", path)
+	}
+	changed := 0
+	for _, d := range r.Containers {
+		fmt.Fprintf(w, "%s: %d added, %d removed, %d changed\n", d.Path,
+			len(d.Added), len(d.Removed), len(d.Changed))
+		for _, id := range d.Added {
+			fmt.Fprintf(w, "  + %d\n", id)
+		}
+		for _, id := range d.Removed {
+			fmt.Fprintf(w, "  - %d\n", id)
+		}
+		for _, id := range d.Changed {
+			fmt.Fprintf(w, "  ~ %d\n", id)
+		}
+		changed += len(d.Added) + len(d.Removed) + len(d.Changed)
+	}
+	fmt.Fprintf(messages, "%d wem(s) differ across %d container(s); %d "+
+		"container(s) added and %d removed\n", changed, len(r.Containers),
+		len(r.AddedContainers), len(r.RemovedContainers))
+}
+
+func writeDiffReport(r *diffReport, path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		fatal(path, exitFailure, "Could not create report: %s", err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		fatal(path, exitFailure, "Could not write report: %s", err)
+	}
+}
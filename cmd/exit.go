@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+)
+
+import (
+	"util"
+	"wwise"
+)
+
+// The exit codes reported by this tool. Scripts can use these to tell bad input
+// apart from failures of the tool itself.
+const (
+	// Every operation completed successfully.
+	exitSuccess = 0
+	// An unexpected error occured, such as a failure to read or write a file.
+	exitFailure = 1
+	// The command line flags were invalid.
+	exitUsage = 2
+	// The source .bnk or .pck could not be parsed.
+	exitParseError = 3
+	// The source .bnk or .pck uses a version of the format that is not
+	// supported, or that does not support the requested edit.
+	exitUnsupportedVersion = 4
+	// An input failed validation, such as a replacement wem with an invalid
+	// index.
+	exitValidationFailure = 5
+	// The operation completed, but one or more files could not be processed.
+	exitPartialSuccess = 6
+	// The output was written, but re-opening it showed that it does not hold
+	// what was written.
+	exitVerifyFailure = 7
+)
+
+var errorsJsonPath string
+
+// A fileError describes a failure to process a single file.
+type fileError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+}
+
+// An errorSummary is the document written to the path given by errors-json.
+type errorSummary struct {
+	ExitCode int          `json:"exit_code"`
+	Errors   []*fileError `json:"errors"`
+}
+
+// The per-file failures that have occured so far.
+var fileErrors = make([]*fileError, 0)
+
+// Guards fileErrors, which may be recorded to from several workers at once.
+var fileErrorsMu sync.Mutex
+
+func init() {
+	const (
+		usage = "The path of a JSON file to write a summary of all per-file " +
+			"failures and the exit code to. Nothing is written if this is empty."
+		flagName = "errors-json"
+	)
+	flag.StringVar(&errorsJsonPath, flagName, "", usage)
+}
+
+// recordError logs a failure to process the file at path and records it for
+// the error summary. Processing is expected to continue afterwards.
+func recordError(path string, code int, format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	log.Println(msg)
+	fileErrorsMu.Lock()
+	fileErrors = append(fileErrors, &fileError{path, msg, code})
+	fileErrorsMu.Unlock()
+}
+
+// fatal records a failure to process the file at path, then exits with code.
+func fatal(path string, code int, format string, v ...interface{}) {
+	recordError(path, code, format, v...)
+	exit(code)
+}
+
+// usageError prints the usage of this tool along with err, then exits.
+func usageError(err flagError) {
+	flag.Usage()
+	fatal("", exitUsage, "%s", err)
+}
+
+// exit writes the error summary, if one was requested, and exits with code.
+func exit(code int) {
+	if errorsJsonPath != "" {
+		err := writeErrorSummary(errorsJsonPath, code)
+		if err != nil {
+			log.Printf("Could not write error summary \"%s\": %s", errorsJsonPath,
+				err)
+		}
+	}
+	util.RemoveSpillFiles()
+	os.Exit(code)
+}
+
+// finish exits with exitPartialSuccess if any per-file failures were recorded,
+// or exitSuccess otherwise.
+func finish() {
+	if len(fileErrors) > 0 {
+		exit(exitPartialSuccess)
+	}
+	exit(exitSuccess)
+}
+
+func writeErrorSummary(path string, code int) error {
+	b, err := json.MarshalIndent(&errorSummary{code, fileErrors}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// openErrorCode returns the exit code that best describes err, an error
+// returned while opening a .bnk or .pck file. Errors are matched through any
+// errors that wrap them, such as a *wwise.ParseError.
+func openErrorCode(err error) int {
+	var versionErr *wwise.VersionError
+	var pathErr *os.PathError
+	switch {
+	case errors.As(err, &versionErr):
+		return exitUnsupportedVersion
+	case errors.As(err, &pathErr), errors.Is(err, context.Canceled):
+		return exitFailure
+	}
+	return exitParseError
+}
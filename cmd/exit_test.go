@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+import (
+	"wwise"
+)
+
+func TestOpenErrorCode(t *testing.T) {
+	versionErr := &wwise.VersionError{"File Package", 1, wwise.EditWems,
+		[]uint32{0x1}}
+	pathErr := &os.PathError{Op: "open", Path: "a.bnk", Err: os.ErrNotExist}
+	cases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"version", versionErr, exitUnsupportedVersion},
+		{"wrapped version", fmt.Errorf("opening: %w", versionErr),
+			exitUnsupportedVersion},
+		{"parsed version", &wwise.ParseError{"Header", 0, versionErr},
+			exitUnsupportedVersion},
+		{"path", pathErr, exitFailure},
+		{"parsed path", &wwise.ParseError{"DATA section", 8, pathErr},
+			exitFailure},
+		{"cancelled", context.Canceled, exitFailure},
+		{"parse", &wwise.ParseError{"HIRC object 3", 0x40,
+			errors.New("The object is shorter than its fields")}, exitParseError},
+		{"other", errors.New("not a SoundBank"), exitParseError},
+	}
+	for _, c := range cases {
+		if got := openErrorCode(c.err); got != c.code {
+			t.Errorf("%s: expected exit code %d but got %d", c.name, c.code, got)
+		}
+	}
+}
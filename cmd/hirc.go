@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+import (
+	"bnk"
+	"wwise"
+)
+
+const hircCommand = "hirc"
+const hircIndent = "  "
+
+// runHirc prints the decoded object hierarchy of the SoundBank in args as an
+// indented tree.
+func runHirc(args []string) {
+	if len(args) != 1 {
+		usageError("hirc expects exactly one .bnk file")
+	}
+	filePath = args[0]
+	if !verifyInputType() && filePath != stdioPath {
+		usageError("hirc only supports SoundBank files")
+	}
+	names := openNames()
+	ctn := openContainer(true)
+	defer ctn.Close()
+
+	b, ok := ctn.(*bnk.File)
+	if !ok {
+		usageError("hirc only supports SoundBank files")
+	}
+	for _, node := range b.Hierarchy() {
+		printObjectNode(os.Stdout, node, names, 0)
+	}
+}
+
+func printObjectNode(w io.Writer, n *bnk.ObjectNode, names *wwise.NameDatabase,
+	depth int) {
+	fmt.Fprintf(w, "%s%s %d", strings.Repeat(hircIndent, depth), n.TypeName(),
+		n.Id)
+	if name, ok := names.NameOf(n.Id); ok {
+		fmt.Fprintf(w, " \"%s\"", name)
+	}
+	fmt.Fprintln(w)
+
+	if n.IsSound() {
+		fmt.Fprintf(w, "%sWem %d", strings.Repeat(hircIndent, depth+1), n.WemId)
+		if name, ok := names.NameOf(n.WemId); ok {
+			fmt.Fprintf(w, " \"%s\"", name)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, child := range n.Children {
+		printObjectNode(w, child, names, depth+1)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+)
+
+import (
+	"util"
+)
+
+// An indexCacheFlag enables caching the indexes of large containers in the
+// user cache directory.
+type indexCacheFlag struct{}
+
+func init() {
+	const (
+		usage = "Caches the indexes of containers larger than 64M in the user " +
+			"cache directory, so that opening the same file again is near-instant."
+		flagName = "index-cache"
+	)
+	flag.Var(indexCacheFlag{}, flagName, usage)
+}
+
+func (indexCacheFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (indexCacheFlag) String() string {
+	return strconv.FormatBool(util.IndexCacheDir != "")
+}
+
+func (indexCacheFlag) Set(value string) error {
+	enable, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	util.IndexCacheDir = ""
+	if enable {
+		util.IndexCacheDir = util.DefaultIndexCacheDir()
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+import (
+	"bnk"
+	"pck"
+	"util"
+	"wwise"
+)
+
+const infoCommand = "info"
+
+// The largest format version expected of a container. A larger version means
+// the container was most likely written with a different byte order.
+const maxPlausibleVersion = 0xFFFF
+
+// runInfo prints a report of the format, contents and supported operations of
+// the .bnk or .pck in args.
+func runInfo(args []string) {
+	if len(args) != 1 {
+		usageError("info expects exactly one .bnk or .pck file")
+	}
+	filePath = args[0]
+	if filePath == stdioPath {
+		usageError("info cannot read a file from stdin")
+	}
+	w := os.Stdout
+	fmt.Fprintf(w, "File: %s\n", filePath)
+	printRawHeader(w, filePath)
+
+	ctn, err := openFile(filePath)
+	if err != nil {
+		fmt.Fprintln(w, "Supported operations: none")
+		fatal(filePath, openErrorCode(err), "Could not parse .bnk or .pck file: "+
+			"%s", err)
+	}
+	defer ctn.Close()
+
+	switch ctn := ctn.(type) {
+	case *bnk.File:
+		printSoundBankInfo(w, ctn)
+	case *pck.File:
+		printFilePackageInfo(w, ctn)
+	}
+}
+
+// printRawHeader prints the type, version and byte order of the container at
+// path, as read directly from its leading bytes. This is printed even if the
+// container cannot be parsed.
+func printRawHeader(w io.Writer, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fatal(path, exitFailure, "Could not open file: %s", err)
+	}
+	defer f.Close()
+
+	var hdr [12]byte
+	if n, err := f.ReadAt(hdr[:], 0); err == io.EOF {
+		fatal(path, exitParseError, "The file is %d bytes long, too short to "+
+			"hold the header of a SoundBank or File Package", n)
+	} else if err != nil {
+		fatal(path, exitParseError, "Could not read file header: %s", err)
+	}
+	switch util.GetStreamType(f) {
+	case util.SoundBankFileType:
+		fmt.Fprintln(w, "Type: SoundBank")
+	case util.FilePackageFileType:
+		fmt.Fprintln(w, "Type: File Package")
+	default:
+		fmt.Fprintln(w, "Type: Unknown")
+		fatal(path, exitParseError, "The file is not a SoundBank or File Package")
+	}
+
+	// Both containers store their format version after an identifier and a
+	// length.
+	version, order := binary.LittleEndian.Uint32(hdr[8:]), "little-endian"
+	if version > maxPlausibleVersion {
+		if be := binary.BigEndian.Uint32(hdr[8:]); be <= maxPlausibleVersion {
+			version, order = be, "big-endian (not supported)"
+		}
+	}
+	fmt.Fprintf(w, "Version: %d\n", version)
+	fmt.Fprintf(w, "Byte order: %s\n", order)
+}
+
+func printSoundBankInfo(w io.Writer, b *bnk.File) {
+	version := uint32(0)
+	if b.BankHeaderSection != nil {
+		version = b.BankHeaderSection.Descriptor.Version
+		fmt.Fprintf(w, "Bank id: %d\n", b.BankHeaderSection.Descriptor.BankId)
+	}
+
+	fmt.Fprintln(w, "Sections:")
+	for _, s := range b.Sections() {
+		fmt.Fprintf(w, "  %s  %d bytes\n", s.Identifier, s.Length)
+	}
+	for _, m := range b.LengthMismatches() {
+		fmt.Fprintf(w, "Note: the %s section declares %d bytes, but only %d "+
+			"could be parsed\n", m.Identifier, m.Declared, m.Parsed)
+	}
+	if n := b.TrailingDataSize(); n > 0 {
+		fmt.Fprintf(w, "Note: %d byte(s) follow the last section, and are kept "+
+			"as they are\n", n)
+	}
+	for _, o := range b.Overlaps() {
+		fmt.Fprintf(w, "Note: the wem at index %d overlaps the wem at index %d\n",
+			o.Index+1, o.Other+1)
+	}
+	for _, p := range b.HierarchyProblems() {
+		fmt.Fprintf(w, "Note: %s\n", p.Description)
+	}
+	printMediaInfo(w, b)
+
+	counts := b.ObjectTypeCounts()
+	var types []int
+	for t := range counts {
+		types = append(types, int(t))
+	}
+	sort.Ints(types)
+	if len(types) == 0 {
+		fmt.Fprintln(w, "HIRC objects: none")
+	} else {
+		fmt.Fprintln(w, "HIRC objects:")
+	}
+	for _, t := range types {
+		fmt.Fprintf(w, "  %-28s%d\n", bnk.ObjectTypeName(byte(t)), counts[byte(t)])
+	}
+
+	hasHirc := b.ObjectSection != nil
+	loopReason := "no sound objects play wems embedded in this SoundBank"
+	if err := b.CheckVersion(wwise.EditLoops); err != nil {
+		loopReason = versionReason(err)
+	}
+	fmt.Fprintln(w, "Supported operations:")
+	printWemSupport(w, b)
+	printSupport(w, "Edit loops", b.LoopableWemCount() > 0, loopReason)
+	printSupport(w, "Decode hierarchy", hasHirc, "there is no HIRC section")
+	if !bnk.IsTestedVersion(version) {
+		fmt.Fprintf(w, "Note: version %d has not been tested; verify any edits "+
+			"in game\n", version)
+	}
+}
+
+func printFilePackageInfo(w io.Writer, p *pck.File) {
+	printMediaInfo(w, p)
+	banks := len(p.SoundBankIndexes())
+	fmt.Fprintf(w, "Embedded SoundBanks: %d\n", banks)
+
+	fmt.Fprintln(w, "Supported operations:")
+	printWemSupport(w, p)
+	printSupport(w, "Extract SoundBanks", banks > 0,
+		"there are no embedded SoundBanks")
+	injectReason := "there are no embedded SoundBanks"
+	err := p.CheckVersion(wwise.EditWems)
+	if banks > 0 && err != nil {
+		injectReason = versionReason(err)
+	}
+	printSupport(w, "Inject SoundBanks", banks > 0 && err == nil, injectReason)
+	printSupport(w, "Edit loops", false, "loops are stored within SoundBanks")
+}
+
+func printMediaInfo(w io.Writer, ctn wwise.Container) {
+	total := int64(0)
+	for _, wem := range ctn.Wems() {
+		total += int64(wem.Descriptor.Length)
+	}
+	fmt.Fprintf(w, "Wems: %d (%d bytes of media)\n", len(ctn.Wems()), total)
+}
+
+// printWemSupport prints whether the wems of ctn can be exported and replaced,
+// which they can unless it has none, as placeholder containers often do not,
+// or, for replacing them, its version does not support it.
+func printWemSupport(w io.Writer, ctn wwise.Container) {
+	hasWems := len(ctn.Wems()) > 0
+	printSupport(w, "Export wems", hasWems, "there are no wems")
+	replaceReason := "there are no wems"
+	err := ctn.CheckVersion(wwise.EditWems)
+	if hasWems && err != nil {
+		replaceReason = versionReason(err)
+	}
+	printSupport(w, "Replace wems", hasWems && err == nil, replaceReason)
+}
+
+// versionReason returns why an edit is not supported, as described by the
+// *wwise.VersionError err, in the form printed by printSupport.
+func versionReason(err error) string {
+	verr, ok := err.(*wwise.VersionError)
+	if !ok {
+		return err.Error()
+	}
+	return fmt.Sprintf("version %d is not supported; the supported versions "+
+		"are %s", verr.Version, wwise.FormatVersions(verr.Supported))
+}
+
+func printSupport(w io.Writer, operation string, supported bool,
+	reason string) {
+	if supported {
+		fmt.Fprintf(w, "  %s: yes\n", operation)
+		return
+	}
+	fmt.Fprintf(w, "  %s: no; %s\n", operation, reason)
+}
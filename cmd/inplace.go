@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+import (
+	"util"
+	"wwise"
+)
+
+var inPlace bool
+
+func init() {
+	const (
+		usage = "When the output file already exists, patches it in place, " +
+			"rewriting only the parts that change, rather than writing it again " +
+			"in full. This is much faster when the output is the file being " +
+			"edited, and replacements do not move the wems that follow them."
+		flagName = "in-place"
+	)
+	flag.BoolVar(&inPlace, flagName, false, usage)
+}
+
+// writeInPlace writes ctn over the existing file at path, rewriting only the
+// parts of it that change, and returns the number of bytes in the file. If the
+// changes cannot be made in place, the file is replaced in full instead. It
+// returns false, having written nothing, if there is no file at path.
+func writeInPlace(ctn wwise.Container, path string) (int64, bool) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return 0, false
+	}
+	if err != nil {
+		fatal(path, exitFailure, "Could not open output file \"%s\": %s", path,
+			err)
+	}
+	defer f.Close()
+
+	total, rewritten, err := wwise.WriteInPlace(ctn, f, nil)
+	switch {
+	case err == wwise.ErrNotInPlace:
+		fmt.Fprintf(messages, "%s cannot be patched in place, as the wems "+
+			"following a replacement move or the file would shrink while it "+
+			"is read from; writing it in full\n", path)
+		f.Close()
+		return replaceFile(ctn, path), true
+	case err != nil:
+		fatal(path, exitFailure, "Could not write output to file: %s", err)
+	}
+	fmt.Fprintf(messages, "Patched %s in place, rewriting %d of %d bytes\n",
+		path, rewritten, total)
+	return total, true
+}
+
+// replaceFile writes ctn to a new file beside path, then renames it over path,
+// so that the file at path can still be read from while ctn is written, and is
+// left as it was if ctn cannot be written.
+func replaceFile(ctn wwise.Container, path string) int64 {
+	var total int64
+	err := util.WriteFileAtomic(path, func(f *os.File) (err error) {
+		total, err = ctn.WriteTo(f)
+		return err
+	})
+	if err != nil {
+		fatal(path, exitFailure, "Could not write output to file: %s", err)
+	}
+	return total
+}
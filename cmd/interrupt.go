@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+)
+
+// openContext is the context that containers are opened with. A batch replaces
+// it with one that is cancelled when the tool is interrupted.
+var openContext = context.Background()
+
+// cancelOpensOnInterrupt makes the first interrupt, such as Ctrl+C, cancel the
+// opening of containers rather than exit the tool, so that a batch finishes
+// the containers it has opened and reports those it skipped. A second
+// interrupt exits the tool as usual. It must be called before any container is
+// opened.
+func cancelOpensOnInterrupt() {
+	ctx, cancel := context.WithCancel(context.Background())
+	openContext = ctx
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		signal.Stop(c)
+		log.Println("Interrupted; the remaining containers will be skipped. " +
+			"Interrupt again to exit immediately.")
+		cancel()
+	}()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+import (
+	"util"
+)
+
+var jobs int
+
+func init() {
+	const (
+		usage = "The number of containers or wems to process at once, when a " +
+			"command processes several of them. Defaults to the number of CPUs."
+		flagName = "jobs"
+	)
+	flag.IntVar(&jobs, flagName, runtime.NumCPU(), usage)
+	flag.IntVar(&jobs, "j", runtime.NumCPU(), shorthandDesc(flagName))
+}
+
+func verifyJobs() {
+	if jobs < 1 {
+		usageError("jobs must be at least 1")
+	}
+}
+
+// forEachJob calls f for every index from 0 to n - 1, running at most jobs
+// calls at once. It returns once every call has returned.
+func forEachJob(n int, f func(i int)) {
+	workers := jobs
+	if workers > n {
+		workers = n
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// findContainers returns the paths of every .bnk and .pck file within dir and
+// its subdirectories, in lexical order.
+func findContainers(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo,
+		err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch fileType, _ := util.GetFileType(path); fileType {
+		case util.SoundBankFileType, util.FilePackageFileType:
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// batchOutputDir returns the directory, within output, that the contents of the
+// container at path are written to when several containers are processed at
+// once. The directory mirrors the location of the container relative to root.
+func batchOutputDir(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(path)
+	}
+	return filepath.Join(output, strings.TrimSuffix(rel, filepath.Ext(rel)))
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+import (
+	"bnk"
+	"util"
+	"wwise"
+)
+
+const loopsCommand = "loops"
+
+var loopRulesPath string
+
+func init() {
+	const (
+		usage = "The path to a JSON file of loop rules, used by the loops " +
+			"command. Each rule selects wems by id, index or duration, and sets " +
+			"their loop to \"none\", \"infinite\" or a number of times to loop. " +
+			"When several rules match a wem, the last one wins."
+		flagName = "rules"
+	)
+	flag.StringVar(&loopRulesPath, flagName, "", usage)
+}
+
+// A loopRuleSet is the document read from a loop rules file.
+type loopRuleSet struct {
+	Rules []*loopRule `json:"rules"`
+}
+
+// A loopRule sets the loop of every wem that matches all of its given
+// conditions. A rule with no conditions matches every wem.
+type loopRule struct {
+	// The ids of the wems this rule applies to.
+	Ids []uint32 `json:"ids"`
+	// The indexes, where the first wem is 1, of the wems this rule applies to.
+	Indexes []int `json:"indexes"`
+	// The rule only applies to wems that play for at least this long, such as
+	// "30s".
+	MinDuration string `json:"min_duration"`
+	// The rule only applies to wems that play for at most this long.
+	MaxDuration string `json:"max_duration"`
+	// The loop to set; one of "none", "infinite" or a number of times to loop.
+	Loop json.RawMessage `json:"loop"`
+
+	loop        bnk.LoopValue
+	minDuration time.Duration
+	maxDuration time.Duration
+}
+
+// readLoopRules reads and validates the loop rules file at path.
+func readLoopRules(path string) ([]*loopRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeLoopRules(f)
+}
+
+func decodeLoopRules(r io.Reader) ([]*loopRule, error) {
+	set := new(loopRuleSet)
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(set); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range set.Rules {
+		err := rule.prepare()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %s", i+1, err)
+		}
+	}
+	return set.Rules, nil
+}
+
+// prepare parses the loop value and durations of this rule.
+func (rule *loopRule) prepare() (err error) {
+	if rule.MinDuration != "" {
+		rule.minDuration, err = time.ParseDuration(rule.MinDuration)
+		if err != nil {
+			return err
+		}
+	}
+	if rule.MaxDuration != "" {
+		rule.maxDuration, err = time.ParseDuration(rule.MaxDuration)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(rule.Loop) == 0 {
+		return errors.New("a loop value is required")
+	}
+	var name string
+	if json.Unmarshal(rule.Loop, &name) == nil {
+		switch strings.ToLower(name) {
+		case "none":
+			rule.loop = bnk.LoopValue{Loops: false}
+		case "infinite", "infinity":
+			rule.loop = bnk.LoopValue{Loops: true, Value: bnk.InfiniteLoops}
+		default:
+			return fmt.Errorf("\"%s\" is not a valid loop value", name)
+		}
+		return nil
+	}
+	var times uint32
+	if err := json.Unmarshal(rule.Loop, &times); err != nil || times < 2 {
+		return fmt.Errorf("%s is not a valid loop value; a number of times to "+
+			"loop must be an integer >= 2", rule.Loop)
+	}
+	rule.loop = bnk.LoopValue{Loops: true, Value: times}
+	return nil
+}
+
+// usesDuration returns true if this rule needs the duration of a wem to decide
+// whether it matches.
+func (rule *loopRule) usesDuration() bool {
+	return rule.MinDuration != "" || rule.MaxDuration != ""
+}
+
+// matches returns true if this rule applies to the wem at index, with the
+// specified id and duration.
+func (rule *loopRule) matches(index int, id uint32,
+	duration time.Duration) bool {
+	if len(rule.Ids) > 0 && !containsId(rule.Ids, id) {
+		return false
+	}
+	if len(rule.Indexes) > 0 && !containsIndex(rule.Indexes, index+1) {
+		return false
+	}
+	if rule.MinDuration != "" && duration < rule.minDuration {
+		return false
+	}
+	if rule.MaxDuration != "" && duration > rule.maxDuration {
+		return false
+	}
+	return true
+}
+
+// applyLoopRules sets the loops of the wems of b, read from path, as given by
+// rules, returning the number of wems whose loop changed.
+func applyLoopRules(b *bnk.File, path string, rules []*loopRule) int {
+	changed := 0
+wems:
+	for i, wem := range b.Wems() {
+		id := wem.Descriptor.WemId
+		name := util.CanonicalWemName(i, len(b.Wems()))
+
+		var duration time.Duration
+		durationKnown := false
+		var loop *bnk.LoopValue
+		for _, rule := range rules {
+			if rule.usesDuration() && !durationKnown {
+				format, err := wem.Format()
+				if err != nil {
+					recordError(path, exitValidationFailure, "Could not read "+
+						"the duration of %s (id %d): %s", name, id, err)
+					continue wems
+				}
+				duration, durationKnown = format.Duration(), true
+			}
+			if rule.matches(i, id, duration) {
+				value := rule.loop
+				loop = &value
+			}
+		}
+		if loop == nil {
+			continue
+		}
+
+		old := b.LoopOf(i)
+		if old == *loop || (!old.Loops && !loop.Loops) {
+			continue
+		}
+		if err := b.ReplaceLoopOf(i, *loop); err != nil {
+			recordError(path, exitValidationFailure, "%s (id %d): %s", name, id,
+				err)
+			continue
+		}
+		fmt.Fprintf(messages, "Loop of %s (id %d): %s -> %s\n", name, id,
+			loopString(old), loopString(*loop))
+		changed++
+	}
+	return changed
+}
+
+func loopString(loop bnk.LoopValue) string {
+	switch {
+	case !loop.Loops:
+		return "None"
+	case loop.Value == bnk.InfiniteLoops:
+		return "Infinity"
+	}
+	return fmt.Sprintf("%d times", loop.Value)
+}
+
+// runLoops applies the loop rules file given by the rules flag to the
+// SoundBank in args, writing the result to output.
+func runLoops(args []string) {
+	if len(args) != 1 {
+		usageError("loops expects exactly one .bnk file")
+	}
+	filePath = args[0]
+	switch {
+	case loopRulesPath == "":
+		usageError("rules cannot be empty")
+	case output == "":
+		usageError("output cannot be empty")
+	}
+	if output == stdioPath {
+		messages = os.Stderr
+	}
+
+	rules, err := readLoopRules(loopRulesPath)
+	if err != nil {
+		fatal(loopRulesPath, exitValidationFailure,
+			"Could not read loop rules: %s", err)
+	}
+
+	if !verifyInputType() && filePath != stdioPath {
+		usageError("loops only supports SoundBank files")
+	}
+	ctn := openContainer(true)
+	defer ctn.Close()
+	b, ok := ctn.(*bnk.File)
+	if !ok {
+		usageError("loops only supports SoundBank files")
+	}
+	checkVersion(b, filePath, wwise.EditLoops)
+
+	changed := applyLoopRules(b, filePath, rules)
+	if dryRun {
+		fmt.Fprintf(messages, "Dry run: the loops of %d wem(s) would be "+
+			"changed\n", changed)
+		return
+	}
+	out := modOutputPath(filePath, output)
+	createModOutputDir(out)
+	total := writeOutput(ctn, filePath, out)
+	fmt.Fprintf(messages, "Changed the loops of %d wem(s). Output file "+
+		"written to: %s\n", changed, out)
+	fmt.Fprintf(messages, "Wrote %d bytes in total\n", total)
+}
+
+func containsId(ids []uint32, id uint32) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIndex(indexes []int, index int) bool {
+	for _, i := range indexes {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -15,6 +17,7 @@ import (
 import (
 	"bnk"
 	"pck"
+	"session"
 	"util"
 	"wwise"
 )
@@ -22,12 +25,21 @@ import (
 const shorthandSuffix = " (shorthand)"
 const wemExtension = ".wem"
 
+// The path used to refer to stdin when used as an input, or stdout when used as
+// an output.
+const stdioPath = "-"
+
 var shouldUnpack bool
 var shouldReplace bool
 var filePath string
 var output string
 var targetPath string
 var verbose bool
+var dryRun bool
+
+// The writer that informational messages are written to. This is stderr when
+// the output file is written to stdout, so that the two are not mixed.
+var messages io.Writer = os.Stdout
 
 type flagError string
 
@@ -56,7 +68,9 @@ func init() {
 		usage = "the path to the source .bnk or .pck. When unpack is used, this " +
 			"is the bnk or pck file to unpack. When replace is used, this .bnk or " +
 			".pck is used as a source; the wem files, offsets and lengths of this " +
-			".bnk or .pck will updated and written to the file specified by output."
+			".bnk or .pck will updated and written to the file specified by output. " +
+			"Use - to read from stdin. When unpack is used, this may also be a " +
+			"directory, in which case every .bnk and .pck within it is unpacked."
 		flagName = "filepath"
 	)
 	flag.StringVar(&filePath, flagName, "", usage)
@@ -67,7 +81,7 @@ func init() {
 	const (
 		usage = "When unpack is used, this is the directory to output unpacked " +
 			".wem files. When replace is used, this is the directory to output the " +
-			"updated .bnk or .pck."
+			"updated .bnk or .pck, or - to write it to stdout."
 		flagName = "output"
 	)
 	flag.StringVar(&output, flagName, "", usage)
@@ -99,6 +113,17 @@ func init() {
 	flag.BoolVar(&verbose, "v", false, shorthandDesc(flagName))
 }
 
+func init() {
+	const (
+		usage = "Parses the source file and plans all replacements, printing " +
+			"exactly what would change (sizes, offsets and counts) without " +
+			"writing any files."
+		flagName = "dry-run"
+	)
+	flag.BoolVar(&dryRun, flagName, false, usage)
+	flag.BoolVar(&dryRun, "n", false, shorthandDesc(flagName))
+}
+
 func shorthandDesc(flagName string) string {
 	return "(shorthand for -" + flagName + ")"
 }
@@ -114,11 +139,12 @@ func verifyFlags() {
 		err = "bnkpath cannot be empty"
 	case output == "":
 		err = "output cannot be empty"
+	case shouldUnpack && output == stdioPath:
+		err = "unpack cannot write to stdout"
 	}
 
 	if err != "" {
-		flag.Usage()
-		log.Fatal(err)
+		usageError(err)
 	}
 }
 
@@ -130,103 +156,272 @@ func verifyReplaceFlags() {
 	}
 
 	if err != "" {
-		flag.Usage()
-		log.Fatal(err)
+		usageError(err)
 	}
 }
 
 // Verifies that the extension of the input file is supported. Returns true if
 // the file is a SoundBank file and false if it is a File Package file.
 func verifyInputType() bool {
+	if filePath == stdioPath {
+		// The type of stdin is determined from its contents once it is read.
+		return false
+	}
+	if shouldUnpack && isDir(filePath) {
+		// Each container within the directory is typed by its own extension.
+		return false
+	}
 	fileType, ext := util.GetFileType(filePath)
 	isSoundBank := fileType == util.SoundBankFileType
 	isFilePath := fileType == util.FilePackageFileType
 	if !(isSoundBank || isFilePath) {
-		flag.Usage()
-		log.Fatal(ext, ", is not a supported input file type")
+		usageError(flagError(ext + ", is not a supported input file type"))
 	}
 	return isSoundBank
 }
 
-func unpack(isSoundBank bool) {
+// openFile opens the .bnk or .pck file at path, determining its type from its
+// extension.
+func openFile(path string) (wwise.Container, error) {
+	return openFileContext(openContext, path)
+}
+
+// openFileContext is like openFile, but stops parsing once ctx is done.
+func openFileContext(ctx context.Context, path string) (wwise.Container,
+	error) {
+	return session.OpenFile(ctx, path)
+}
+
+// openContainer opens the source .bnk or .pck file, exiting if it could not be
+// parsed.
+func openContainer(isSoundBank bool) wwise.Container {
 	var ctn wwise.Container
 	var err error
 
-	if isSoundBank {
+	switch {
+	case filePath == stdioPath:
+		ctn, err = newStdinContainer()
+	case isSoundBank:
 		ctn, err = bnk.Open(filePath)
-	} else { // Input is file package
+	default: // Input is file package
 		ctn, err = pck.Open(filePath)
 	}
-	defer ctn.Close()
-
 	if err != nil {
-		log.Fatalln("Could not parse .bnk or .pck file:", err)
+		fatal(filePath, openErrorCode(err),
+			"Could not parse .bnk or .pck file: %s", err)
 	}
 	if verbose {
-		fmt.Println(ctn)
+		fmt.Fprintln(messages, ctn)
+	}
+	return ctn
+}
+
+// checkVersion exits if the version of ctn, read from path, does not support
+// edit, before any edit is made, rather than writing a container that the game
+// may misread.
+func checkVersion(ctn wwise.Container, path, edit string) {
+	if err := ctn.CheckVersion(edit); err != nil {
+		fatal(path, exitUnsupportedVersion, "%s", err)
 	}
+}
 
-	err = createDirIfEmpty(output)
+// newStdinContainer parses a .bnk or .pck read from stdin. The type of
+// container is determined from its contents.
+func newStdinContainer() (wwise.Container, error) {
+	r, err := util.NewSeekableReaderAt(os.Stdin)
 	if err != nil {
-		log.Fatalln("Could not create output directory:", err)
+		return nil, err
 	}
-	total := int64(0)
-	for _, wem := range ctn.Wems() {
-		filename := fmt.Sprintf("%d.wem", wem.Descriptor.WemId)
-		f, err := os.Create(filepath.Join(output, filename))
-		if err != nil {
-			log.Fatalf("Could not create wem file \"%s\": %s", filename, err)
-		}
-		n, err := io.Copy(f, wem)
-		if err != nil {
-			log.Fatalf("Could not write wem file \"%s\": %s", filename, err)
-		}
-		total += n
+	switch util.GetStreamType(r) {
+	case util.SoundBankFileType:
+		return bnk.NewFile(r)
+	case util.FilePackageFileType:
+		return pck.NewFile(r)
 	}
-	fmt.Printf("Successfully wrote %d wem(s) to %s\n", len(ctn.Wems()),
-		output)
-	fmt.Printf("Wrote %d bytes in total\n", total)
+	return nil, errors.New("stdin is not a .bnk or .pck file")
 }
 
-func replace(isSoundBank bool) {
-	var ctn wwise.Container
-	var err error
-
-	if isSoundBank {
-		ctn, err = bnk.Open(filePath)
-	} else { // Input is file package
-		ctn, err = pck.Open(filePath)
+func unpack(isSoundBank bool) {
+	names := openNames()
+	if isDir(filePath) {
+		unpackDir(names)
+		return
 	}
+	ctn := openContainer(isSoundBank)
 	defer ctn.Close()
+	unpackTo(ctn, names, output)
+}
 
+// unpackDir unpacks every container within the directory given by filePath,
+// running up to jobs of them at once. The wems of each container are written
+// to their own directory within output.
+func unpackDir(names *wwise.NameDatabase) {
+	verifyJobs()
+	paths, err := findContainers(filePath)
 	if err != nil {
-		log.Fatalln("Could not parse .bnk or .pck file:", err)
+		fatal(filePath, exitFailure, "Could not read input directory: %s", err)
 	}
-	if verbose {
-		fmt.Println(ctn)
+	if len(paths) == 0 {
+		fatal(filePath, exitValidationFailure, "No .bnk or .pck files were found")
 	}
+	cancelOpensOnInterrupt()
+	wwise.OpenAll(openContext, paths, jobs, openFileContext, func(i int,
+		ctn wwise.Container, err error) {
+		if err != nil {
+			recordError(paths[i], openErrorCode(err), "Could not parse .bnk or "+
+				".pck file: %s", err)
+			return
+		}
+		defer ctn.Close()
+		unpackTo(ctn, names, batchOutputDir(filePath, paths[i]))
+	})
+}
+
+// unpackTo writes every wem of ctn to dir, up to jobs wems at once.
+func unpackTo(ctn wwise.Container, names *wwise.NameDatabase, dir string) {
+	filenames := exportPaths(ctn, names)
+	if dryRun {
+		printUnpackPlan(ctn, filenames, dir)
+		return
+	}
+
+	err := os.MkdirAll(util.LongPath(dir), os.ModePerm)
+	if err != nil {
+		recordError(dir, exitFailure, "Could not create output directory: %s", err)
+		return
+	}
+	paths := make([]string, len(filenames))
+	for i, filename := range filenames {
+		paths[i] = filepath.Join(dir, filename)
+	}
+	exporter := &wwise.Exporter{Workers: jobs, Export: unpackWem}
+	res, _ := exporter.Run(ctn.Wems(), paths, nil)
+	for _, xerr := range res.Errors {
+		recordError(xerr.Path, exitFailure, "Could not write wem file \"%s\": %s",
+			filenames[xerr.Index], xerr.Err)
+	}
+	fmt.Fprintf(messages, "Successfully wrote %d wem(s) to %s\n", res.Exported,
+		dir)
+	fmt.Fprintf(messages, "Wrote %d bytes in total\n", res.Written)
+}
 
-	targetFileInfos, err := ioutil.ReadDir(targetPath)
+// unpackWem writes wem to the file at path, creating its directory if the wem
+// is named by a path within the output directory.
+func unpackWem(wem *wwise.Wem, path string) (int64, error) {
+	err := os.MkdirAll(util.LongPath(filepath.Dir(path)), os.ModePerm)
 	if err != nil {
-		log.Fatalf("Could not open target directory, \"%s\": %s\n", targetPath, err)
+		return 0, err
+	}
+	return wwise.ExportWem(wem, path)
+}
+
+// runExport unpacks the .bnk, .pck or directory in args, as the unpack flag
+// does.
+func runExport(args []string) {
+	if len(args) != 1 {
+		usageError("export expects exactly one .bnk or .pck file, or directory")
 	}
-	targets := processTargetFiles(ctn, targetFileInfos)
+	filePath, shouldUnpack = args[0], true
+	verifyFlags()
+	unpack(verifyInputType())
+}
+
+// runReplace replaces the wems of the .bnk or .pck in args, as the replace flag
+// does.
+func runReplace(args []string) {
+	if len(args) != 1 {
+		usageError("replace expects exactly one .bnk or .pck file")
+	}
+	filePath, shouldReplace = args[0], true
+	verifyFlags()
+	verifyReplaceFlags()
+	if output == stdioPath {
+		messages = os.Stderr
+	}
+	replace(verifyInputType())
+}
 
+// runDump prints the structure of the .bnk or .pck in args.
+func runDump(args []string) {
+	if len(args) != 1 {
+		usageError("dump expects exactly one .bnk or .pck file")
+	}
+	filePath = args[0]
+	ctn := openContainer(verifyInputType())
+	defer ctn.Close()
+	if !verbose {
+		// The structure has already been printed when verbose is set.
+		fmt.Print(ctn)
+	}
+}
+
+func replace(isSoundBank bool) {
+	ctn := openContainer(isSoundBank)
+	defer ctn.Close()
+	checkVersion(ctn, filePath, wwise.EditWems)
+
+	targets := readTargetDir(ctn, targetPath)
+
+	before := snapshotDescriptors(ctn)
 	ctn.ReplaceWems(targets...)
 
-	outputFile, err := os.Create(output)
+	out := modOutputPath(filePath, output)
+	if dryRun {
+		printReplacePlan(ctn, before, targets, out)
+		return
+	}
+
+	createModOutputDir(out)
+	total := writeOutput(ctn, filePath, out)
+	fmt.Fprintln(messages, "Sucessfuly replaced! Output file written to:", out)
+	fmt.Fprintf(messages, "Wrote %d bytes in total\n", total)
+}
+
+// writeOutput writes ctn, edited from the file at source, to the file at path,
+// or stdout, returning the number of bytes written. If the in-place flag is
+// given, an existing file at path is patched rather than written again. If the
+// verify flag is given, the file is then re-opened and checked against ctn.
+func writeOutput(ctn wwise.Container, source, path string) int64 {
+	if err := backupOutput(source, path); err != nil {
+		fatal(path, exitFailure, "%s", err)
+	}
+	total := writeOutputFile(ctn, path)
+	if err := verifyOutputFile(ctn, path); err != nil {
+		fatal(path, exitVerifyFailure, "%s", err)
+	}
+	return total
+}
+
+// writeOutputFile is like writeOutput, but never verifies the file.
+func writeOutputFile(ctn wwise.Container, path string) int64 {
+	applyStrictDataLength(ctn)
+	if inPlace && path != stdioPath {
+		if total, ok := writeInPlace(ctn, path); ok {
+			return total
+		}
+	}
+	if path != stdioPath {
+		return replaceFile(ctn, path)
+	}
+	total, err := ctn.WriteTo(os.Stdout)
 	if err != nil {
-		log.Fatalf("Could not create output file \"%s\": %s\n", output, err)
+		fatal(path, exitFailure, "Could not write output to file: %s", err)
 	}
-	total, err := ctn.WriteTo(outputFile)
+	return total
+}
+
+// readTargetDir returns replacements for the wems of c from the wem files in
+// dir, which are named by the index of the wem that they replace.
+func readTargetDir(c wwise.Container, dir string) []*wwise.ReplacementWem {
+	targetFileInfos, err := ioutil.ReadDir(dir)
 	if err != nil {
-		log.Fatalln("Could not write output to file: ", err)
+		fatal(dir, exitFailure, "Could not open target directory, \"%s\": %s",
+			dir, err)
 	}
-	fmt.Println("Sucessfuly replaced! Output file written to:", output)
-	fmt.Printf("Wrote %d bytes in total\n", total)
+	return processTargetFiles(c, dir, targetFileInfos)
 }
 
-func processTargetFiles(c wwise.Container,
+func processTargetFiles(c wwise.Container, dir string,
 	fis []os.FileInfo) []*wwise.ReplacementWem {
 	var targets []*wwise.ReplacementWem
 	var names []string
@@ -242,43 +437,114 @@ func processTargetFiles(c wwise.Container,
 		// Wems are indexed internally starting from 0, but the file names start
 		// at 1.
 		wemIndex--
+		path := filepath.Join(dir, name)
 		if err != nil {
-			log.Printf("Ignoring %s: It does not have a valid integer name",
-				name)
+			recordError(path, exitValidationFailure,
+				"Ignoring %s: It does not have a valid integer name", name)
 			continue
 		}
 		if wemIndex < 0 || wemIndex >= len(c.Wems()) {
-			log.Printf("Ignoring %s: This files's valid index range is "+
-				"%d to %d", name, 1, len(c.Wems()))
+			recordError(path, exitValidationFailure,
+				"Ignoring %s: This files's valid index range is %d to %d", name, 1,
+				len(c.Wems()))
 			continue
 		}
-		f, err := os.Open(filepath.Join(targetPath, name))
+		// The replacement is read from the file only once the container is
+		// written, so that many can be staged without being held open.
+		f, err := util.OpenLazy(path)
 		if err != nil {
-			log.Printf("Ignoring %s: Could not open file: %s", name, err)
+			recordError(path, exitFailure, "Ignoring %s: Could not open file: %s",
+				name, err)
 			continue
 		}
 
 		names = append(names, fi.Name())
-		targets = append(targets, &wwise.ReplacementWem{f, wemIndex, fi.Size()})
+		targets = append(targets, &wwise.ReplacementWem{f, wemIndex, f.Size()})
 	}
 	if len(targets) == 0 {
-		log.Fatal("There are no replacement wems")
+		fatal(dir, exitValidationFailure, "There are no replacement wems")
 	}
-	fmt.Printf("Using %d replacement wem(s): %s\n", len(targets),
+	fmt.Fprintf(messages, "Using %d replacement wem(s): %s\n", len(targets),
 		strings.Join(names, ", "))
 	return targets
 }
 
-func createDirIfEmpty(path string) error {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return os.Mkdir(output, os.ModePerm)
+// snapshotDescriptors returns a copy of the descriptors of every wem in ctn, in
+// the order that they appear in the container.
+func snapshotDescriptors(ctn wwise.Container) []wwise.WemDescriptor {
+	var descs []wwise.WemDescriptor
+	for _, wem := range ctn.Wems() {
+		descs = append(descs, *wem.Descriptor)
 	}
-	return nil
+	return descs
+}
+
+// printUnpackPlan prints the wem files that would be written to the output
+// directory by an unpack operation, where filenames gives the path of each wem
+// relative to the output directory.
+func printUnpackPlan(ctn wwise.Container, filenames []string, dir string) {
+	total := int64(0)
+	for i, wem := range ctn.Wems() {
+		fmt.Fprintf(messages, "Would write %s (%d bytes)\n",
+			filepath.Join(dir, filenames[i]), wem.Descriptor.Length)
+		total += int64(wem.Descriptor.Length)
+	}
+	fmt.Fprintf(messages, "Dry run: %d wem(s) would be written to %s\n",
+		len(ctn.Wems()), dir)
+	fmt.Fprintf(messages, "Would write %d bytes in total\n", total)
+}
+
+// printReplacePlan prints every change made to the wems of ctn, compared to
+// the descriptors in before, as a result of replacing the wems in rs.
+func printReplacePlan(ctn wwise.Container, before []wwise.WemDescriptor,
+	rs []*wwise.ReplacementWem, path string) {
+	replaced := make(map[int]bool)
+	for _, r := range rs {
+		replaced[r.WemIndex] = true
+	}
+
+	moved := 0
+	for i, wem := range ctn.Wems() {
+		org, desc := before[i], wem.Descriptor
+		name := util.CanonicalWemName(i, len(ctn.Wems()))
+		if replaced[i] {
+			fmt.Fprintf(messages, "Would replace %s (id %d): length %d -> %d "+
+				"bytes, offset 0x%X -> 0x%X\n", name, desc.WemId, org.Length,
+				desc.Length, org.Offset, desc.Offset)
+		} else if org.Offset != desc.Offset {
+			fmt.Fprintf(messages, "Would move %s (id %d): offset 0x%X -> 0x%X\n",
+				name, desc.WemId, org.Offset, desc.Offset)
+			moved++
+		}
+	}
+
+	total, err := ctn.WriteTo(ioutil.Discard)
+	if err != nil {
+		fatal(path, exitFailure, "Could not plan output file: %s", err)
+	}
+	fmt.Fprintf(messages, "Dry run: %d wem(s) would be replaced and %d "+
+		"wem(s) would be moved\n", len(replaced), moved)
+	fmt.Fprintf(messages, "Would write %d bytes in total to %s\n", total, path)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd := lookupCommand(os.Args[1]); cmd != nil {
+			cmd.execute(os.Args[2:])
+			finish()
+		}
+	}
+
 	flag.Parse()
 	verifyFlags()
+	if output == stdioPath {
+		messages = os.Stderr
+	}
 	isSoundBank := verifyInputType()
 
 	switch {
@@ -288,4 +554,5 @@ func main() {
 		verifyReplaceFlags()
 		replace(isSoundBank)
 	}
+	finish()
 }
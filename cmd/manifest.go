@@ -0,0 +1,231 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"bnk"
+	"pck"
+	"util"
+)
+
+const manifestCommand = "manifest"
+const verifyCommand = "verify"
+
+// A manifest describes a set of files produced by wwiseutil, so that they can
+// be verified once they have been distributed.
+type manifest struct {
+	Tool        string           `json:"tool"`
+	ToolVersion string           `json:"tool_version"`
+	Created     string           `json:"created"`
+	Files       []*manifestEntry `json:"files"`
+}
+
+// A manifestEntry describes a single file listed within a manifest.
+type manifestEntry struct {
+	// The path of the file, relative to the directory containing the manifest,
+	// using forward slashes.
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// The type of container, if the file is a SoundBank or File Package.
+	Container string `json:"container,omitempty"`
+	// The version of the container format used by the file.
+	Version uint32 `json:"version,omitempty"`
+	// The number of wems within the container.
+	WemCount int `json:"wem_count,omitempty"`
+}
+
+// runManifest writes a manifest of the files and directories in args to
+// output. Directories are listed recursively.
+func runManifest(args []string) {
+	if len(args) == 0 {
+		usageError("manifest expects at least one file or directory")
+	}
+	if output == "" || output == stdioPath {
+		usageError("output must be the path of the manifest file to write")
+	}
+	verifyJobs()
+
+	root := filepath.Dir(output)
+	var paths []string
+	for _, arg := range args {
+		err := filepath.Walk(arg, func(path string, info os.FileInfo,
+			err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !util.SameFile(path, output) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			fatal(arg, exitFailure, "Could not list files: %s", err)
+		}
+	}
+
+	m := &manifest{"wwiseutil", util.Version,
+		manifestTime().UTC().Format(time.RFC3339), nil}
+	var mu sync.Mutex
+	forEachJob(len(paths), func(i int) {
+		e, err := newManifestEntry(root, paths[i])
+		if err != nil {
+			recordError(paths[i], exitFailure, "Could not describe %s: %s",
+				paths[i], err)
+			return
+		}
+		mu.Lock()
+		m.Files = append(m.Files, e)
+		mu.Unlock()
+	})
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+
+	if dryRun {
+		for _, e := range m.Files {
+			fmt.Fprintf(messages, "Would list %s (%d bytes)\n", e.Path, e.Size)
+		}
+		fmt.Fprintf(messages, "Dry run: %d file(s) would be listed in %s\n",
+			len(m.Files), output)
+		return
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		fatal(output, exitFailure, "Could not create manifest: %s", err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		fatal(output, exitFailure, "Could not write manifest: %s", err)
+	}
+	fmt.Fprintf(messages, "Listed %d file(s) in %s\n", len(m.Files), output)
+}
+
+// manifestTime returns the time that a manifest is created at: the time given
+// by SOURCE_DATE_EPOCH, as seconds since the Unix epoch, if it is set, so that
+// the manifest of the same files is the same wherever it is made, or the
+// current time otherwise.
+func manifestTime() time.Time {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now()
+	}
+	secs, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		fatal("", exitUsage, "SOURCE_DATE_EPOCH must be a number of seconds, "+
+			"not \"%s\"", epoch)
+	}
+	return time.Unix(secs, 0)
+}
+
+// newManifestEntry describes the file at path, which is listed relative to
+// root.
+func newManifestEntry(root, path string) (*manifestEntry, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return nil, err
+	}
+	e := &manifestEntry{Path: filepath.ToSlash(rel)}
+	e.Size, e.SHA256, err = hashFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t, _ := util.GetFileType(path); t {
+	case util.SoundBankFileType:
+		b, err := bnk.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer b.Close()
+		e.Container, e.WemCount = "SoundBank", len(b.Wems())
+		if b.BankHeaderSection != nil {
+			e.Version = b.BankHeaderSection.Descriptor.Version
+		}
+	case util.FilePackageFileType:
+		p, err := pck.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer p.Close()
+		e.Container, e.WemCount = "File Package", len(p.Wems())
+		e.Version = p.Header.Version()
+	}
+	return e, nil
+}
+
+// hashFile returns the size and hex encoded SHA-256 of the file at path.
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// runVerify checks that every file listed by the manifest in args has the size
+// and SHA-256 recorded in the manifest.
+func runVerify(args []string) {
+	if len(args) != 1 {
+		usageError("verify expects exactly one manifest file")
+	}
+	verifyJobs()
+	f, err := os.Open(args[0])
+	if err != nil {
+		fatal(args[0], exitFailure, "Could not open manifest: %s", err)
+	}
+	m := new(manifest)
+	err = json.NewDecoder(f).Decode(m)
+	f.Close()
+	if err != nil {
+		fatal(args[0], exitValidationFailure, "Could not read manifest: %s", err)
+	}
+
+	root := filepath.Dir(args[0])
+	var mu sync.Mutex
+	verified := 0
+	forEachJob(len(m.Files), func(i int) {
+		e := m.Files[i]
+		path := filepath.Join(root, filepath.FromSlash(e.Path))
+		size, sum, err := hashFile(path)
+		switch {
+		case err != nil:
+			recordError(path, exitFailure, "Could not read %s: %s", e.Path, err)
+		case size != e.Size:
+			recordError(path, exitValidationFailure, "%s: expected %d bytes, found "+
+				"%d", e.Path, e.Size, size)
+		case sum != e.SHA256:
+			recordError(path, exitValidationFailure, "%s: SHA-256 does not match "+
+				"the manifest", e.Path)
+		default:
+			mu.Lock()
+			verified++
+			mu.Unlock()
+		}
+	})
+	fmt.Fprintf(messages, "Verified %d of %d file(s) listed in %s\n", verified,
+		len(m.Files), args[0])
+	if verified != len(m.Files) {
+		exit(exitValidationFailure)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+import (
+	"util"
+)
+
+var modLayout string
+
+// The mod layouts that can be selected by name, rather than by template.
+var modLayoutPresets = map[string]string{
+	// Monster Hunter: World loads loose files from nativePC, using the same path
+	// that the file has within the game's chunks.
+	"mhw": "nativePC/{game_path}",
+}
+
+func init() {
+	const (
+		usage = "Writes edited containers into a mod folder layout, rather than " +
+			"to output directly; output is then the root directory of the mod. " +
+			"Either a preset, mhw, or a path template. Templates may use " +
+			"{game_path}, the path of the source within the game's data (found " +
+			"after a nativePC or chunk directory), {name}, the file name of the " +
+			"source, {base}, its name without extension, and {ext}, its extension."
+		flagName = "mod-layout"
+	)
+	flag.StringVar(&modLayout, flagName, "", usage)
+}
+
+// modOutputPath returns the path to write the edited container read from
+// source to. If no mod layout was given, this is out; otherwise, it is the path
+// given by the mod layout within the mod root directory out.
+func modOutputPath(source, out string) string {
+	if modLayout == "" {
+		return out
+	}
+	if source == stdioPath || out == stdioPath {
+		usageError("mod-layout cannot be used with stdin or stdout")
+	}
+	template, ok := modLayoutPresets[strings.ToLower(modLayout)]
+	if !ok {
+		template = modLayout
+	}
+	name := filepath.Base(source)
+	ext := filepath.Ext(name)
+	path := strings.NewReplacer(
+		"{game_path}", gamePath(source),
+		"{name}", name,
+		"{base}", strings.TrimSuffix(name, ext),
+		"{ext}", ext,
+	).Replace(template)
+	return filepath.Join(out, filepath.FromSlash(path))
+}
+
+// gamePath returns the path of source relative to the root of the game data it
+// was extracted from, which is the innermost nativePC or chunk directory. If
+// source is not within such a directory, its file name is returned.
+func gamePath(source string) string {
+	if path, ok := util.GamePath(source); ok {
+		return path
+	}
+	return filepath.Base(source)
+}
+
+// createModOutputDir creates the directories leading to path when a mod layout
+// is used, exiting if they cannot be created.
+func createModOutputDir(path string) {
+	if modLayout == "" {
+		return
+	}
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		fatal(path, exitFailure, "Could not create mod directory: %s", err)
+	}
+}
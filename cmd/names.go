@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+)
+
+import (
+	"bnk"
+	"util"
+	"wwise"
+)
+
+var namesPath string
+
+func init() {
+	const (
+		usage = "The path to a SoundbanksInfo.xml or SoundbanksInfo.json file " +
+			"generated alongside the SoundBanks, or a Wwise_IDs.h file. When " +
+			"given, the original names of banks, events and wems are shown where " +
+			"they are known, and unpacked wems are written with their original " +
+			"names into a directory for their bank and event."
+		flagName = "names"
+	)
+	flag.StringVar(&namesPath, flagName, "", usage)
+}
+
+// openNames opens the name database given by the names flag. If the flag was
+// not given, a nil database, which resolves no names, is returned.
+func openNames() *wwise.NameDatabase {
+	if namesPath == "" {
+		return nil
+	}
+	db, err := wwise.OpenNameDatabase(namesPath)
+	if err != nil {
+		fatal(namesPath, exitParseError, "Could not read names file: %s", err)
+	}
+	return db
+}
+
+// exportPaths returns the path, relative to the output directory, that each
+// wem of ctn should be unpacked to. Without a name database, wems are named by
+// their id. With one, wems are given their original names and organized into a
+// directory for their bank, then a directory for the first event that plays
+// them, when these are known.
+func exportPaths(ctn wwise.Container, names *wwise.NameDatabase) []string {
+	var paths []string
+	if names == nil {
+		for _, wem := range ctn.Wems() {
+			paths = append(paths, fmt.Sprintf("%d%s", wem.Descriptor.WemId,
+				wemExtension))
+		}
+		return paths
+	}
+
+	var eventsOf map[uint32][]uint32
+	if b, ok := ctn.(*bnk.File); ok {
+		eventsOf = b.EventsOfWems()
+	}
+
+	used := make(map[string]bool)
+	for _, wem := range ctn.Wems() {
+		id := wem.Descriptor.WemId
+		var dirs []string
+		if bank, ok := names.BankOf(id); ok {
+			dirs = append(dirs, util.SanitizeFileName(bank))
+		}
+		if events := eventsOf[id]; len(events) > 0 {
+			event, ok := names.NameOf(events[0])
+			if !ok {
+				event = fmt.Sprintf("%d", events[0])
+			}
+			dirs = append(dirs, util.SanitizeFileName(event))
+		}
+
+		name, ok := names.WemNameOf(id)
+		if !ok {
+			name = fmt.Sprintf("%d", id)
+		}
+		path := filepath.Join(append(dirs,
+			util.SanitizeFileName(name)+wemExtension)...)
+		if used[path] {
+			// Different wems may share an original name; keep both.
+			path = filepath.Join(append(dirs,
+				fmt.Sprintf("%s_%d%s", util.SanitizeFileName(name), id,
+					wemExtension))...)
+		}
+		used[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+import (
+	"bnk"
+	"pck"
+	"util"
+	"wwise"
+)
+
+const pckCommand = "pck"
+
+const bnkExtension = ".bnk"
+
+// The actions of the pck command.
+var pckActions = map[string]func(args []string){
+	"extract-bnk": runExtractBnk,
+	"inject-bnk":  runInjectBnk,
+}
+
+// runPck runs the File Package action named by the first of args.
+func runPck(args []string) {
+	if len(args) == 0 {
+		usageError("pck expects an action; either extract-bnk or inject-bnk")
+	}
+	run, ok := pckActions[args[0]]
+	if !ok {
+		usageError(flagError(args[0] + " is not a pck action; expected either " +
+			"extract-bnk or inject-bnk"))
+	}
+	run(args[1:])
+}
+
+// openFilePackage opens the File Package at path, exiting if it is invalid.
+func openFilePackage(path string) *pck.File {
+	filePath = path
+	if verifyInputType() {
+		usageError("pck only supports File Package files")
+	}
+	p, ok := openContainer(false).(*pck.File)
+	if !ok {
+		usageError("pck only supports File Package files")
+	}
+	return p
+}
+
+// runExtractBnk writes the SoundBanks embedded within the File Package that is
+// the first of args to the output directory, named by their ids. If any ids
+// follow the File Package, only the SoundBanks with those ids are written.
+func runExtractBnk(args []string) {
+	if len(args) == 0 {
+		usageError("extract-bnk expects a .pck file, optionally followed by " +
+			"SoundBank ids")
+	}
+	if output == "" || output == stdioPath {
+		usageError("output must be a directory")
+	}
+	p := openFilePackage(args[0])
+	defer p.Close()
+
+	var indexes []int
+	if len(args) == 1 {
+		indexes = p.SoundBankIndexes()
+	}
+	for _, arg := range args[1:] {
+		id, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil {
+			usageError(flagError(arg + " is not a valid SoundBank id"))
+		}
+		i := p.IndexOf(uint32(id))
+		if i < 0 {
+			fatal(args[0], exitValidationFailure, "There is no entry with id %d",
+				id)
+		}
+		indexes = append(indexes, i)
+	}
+
+	if !dryRun {
+		err := os.MkdirAll(util.LongPath(output), os.ModePerm)
+		if err != nil {
+			fatal(output, exitFailure, "Could not create output directory: %s", err)
+		}
+	}
+	count := 0
+	for _, i := range indexes {
+		wem := p.Wems()[i]
+		path := filepath.Join(output,
+			strconv.FormatUint(uint64(wem.Descriptor.WemId), 10)+bnkExtension)
+		if _, err := p.SoundBank(i); err != nil {
+			recordError(path, exitValidationFailure, "Could not read SoundBank %d: "+
+				"%s", wem.Descriptor.WemId, err)
+			continue
+		}
+		if dryRun {
+			fmt.Fprintf(messages, "Would write %s (%d bytes)\n", path,
+				wem.Descriptor.Length)
+			count++
+			continue
+		}
+		if err := writeEntry(wem, path); err != nil {
+			recordError(path, exitFailure, "Could not write SoundBank %d: %s",
+				wem.Descriptor.WemId, err)
+			continue
+		}
+		count++
+	}
+	if dryRun {
+		fmt.Fprintf(messages, "Dry run: %d SoundBank(s) would be written to %s\n",
+			count, output)
+		return
+	}
+	fmt.Fprintf(messages, "Successfully wrote %d SoundBank(s) to %s\n", count,
+		output)
+}
+
+func writeEntry(wem *wwise.Wem, path string) error {
+	f, err := os.Create(util.LongPath(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = util.Copy(f, wem.NewReader())
+	return err
+}
+
+// runInjectBnk replaces SoundBanks embedded within the File Package that is
+// the first of args with the SoundBank files that follow it, writing the
+// result to output. Each SoundBank replaces the embedded SoundBank with the
+// same bank id.
+func runInjectBnk(args []string) {
+	if len(args) < 2 {
+		usageError("inject-bnk expects a .pck file followed by the .bnk files " +
+			"to inject")
+	}
+	if output == "" {
+		usageError("output cannot be empty")
+	}
+	if output == stdioPath {
+		messages = os.Stderr
+	}
+	p := openFilePackage(args[0])
+	defer p.Close()
+	checkVersion(p, args[0], wwise.EditWems)
+
+	before := snapshotDescriptors(p)
+	var rs []*wwise.ReplacementWem
+	for _, path := range args[1:] {
+		b, err := bnk.Open(path)
+		if err != nil {
+			fatal(path, openErrorCode(err), "Could not parse .bnk file: %s", err)
+		}
+		defer b.Close()
+		if b.BankHeaderSection == nil {
+			fatal(path, exitValidationFailure, "The SoundBank has no header")
+		}
+		id := b.BankHeaderSection.Descriptor.BankId
+		i := p.IndexOf(id)
+		if i < 0 {
+			fatal(path, exitValidationFailure, "%s has no embedded SoundBank with "+
+				"id %d", args[0], id)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			fatal(path, exitFailure, "Could not open SoundBank: %s", err)
+		}
+		defer f.Close()
+		stat, err := f.Stat()
+		if err != nil {
+			fatal(path, exitFailure, "Could not open SoundBank: %s", err)
+		}
+		err = p.ReplaceSoundBank(i, f, stat.Size())
+		if err != nil {
+			fatal(path, exitValidationFailure, "Could not inject SoundBank %d: %s",
+				id, err)
+		}
+		rs = append(rs, &wwise.ReplacementWem{Wem: f, WemIndex: i,
+			Length: stat.Size()})
+	}
+	// A SoundBank given more than once is only injected from its last file.
+	rs = wwise.SortReplacements(rs)
+
+	out := modOutputPath(args[0], output)
+	if dryRun {
+		printReplacePlan(p, before, rs, out)
+		return
+	}
+	createModOutputDir(out)
+	total := writeOutput(p, args[0], out)
+	fmt.Fprintf(messages, "Injected %d SoundBank(s). Output file written to: "+
+		"%s\n", len(rs), out)
+	fmt.Fprintf(messages, "Wrote %d bytes in total\n", total)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+import (
+	"bnk"
+	"wwise"
+)
+
+func TestReplacePlanCountsEachWemOnce(t *testing.T) {
+	ctn, err := bnk.Open("../bnk/testdata/loop_none.bnk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctn.Close()
+	out := new(bytes.Buffer)
+	defer func(w io.Writer) { messages = w }(messages)
+	messages = out
+
+	before := snapshotDescriptors(ctn)
+	wem := bytes.NewReader(make([]byte, 16))
+	rs := []*wwise.ReplacementWem{
+		{Wem: wem, WemIndex: 0, Length: 16},
+		{Wem: wem, WemIndex: 0, Length: 16},
+	}
+	ctn.ReplaceWems(wwise.SortReplacements(rs)...)
+	printReplacePlan(ctn, before, rs, "out.bnk")
+	if !strings.Contains(out.String(), "1 wem(s) would be replaced") {
+		t.Errorf("Expected a wem replaced twice to be counted once:\n%s", out)
+	}
+}
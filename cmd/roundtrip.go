@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+import (
+	"session"
+	"wwise"
+)
+
+var verifyOutput bool
+
+func init() {
+	const (
+		usage = "Once the output file is written, re-opens it and checks that it " +
+			"holds the same wems, sections and descriptors that were written, " +
+			"failing if it does not."
+		flagName = "verify"
+	)
+	flag.BoolVar(&verifyOutput, flagName, false, usage)
+}
+
+// verifyOutputFile checks that the file at path, to which ctn was just written,
+// holds what ctn does, if the verify flag is given. It returns an error if the
+// file does not. The warnings about ctn that may matter to the game are
+// printed, as the file holds what ctn does.
+func verifyOutputFile(ctn wwise.Container, path string) error {
+	if !verifyOutput || path == stdioPath {
+		return nil
+	}
+	if err := wwise.Verify(openContext, ctn, path, session.OpenLike(ctn),
+		nil); err != nil {
+		return err
+	}
+	fmt.Fprintln(messages, "Verified the output file:", path)
+	for _, w := range ctn.Warnings() {
+		if w.Severity >= wwise.SeverityCaution {
+			fmt.Fprintf(messages, "Warning (%s): %s\n", w.Severity, w.Message)
+		}
+	}
+	return nil
+}
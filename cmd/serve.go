@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+)
+
+import (
+	"convert"
+	"server"
+)
+
+const serveCommand = "serve"
+
+var serveAddr string
+
+var serveRoot string
+
+func init() {
+	const (
+		usage = "The address that the serve command listens for HTTP " +
+			"requests on."
+		flagName = "addr"
+	)
+	flag.StringVar(&serveAddr, flagName, "localhost:8080", usage)
+}
+
+func init() {
+	const (
+		usage = "The directory that the paths given to the serve command's " +
+			"HTTP API, to open, save and export to, must be within. Paths are " +
+			"not accepted if it is empty."
+		flagName = "root"
+	)
+	flag.StringVar(&serveRoot, flagName, ".", usage)
+}
+
+// runServe serves the HTTP API of the server package until the process is
+// stopped. Wems are converted with the external tools found on the PATH, and
+// cached as the convert command caches them.
+func runServe(args []string) {
+	if len(args) != 0 {
+		usageError("serve does not expect any arguments")
+	}
+	c := convert.NewConverter()
+	if cacheSize > 0 {
+		c.Cache = convert.NewCache(convert.DefaultCacheDir(),
+			int64(cacheSize)<<20)
+	}
+	s, err := server.New()
+	if err != nil {
+		fatal("", exitFailure, "Could not create a key: %s", err)
+	}
+	s.Root = serveRoot
+	s.Open = openFileContext
+	s.Converter = c
+	http.Handle(server.Path, s)
+	http.Handle(server.Path+"/", s)
+	fmt.Fprintf(messages, "Listening on http://%s%s\n", serveAddr, server.Path)
+	fmt.Fprintf(messages, "Send the key %s with every request, as an "+
+		"Authorization: Bearer header or the key query parameter\n", s.Key)
+	err = http.ListenAndServe(serveAddr, nil)
+	s.Close()
+	fatal("", exitFailure, "Could not serve: %s", err)
+}
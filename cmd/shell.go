@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+import (
+	"bnk"
+	"util"
+	"wwise"
+)
+
+const shellCommand = "shell"
+const shellPrompt = "> "
+
+// A shell is an interactive session over a single open container. The
+// container is kept open between commands, so that large files only need to be
+// parsed once.
+type shell struct {
+	path string
+	ctn  wwise.Container
+	// A mapping from wem index to the replacement wem that will be applied on
+	// the next save.
+	replacements map[int]*wwise.ReplacementWem
+	out          io.Writer
+}
+
+type shellCommandFunc func(sh *shell, args []string) error
+
+type shellCommandBinding struct {
+	usage string
+	run   shellCommandFunc
+}
+
+var shellCommands map[string]*shellCommandBinding
+
+func init() {
+	shellCommands = map[string]*shellCommandBinding{
+		"help":    {"help", (*shell).help},
+		"list":    {"list", (*shell).list},
+		"info":    {"info <wem id>", (*shell).info},
+		"replace": {"replace <wem id> <path to .wem>", (*shell).replace},
+		"save":    {"save <output path>", (*shell).save},
+		"quit":    {"quit", nil},
+	}
+}
+
+// runShell opens the container at path and reads commands from stdin until the
+// input ends or the quit command is given.
+func runShell(args []string) {
+	if len(args) != 1 {
+		usageError("shell expects exactly one .bnk or .pck file")
+	}
+	filePath = args[0]
+	if filePath == stdioPath {
+		usageError("shell cannot read a file from stdin")
+	}
+	ctn := openContainer(verifyInputType())
+	defer ctn.Close()
+
+	sh := &shell{filePath, ctn, make(map[int]*wwise.ReplacementWem), os.Stdout}
+	fmt.Fprintf(sh.out, "Opened %s with %d wem(s). Type \"help\" for a list "+
+		"of commands.\n", filepath.Base(filePath), len(ctn.Wems()))
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(sh.out, shellPrompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(sh.out)
+			break
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		name, cmdArgs := fields[0], fields[1:]
+		if name == "quit" || name == "exit" {
+			break
+		}
+		cmd, ok := shellCommands[name]
+		if !ok {
+			fmt.Fprintf(sh.out, "Unknown command \"%s\". Type \"help\" for a "+
+				"list of commands.\n", name)
+			continue
+		}
+		if err := cmd.run(sh, cmdArgs); err != nil {
+			fmt.Fprintln(sh.out, "Error:", err)
+		}
+	}
+
+	if len(sh.replacements) > 0 {
+		fmt.Fprintf(sh.out, "Discarding %d unsaved replacement(s)\n",
+			len(sh.replacements))
+	}
+}
+
+func (sh *shell) help(args []string) error {
+	var names []string
+	for name := range shellCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(sh.out, " ", shellCommands[name].usage)
+	}
+	return nil
+}
+
+func (sh *shell) list(args []string) error {
+	fmt.Fprintln(sh.out, sh.ctn)
+	return nil
+}
+
+func (sh *shell) info(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + shellCommands["info"].usage)
+	}
+	index, err := sh.indexOf(args[0])
+	if err != nil {
+		return err
+	}
+
+	wem := sh.ctn.Wems()[index]
+	desc := wem.Descriptor
+	fmt.Fprintf(sh.out, "Name:        %s\n",
+		util.CanonicalWemName(index, len(sh.ctn.Wems())))
+	fmt.Fprintf(sh.out, "Id:          %d\n", desc.WemId)
+	fmt.Fprintf(sh.out, "Size:        %d bytes\n", desc.Length)
+	fmt.Fprintf(sh.out, "File offset: 0x%X\n", sh.ctn.DataStart()+desc.Offset)
+	fmt.Fprintf(sh.out, "Padding:     %d bytes\n", wem.Padding.Size())
+	if b, ok := sh.ctn.(*bnk.File); ok {
+		loop := b.LoopOf(index)
+		switch {
+		case !loop.Loops:
+			fmt.Fprintln(sh.out, "Loops:       None")
+		case loop.Value == bnk.InfiniteLoops:
+			fmt.Fprintln(sh.out, "Loops:       Infinity")
+		default:
+			fmt.Fprintf(sh.out, "Loops:       %d times\n", loop.Value)
+		}
+	}
+	if r, ok := sh.replacements[index]; ok {
+		fmt.Fprintf(sh.out, "Replacing with %d bytes on next save\n", r.Length)
+	}
+	return nil
+}
+
+func (sh *shell) replace(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: " + shellCommands["replace"].usage)
+	}
+	index, err := sh.indexOf(args[0])
+	if err != nil {
+		return err
+	}
+	if err := sh.ctn.CheckVersion(wwise.EditWems); err != nil {
+		return err
+	}
+
+	f, err := util.OpenLazy(args[1])
+	if err != nil {
+		return err
+	}
+	if old, ok := sh.replacements[index]; ok {
+		old.Wem.(io.Closer).Close()
+	}
+	sh.replacements[index] = &wwise.ReplacementWem{
+		Wem: f, WemIndex: index, Length: f.Size()}
+	fmt.Fprintf(sh.out, "Staged %s as a replacement for wem %s\n", args[1],
+		args[0])
+	return nil
+}
+
+func (sh *shell) save(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + shellCommands["save"].usage)
+	}
+	path := args[0]
+	if abs, err := filepath.Abs(path); err == nil {
+		if src, err := filepath.Abs(sh.path); err == nil && abs == src {
+			return errors.New("cannot overwrite the file that is currently open")
+		}
+	}
+
+	var rs []*wwise.ReplacementWem
+	for _, r := range sh.replacements {
+		rs = append(rs, r)
+	}
+	sh.ctn.ReplaceWems(rs...)
+	// The replacements now belong to the container.
+	sh.replacements = make(map[int]*wwise.ReplacementWem)
+
+	if err := backupOutput(sh.path, path); err != nil {
+		return err
+	}
+	applyStrictDataLength(sh.ctn)
+	var total int64
+	err := util.WriteFileAtomic(path, func(f *os.File) (err error) {
+		total, err = sh.ctn.WriteTo(f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(sh.out, "Replaced %d wem(s) and wrote %d bytes to %s\n",
+		len(rs), total, path)
+	return verifyOutputFile(sh.ctn, path)
+}
+
+// indexOf returns the index of the wem with the wem id given by s.
+func (sh *shell) indexOf(s string) (int, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return -1, fmt.Errorf("\"%s\" is not a valid wem id", s)
+	}
+	if i := sh.ctn.IndexOf(uint32(id)); i >= 0 {
+		return i, nil
+	}
+	return -1, fmt.Errorf("there is no wem with id %d", id)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+)
+
+import (
+	"util"
+)
+
+// A memoryThresholdFlag sets the amount of data that is buffered in memory
+// before it is spilled to a temporary file.
+type memoryThresholdFlag struct{}
+
+func init() {
+	const (
+		usage = "The amount of data, such as 64M, that is held in memory when a " +
+			"container must be buffered, such as when it is read from a pipe, " +
+			"before it is moved to a temporary file. Defaults to auto, which is 256M."
+		flagName = "memory-threshold"
+	)
+	flag.Var(memoryThresholdFlag{}, flagName, usage)
+}
+
+func (memoryThresholdFlag) String() string {
+	return strconv.FormatInt(util.SpillThreshold, 10)
+}
+
+func (memoryThresholdFlag) Set(value string) error {
+	size, err := parseBufferSize(value)
+	if err != nil {
+		return err
+	}
+	if size == util.AutoBufferSize {
+		size = util.DefaultSpillThreshold
+	}
+	util.SpillThreshold = int64(size)
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+)
+
+import (
+	"bnk"
+	"wwise"
+)
+
+var strictDataLength bool
+
+func init() {
+	const (
+		usage = "Recomputes the length of the DATA section of each SoundBank " +
+			"written from the layout of its wems, and fails if the bytes written " +
+			"for the section differ from it."
+		flagName = "strict-data-length"
+	)
+	flag.BoolVar(&strictDataLength, flagName, false, usage)
+}
+
+// applyStrictDataLength sets ctn to recompute the length of its DATA section
+// as it is written, if it is a SoundBank and the strict-data-length flag is
+// given.
+func applyStrictDataLength(ctn wwise.Container) {
+	if b, ok := ctn.(*bnk.File); ok && strictDataLength {
+		b.StrictDataLength = true
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+import (
+	"bnk"
+)
+
+const structureCommand = "structure"
+
+// The actions of the structure command.
+var structureActions = map[string]func(args []string){
+	"export": runExportStructure,
+	"import": runImportStructure,
+}
+
+// runStructure runs the structure action named by the first of args.
+func runStructure(args []string) {
+	if len(args) == 0 {
+		usageError("structure expects an action; either export or import")
+	}
+	run, ok := structureActions[args[0]]
+	if !ok {
+		usageError(flagError(args[0] + " is not a structure action; expected " +
+			"either export or import"))
+	}
+	run(args[1:])
+}
+
+// runExportStructure writes the structure of the SoundBank in args to the JSON
+// file output, and the data of its wems to a directory beside it.
+func runExportStructure(args []string) {
+	if len(args) != 1 {
+		usageError("export expects exactly one .bnk file")
+	}
+	if output == "" || output == stdioPath {
+		usageError("output must be the path of the JSON file to write")
+	}
+	filePath = args[0]
+	if !verifyInputType() && filePath != stdioPath {
+		usageError("structure only supports SoundBank files")
+	}
+	b, ok := openContainer(true).(*bnk.File)
+	if !ok {
+		usageError("structure only supports SoundBank files")
+	}
+	defer b.Close()
+
+	if err := b.ExportStructure(output); err != nil {
+		fatal(output, exitFailure, "Could not export the structure: %s", err)
+	}
+	fmt.Fprintf(messages, "Exported the structure of %d wem(s) to: %s\n",
+		len(b.Wems()), output)
+}
+
+// runImportStructure writes the SoundBank described by the JSON structure in
+// args to output.
+func runImportStructure(args []string) {
+	if len(args) != 1 {
+		usageError("import expects exactly one JSON structure file")
+	}
+	if output == "" {
+		usageError("output cannot be empty")
+	}
+	if output == stdioPath {
+		messages = os.Stderr
+	}
+	b, err := bnk.OpenStructure(args[0])
+	if err != nil {
+		fatal(args[0], exitValidationFailure, "Could not build the SoundBank: %s",
+			err)
+	}
+	total := writeOutput(b, args[0], output)
+	fmt.Fprintf(messages, "Built a SoundBank of %d wem(s). Output file written "+
+		"to: %s\n", len(b.Wems()), output)
+	fmt.Fprintf(messages, "Wrote %d bytes in total\n", total)
+}
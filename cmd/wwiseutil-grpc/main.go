@@ -0,0 +1,73 @@
+// Command wwiseutil-grpc serves the gRPC API of the rpc package, which offers
+// the operations of the HTTP API of wwiseutil serve with a typed contract. It
+// is a separate binary from wwiseutil, as it depends on google.golang.org/grpc
+// and google.golang.org/protobuf, which need a newer Go release than wwiseutil
+// does. Build it, from the root of the repository, with:
+//
+//	go build -o wwiseutil-grpc ./cmd/wwiseutil-grpc
+//
+// Every request must give the key that is printed once the server starts, which
+// is new each run, as "authorization: Bearer <key>" metadata. The paths given
+// to open and save to must be within the directory given by -root. Wems are
+// converted with the external tools found on the PATH, and cached as the
+// convert command of wwiseutil caches them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+)
+
+import (
+	"convert"
+	"rpc"
+)
+
+import (
+	"google.golang.org/grpc"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9090", "The address that gRPC "+
+		"requests are listened for on.")
+	root := flag.String("root", ".", "The directory that the paths given to "+
+		"open and save to must be within. Paths are not accepted if it is "+
+		"empty.")
+	cacheSize := flag.Int("cache-size", 1024, "The size limit, in megabytes, "+
+		"of the cache of converted wems, which is shared with wwiseutil and the "+
+		"GUI. 0 disables the cache.")
+	flag.Parse()
+	if flag.NArg() != 0 || *cacheSize < 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := convert.NewConverter()
+	if *cacheSize > 0 {
+		c.Cache = convert.NewCache(convert.DefaultCacheDir(),
+			int64(*cacheSize)<<20)
+	}
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not serve gRPC: %s\n", err)
+		os.Exit(1)
+	}
+	svc, err := rpc.NewService()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not create a key: %s\n", err)
+		os.Exit(1)
+	}
+	svc.Root = *root
+	svc.Converter = c
+	g := grpc.NewServer(svc.ServerOptions()...)
+	rpc.RegisterContainersServer(g, svc)
+	fmt.Fprintf(os.Stderr, "Serving gRPC on %s\n", lis.Addr())
+	fmt.Fprintf(os.Stderr, "Send the key %s with every request, as "+
+		"authorization: Bearer metadata\n", svc.Key)
+	err = g.Serve(lis)
+	svc.CloseAll()
+	fmt.Fprintf(os.Stderr, "Could not serve gRPC: %s\n", err)
+	os.Exit(1)
+}
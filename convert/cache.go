@@ -0,0 +1,174 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"util"
+	"wwise"
+)
+
+// The name of the directory, within the temporary directory, that converted
+// wems are cached in by default. It is shared by the GUI and the command line
+// tool.
+const cacheDirName = "wwiseutil-convert-cache"
+
+// The prefix of the files that wems are converted to before they are added to
+// the cache. These files are never pruned, as they may still be written.
+const cacheTempPrefix = "."
+
+// DefaultCacheDir returns the directory that converted wems are cached in,
+// unless another is chosen.
+func DefaultCacheDir() string {
+	return filepath.Join(os.TempDir(), cacheDirName)
+}
+
+// A Cache is a directory of converted wems, named by the hash of the contents
+// of each wem and the format it was converted to, so that converting the same
+// wem again is a copy. Once the cache is larger than its limit, the least
+// recently used files are removed. A Cache may be used by several goroutines,
+// and several processes, at once.
+type Cache struct {
+	// The directory that converted wems are stored in.
+	Dir string
+
+	mu sync.Mutex
+	// The size in bytes that the cache is pruned to whenever a wem is added.
+	limit int64
+	// The number of callers using each cached file, which are not pruned.
+	inUse map[string]int
+}
+
+// NewCache creates a Cache of the converted wems in dir, holding at most limit
+// bytes. If limit is less than 0, the cache is never pruned.
+func NewCache(dir string, limit int64) *Cache {
+	return &Cache{Dir: dir, limit: limit, inUse: make(map[string]int)}
+}
+
+// SetLimit sets the size in bytes that the cache is pruned to whenever a wem is
+// added. If limit is less than 0, the cache is never pruned.
+func (c *Cache) SetLimit(limit int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+}
+
+// CacheKey returns the name that wem is cached under once converted to the
+// format to, which is the SHA-256 of its contents followed by the extension of
+// the format.
+func CacheKey(wem *wwise.Wem, to Format) (string, error) {
+	h := sha256.New()
+	if _, err := util.Copy(h, wem.NewReader()); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)) + to.Extension(), nil
+}
+
+// Get returns the path of the file cached under key, first calling create to
+// write it if it is not cached. create is given the path of a temporary file,
+// with the same extension as key, that is moved into the cache only if create
+// succeeds. The file is marked as recently used, and is not pruned until
+// release is called.
+func (c *Cache) Get(key string, create func(path string) error) (path string,
+	release func(), err error) {
+	path = filepath.Join(c.Dir, key)
+	c.acquire(path)
+	release = func() { c.release(path) }
+
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return path, release, nil
+	}
+	if err := c.add(path, create); err != nil {
+		release()
+		return "", nil, err
+	}
+	c.prune()
+	return path, release, nil
+}
+
+// add calls create to write a temporary file, which is then moved to path.
+func (c *Cache) add(path string, create func(path string) error) error {
+	if err := os.MkdirAll(c.Dir, os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.Dir, cacheTempPrefix+"*"+filepath.Ext(path))
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	if err := create(tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (c *Cache) acquire(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inUse == nil {
+		c.inUse = make(map[string]int)
+	}
+	c.inUse[path]++
+}
+
+func (c *Cache) release(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inUse[path]--; c.inUse[path] <= 0 {
+		delete(c.inUse, path)
+	}
+}
+
+// prune removes the least recently used files from the cache until it is within
+// its limit. Files that are in use are never removed.
+func (c *Cache) prune() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.limit < 0 {
+		return
+	}
+	fis, err := ioutil.ReadDir(c.Dir)
+	if err != nil {
+		return
+	}
+	size := int64(0)
+	for _, fi := range fis {
+		size += fi.Size()
+	}
+	sort.Slice(fis, func(i, j int) bool {
+		return fis[i].ModTime().Before(fis[j].ModTime())
+	})
+	for _, fi := range fis {
+		if size <= c.limit {
+			return
+		}
+		path := filepath.Join(c.Dir, fi.Name())
+		if c.inUse[path] > 0 || strings.HasPrefix(fi.Name(), cacheTempPrefix) {
+			continue
+		}
+		if os.Remove(path) == nil {
+			size -= fi.Size()
+		}
+	}
+}
+
+// Clear removes every file from the cache.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
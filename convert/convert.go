@@ -0,0 +1,326 @@
+// Package convert implements conversion of wems to commonly playable audio
+// formats.
+package convert
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+import (
+	"util"
+	"wwise"
+)
+
+// A Format is an audio format that wems can be converted to.
+type Format int
+
+const (
+	UnknownFormat Format = iota
+	OggFormat     Format = iota
+	WavFormat     Format = iota
+)
+
+// The names of the external tools used for codecs that cannot be converted
+// natively.
+const (
+	ww2oggTool    = "ww2ogg"
+	revorbTool    = "revorb"
+	vgmstreamTool = "vgmstream-cli"
+)
+
+// The name of the ww2ogg codebook file, expected to be found beside ww2ogg.
+const packedCodebooksFile = "packed_codebooks_aoTuV_603.bin"
+
+// The size in bytes of a standard WAVE header, up to the start of the data.
+const wavHeaderBytes = 44
+
+var formatExtensions = map[Format]string{
+	OggFormat: ".ogg",
+	WavFormat: ".wav",
+}
+
+// ParseFormat returns the Format with the specified name, such as "ogg".
+func ParseFormat(name string) Format {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "ogg":
+		return OggFormat
+	case "wav":
+		return WavFormat
+	}
+	return UnknownFormat
+}
+
+// Extension returns the file extension, including the leading dot, used by
+// files of this format.
+func (f Format) Extension() string {
+	return formatExtensions[f]
+}
+
+// A Converter converts wems into playable formats. PCM wems are converted to
+// WAV natively; all other conversions are delegated to external tools.
+type Converter struct {
+	// The paths to the external tools. A tool is unavailable if its path is
+	// empty.
+	Ww2ogg    string
+	Revorb    string
+	Vgmstream string
+	// The cache that converted wems are kept in, so that a wem is converted only
+	// once. Every wem is converted again if it is nil.
+	Cache *Cache
+}
+
+// NewConverter creates a new Converter that uses the external tools found in
+// the directories named by the PATH environment variable.
+func NewConverter() *Converter {
+	c := new(Converter)
+	c.Ww2ogg, _ = exec.LookPath(ww2oggTool)
+	c.Revorb, _ = exec.LookPath(revorbTool)
+	c.Vgmstream, _ = exec.LookPath(vgmstreamTool)
+	return c
+}
+
+// PlayableFormat returns the format that a wem with the specified format is
+// best converted to for playback, using the tools available to c. WAV is
+// preferred, as it can be produced natively or by vgmstream for every codec.
+func (c *Converter) PlayableFormat(format *wwise.WemFormat) (Format, error) {
+	switch {
+	case format.Codec == wwise.CodecPCM || format.Codec == wwise.CodecExtensible:
+		return WavFormat, nil
+	case c.Vgmstream != "":
+		return WavFormat, nil
+	case format.Codec == wwise.CodecVorbis && c.Ww2ogg != "":
+		return OggFormat, nil
+	}
+	return UnknownFormat, fmt.Errorf("Playing %s wems requires %s",
+		format.CodecName(), vgmstreamTool)
+}
+
+// Convert converts wem, with the specified format, to the audio format given by
+// to, writing the result to the file at dst. If c has a cache, the wem is
+// copied from the cache if it has been converted before.
+func (c *Converter) Convert(wem *wwise.Wem, format *wwise.WemFormat,
+	to Format, dst string) error {
+	if c.Cache == nil {
+		return c.convert(wem, format, to, dst)
+	}
+	path, release, err := c.Cached(wem, format, to)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return copyFile(dst, path)
+}
+
+// Cached returns the path of wem, with the specified format, within the cache
+// of c once converted to the audio format given by to, converting it only if it
+// is not already cached. The file is not pruned from the cache until release is
+// called.
+func (c *Converter) Cached(wem *wwise.Wem, format *wwise.WemFormat,
+	to Format) (path string, release func(), err error) {
+	if c.Cache == nil {
+		return "", nil, errors.New("The converter has no cache")
+	}
+	if to.Extension() == "" {
+		return "", nil, errors.New("Unknown output format")
+	}
+	key, err := CacheKey(wem, to)
+	if err != nil {
+		return "", nil, err
+	}
+	return c.Cache.Get(key, func(path string) error {
+		return c.convert(wem, format, to, path)
+	})
+}
+
+// Converted returns the path of a file holding wem, with the specified format,
+// converted to the audio format given by to. The file is within the cache of c
+// if it has one, and is otherwise a temporary file. Either way, it is kept
+// until release is called, which removes the temporary file. It suits callers
+// that read the converted wem, such as to send it elsewhere, rather than keep
+// it.
+func (c *Converter) Converted(wem *wwise.Wem, format *wwise.WemFormat,
+	to Format) (path string, release func(), err error) {
+	if c.Cache != nil {
+		return c.Cached(wem, format, to)
+	}
+	if to.Extension() == "" {
+		return "", nil, errors.New("Unknown output format")
+	}
+	tmp, err := ioutil.TempFile("", "wwiseutil-*"+to.Extension())
+	if err != nil {
+		return "", nil, err
+	}
+	tmp.Close()
+	if err := c.convert(wem, format, to, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, err
+	}
+	return tmp.Name(), func() { os.Remove(tmp.Name()) }, nil
+}
+
+// convert converts wem to the audio format given by to, writing the result to
+// the file at dst.
+func (c *Converter) convert(wem *wwise.Wem, format *wwise.WemFormat,
+	to Format, dst string) error {
+	switch to {
+	case WavFormat:
+		if format.Codec == wwise.CodecPCM || format.Codec == wwise.CodecExtensible {
+			return writeWav(wem, format, dst)
+		}
+		if c.Vgmstream == "" {
+			return fmt.Errorf("Converting %s wems to WAV requires %s",
+				format.CodecName(), vgmstreamTool)
+		}
+		return c.withTempWem(wem, func(src string) error {
+			return withTempOutput(dst, to, func(out string) error {
+				return run(c.Vgmstream, "-o", out, src)
+			})
+		})
+	case OggFormat:
+		if format.Codec != wwise.CodecVorbis {
+			return fmt.Errorf("%s wems can not be converted to Ogg Vorbis; "+
+				"convert them to WAV instead", format.CodecName())
+		}
+		if c.Ww2ogg == "" {
+			return fmt.Errorf("Converting wems to Ogg Vorbis requires %s",
+				ww2oggTool)
+		}
+		return c.withTempWem(wem, func(src string) error {
+			return withTempOutput(dst, to, func(out string) error {
+				args := []string{src, "-o", out}
+				codebooks := filepath.Join(filepath.Dir(c.Ww2ogg),
+					packedCodebooksFile)
+				if _, err := os.Stat(codebooks); err == nil {
+					args = append(args, "--pcb", codebooks)
+				}
+				if err := run(c.Ww2ogg, args...); err != nil {
+					return err
+				}
+				if c.Revorb != "" {
+					// Revorb fixes the granule positions written by ww2ogg, so that
+					// players can seek within the file.
+					return run(c.Revorb, out)
+				}
+				return nil
+			})
+		})
+	}
+	return errors.New("Unknown output format")
+}
+
+// withTempWem writes wem to a temporary file, then calls f with its path. The
+// file is removed once f returns.
+func (c *Converter) withTempWem(wem *wwise.Wem, f func(path string) error) error {
+	tmp, err := ioutil.TempFile("", "wwiseutil-*.wem")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = util.Copy(tmp, wem.NewReader())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return f(tmp.Name())
+}
+
+// withTempOutput calls f with the path of a temporary file for a tool to write
+// to, then copies that file to dst. Tools are never given dst itself, as on
+// Windows they may be unable to open paths with characters outside of the
+// system code page, such as those of localized names, or paths longer than
+// MAX_PATH.
+func withTempOutput(dst string, to Format, f func(path string) error) error {
+	tmp, err := ioutil.TempFile("", "wwiseutil-*"+to.Extension())
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := f(tmp.Name()); err != nil {
+		return err
+	}
+	return copyFile(dst, tmp.Name())
+}
+
+// copyFile copies the file at src to the file at dst.
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(util.LongPath(dst))
+	if err != nil {
+		return err
+	}
+	_, err = util.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			return fmt.Errorf("%s failed: %s", filepath.Base(name), err)
+		}
+		return fmt.Errorf("%s failed: %s: %s", filepath.Base(name), err, msg)
+	}
+	return nil
+}
+
+// writeWav writes the audio of a PCM wem to dst as a standard WAVE file.
+func writeWav(wem *wwise.Wem, format *wwise.WemFormat, dst string) error {
+	r, ok := wem.Reader.(io.ReaderAt)
+	if !ok {
+		return errors.New("The wem does not support random access")
+	}
+	f, err := os.Create(util.LongPath(dst))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hdr := struct {
+		Riff          [4]byte
+		RiffLength    uint32
+		Wave          [4]byte
+		Fmt           [4]byte
+		FmtLength     uint32
+		Codec         uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataLength    uint32
+	}{
+		[4]byte{'R', 'I', 'F', 'F'}, wavHeaderBytes - 8 + format.DataLength,
+		[4]byte{'W', 'A', 'V', 'E'}, [4]byte{'f', 'm', 't', ' '}, 16,
+		wwise.CodecPCM, format.Channels, format.SampleRate,
+		format.AvgBytesPerSecond, format.BlockAlign, format.BitsPerSample,
+		[4]byte{'d', 'a', 't', 'a'}, format.DataLength,
+	}
+	err = binary.Write(f, binary.LittleEndian, &hdr)
+	if err != nil {
+		return err
+	}
+	data := io.NewSectionReader(r, format.DataOffset, int64(format.DataLength))
+	_, err = util.Copy(f, data)
+	return err
+}
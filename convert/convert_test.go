@@ -0,0 +1,180 @@
+// Package convert implements conversion of wems to commonly playable audio
+// formats.
+package convert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"util"
+	"wwise"
+)
+
+// newPCMWem creates a 16-bit mono PCM wem containing samples, with an extra
+// chunk between the fmt and data chunks.
+func newPCMWem(samples []int16) *wwise.Wem {
+	b := new(bytes.Buffer)
+	dataLength := uint32(len(samples) * 2)
+	b.WriteString("RIFF")
+	binary.Write(b, binary.LittleEndian, uint32(4+8+24+8+4+8)+dataLength)
+	b.WriteString("WAVEfmt ")
+	binary.Write(b, binary.LittleEndian, []uint32{24, 0x00010001, 48000, 96000})
+	binary.Write(b, binary.LittleEndian, []uint16{2, 16, 6, 0})
+	binary.Write(b, binary.LittleEndian, uint32(4))
+	b.WriteString("JUNK")
+	binary.Write(b, binary.LittleEndian, uint32(4))
+	b.WriteString("xxxx")
+	b.WriteString("data")
+	binary.Write(b, binary.LittleEndian, dataLength)
+	binary.Write(b, binary.LittleEndian, samples)
+
+	r := bytes.NewReader(b.Bytes())
+	desc := &wwise.WemDescriptor{WemId: 1, Length: uint32(r.Size())}
+	return &wwise.Wem{Reader: util.NewResettingReader(r, 0, r.Size()),
+		Descriptor: desc}
+}
+
+func TestPCMWemToWav(t *testing.T) {
+	samples := []int16{0, 1000, -1000, 32767, -32768}
+	wem := newPCMWem(samples)
+	format, err := wem.Format()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	dir, err := ioutil.TempDir("", "convert")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "out.wav")
+
+	// No external tools are needed to convert PCM wems to WAV.
+	err = new(Converter).Convert(wem, format, WavFormat, dst)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	wav, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(wav) != wavHeaderBytes+len(samples)*2 {
+		t.Errorf("Expected a %d byte WAV file but got %d bytes",
+			wavHeaderBytes+len(samples)*2, len(wav))
+		t.FailNow()
+	}
+	converted, err := wwise.ReadWemFormat(bytes.NewReader(wav), int64(len(wav)))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if converted.Codec != wwise.CodecPCM || converted.Samples() != 5 ||
+		converted.SampleRate != 48000 {
+		t.Errorf("The converted WAV has an unexpected format: %+v", converted)
+	}
+	got := make([]int16, len(samples))
+	binary.Read(bytes.NewReader(wav[wavHeaderBytes:]), binary.LittleEndian, got)
+	for i := range samples {
+		if got[i] != samples[i] {
+			t.Errorf("Sample %d was expected to be %d but was %d", i, samples[i],
+				got[i])
+		}
+	}
+}
+
+func TestConvertedWemsAreCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wem := newPCMWem([]int16{0, 1000, -1000})
+	format, err := wem.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Converter{Cache: NewCache(filepath.Join(dir, "cache"), -1)}
+	first, second := filepath.Join(dir, "1.wav"), filepath.Join(dir, "2.wav")
+	if err := c.Convert(wem, format, WavFormat, first); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once cached, the wem is copied from the cache rather than converted.
+	key, err := CacheKey(wem, WavFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, release, err := c.Cache.Get(key, func(path string) error {
+		t.Error("Expected the converted wem to be found in the cache")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if err := ioutil.WriteFile(path, []byte("cached"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Convert(wem, format, WavFormat, second); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(second); string(b) != "cached" {
+		t.Errorf("Expected the wem to be copied from the cache, got %q", b)
+	}
+	if b, _ := ioutil.ReadFile(first); len(b) == 0 || string(b) == "cached" {
+		t.Error("Expected the first conversion to be unaffected by the cache")
+	}
+}
+
+func TestCacheRemovesLeastRecentlyUsed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := NewCache(dir, 25)
+	add := func(key string) func() {
+		_, release, err := cache.Get(key, func(path string) error {
+			return ioutil.WriteFile(path, make([]byte, 10), 0644)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return release
+	}
+	add("a.wav")()
+	add("b.wav")()
+	// a.wav is the least recently used, but is in use, so b.wav is removed once
+	// c.wav is added.
+	releaseA := add("a.wav")
+	for i, key := range []string{"a.wav", "b.wav"} {
+		old := time.Now().Add(time.Duration(i-2) * time.Hour)
+		os.Chtimes(filepath.Join(dir, key), old, old)
+	}
+	add("c.wav")()
+	releaseA()
+
+	for key, kept := range map[string]bool{"a.wav": true, "b.wav": false,
+		"c.wav": true} {
+		_, err := os.Stat(filepath.Join(dir, key))
+		if kept && err != nil {
+			t.Errorf("Expected %s to be kept in the cache", key)
+		} else if !kept && err == nil {
+			t.Errorf("Expected %s to be removed from the cache", key)
+		}
+	}
+}
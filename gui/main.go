@@ -7,35 +7,80 @@ import (
 
 import (
 	"gui/viewer"
+	"util"
 	"github.com/therecipe/qt/core"
 	"github.com/therecipe/qt/widgets"
 )
 
+// The default size of the window, in device independent pixels, and the
+// largest fraction of the screen it takes up by default.
 const (
-	windowWidth  = 860
-	windowHeight = 480
+	windowWidth    = 860
+	windowHeight   = 480
+	windowMaxShare = 0.8
 )
 
 func main() {
 	log.Println("Starting wwiseutil GUI...")
+	// Scale the window, fonts and icons by the device pixel ratio of the screen,
+	// so that they are not tiny on high-DPI displays. These must be set before
+	// the application is created.
+	core.QCoreApplication_SetAttribute(core.Qt__AA_EnableHighDpiScaling, true)
+	core.QCoreApplication_SetAttribute(core.Qt__AA_UseHighDpiPixmaps, true)
 	app := widgets.NewQApplication(len(os.Args), os.Args)
 	core.QCoreApplication_SetApplicationName("Wwise Audio Utilities")
-	core.QCoreApplication_SetApplicationVersion("1.0")
+	core.QCoreApplication_SetApplicationVersion(util.Version)
+	// The same large containers are often opened again, so their indexes are
+	// cached.
+	util.IndexCacheDir = util.DefaultIndexCacheDir()
 
 	parser := core.NewQCommandLineParser()
 	parser.SetApplicationDescription(core.QCoreApplication_ApplicationName())
 	parser.AddHelpOption()
 	parser.AddVersionOption()
+	parser.AddPositionalArgument("file",
+		"The SoundBank or File Package to open.", "[file]")
+	registerOption := core.NewQCommandLineOption3("register-associations",
+		"Register this application to open SoundBanks and File Packages, then "+
+			"exit. This is intended for installers.", "", "")
+	parser.AddOption(registerOption)
 	parser.Process2(app)
 
+	if parser.IsSet2(registerOption) {
+		if err := viewer.RegisterFileAssociations(); err != nil {
+			log.Fatalf("Could not register file associations: %s", err)
+		}
+		return
+	}
+
 	window := viewer.New()
 
-	availableGeometry := widgets.QApplication_Desktop().AvailableGeometry2(window)
-	window.Resize2(windowWidth, windowHeight)
-	// Move the window to the center of the screen.
-	window.Move2((availableGeometry.Width()-window.Width())/2,
-		(availableGeometry.Height()-window.Height())/2)
+	if !window.RestoreWindowState() {
+		availableGeometry :=
+			widgets.QApplication_Desktop().AvailableGeometry2(window)
+		window.Resize2(defaultSize(windowWidth, availableGeometry.Width()),
+			defaultSize(windowHeight, availableGeometry.Height()))
+		// Move the window to the center of the screen.
+		window.Move2((availableGeometry.Width()-window.Width())/2,
+			(availableGeometry.Height()-window.Height())/2)
+	}
 
 	window.Show()
+	if args := parser.PositionalArguments(); len(args) > 0 {
+		window.Open(args[0])
+	} else {
+		window.OfferFileAssociations()
+		window.OfferSessionRestore()
+	}
 	app.Exec()
+	window.Cleanup()
+	util.RemoveSpillFiles()
+}
+
+// defaultSize returns size, limited to windowMaxShare of the available space.
+func defaultSize(size, available int) int {
+	if limit := int(float64(available) * windowMaxShare); limit < size {
+		return limit
+	}
+	return size
 }
@@ -0,0 +1,163 @@
+package viewer
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The extensions of the files that are opened with the viewer once file
+// associations are registered.
+var associatedExtensions = []string{".bnk", ".nbnk", ".pck", ".npck"}
+
+// The identifiers that the associated file type is registered under.
+const (
+	associationProgId   = "wwiseutil.container"
+	associationMimeType = "application/x-wwise-container"
+	associationDesktop  = "wwiseutil.desktop"
+)
+
+// RegisterFileAssociations registers the viewer, for the current user, as the
+// application that opens SoundBanks and File Packages.
+func RegisterFileAssociations() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return registerWindowsAssociations(exe)
+	case "darwin":
+		return errors.New("On macOS, files are associated with the application " +
+			"bundle when it is installed")
+	}
+	return registerFreedesktopAssociations(exe)
+}
+
+// registerWindowsAssociations adds a file type for containers to the classes
+// of the current user, opened by exe, and associates each extension with it.
+func registerWindowsAssociations(exe string) error {
+	classes := core.NewQSettings4(`HKEY_CURRENT_USER\Software\Classes`,
+		core.QSettings__NativeFormat, nil)
+	for _, ext := range associatedExtensions {
+		classes.SetValue(ext+"/Default", core.NewQVariant12(associationProgId))
+	}
+	prefix := associationProgId + "/"
+	classes.SetValue(prefix+"Default", core.NewQVariant12("Wwise container"))
+	classes.SetValue(prefix+"DefaultIcon/Default",
+		core.NewQVariant12(fmt.Sprintf(`"%s",0`, exe)))
+	classes.SetValue(prefix+"shell/open/command/Default",
+		core.NewQVariant12(fmt.Sprintf(`"%s" "%%1"`, exe)))
+	classes.Sync()
+	if classes.Status() != core.QSettings__NoError {
+		return errors.New("The file types could not be written to the registry")
+	}
+	return nil
+}
+
+// registerFreedesktopAssociations installs a MIME type for containers and a
+// desktop entry that opens them with exe, in the data directory of the current
+// user, then makes the desktop entry the default for the MIME type.
+func registerFreedesktopAssociations(exe string) error {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		data = filepath.Join(os.Getenv("HOME"), ".local", "share")
+	}
+	mimeDir := filepath.Join(data, "mime")
+	appsDir := filepath.Join(data, "applications")
+
+	var globs []string
+	for _, ext := range associatedExtensions {
+		globs = append(globs, fmt.Sprintf(`    <glob pattern="*%s"/>`, ext))
+	}
+	mimeXml := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<mime-info xmlns="http://www.freedesktop.org/standards/shared-mime-info">
+  <mime-type type="%s">
+    <comment>Wwise container</comment>
+%s
+  </mime-type>
+</mime-info>
+`, associationMimeType, strings.Join(globs, "\n"))
+	desktop := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=%s
+Exec="%s" %%f
+MimeType=%s;
+Categories=AudioVideo;Audio;
+`, core.QCoreApplication_ApplicationName(), exe, associationMimeType)
+
+	err := writeFileAll(filepath.Join(mimeDir, "packages", "wwiseutil.xml"),
+		mimeXml)
+	if err != nil {
+		return err
+	}
+	err = writeFileAll(filepath.Join(appsDir, associationDesktop), desktop)
+	if err != nil {
+		return err
+	}
+	// These tools are not installed everywhere; without them, the association
+	// takes effect once the desktop next rebuilds its databases.
+	exec.Command("update-mime-database", mimeDir).Run()
+	exec.Command("update-desktop-database", appsDir).Run()
+	exec.Command("xdg-mime", "default", associationDesktop,
+		associationMimeType).Run()
+	return nil
+}
+
+// writeFileAll writes contents to the file at path, creating its directory if
+// it does not exist.
+func writeFileAll(path string, contents string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(contents), 0644)
+}
+
+func (wv *WwiseViewerWindow) setupRegisterAssociations() {
+	wv.actionAssociate = widgets.NewQAction2("Register &File Associations", wv)
+	wv.actionAssociate.ConnectTriggered(func(checked bool) {
+		wv.registerAssociations()
+	})
+}
+
+// registerAssociations registers the file associations, reporting the result.
+func (wv *WwiseViewerWindow) registerAssociations() {
+	if err := RegisterFileAssociations(); err != nil {
+		msg := fmt.Sprintf("Could not register file associations:\n%s", err)
+		widgets.QMessageBox_Critical(wv, errorTitle, msg, 0, 0)
+		return
+	}
+	msg := fmt.Sprintf("SoundBanks and File Packages (%s) will now open with "+
+		"this application.", strings.Join(associatedExtensions, ", "))
+	widgets.QMessageBox_Information(wv, "File associations registered", msg,
+		0, 0)
+}
+
+// OfferFileAssociations asks, the first time the viewer is run, whether to
+// register the file associations. They can be registered later from the Tools
+// menu.
+func (wv *WwiseViewerWindow) OfferFileAssociations() {
+	if runtime.GOOS == "darwin" || loadString(associationsOfferedKey) != "" {
+		return
+	}
+	saveString(associationsOfferedKey, "true")
+	msg := fmt.Sprintf("Do you want to open SoundBanks and File Packages (%s) "+
+		"with this application when they are double clicked?\n\nThis can be "+
+		"done later from the Tools menu.",
+		strings.Join(associatedExtensions, ", "))
+	answer := widgets.QMessageBox_Question(wv, "File associations", msg,
+		widgets.QMessageBox__Yes|widgets.QMessageBox__No, widgets.QMessageBox__Yes)
+	if answer == widgets.QMessageBox__Yes {
+		wv.registerAssociations()
+	}
+}
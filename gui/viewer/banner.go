@@ -0,0 +1,89 @@
+package viewer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+import (
+	"wwise"
+	"github.com/therecipe/qt/widgets"
+)
+
+// setupMismatchBanner creates the banner that warns of staged replacements
+// whose format differs from the wem they replace. It is hidden until there is
+// something to warn of.
+func (wv *WwiseViewerWindow) setupMismatchBanner() {
+	wv.bannerMismatch = widgets.NewQFrame(nil, 0)
+	wv.bannerMismatch.SetObjectName("mismatchBanner")
+	wv.bannerMismatch.SetFrameShape(widgets.QFrame__StyledPanel)
+	wv.bannerMismatch.SetStyleSheet("#mismatchBanner { background: #fff3cd; " +
+		"color: #664d03; }")
+
+	wv.labelMismatch = widgets.NewQLabel(nil, 0)
+	wv.labelMismatch.SetWordWrap(true)
+
+	dismiss := widgets.NewQToolButton(nil)
+	dismiss.SetText("Dismiss")
+	dismiss.SetAutoRaise(true)
+	dismiss.ConnectClicked(func(checked bool) {
+		wv.clearMismatches()
+	})
+
+	layout := widgets.NewQHBoxLayout2(wv.bannerMismatch)
+	layout.AddWidget(wv.labelMismatch, 1, 0)
+	layout.AddWidget(dismiss, 0, 0)
+	wv.bannerMismatch.Hide()
+}
+
+// checkReplacementFormat warns, using the mismatch banner, if the format of
+// the replacement r differs from that of the wem it replaces. Replacements
+// whose format cannot be read are not warned of, as they are reported when
+// played or converted instead.
+func (wv *WwiseViewerWindow) checkReplacementFormat(name string,
+	r *wwise.ReplacementWem) {
+	wem := wv.table.GetContainer().Wems()[r.WemIndex]
+	original, err := wem.Format()
+	if err != nil {
+		return
+	}
+	ra, ok := r.Wem.(io.ReaderAt)
+	if !ok {
+		return
+	}
+	replacement, err := wwise.ReadWemFormat(ra, r.Length)
+	if err != nil {
+		return
+	}
+	ms := original.Mismatches(replacement)
+	if len(ms) == 0 {
+		return
+	}
+	msg := fmt.Sprintf("%s (replacing wem %d) has %s", name,
+		wem.Descriptor.WemId, strings.Join(ms, ", "))
+	wv.mismatches = append(wv.mismatches, msg)
+	wv.logConsole(consoleWarning, "Replace", msg)
+	wv.refreshMismatchBanner()
+}
+
+// clearMismatches hides the mismatch banner and forgets its warnings.
+func (wv *WwiseViewerWindow) clearMismatches() {
+	wv.mismatches = nil
+	wv.refreshMismatchBanner()
+}
+
+func (wv *WwiseViewerWindow) refreshMismatchBanner() {
+	if len(wv.mismatches) == 0 {
+		wv.bannerMismatch.Hide()
+		return
+	}
+	text := "Warning: " + wv.mismatches[len(wv.mismatches)-1] + "."
+	if len(wv.mismatches) > 1 {
+		text = fmt.Sprintf("Warning: %d replacements differ in format from the "+
+			"wems they replace. Hover for details.", len(wv.mismatches))
+	}
+	wv.labelMismatch.SetText(text + " The game may fail to play them.")
+	wv.labelMismatch.SetToolTip(strings.Join(wv.mismatches, "\n"))
+	wv.bannerMismatch.Show()
+}
@@ -0,0 +1,327 @@
+package viewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"bnk"
+	"pck"
+	"session"
+	"util"
+	"wwise"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The background color of the rows of the batch results that failed.
+var failedColor = gui.NewQColor3(245, 200, 200, 255)
+
+// A batchResult is the outcome of applying the staged replacements to a single
+// target container.
+type batchResult struct {
+	target string
+	output string
+	// The number of wems that were replaced.
+	replaced int
+	// The ids of the replaced wems that the target does not have.
+	missing []uint32
+	err     error
+}
+
+func (wv *WwiseViewerWindow) setupBatch() {
+	wv.actionBatch = widgets.NewQAction2("&Apply to Other Files...", wv)
+	wv.actionBatch.SetEnabled(false)
+	wv.actionBatch.SetToolTip("Apply the staged replacements, by wem id, to " +
+		"other SoundBanks or File Packages, such as other language variants")
+	wv.actionBatch.ConnectTriggered(func(checked bool) {
+		wv.showBatchDialog()
+	})
+}
+
+// showBatchDialog asks for the containers to apply the staged replacements to,
+// and where to write the results, then applies them.
+func (wv *WwiseViewerWindow) showBatchDialog() {
+	rs := wv.table.model.stagedReplacements()
+	if len(rs) == 0 {
+		widgets.QMessageBox_Information(wv, "Apply to Other Files", "There are "+
+			"no staged replacements to apply. Replace some wems first.", 0, 0)
+		return
+	}
+
+	dlg := widgets.NewQDialog(wv, 0)
+	dlg.SetWindowTitle("Apply to Other Files")
+	layout := widgets.NewQVBoxLayout2(dlg)
+	label := widgets.NewQLabel2(fmt.Sprintf("The %d staged replacement(s) "+
+		"will be applied to the wems with the same ids in each of these files:",
+		len(rs)), nil, 0)
+	label.SetWordWrap(true)
+	layout.AddWidget(label, 0, 0)
+
+	listTargets := widgets.NewQListWidget(nil)
+	listTargets.SetSelectionMode(widgets.QAbstractItemView__ExtendedSelection)
+	layout.AddWidget(listTargets, 1, 0)
+	buttonAdd := widgets.NewQPushButton2("Add Files...", nil)
+	buttonAdd.ConnectClicked(func(checked bool) {
+		paths := widgets.QFileDialog_GetOpenFileNames(dlg, "Add files",
+			preferredDir(openDirKey), supportedFileFilters, "", 0)
+		for _, path := range paths {
+			if len(listTargets.FindItems(path, core.Qt__MatchExactly)) == 0 {
+				listTargets.AddItem(path)
+			}
+		}
+	})
+	buttonRemove := widgets.NewQPushButton2("Remove", nil)
+	buttonRemove.ConnectClicked(func(checked bool) {
+		for _, item := range listTargets.SelectedItems() {
+			listTargets.TakeItem(listTargets.Row(item))
+		}
+	})
+	row := widgets.NewQHBoxLayout()
+	row.AddWidget(buttonAdd, 0, 0)
+	row.AddWidget(buttonRemove, 0, 0)
+	row.AddStretch(1)
+	layout.AddLayout(row, 0)
+
+	form := widgets.NewQFormLayout(nil)
+	editOutput := widgets.NewQLineEdit2(preferredDir(exportDirKey), nil)
+	buttonBrowse := widgets.NewQPushButton2("Browse...", nil)
+	buttonBrowse.ConnectClicked(func(checked bool) {
+		opts := widgets.QFileDialog__ShowDirsOnly |
+			widgets.QFileDialog__DontResolveSymlinks
+		dir := widgets.QFileDialog_GetExistingDirectory(dlg, "Output directory",
+			editOutput.Text(), opts)
+		if dir != "" {
+			editOutput.SetText(dir)
+		}
+	})
+	row = widgets.NewQHBoxLayout()
+	row.AddWidget(editOutput, 1, 0)
+	row.AddWidget(buttonBrowse, 0, 0)
+	form.AddRow4("Output directory:", row)
+	layout.AddLayout(form, 0)
+
+	buttons := widgets.NewQDialogButtonBox3(
+		widgets.QDialogButtonBox__Ok|widgets.QDialogButtonBox__Cancel, nil)
+	buttons.Button(widgets.QDialogButtonBox__Ok).SetText("Apply")
+	buttons.ConnectAccepted(func() {
+		if listTargets.Count() == 0 || editOutput.Text() == "" {
+			widgets.QMessageBox_Information(dlg, "Apply to Other Files",
+				"Add at least one file, and choose an output directory.", 0, 0)
+			return
+		}
+		dlg.Accept()
+	})
+	buttons.ConnectRejected(dlg.Reject)
+	layout.AddWidget(buttons, 0, 0)
+	dlg.Resize2(560, 400)
+
+	if dlg.Exec() != int(widgets.QDialog__Accepted) {
+		return
+	}
+	var targets []string
+	for i := 0; i < listTargets.Count(); i++ {
+		targets = append(targets, listTargets.Item(i).Text())
+	}
+	wv.applyToTargets(rs, targets, editOutput.Text())
+}
+
+// applyToTargets applies rs, which replace wems of the open file, to each of
+// targets in the background, writing the results beneath dir, then shows a
+// summary of the results. The targets are opened several at once, but written
+// one at a time, as the replacements are read from the same readers for each.
+func (wv *WwiseViewerWindow) applyToTargets(rs []*wwise.ReplacementWem,
+	targets []string, dir string) {
+	ctn := wv.table.GetContainer()
+	base := commonDir(targets)
+	outputs := make([]string, len(targets))
+	for i, target := range targets {
+		rel, err := filepath.Rel(base, target)
+		if err != nil {
+			rel = filepath.Base(target)
+		}
+		outputs[i] = filepath.Join(dir, rel)
+	}
+	verify, backup := verifySaves(), backupOriginals()
+	var results []*batchResult
+	wv.runInBackground(fmt.Sprintf("Applying replacements to %d file(s)...",
+		len(targets)), func(progress wwise.ProgressFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		applied := make([]*batchResult, len(targets))
+		var mu sync.Mutex
+		done := int64(0)
+		wwise.OpenAll(ctx, targets, 0, openTarget, func(i int,
+			other wwise.Container, err error) {
+			if other != nil {
+				defer other.Close()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if ctx.Err() != nil {
+				return
+			}
+			res := &batchResult{target: targets[i], output: outputs[i], err: err}
+			if err == nil {
+				applyToTarget(ctn, rs, other, res, verify, backup)
+			}
+			applied[i] = res
+			done++
+			if !progress(done, int64(len(targets))) {
+				cancel()
+			}
+		})
+		for _, res := range applied {
+			if res != nil {
+				results = append(results, res)
+			}
+		}
+	}, func() {
+		wv.showBatchResults(results, len(targets))
+	})
+}
+
+// openTarget opens the container at target, which replacements are applied to.
+// It is a wwise.OpenFunc.
+func openTarget(ctx context.Context, target string) (wwise.Container, error) {
+	switch t, ext := util.GetFileType(target); t {
+	case util.SoundBankFileType:
+		return bnk.OpenContext(ctx, target, nil)
+	case util.FilePackageFileType:
+		return pck.OpenContext(ctx, target, nil)
+	default:
+		return nil, fmt.Errorf("%s(%s) is not a supported file format", target,
+			ext)
+	}
+}
+
+// applyToTarget applies rs, which replace wems of ctn, to other, which was
+// opened from res.target, writing the result to res.output. If verify is set,
+// the output is then re-opened and checked against other. If backup is set, an
+// existing output that is an original file of the game is first backed up.
+func applyToTarget(ctn wwise.Container, rs []*wwise.ReplacementWem,
+	other wwise.Container, res *batchResult, verify, backup bool) {
+	target, output := res.target, res.output
+	if filepath.Clean(target) == filepath.Clean(output) {
+		res.err = errors.New("The output would overwrite the file")
+		return
+	}
+
+	matched, missing := wwise.MatchReplacements(ctn, other, rs)
+	res.missing = missing
+	if len(matched) == 0 {
+		res.err = errors.New("None of the replaced wems are in the file")
+		return
+	}
+	applyAlignment(other)
+	if p, ok := other.(*pck.File); ok {
+		p.Mode = packageMode()
+	}
+	other.ReplaceWems(matched...)
+
+	if res.err = os.MkdirAll(filepath.Dir(output), os.ModePerm); res.err != nil {
+		return
+	}
+	if backup && util.NeedsBackup(output, target) {
+		if _, res.err = util.BackupFile(output, time.Now()); res.err != nil {
+			return
+		}
+	}
+	err := util.WriteFileAtomic(output, func(f *os.File) error {
+		_, err := other.WriteTo(f)
+		return err
+	})
+	if err != nil {
+		res.err = err
+		return
+	}
+	if verify {
+		err := wwise.Verify(context.Background(), other, output,
+			session.OpenLike(other), nil)
+		if err != nil {
+			res.err = err
+			return
+		}
+	}
+	res.replaced = len(matched)
+}
+
+// commonDir returns the deepest directory that contains every path of paths.
+func commonDir(paths []string) string {
+	dir := filepath.Dir(paths[0])
+	for _, path := range paths[1:] {
+		for !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return dir
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+// showBatchResults shows the outcome of applying the staged replacements to
+// each of total targets, of which results were attempted before any
+// cancellation.
+func (wv *WwiseViewerWindow) showBatchResults(results []*batchResult,
+	total int) {
+	table := widgets.NewQTableWidget2(len(results), 4, nil)
+	table.SetHorizontalHeaderLabels([]string{"File", "Replaced",
+		"Missing ids", "Result"})
+	table.VerticalHeader().Hide()
+	table.SetEditTriggers(widgets.QAbstractItemView__NoEditTriggers)
+	table.SetSelectionBehavior(widgets.QAbstractItemView__SelectRows)
+	table.HorizontalHeader().SetStretchLastSection(true)
+
+	succeeded := 0
+	for row, res := range results {
+		var ids []string
+		for _, id := range res.missing {
+			ids = append(ids, fmt.Sprintf("%d", id))
+		}
+		result := "Written to " + res.output
+		if res.err != nil {
+			result = res.err.Error()
+			wv.logConsole(consoleError, res.target, result)
+		} else {
+			succeeded++
+		}
+		cells := []string{res.target, fmt.Sprintf("%d", res.replaced),
+			strings.Join(ids, ", "), result}
+		for col, text := range cells {
+			item := widgets.NewQTableWidgetItem2(text, 0)
+			item.SetToolTip(text)
+			if res.err != nil {
+				item.SetBackground(gui.NewQBrush3(failedColor,
+					core.Qt__SolidPattern))
+			}
+			table.SetItem(row, col, item)
+		}
+	}
+	table.ResizeColumnsToContents()
+
+	text := fmt.Sprintf("Wrote %d of %d file(s).", succeeded, total)
+	if len(results) < total {
+		text += fmt.Sprintf(" %d file(s) were skipped, as applying was "+
+			"cancelled.", total-len(results))
+	}
+	summary := widgets.NewQLabel2(text, nil, 0)
+
+	dlg := widgets.NewQDialog(wv, 0)
+	dlg.SetWindowTitle("Apply to Other Files")
+	dlg.SetAttribute(core.Qt__WA_DeleteOnClose, true)
+	layout := widgets.NewQVBoxLayout2(dlg)
+	layout.AddWidget(summary, 0, 0)
+	layout.AddWidget(table, 0, 0)
+	dlg.Resize2(720, 400)
+	dlg.Show()
+}
@@ -0,0 +1,153 @@
+package viewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+import (
+	"util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+var changeLogFileFilters = strings.Join([]string{
+	"Text report (*.txt)",
+	"JSON report (*.json)",
+}, ";;")
+
+// A stagedChange is an entry of the staged changes log, describing a single
+// staged operation on a wem.
+type stagedChange struct {
+	Operation string `json:"operation"`
+	WemId     uint32 `json:"wem_id"`
+	// The index of the wem, where the first wem is 1.
+	WemIndex int `json:"wem_index"`
+	// The path of the replacement wem, if the operation replaced the wem.
+	Replacement string `json:"replacement,omitempty"`
+	// The loop of the wem before and after the operation, if it was changed.
+	LoopBefore string `json:"loop_before,omitempty"`
+	LoopAfter  string `json:"loop_after,omitempty"`
+
+	// Whether the operation is currently applied; it is not once it is undone.
+	applied bool
+}
+
+// String returns a single line description of this change.
+func (c *stagedChange) String() string {
+	var details []string
+	if c.Replacement != "" {
+		details = append(details, "replaced with "+c.Replacement)
+	}
+	if c.LoopBefore != "" {
+		details = append(details, fmt.Sprintf("loop changed from %s to %s",
+			c.LoopBefore, c.LoopAfter))
+	}
+	if len(details) == 0 {
+		details = append(details, "no change")
+	}
+	return fmt.Sprintf("%s: wem %d (id %d) %s", c.Operation, c.WemIndex,
+		c.WemId, strings.Join(details, ", "))
+}
+
+// newStagedChange describes the operation text on the wem at index, which
+// changed its staged state from before to after.
+func (wv *WwiseViewerWindow) newStagedChange(text string, index int,
+	before, after rowState) *stagedChange {
+	wem := wv.table.GetContainer().Wems()[index]
+	c := &stagedChange{Operation: text, WemId: wem.Descriptor.WemId,
+		WemIndex: index + 1}
+	if after.replacement != nil && after.replacement != before.replacement {
+		c.Replacement = after.replacement.name
+		if f, ok := after.replacement.replacement.Wem.(*os.File); ok {
+			c.Replacement = f.Name()
+		}
+	}
+	if after.loop != before.loop {
+		c.LoopBefore, c.LoopAfter = loopText(before.loop), loopText(after.loop)
+	}
+	return c
+}
+
+func (wv *WwiseViewerWindow) setupChangeLogDock() {
+	wv.listChangeLog = widgets.NewQListWidget(wv)
+
+	buttonExport := widgets.NewQPushButton2("Export Log...", wv)
+	buttonExport.ConnectClicked(func(checked bool) {
+		home := util.UserHome()
+		path := widgets.QFileDialog_GetSaveFileName(
+			wv, "Export staged changes", home, changeLogFileFilters, "", 0)
+		if path != "" {
+			wv.exportChangeLog(path)
+		}
+	})
+
+	contents := widgets.NewQWidget(nil, 0)
+	layout := widgets.NewQVBoxLayout2(contents)
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.AddWidget(wv.listChangeLog, 0, 0)
+	layout.AddWidget(buttonExport, 0, core.Qt__AlignRight)
+
+	wv.dockChangeLog = widgets.NewQDockWidget("Staged Changes", wv, 0)
+	wv.dockChangeLog.SetObjectName("changeLogDock")
+	wv.dockChangeLog.SetWidget(contents)
+	wv.AddDockWidget(core.Qt__BottomDockWidgetArea, wv.dockChangeLog)
+	wv.viewMenu.QWidget.AddAction(wv.dockChangeLog.ToggleViewAction())
+}
+
+// appliedChanges returns the staged changes that are currently applied, in the
+// order they were made.
+func (wv *WwiseViewerWindow) appliedChanges() []*stagedChange {
+	var applied []*stagedChange
+	for _, c := range wv.changeLog {
+		if c.applied {
+			applied = append(applied, c)
+		}
+	}
+	return applied
+}
+
+// clearChangeLog empties the staged changes log, once the changes have been
+// saved or discarded.
+func (wv *WwiseViewerWindow) clearChangeLog() {
+	wv.changeLog = nil
+	wv.refreshChangeLog()
+}
+
+func (wv *WwiseViewerWindow) refreshChangeLog() {
+	wv.listChangeLog.Clear()
+	for i, c := range wv.appliedChanges() {
+		wv.listChangeLog.AddItem(fmt.Sprintf("%d. %s", i+1, c))
+	}
+}
+
+// exportChangeLog writes the applied staged changes to path, as JSON if path
+// ends in .json and as text otherwise.
+func (wv *WwiseViewerWindow) exportChangeLog(path string) {
+	changes := wv.appliedChanges()
+	var b []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var err error
+		b, err = json.MarshalIndent(struct {
+			File    string          `json:"file"`
+			Changes []*stagedChange `json:"changes"`
+		}{wv.currPath, changes}, "", "  ")
+		if err != nil {
+			wv.showSaveError(path, err)
+			return
+		}
+	} else {
+		lines := []string{fmt.Sprintf("Staged changes to %s:", wv.currPath)}
+		for i, c := range changes {
+			lines = append(lines, fmt.Sprintf("%d. %s", i+1, c))
+		}
+		b = []byte(strings.Join(lines, "\n") + "\n")
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		wv.showSaveError(path, err)
+	}
+}
@@ -0,0 +1,77 @@
+package viewer
+
+import (
+	"github.com/therecipe/qt/widgets"
+)
+
+func (wv *WwiseViewerWindow) setupViewMenu() {
+	wv.columnVisible = make(map[string]bool)
+	wv.columnActions = make(map[string]*widgets.QAction)
+	for _, title := range wv.table.ColumnTitles() {
+		wv.columnVisible[title] = true
+	}
+	for _, title := range optionalColumns {
+		wv.columnVisible[title] = false
+	}
+	saved := make(map[string]bool)
+	loadJSON(sessionVisibilityKey, &saved)
+	for title, visible := range saved {
+		if _, ok := wv.columnVisible[title]; ok {
+			wv.columnVisible[title] = visible
+		}
+	}
+	wv.columnWidths = make(map[string]int)
+	loadJSON(sessionColumnsKey, &wv.columnWidths)
+
+	wv.viewMenu = wv.MenuBar().AddMenu2("&View")
+	columns := wv.viewMenu.AddMenu2("&Columns")
+	for _, title := range wv.table.ColumnTitles() {
+		title := title
+		action := columns.AddAction(title)
+		action.SetCheckable(true)
+		action.SetChecked(wv.columnVisible[title])
+		action.ConnectToggled(func(checked bool) {
+			wv.columnVisible[title] = checked
+			saveJSON(sessionVisibilityKey, wv.columnVisible)
+			wv.applyColumnVisibility()
+		})
+		wv.columnActions[title] = action
+	}
+	columns.AddSeparator()
+	actionReset := columns.AddAction("&Reset Columns")
+	actionReset.ConnectTriggered(func(checked bool) {
+		wv.resetColumns()
+	})
+	wv.applyColumnVisibility()
+}
+
+// resetColumns shows the columns that are shown by default, and sizes every
+// column to fit its contents, forgetting the layout chosen by the user.
+func (wv *WwiseViewerWindow) resetColumns() {
+	optional := make(map[string]bool)
+	for _, title := range optionalColumns {
+		optional[title] = true
+	}
+	for title, action := range wv.columnActions {
+		action.SetChecked(!optional[title])
+	}
+	wv.columnWidths = make(map[string]int)
+	wv.table.ResizeColumnsToContents()
+}
+
+// showColumn shows the column with the specified title, as if it had been
+// checked in the View menu.
+func (wv *WwiseViewerWindow) showColumn(title string) {
+	if action, ok := wv.columnActions[title]; ok {
+		action.SetChecked(true)
+	}
+}
+
+// applyColumnVisibility shows or hides each column of the table as chosen from
+// the View menu. This must be called whenever the table loads a new model.
+func (wv *WwiseViewerWindow) applyColumnVisibility() {
+	for i, title := range wv.table.ColumnTitles() {
+		visible, ok := wv.columnVisible[title]
+		wv.table.SetColumnHidden(i, ok && !visible)
+	}
+}
@@ -0,0 +1,157 @@
+package viewer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+import (
+	"bnk"
+	"pck"
+	"util"
+	"wwise"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The background colors of the rows of the comparison table.
+var (
+	addedColor   = gui.NewQColor3(200, 240, 200, 255)
+	removedColor = gui.NewQColor3(245, 200, 200, 255)
+	changedColor = gui.NewQColor3(250, 235, 180, 255)
+)
+
+func (wv *WwiseViewerWindow) setupCompare() {
+	wv.actionCompare = widgets.NewQAction2("&Compare...", wv)
+	wv.actionCompare.SetEnabled(false)
+	wv.actionCompare.ConnectTriggered(func(checked bool) {
+		start := preferredDir(openDirKey)
+		path := widgets.QFileDialog_GetOpenFileName(
+			wv, "Compare with", start, supportedFileFilters, "", 0)
+		if path != "" {
+			wv.compareWith(path)
+		}
+	})
+}
+
+// compareWith compares the wems of the open file with those of the container
+// at path, in the background, then shows the result.
+func (wv *WwiseViewerWindow) compareWith(path string) {
+	ctn := wv.table.GetContainer()
+	var other wwise.Container
+	var d *wwise.WemDiff
+	var err error
+	wv.runInBackground("Comparing with "+filepath.Base(path)+"...",
+		func(progress wwise.ProgressFunc) {
+			switch t, ext := util.GetFileType(path); t {
+			case util.SoundBankFileType:
+				other, err = bnk.Open(path)
+			case util.FilePackageFileType:
+				other, err = pck.Open(path)
+			default:
+				err = fmt.Errorf("%s(%s) is not a supported file format", path, ext)
+			}
+			if err != nil {
+				return
+			}
+			d, err = wwise.DiffWems(ctn, other)
+		}, func() {
+			if err != nil {
+				if other != nil {
+					other.Close()
+				}
+				wv.showOpenError(path, err)
+				return
+			}
+			wv.showComparison(ctn, other, filepath.Base(path), d)
+			other.Close()
+		})
+}
+
+// showComparison shows the wems of ctn and other side by side, aligned by id,
+// with the differences given by d highlighted.
+func (wv *WwiseViewerWindow) showComparison(ctn, other wwise.Container,
+	otherName string, d *wwise.WemDiff) {
+	sizes := func(c wwise.Container) map[uint32]uint32 {
+		m := make(map[uint32]uint32)
+		for _, wem := range c.Wems() {
+			m[wem.Descriptor.WemId] = wem.Descriptor.Length
+		}
+		return m
+	}
+	ctnSizes, otherSizes := sizes(ctn), sizes(other)
+	status := make(map[uint32]string)
+	var ids []uint32
+	for id := range ctnSizes {
+		ids = append(ids, id)
+	}
+	for _, id := range d.Added {
+		ids = append(ids, id)
+		status[id] = "Added"
+	}
+	for _, id := range d.Removed {
+		status[id] = "Removed"
+	}
+	for _, id := range d.Changed {
+		status[id] = "Changed"
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	thisName := filepath.Base(wv.currPath)
+	table := widgets.NewQTableWidget2(len(ids), 4, nil)
+	table.SetHorizontalHeaderLabels([]string{"Id", "Status",
+		thisName + " size", otherName + " size"})
+	table.VerticalHeader().Hide()
+	table.SetEditTriggers(widgets.QAbstractItemView__NoEditTriggers)
+	table.SetSelectionBehavior(widgets.QAbstractItemView__SelectRows)
+	table.HorizontalHeader().SetSectionResizeMode(widgets.QHeaderView__Stretch)
+	sizeText := func(sizes map[uint32]uint32, id uint32) string {
+		if size, ok := sizes[id]; ok {
+			return fmt.Sprintf("%d bytes", size)
+		}
+		return ""
+	}
+	for row, id := range ids {
+		cells := []string{fmt.Sprintf("%d", id), status[id],
+			sizeText(ctnSizes, id), sizeText(otherSizes, id)}
+		var color *gui.QColor
+		switch status[id] {
+		case "Added":
+			color = addedColor
+		case "Removed":
+			color = removedColor
+		case "Changed":
+			color = changedColor
+		}
+		for col, text := range cells {
+			item := widgets.NewQTableWidgetItem2(text, 0)
+			if color != nil {
+				item.SetBackground(gui.NewQBrush3(color, core.Qt__SolidPattern))
+			}
+			table.SetItem(row, col, item)
+		}
+	}
+
+	checkboxDiffOnly := widgets.NewQCheckBox2("Only show differences", nil)
+	checkboxDiffOnly.ConnectStateChanged(func(state int) {
+		diffOnly := state == int(core.Qt__Checked)
+		for row, id := range ids {
+			table.SetRowHidden(row, diffOnly && status[id] == "")
+		}
+	})
+	summary := widgets.NewQLabel2(fmt.Sprintf("%d added, %d removed and %d "+
+		"changed in %s, compared to %s.", len(d.Added), len(d.Removed),
+		len(d.Changed), otherName, thisName), nil, 0)
+
+	dlg := widgets.NewQDialog(wv, 0)
+	dlg.SetWindowTitle(fmt.Sprintf("Compare %s with %s", thisName, otherName))
+	dlg.SetAttribute(core.Qt__WA_DeleteOnClose, true)
+	layout := widgets.NewQVBoxLayout2(dlg)
+	layout.AddWidget(summary, 0, 0)
+	layout.AddWidget(checkboxDiffOnly, 0, 0)
+	layout.AddWidget(table, 0, 0)
+	dlg.Resize2(640, 480)
+	dlg.Show()
+}
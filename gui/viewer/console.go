@@ -0,0 +1,102 @@
+package viewer
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+import (
+	"wwise"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The severities of console messages.
+const (
+	consoleInfo = iota
+	consoleWarning
+	consoleError
+)
+
+func (wv *WwiseViewerWindow) setupConsoleDock() {
+	wv.listConsole = widgets.NewQListWidget(wv)
+	wv.listConsole.SetWordWrap(true)
+
+	buttonClear := widgets.NewQPushButton2("Clear", wv)
+	buttonClear.ConnectClicked(func(checked bool) {
+		wv.listConsole.Clear()
+		wv.consoleWarnings = 0
+		wv.refreshConsoleTitle()
+	})
+
+	contents := widgets.NewQWidget(nil, 0)
+	layout := widgets.NewQVBoxLayout2(contents)
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.AddWidget(wv.listConsole, 0, 0)
+	layout.AddWidget(buttonClear, 0, core.Qt__AlignRight)
+
+	wv.dockConsole = widgets.NewQDockWidget("Console", wv, 0)
+	wv.dockConsole.SetObjectName("consoleDock")
+	wv.dockConsole.SetWidget(contents)
+	wv.AddDockWidget(core.Qt__BottomDockWidgetArea, wv.dockConsole)
+	wv.dockConsole.Hide()
+	wv.viewMenu.QWidget.AddAction(wv.dockConsole.ToggleViewAction())
+}
+
+// logConsole adds a message, from the file or operation named by source, to
+// the console. Warnings and errors are counted in the title of the console,
+// and mentioned in the status bar, so that they are noticed without
+// interrupting the user.
+func (wv *WwiseViewerWindow) logConsole(severity int, source string,
+	msg string) {
+	var icon *gui.QIcon
+	switch severity {
+	case consoleWarning:
+		icon = wv.Style().StandardIcon(widgets.QStyle__SP_MessageBoxWarning,
+			nil, nil)
+	case consoleError:
+		icon = wv.Style().StandardIcon(widgets.QStyle__SP_MessageBoxCritical,
+			nil, nil)
+	default:
+		icon = wv.Style().StandardIcon(widgets.QStyle__SP_MessageBoxInformation,
+			nil, nil)
+	}
+	text := fmt.Sprintf("[%s] %s: %s", time.Now().Format("15:04:05"), source,
+		msg)
+	item := widgets.NewQListWidgetItem3(icon, text, wv.listConsole, 0)
+	wv.listConsole.ScrollToItem(item, widgets.QAbstractItemView__EnsureVisible)
+
+	if severity != consoleInfo {
+		wv.consoleWarnings++
+		wv.refreshConsoleTitle()
+		wv.StatusBar().ShowMessage(fmt.Sprintf("%s: %s (see the Console for "+
+			"details)", source, msg), 0)
+	}
+}
+
+// logWarnings adds each of warnings about the file at path to the console,
+// with the console severity that matches its own. Notices are only logged, and
+// not counted.
+func (wv *WwiseViewerWindow) logWarnings(path string,
+	warnings []wwise.Warning) {
+	for _, w := range warnings {
+		severity := consoleWarning
+		switch w.Severity {
+		case wwise.SeverityNotice:
+			severity = consoleInfo
+		case wwise.SeverityProblem:
+			severity = consoleError
+		}
+		wv.logConsole(severity, filepath.Base(path), w.Message)
+	}
+}
+
+func (wv *WwiseViewerWindow) refreshConsoleTitle() {
+	title := "Console"
+	if wv.consoleWarnings > 0 {
+		title = fmt.Sprintf("Console (%d)", wv.consoleWarnings)
+	}
+	wv.dockConsole.SetWindowTitle(title)
+}
@@ -0,0 +1,120 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+)
+
+import (
+	"wwise"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// setupContextMenu shows a menu of the actions that apply to the selected rows
+// when a row of the table is right clicked.
+func (wv *WwiseViewerWindow) setupContextMenu() {
+	wv.table.SetContextMenuPolicy(core.Qt__CustomContextMenu)
+	wv.table.ConnectCustomContextMenuRequested(func(pos *core.QPoint) {
+		if !wv.table.IndexAt(pos).IsValid() {
+			return
+		}
+		indexes := wv.getSelectedRows()
+		if len(indexes) == 0 {
+			return
+		}
+		wems := wv.table.GetContainer().Wems()
+
+		menu := widgets.NewQMenu(wv)
+		actionReplace := menu.AddAction("&Replace...")
+		actionReplace.SetEnabled(len(indexes) == 1)
+		actionReplace.ConnectTriggered(func(checked bool) {
+			wv.actionReplace.Trigger()
+		})
+		actionExport := menu.AddAction("&Export...")
+		actionExport.ConnectTriggered(func(checked bool) {
+			wv.exportSelected(indexes)
+		})
+		actionPlay := menu.AddAction("Convert && &Play")
+		actionPlay.SetEnabled(len(indexes) == 1)
+		actionPlay.ConnectTriggered(func(checked bool) {
+			wv.preview.Stop()
+			wv.actionPlay.Trigger()
+		})
+		menu.AddSeparator()
+
+		actionCopy := menu.AddAction("&Copy ID")
+		if len(indexes) > 1 {
+			actionCopy.SetText("&Copy IDs")
+		}
+		actionCopy.ConnectTriggered(func(checked bool) {
+			ids := make([]string, len(indexes))
+			for i, index := range indexes {
+				ids[i] = fmt.Sprintf("%d", wems[index].Descriptor.WemId)
+			}
+			gui.QGuiApplication_Clipboard().SetText(strings.Join(ids, "\n"),
+				gui.QClipboard__Clipboard)
+		})
+
+		staged := false
+		for _, index := range indexes {
+			staged = staged || wv.table.IsStaged(index)
+		}
+		actionRevert := menu.AddAction("Re&vert")
+		actionRevert.SetEnabled(staged)
+		actionRevert.ConnectTriggered(func(checked bool) {
+			wv.revertAll(indexes)
+		})
+
+		id := wems[indexes[0]].Descriptor.WemId
+		actionReferences := menu.AddAction("Show Re&ferences")
+		actionReferences.SetEnabled(len(indexes) == 1 &&
+			(len(wv.soundItems[id]) > 0 || len(wv.eventsOfWems[id]) > 0))
+		actionReferences.ConnectTriggered(func(checked bool) {
+			wv.showReferences(id)
+		})
+		menu.AddSeparator()
+		wv.addExtensionsMenu(menu, indexes)
+		menu.Exec2(wv.table.Viewport().MapToGlobal(pos), nil)
+	})
+}
+
+// exportSelected asks for a directory, then exports the wems at indexes to it.
+func (wv *WwiseViewerWindow) exportSelected(indexes []int) {
+	start := preferredDir(exportDirKey)
+	opts := widgets.QFileDialog__ShowDirsOnly |
+		widgets.QFileDialog__DontResolveSymlinks
+	dir := widgets.QFileDialog_GetExistingDirectory(
+		wv, "Choose directory to export into", start, opts)
+	if dir == "" {
+		return
+	}
+	all := wv.table.GetContainer().Wems()
+	wems := make([]*wwise.Wem, len(indexes))
+	for i, index := range indexes {
+		wems[i] = all[index]
+	}
+	wv.exportWems(dir, wems)
+}
+
+// revertAll reverts every staged wem at indexes as a single undoable change.
+func (wv *WwiseViewerWindow) revertAll(indexes []int) {
+	wv.undoStack.BeginMacro("Revert")
+	for _, index := range indexes {
+		if wv.table.IsStaged(index) {
+			wv.revert(index)
+		}
+	}
+	wv.undoStack.EndMacro()
+}
+
+// showReferences shows the objects and events that play the wem with the
+// specified id, in the hierarchy and details panels.
+func (wv *WwiseViewerWindow) showReferences(id uint32) {
+	wv.dockHierarchy.Show()
+	wv.dockHierarchy.Raise()
+	wv.dockDetails.Show()
+	wv.dockDetails.Raise()
+	wv.highlightSounds(id)
+}
@@ -0,0 +1,97 @@
+package viewer
+
+import (
+	"fmt"
+)
+
+import (
+	"bnk"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+func (wv *WwiseViewerWindow) setupDetailsDock() {
+	wv.treeDetails = widgets.NewQTreeWidget(wv)
+	wv.treeDetails.SetColumnCount(2)
+	wv.treeDetails.SetHeaderLabels([]string{"Property", "Value"})
+	wv.treeDetails.SetRootIsDecorated(false)
+
+	wv.dockDetails = widgets.NewQDockWidget("Details", wv, 0)
+	wv.dockDetails.SetObjectName("detailsDock")
+	wv.dockDetails.SetWidget(wv.treeDetails)
+	wv.AddDockWidget(core.Qt__RightDockWidgetArea, wv.dockDetails)
+	wv.viewMenu.QWidget.AddAction(wv.dockDetails.ToggleViewAction())
+}
+
+// refreshDetails shows everything that is known about the wem in the selected
+// row in the details panel.
+func (wv *WwiseViewerWindow) refreshDetails() {
+	wv.treeDetails.Clear()
+	index := wv.getSelectedRow()
+	if index < 0 {
+		return
+	}
+	wem := wv.table.GetContainer().Wems()[index]
+
+	group := func(title string) *widgets.QTreeWidgetItem {
+		item := widgets.NewQTreeWidgetItem2([]string{title}, 0)
+		wv.treeDetails.AddTopLevelItem(item)
+		item.SetFirstColumnSpanned(true)
+		item.SetExpanded(true)
+		return item
+	}
+	add := func(parent *widgets.QTreeWidgetItem, property, value string) {
+		widgets.NewQTreeWidgetItem4(parent, []string{property, value}, 0)
+	}
+
+	// Every column of the table is shown, including those that are hidden.
+	wemGroup := group("Wem")
+	for _, b := range wv.table.model.bindings {
+		if value := b.accessor(index); value != "" {
+			add(wemGroup, b.title, value)
+		}
+	}
+	add(wemGroup, "Offset in data", fmt.Sprintf("0x%X", wem.Descriptor.Offset))
+
+	formatGroup := group("Format")
+	if f, err := wem.Format(); err != nil {
+		add(formatGroup, "Error", err.Error())
+	} else {
+		add(formatGroup, "Codec", fmt.Sprintf("%s (0x%04X)", f.CodecName(),
+			f.Codec))
+		add(formatGroup, "Channels", fmt.Sprintf("%d", f.Channels))
+		add(formatGroup, "Sample rate", fmt.Sprintf("%d Hz", f.SampleRate))
+		add(formatGroup, "Bits per sample", fmt.Sprintf("%d", f.BitsPerSample))
+		add(formatGroup, "Average bitrate", fmt.Sprintf("%d bytes/s",
+			f.AvgBytesPerSecond))
+		add(formatGroup, "Block align", fmt.Sprintf("%d", f.BlockAlign))
+		if samples := f.Samples(); samples != 0 {
+			add(formatGroup, "Samples", fmt.Sprintf("%d", samples))
+		}
+		if d := f.Duration(); d != 0 {
+			add(formatGroup, "Duration", d.String())
+		}
+		add(formatGroup, "Audio data", fmt.Sprintf("%d bytes at 0x%X",
+			f.DataLength, f.DataOffset))
+	}
+
+	if _, ok := wv.table.GetContainer().(*bnk.File); !ok {
+		return
+	}
+	refGroup := group("References")
+	id := wem.Descriptor.WemId
+	seen := make(map[string]bool)
+	for _, item := range wv.soundItems[id] {
+		sound := item.Text(hierarchyIdColumn)
+		if !seen[sound] {
+			seen[sound] = true
+			add(refGroup, "Sound", sound)
+		}
+	}
+	for _, event := range wv.eventsOfWems[id] {
+		add(refGroup, "Event", fmt.Sprintf("%d", event))
+	}
+	if len(seen) == 0 {
+		add(refGroup, "None", "This wem is not played by any object")
+	}
+}
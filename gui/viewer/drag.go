@@ -0,0 +1,79 @@
+package viewer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+import (
+	"wwise"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// setupDragOut lets rows of the table be dragged to a file manager, exporting
+// their wems to wherever they are dropped.
+func (wv *WwiseViewerWindow) setupDragOut() {
+	wv.table.SetDragEnabled(true)
+	wv.table.SetDragDropMode(widgets.QAbstractItemView__DragOnly)
+	wv.table.ConnectStartDrag(func(supportedActions core.Qt__DropAction) {
+		if indexes := wv.getSelectedRows(); len(indexes) > 0 {
+			wv.dragWems(indexes)
+		}
+	})
+}
+
+// dragWems exports the wems at indexes, in the format chosen in the
+// preferences, to a temporary directory, then drags the exported files. File
+// managers copy the files to wherever they are dropped.
+func (wv *WwiseViewerWindow) dragWems(indexes []int) {
+	dir, err := ioutil.TempDir("", "wwiseutil-drag-")
+	if err != nil {
+		wv.showExportError("", os.TempDir(), err)
+		return
+	}
+	// File managers may read the files after the drag has finished, so they are
+	// only removed once the viewer is closed.
+	wv.dragDirs = append(wv.dragDirs, dir)
+
+	all := wv.table.GetContainer().Wems()
+	wems := make([]*wwise.Wem, len(indexes))
+	for i, index := range indexes {
+		wems[i] = all[index]
+	}
+	to := exportFormat()
+	filenames := exportFilenames(wems, wv.table.names, exportExtension(to))
+	var filename string
+	wv.waitInBackground("Exporting wems...",
+		func(progress wwise.ProgressFunc) {
+			filename, _, err = writeWems(dir, wems, filenames, to, progress)
+		}, func() {})
+	switch {
+	case err == wwise.ErrCancelled:
+		wv.showCancelledStatus("Dragging wems")
+		return
+	case err != nil:
+		wv.showExportError(filename, dir, err)
+		return
+	}
+
+	urls := make([]*core.QUrl, len(filenames))
+	for i, name := range filenames {
+		urls[i] = core.QUrl_FromLocalFile(filepath.Join(dir, name))
+	}
+	mime := core.NewQMimeData()
+	mime.SetUrls(urls)
+	drag := gui.NewQDrag(wv.table)
+	drag.SetMimeData(mime)
+	drag.Exec2(core.Qt__CopyAction, core.Qt__CopyAction)
+}
+
+// removeDragDirs removes the files exported by dragging wems out of the table.
+func (wv *WwiseViewerWindow) removeDragDirs() {
+	for _, dir := range wv.dragDirs {
+		os.RemoveAll(dir)
+	}
+	wv.dragDirs = nil
+}
@@ -0,0 +1,186 @@
+package viewer
+
+import (
+	"fmt"
+)
+
+import (
+	"bnk"
+	"pck"
+	"util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The view of the File Package itself, rather than one of its SoundBanks.
+const packageView = -1
+
+// An embeddedBanks tracks the views of an open File Package that contains
+// embedded SoundBanks. Each view keeps its own model, so that changes staged
+// in one view are kept while another is shown.
+type embeddedBanks struct {
+	pck *pck.File
+	// The entry indexes of the embedded SoundBanks.
+	indexes []int
+	// A mapping from entry index to the model of its view, for every view that
+	// has been shown. The File Package itself has the index packageView.
+	models  map[int]*WemModel
+	current int
+}
+
+func (wv *WwiseViewerWindow) setupBanksDock() {
+	wv.listBanks = widgets.NewQListWidget(wv)
+	wv.listBanks.ConnectCurrentRowChanged(func(row int) {
+		if wv.embedded == nil || row < 0 {
+			return
+		}
+		entry := packageView
+		if row > 0 {
+			entry = wv.embedded.indexes[row-1]
+		}
+		wv.switchView(entry)
+	})
+
+	wv.dockBanks = widgets.NewQDockWidget("Embedded SoundBanks", wv, 0)
+	wv.dockBanks.SetObjectName("banksDock")
+	wv.dockBanks.SetWidget(wv.listBanks)
+	wv.AddDockWidget(core.Qt__LeftDockWidgetArea, wv.dockBanks)
+	wv.dockBanks.Hide()
+	wv.viewMenu.QWidget.AddAction(wv.dockBanks.ToggleViewAction())
+}
+
+// loadEmbeddedBanks lists the SoundBanks embedded in p, which has just been
+// loaded into the table, in the banks sidebar. The sidebar is hidden if p is
+// nil or has no embedded SoundBanks.
+func (wv *WwiseViewerWindow) loadEmbeddedBanks(p *pck.File) {
+	wv.embedded = nil
+	wv.listBanks.BlockSignals(true)
+	defer wv.listBanks.BlockSignals(false)
+	wv.listBanks.Clear()
+	if p == nil {
+		wv.dockBanks.Hide()
+		return
+	}
+	indexes := p.SoundBankIndexes()
+	if len(indexes) == 0 {
+		wv.dockBanks.Hide()
+		return
+	}
+
+	wv.embedded = &embeddedBanks{pck: p, indexes: indexes,
+		models:  map[int]*WemModel{packageView: wv.table.model},
+		current: packageView}
+	wv.listBanks.AddItem("File Package")
+	for _, index := range indexes {
+		id := p.Wems()[index].Descriptor.WemId
+		wv.listBanks.AddItem(fmt.Sprintf("SoundBank %d", id))
+	}
+	wv.listBanks.SetCurrentRow(0)
+	wv.dockBanks.Show()
+}
+
+// switchView shows the view of the entry at index, or of the File Package
+// itself if index is packageView.
+func (wv *WwiseViewerWindow) switchView(index int) {
+	e := wv.embedded
+	if index == e.current {
+		return
+	}
+	wv.recordColumnWidths()
+	m, ok := e.models[index]
+	if ok {
+		wv.table.setModel(m)
+	} else {
+		b, err := e.pck.SoundBank(index)
+		if err != nil {
+			wv.showOpenError(fmt.Sprintf("embedded SoundBank %d",
+				e.pck.Wems()[index].Descriptor.WemId), err)
+			wv.selectViewRow(e.current)
+			return
+		}
+		applyAlignment(b)
+		wv.logWarnings(fmt.Sprintf("SoundBank %d",
+			e.pck.Wems()[index].Descriptor.WemId), b.Warnings())
+		wv.table.LoadSoundBankModel(b)
+		e.models[index] = wv.table.model
+	}
+	e.current = index
+
+	b, _ := wv.table.GetContainer().(*bnk.File)
+	wv.loadHierarchy(b)
+	wv.loadHexSources(b)
+	wv.eventsOfWems = nil
+	if b != nil {
+		wv.eventsOfWems = b.EventsOfWems()
+	}
+	wv.applyColumnVisibility()
+	wv.restoreColumnWidths()
+	wv.clearLoopValues()
+	wv.refreshDetails()
+	wv.refreshStatusBar()
+	wv.selectViewRow(index)
+}
+
+// selectViewRow selects the row of the banks sidebar that shows the entry at
+// index.
+func (wv *WwiseViewerWindow) selectViewRow(index int) {
+	row := 0
+	for i, entry := range wv.embedded.indexes {
+		if entry == index {
+			row = i + 1
+		}
+	}
+	wv.listBanks.BlockSignals(true)
+	wv.listBanks.SetCurrentRow(row)
+	wv.listBanks.BlockSignals(false)
+}
+
+// showModel shows the view whose model is m, if it is not already shown.
+func (wv *WwiseViewerWindow) showModel(m *WemModel) {
+	if wv.embedded == nil || wv.table.model == m {
+		return
+	}
+	for index, model := range wv.embedded.models {
+		if model == m {
+			wv.switchView(index)
+			return
+		}
+	}
+}
+
+// rootModel returns the model of the open file, regardless of which view is
+// shown.
+func (wv *WwiseViewerWindow) rootModel() *WemModel {
+	if wv.embedded != nil {
+		return wv.embedded.models[packageView]
+	}
+	return wv.table.model
+}
+
+// commitEmbeddedBanks commits the changes staged in the view of each embedded
+// SoundBank, then writes the SoundBank back into the File Package. The number
+// of replacements committed is returned.
+func (wv *WwiseViewerWindow) commitEmbeddedBanks() (int, error) {
+	if wv.embedded == nil {
+		return 0, nil
+	}
+	count := 0
+	for index, m := range wv.embedded.models {
+		if index == packageView || !m.hasStagedChanges() {
+			continue
+		}
+		count += m.commit()
+		// The SoundBank is read back once the File Package is saved, so it is
+		// buffered until then, in a temporary file if it is large.
+		buf := util.NewSpillBuffer()
+		if _, err := m.ctn.WriteTo(buf); err != nil {
+			buf.Close()
+			return count, err
+		}
+		err := wv.embedded.pck.ReplaceSoundBank(index, buf, buf.Size())
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
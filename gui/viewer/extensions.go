@@ -0,0 +1,244 @@
+package viewer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+import (
+	"util"
+	"wwise"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The name of the directory, within the configuration directory of the
+// application, that extensions are found in.
+const extensionsDirName = "extensions"
+
+// The environment variables that describe the wem an extension is run on. The
+// path of the wem itself is given as the only argument.
+const (
+	envContainer = "WWISEUTIL_CONTAINER"
+	envWemIndex  = "WWISEUTIL_WEM_INDEX"
+	envWemId     = "WWISEUTIL_WEM_ID"
+	envWemName   = "WWISEUTIL_WEM_NAME"
+	// An extension that writes a wem to this path stages it as a replacement for
+	// the wem it was run on.
+	envReplacement = "WWISEUTIL_REPLACEMENT"
+)
+
+// The file extensions of the files that are run as extensions on Windows,
+// where files are not marked as executable.
+var windowsExecutables = []string{".exe", ".bat", ".cmd", ".com"}
+
+// An extension is an executable, found in the extensions directory, that is run
+// on selected wems from the context menu of the table.
+type extension struct {
+	name string
+	path string
+}
+
+// An extensionRun is the outcome of running an extension on a single wem.
+type extensionRun struct {
+	index  int
+	output string
+	// The path of the replacement written by the extension, or "" if it did not
+	// write one.
+	replacement string
+	err         error
+}
+
+// extensionsDir returns the directory that extensions are found in.
+func extensionsDir() string {
+	config := core.QStandardPaths_WritableLocation(
+		core.QStandardPaths__AppConfigLocation)
+	return filepath.Join(config, extensionsDirName)
+}
+
+// findExtensions returns the extensions in dir, in order of name.
+func findExtensions(dir string) []*extension {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var exts []*extension
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if runtime.GOOS == "windows" {
+			if !contains(windowsExecutables, ext) {
+				continue
+			}
+		} else if info.Mode()&0111 == 0 {
+			continue
+		}
+		exts = append(exts, &extension{strings.TrimSuffix(name, ext),
+			filepath.Join(dir, name)})
+	}
+	sort.Slice(exts, func(i, j int) bool { return exts[i].name < exts[j].name })
+	return exts
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// addExtensionsMenu adds a submenu to menu that runs each extension on the wems
+// at indexes.
+func (wv *WwiseViewerWindow) addExtensionsMenu(menu *widgets.QMenu,
+	indexes []int) {
+	submenu := menu.AddMenu2("E&xtensions")
+	dir := extensionsDir()
+	exts := findExtensions(dir)
+	for _, ext := range exts {
+		ext := ext
+		action := submenu.AddAction(strings.Replace(ext.name, "&", "&&", -1))
+		action.SetToolTip(ext.path)
+		action.ConnectTriggered(func(checked bool) {
+			wv.runExtension(ext, indexes)
+		})
+	}
+	if len(exts) == 0 {
+		none := submenu.AddAction("No extensions installed")
+		none.SetEnabled(false)
+	}
+	submenu.AddSeparator()
+	actionOpenDir := submenu.AddAction("&Open Extensions Folder")
+	actionOpenDir.ConnectTriggered(func(checked bool) {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			wv.showOpenError(dir, err)
+			return
+		}
+		gui.QDesktopServices_OpenUrl(core.QUrl_FromLocalFile(dir))
+	})
+}
+
+// runExtension runs ext on each of the wems at indexes in the background. The
+// output of each run is shown in the console, and any replacements that ext
+// writes are staged.
+func (wv *WwiseViewerWindow) runExtension(ext *extension, indexes []int) {
+	ctn := wv.table.GetContainer()
+	names := make([]string, len(indexes))
+	for i, index := range indexes {
+		names[i] = wv.table.model.wemName(index)
+	}
+	path := wv.currPath
+
+	var runs []*extensionRun
+	wv.runInBackground(fmt.Sprintf("Running %s...", ext.name),
+		func(progress wwise.ProgressFunc) {
+			for i, index := range indexes {
+				run := ext.run(ctn, path, index, names[i])
+				runs = append(runs, run)
+				if !progress(int64(i+1), int64(len(indexes))) {
+					break
+				}
+			}
+		}, func() {
+			wv.finishExtension(ext, runs)
+		})
+}
+
+// run runs this extension on the wem of ctn, which was opened from path, at
+// index.
+func (ext *extension) run(ctn wwise.Container, path string, index int,
+	name string) *extensionRun {
+	run := &extensionRun{index: index}
+	dir, err := ioutil.TempDir("", "wwiseutil-extension-")
+	if err != nil {
+		run.err = err
+		return run
+	}
+	wem := ctn.Wems()[index]
+	wemPath := filepath.Join(dir, util.SanitizeFileName(name)+".wem")
+	replacement := filepath.Join(dir, "replacement.wem")
+	if err := writeWemFile(wem, wemPath); err != nil {
+		os.RemoveAll(dir)
+		run.err = err
+		return run
+	}
+
+	cmd := exec.Command(ext.path, wemPath)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		envContainer+"="+path,
+		fmt.Sprintf("%s=%d", envWemIndex, index+1),
+		fmt.Sprintf("%s=%d", envWemId, wem.Descriptor.WemId),
+		envWemName+"="+name,
+		envReplacement+"="+replacement)
+	out, err := cmd.CombinedOutput()
+	run.output = strings.TrimSpace(string(out))
+	run.err = err
+
+	if _, statErr := os.Stat(replacement); err == nil && statErr == nil {
+		// The directory is kept, as the replacement is read from it until the
+		// file is saved.
+		run.replacement = replacement
+		return run
+	}
+	os.RemoveAll(dir)
+	return run
+}
+
+// writeWemFile writes the contents of wem to a new file at path.
+func writeWemFile(wem *wwise.Wem, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = util.Copy(f, wem.NewReader())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// finishExtension shows the outcome of each of runs of ext, and stages the
+// replacements that they wrote.
+func (wv *WwiseViewerWindow) finishExtension(ext *extension,
+	runs []*extensionRun) {
+	staged, failed := 0, 0
+	wv.undoStack.BeginMacro("Run " + ext.name)
+	for _, run := range runs {
+		source := fmt.Sprintf("%s (%s)", ext.name,
+			wv.table.model.wemName(run.index))
+		if run.output != "" {
+			wv.logConsole(consoleInfo, source, run.output)
+		}
+		if run.err != nil {
+			wv.logConsole(consoleError, source, run.err.Error())
+			failed++
+			continue
+		}
+		if run.replacement != "" {
+			wv.addReplacement(run.index, run.replacement)
+			staged++
+		}
+	}
+	wv.undoStack.EndMacro()
+
+	msg := fmt.Sprintf("Ran %s on %d wem(s).", ext.name, len(runs))
+	if staged > 0 {
+		msg += fmt.Sprintf(" %d replacement(s) were staged.", staged)
+	}
+	if failed > 0 {
+		msg += fmt.Sprintf(" %d run(s) failed; see the console.", failed)
+	}
+	wv.StatusBar().ShowMessage(msg, 0)
+}
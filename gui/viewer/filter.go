@@ -0,0 +1,91 @@
+package viewer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The prefix of a filter term that selects wems by their size in bytes.
+const sizeFilterPrefix = "size:"
+
+// A wemFilter narrows the rows of a table to those wems that match every one
+// of its terms. A filter is written as space separated terms, where each term
+// is one of:
+//
+//	replaced         Only wems with a staged replacement.
+//	size:MIN-MAX     Only wems whose size in bytes is within the range. Either
+//	                 end may be omitted, and sizes may use a k or m suffix.
+//	anything else    Only wems whose id or name contains the term.
+type wemFilter struct {
+	text     []string
+	replaced bool
+	minSize  uint64
+	maxSize  uint64
+}
+
+// parseWemFilter parses a filter from the text typed by the user.
+func parseWemFilter(query string) (*wemFilter, error) {
+	f := &wemFilter{maxSize: ^uint64(0)}
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		switch {
+		case term == "replaced":
+			f.replaced = true
+		case strings.HasPrefix(term, sizeFilterPrefix):
+			bounds := strings.SplitN(strings.TrimPrefix(term, sizeFilterPrefix),
+				"-", 2)
+			var err error
+			if bounds[0] != "" {
+				if f.minSize, err = parseSize(bounds[0]); err != nil {
+					return nil, err
+				}
+			}
+			if len(bounds) == 1 {
+				f.maxSize = f.minSize
+			} else if bounds[1] != "" {
+				if f.maxSize, err = parseSize(bounds[1]); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			f.text = append(f.text, term)
+		}
+	}
+	return f, nil
+}
+
+// parseSize parses a size in bytes, with an optional k or m suffix.
+func parseSize(s string) (uint64, error) {
+	multiplier := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "k"):
+		multiplier, s = 1<<10, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		multiplier, s = 1<<20, strings.TrimSuffix(s, "m")
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a valid size", s)
+	}
+	return n * multiplier, nil
+}
+
+// matches returns true if a wem with the specified properties passes this
+// filter.
+func (f *wemFilter) matches(id uint32, name string, size uint32,
+	replaced bool) bool {
+	if f.replaced && !replaced {
+		return false
+	}
+	if uint64(size) < f.minSize || uint64(size) > f.maxSize {
+		return false
+	}
+	idText := strconv.FormatUint(uint64(id), 10)
+	name = strings.ToLower(name)
+	for _, term := range f.text {
+		if !strings.Contains(idText, term) && !strings.Contains(name, term) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+)
+
+import (
+	"pck"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The ways that replacements can be placed within a File Package, as stored in
+// the preferences.
+const (
+	shiftOffsetsMode = "shift"
+	padInPlaceMode   = "pad"
+)
+
+// The distance, in bytes, that a save may move the wems of a File Package
+// before the user is warned.
+const largeShift = 1 << 20
+
+// The largest number of wems named in a fit warning.
+const maxFitNames = 10
+
+// packageMode returns the way that replacements are placed within File
+// Packages, as chosen in the preferences.
+func packageMode() pck.ReplaceMode {
+	if loadString(packageModeKey) == padInPlaceMode {
+		return pck.PadInPlace
+	}
+	return pck.ShiftOffsets
+}
+
+func packageModeName(mode pck.ReplaceMode) string {
+	if mode == pck.PadInPlace {
+		return "Pad in Place"
+	}
+	return "Shift Offsets"
+}
+
+// checkPackageFit checks whether the replacements staged in m fit within its
+// File Package with the mode chosen in the preferences, and asks which mode to
+// save with if they do not. The chosen mode is set on the File Package. It
+// returns false if the save should be cancelled.
+func (wv *WwiseViewerWindow) checkPackageFit(m *WemModel) bool {
+	p, ok := m.ctn.(*pck.File)
+	if !ok {
+		return true
+	}
+	p.Mode = packageMode()
+	rs := m.stagedReplacements()
+	if len(rs) == 0 {
+		return true
+	}
+
+	fit := p.CheckFit(p.Mode, rs...)
+	var msg string
+	other := pck.ShiftOffsets
+	switch {
+	case p.Mode == pck.PadInPlace && len(fit.Overflows) > 0:
+		msg = fmt.Sprintf("%d replacement(s) are larger than the space taken "+
+			"up by the wem they replace:\n%s\n\nSaving will move %d wem(s) by up "+
+			"to %d bytes, which games that expect wems at fixed offsets may not "+
+			"play.", len(fit.Overflows), fitNames(m, fit.Overflows),
+			fit.Moved, fit.LargestShift)
+	case p.Mode == pck.ShiftOffsets && fit.LargestShift >= largeShift:
+		msg = fmt.Sprintf("Saving will move %d wem(s) by up to %d bytes, "+
+			"which games that expect wems at fixed offsets may not play.",
+			fit.Moved, fit.LargestShift)
+		other = pck.PadInPlace
+		overflows := p.CheckFit(pck.PadInPlace, rs...).Overflows
+		if len(overflows) > 0 {
+			msg += fmt.Sprintf("\n\n%d replacement(s) are too large to be "+
+				"padded in place:\n%s", len(overflows), fitNames(m, overflows))
+		}
+	default:
+		return true
+	}
+
+	box := widgets.NewQMessageBox2(widgets.QMessageBox__Warning,
+		"Replacements do not fit", msg, widgets.QMessageBox__Cancel, wv, 0)
+	keep := box.AddButton2("Save with "+packageModeName(p.Mode),
+		widgets.QMessageBox__AcceptRole)
+	switchMode := box.AddButton2("Save with "+packageModeName(other),
+		widgets.QMessageBox__AcceptRole)
+	box.SetDefaultButton(keep)
+	box.Exec()
+	switch box.ClickedButton().Pointer() {
+	case keep.Pointer():
+		return true
+	case switchMode.Pointer():
+		p.Mode = other
+		return true
+	}
+	return false
+}
+
+// fitNames returns the names of the wems of m at indexes, and how much larger
+// their replacements are than the space they take up, one per line.
+func fitNames(m *WemModel, indexes []int) string {
+	p := m.ctn.(*pck.File)
+	var names []string
+	for i, index := range indexes {
+		if i == maxFitNames {
+			names = append(names, fmt.Sprintf("and %d more",
+				len(indexes)-maxFitNames))
+			break
+		}
+		excess := m.replacements[index].replacement.Length - p.SlotSize(index)
+		names = append(names, fmt.Sprintf("%s (%d bytes too large)",
+			m.wemName(index), excess))
+	}
+	return strings.Join(names, "\n")
+}
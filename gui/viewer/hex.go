@@ -0,0 +1,100 @@
+package viewer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+import (
+	"bnk"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The maximum number of bytes shown by the hex preview. Wems can be many
+// megabytes long, and only their headers are usually of interest.
+const maxHexPreviewBytes = 64 * 1024
+
+func (wv *WwiseViewerWindow) setupHexDock() {
+	wv.comboHexSource = widgets.NewQComboBox(wv)
+	wv.comboHexSource.ConnectCurrentIndexChanged(func(index int) {
+		wv.refreshHexPreview()
+	})
+
+	wv.textHex = widgets.NewQPlainTextEdit(wv)
+	wv.textHex.SetReadOnly(true)
+	wv.textHex.SetLineWrapMode(widgets.QPlainTextEdit__NoWrap)
+	wv.textHex.SetFont(gui.QFontDatabase_SystemFont(gui.QFontDatabase__FixedFont))
+
+	contents := widgets.NewQWidget(nil, 0)
+	layout := widgets.NewQVBoxLayout2(contents)
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.AddWidget(wv.comboHexSource, 0, 0)
+	layout.AddWidget(wv.textHex, 0, 0)
+
+	wv.dockHex = widgets.NewQDockWidget("Hex", wv, 0)
+	wv.dockHex.SetObjectName("hexDock")
+	wv.dockHex.SetWidget(contents)
+	wv.AddDockWidget(core.Qt__BottomDockWidgetArea, wv.dockHex)
+	wv.dockHex.Hide()
+	wv.dockHex.ConnectVisibilityChanged(func(visible bool) {
+		if visible {
+			wv.refreshHexPreview()
+		}
+	})
+	wv.viewMenu.QWidget.AddAction(wv.dockHex.ToggleViewAction())
+}
+
+// loadHexSources lists the selected wem, followed by the unknown sections of b,
+// as the sources that the hex preview can show. b may be nil.
+func (wv *WwiseViewerWindow) loadHexSources(b *bnk.File) {
+	wv.hexSections = nil
+	if b != nil {
+		wv.hexSections = b.UnknownSections()
+	}
+	wv.comboHexSource.Clear()
+	wv.comboHexSource.AddItem("Selected wem", core.NewQVariant())
+	for _, s := range wv.hexSections {
+		wv.comboHexSource.AddItem(fmt.Sprintf("%s section (%d bytes)",
+			s.Header.Identifier, s.Header.Length), core.NewQVariant())
+	}
+}
+
+// refreshHexPreview shows the bytes of the source chosen in the hex dock. The
+// preview is only updated while the dock is visible.
+func (wv *WwiseViewerWindow) refreshHexPreview() {
+	if wv.dockHex.IsHidden() {
+		return
+	}
+	var r io.ReaderAt
+	var length int64
+	if source := wv.comboHexSource.CurrentIndex(); source > 0 {
+		s := wv.hexSections[source-1]
+		r, _ = s.Reader.(io.ReaderAt)
+		length = int64(s.Header.Length)
+	} else if row := wv.getSelectedRow(); row >= 0 {
+		wem := wv.table.GetContainer().Wems()[row]
+		r, _ = wem.Reader.(io.ReaderAt)
+		length = int64(wem.Descriptor.Length)
+	}
+	if r == nil {
+		wv.textHex.Clear()
+		return
+	}
+
+	n := length
+	if n > maxHexPreviewBytes {
+		n = maxHexPreviewBytes
+	}
+	b := make([]byte, n)
+	read, err := r.ReadAt(b, 0)
+	text := hex.Dump(b[:read])
+	if err != nil && err != io.EOF {
+		text += fmt.Sprintf("Could not read the data: %s\n", err)
+	} else if length > n {
+		text += fmt.Sprintf("... %d more bytes are not shown\n", length-n)
+	}
+	wv.textHex.SetPlainText(text)
+}
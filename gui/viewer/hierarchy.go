@@ -0,0 +1,112 @@
+package viewer
+
+import (
+	"fmt"
+)
+
+import (
+	"bnk"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The columns of the hierarchy tree.
+const (
+	hierarchyTypeColumn = iota
+	hierarchyIdColumn
+	hierarchyWemColumn
+)
+
+func (wv *WwiseViewerWindow) setupHierarchyDock() {
+	wv.treeHierarchy = widgets.NewQTreeWidget(wv)
+	wv.treeHierarchy.SetColumnCount(3)
+	wv.treeHierarchy.SetHeaderLabels([]string{"Object", "Id", "Wem Id"})
+	wv.treeHierarchy.SetSelectionMode(
+		widgets.QAbstractItemView__ExtendedSelection)
+	wv.treeHierarchy.ConnectCurrentItemChanged(func(current,
+		previous *widgets.QTreeWidgetItem) {
+		if wv.syncingSelection || current.Pointer() == nil {
+			return
+		}
+		text := current.Text(hierarchyWemColumn)
+		if text == "" {
+			return
+		}
+		index := wv.table.WemIndexOfName(text)
+		if index < 0 {
+			return
+		}
+		wv.syncingSelection = true
+		wv.table.SelectWem(index)
+		wv.syncingSelection = false
+	})
+
+	wv.dockHierarchy = widgets.NewQDockWidget("Hierarchy", wv, 0)
+	wv.dockHierarchy.SetObjectName("hierarchyDock")
+	wv.dockHierarchy.SetWidget(wv.treeHierarchy)
+	wv.AddDockWidget(core.Qt__RightDockWidgetArea, wv.dockHierarchy)
+	wv.viewMenu.QWidget.AddAction(wv.dockHierarchy.ToggleViewAction())
+}
+
+// loadHierarchy shows the decoded object hierarchy of b in the hierarchy tree.
+// If b is nil, the tree is cleared.
+func (wv *WwiseViewerWindow) loadHierarchy(b *bnk.File) {
+	wv.treeHierarchy.Clear()
+	wv.soundItems = make(map[uint32][]*widgets.QTreeWidgetItem)
+	if b == nil {
+		return
+	}
+
+	onPath := make(map[*bnk.ObjectNode]bool)
+	var add func(parent *widgets.QTreeWidgetItem, n *bnk.ObjectNode)
+	add = func(parent *widgets.QTreeWidgetItem, n *bnk.ObjectNode) {
+		// Malformed SoundBanks may contain objects that are their own ancestors.
+		if onPath[n] {
+			return
+		}
+		onPath[n] = true
+		defer delete(onPath, n)
+
+		var item *widgets.QTreeWidgetItem
+		if parent == nil {
+			item = widgets.NewQTreeWidgetItem2([]string{}, 0)
+			wv.treeHierarchy.AddTopLevelItem(item)
+		} else {
+			item = widgets.NewQTreeWidgetItem6(parent, 0)
+		}
+		item.SetText(hierarchyTypeColumn, n.TypeName())
+		item.SetText(hierarchyIdColumn, fmt.Sprintf("%d", n.Id))
+		if n.IsSound() {
+			item.SetText(hierarchyWemColumn, fmt.Sprintf("%d", n.WemId))
+			wv.soundItems[n.WemId] = append(wv.soundItems[n.WemId], item)
+		}
+		for _, child := range n.Children {
+			add(item, child)
+		}
+	}
+	for _, root := range b.Hierarchy() {
+		add(nil, root)
+	}
+	wv.treeHierarchy.ResizeColumnToContents(hierarchyTypeColumn)
+}
+
+// highlightSounds selects every Sound object in the hierarchy tree that plays
+// the wem with the specified id, expanding the tree to show them.
+func (wv *WwiseViewerWindow) highlightSounds(wemId uint32) {
+	if wv.syncingSelection {
+		return
+	}
+	wv.syncingSelection = true
+	defer func() { wv.syncingSelection = false }()
+
+	wv.treeHierarchy.ClearSelection()
+	items := wv.soundItems[wemId]
+	for i, item := range items {
+		item.SetSelected(true)
+		if i == 0 {
+			wv.treeHierarchy.SetCurrentItem(item)
+			wv.treeHierarchy.ScrollToItem(item,
+				widgets.QAbstractItemView__PositionAtCenter)
+		}
+	}
+}
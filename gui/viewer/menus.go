@@ -0,0 +1,113 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+)
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// shortcut returns the key sequence described by keys, such as "Ctrl+R".
+func shortcut(keys string) *gui.QKeySequence {
+	return gui.NewQKeySequence2(keys, gui.QKeySequence__PortableText)
+}
+
+func (wv *WwiseViewerWindow) setupEditMenu() {
+	menu := wv.MenuBar().AddMenu2("&Edit")
+	menu.QWidget.AddAction(wv.actionUndo)
+	menu.QWidget.AddAction(wv.actionRedo)
+	menu.AddSeparator()
+	menu.QWidget.AddAction(wv.actionReplace)
+	menu.QWidget.AddAction(wv.actionReplaceDir)
+
+	wv.actionRevert = menu.AddAction("Re&vert")
+	wv.actionRevert.SetEnabled(false)
+	wv.actionRevert.ConnectTriggered(func(checked bool) {
+		if row := wv.getSelectedRow(); row >= 0 {
+			wv.revert(row)
+		}
+	})
+	menu.AddSeparator()
+
+	wv.actionFind = menu.AddAction("&Find")
+	wv.actionFind.SetShortcuts2(gui.QKeySequence__Find)
+	wv.actionFind.ConnectTriggered(func(checked bool) {
+		wv.lineEditFilter.SetFocus2()
+		wv.lineEditFilter.SelectAll()
+	})
+
+	wv.actionGoTo = menu.AddAction("&Go to Wem...")
+	wv.actionGoTo.SetEnabled(false)
+	wv.actionGoTo.SetShortcut(shortcut("Ctrl+G"))
+	wv.actionGoTo.ConnectTriggered(func(checked bool) {
+		ok := false
+		name := widgets.QInputDialog_GetText(wv, "Go to Wem",
+			"Wem id or name:", widgets.QLineEdit__Normal, "", &ok, 0, 0)
+		if ok && strings.TrimSpace(name) != "" {
+			wv.goToWem(strings.TrimSpace(name))
+		}
+	})
+	menu.AddSeparator()
+
+	wv.setupPreferences()
+	menu.QWidget.AddAction(wv.actionPreferences)
+}
+
+// goToWem selects and scrolls to the wem whose id or name is name, clearing
+// the filter if it hides the wem.
+func (wv *WwiseViewerWindow) goToWem(name string) {
+	index := wv.table.WemIndexOfName(name)
+	if index < 0 {
+		msg := fmt.Sprintf("There is no wem with the id or name %s.", name)
+		widgets.QMessageBox_Information(wv, "Wem not found", msg, 0, 0)
+		return
+	}
+	wv.table.SelectWem(index)
+	if wv.getSelectedRow() != index {
+		wv.lineEditFilter.Clear()
+		wv.table.SelectWem(index)
+	}
+	wv.table.SetFocus2()
+}
+
+func (wv *WwiseViewerWindow) setupToolsMenu() {
+	menu := wv.MenuBar().AddMenu2("&Tools")
+	menu.QWidget.AddAction(wv.actionPlay)
+	menu.QWidget.AddAction(wv.actionPreviewLoop)
+	menu.QWidget.AddAction(wv.actionSetLoop)
+	menu.AddSeparator()
+	wv.setupCompare()
+	menu.QWidget.AddAction(wv.actionCompare)
+	wv.setupBatch()
+	menu.QWidget.AddAction(wv.actionBatch)
+	menu.AddSeparator()
+	wv.setupRegisterAssociations()
+	menu.QWidget.AddAction(wv.actionAssociate)
+	actionClearCache := menu.AddAction("&Clear Conversion Cache")
+	actionClearCache.ConnectTriggered(func(checked bool) {
+		if err := wv.preview.ClearCache(); err != nil {
+			msg := fmt.Sprintf("Could not clear the conversion cache:\n%s", err)
+			widgets.QMessageBox_Critical(wv, errorTitle, msg, 0, 0)
+		}
+	})
+}
+
+func (wv *WwiseViewerWindow) setupHelpMenu() {
+	menu := wv.MenuBar().AddMenu2("&Help")
+	actionAbout := menu.AddAction("&About")
+	actionAbout.ConnectTriggered(func(checked bool) {
+		msg := fmt.Sprintf("%s %s\n\n"+
+			"A tool for manipulating Wwise SoundBanks and File Packages.",
+			core.QCoreApplication_ApplicationName(),
+			core.QCoreApplication_ApplicationVersion())
+		widgets.QMessageBox_About(wv, "About", msg)
+	})
+	actionAboutQt := menu.AddAction("About &Qt")
+	actionAboutQt.ConnectTriggered(func(checked bool) {
+		widgets.QMessageBox_AboutQt(wv, "About Qt")
+	})
+}
@@ -0,0 +1,95 @@
+package viewer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+import (
+	"util"
+	"wwise"
+	"github.com/therecipe/qt/widgets"
+)
+
+var nameFileFilters = strings.Join([]string{
+	"Name files (*.xml *.json *.h)",
+	"SoundbanksInfo files (*.xml *.json)",
+	"Wwise_IDs.h files (*.h)",
+	"All files (*.*)",
+}, ";;")
+
+func (wv *WwiseViewerWindow) setupLoadNames() {
+	wv.actionLoadNames = widgets.NewQAction2("Load &Names...", wv)
+	wv.actionLoadNames.ConnectTriggered(func(checked bool) {
+		start := util.UserHome()
+		if path := loadString(namesPathKey); path != "" {
+			start = filepath.Dir(path)
+		}
+		path := widgets.QFileDialog_GetOpenFileName(
+			wv, "Open SoundbanksInfo or Wwise_IDs.h file", start, nameFileFilters,
+			"", 0)
+		if path != "" {
+			wv.loadNames(path)
+		}
+	})
+}
+
+// loadNames reads the names of wems, banks and events from the file at path,
+// and shows them from then on. The names are loaded again when the viewer is
+// next started.
+func (wv *WwiseViewerWindow) loadNames(path string) {
+	db, err := wwise.OpenNameDatabase(path)
+	if err != nil {
+		msg := fmt.Sprintf("Could not read names from %s:\n%s", path, err)
+		widgets.QMessageBox_Critical(wv, errorTitle, msg, 0, 0)
+		return
+	}
+	saveString(namesPathKey, path)
+	wv.table.SetNames(db)
+	wv.showColumn(originalNameColumn)
+	wv.refreshDetails()
+	wv.StatusBar().ShowMessage("Loaded names from "+filepath.Base(path), 0)
+}
+
+// restoreNames loads the names that were last loaded, if any. Names that can
+// no longer be read are reported in the console, rather than interrupting
+// startup.
+func (wv *WwiseViewerWindow) restoreNames() {
+	path := loadString(namesPathKey)
+	if path == "" {
+		return
+	}
+	db, err := wwise.OpenNameDatabase(path)
+	if err != nil {
+		wv.logConsole(consoleError, filepath.Base(path),
+			fmt.Sprintf("Could not read names: %s", err))
+		return
+	}
+	wv.table.SetNames(db)
+	wv.showColumn(originalNameColumn)
+}
+
+// exportFilenames returns the name of the file, with the extension ext, that
+// each wem in wems is exported to. Wems are named by their original name in
+// names where it is known, and by their id otherwise.
+func exportFilenames(wems []*wwise.Wem, names *wwise.NameDatabase,
+	ext string) []string {
+	filenames := make([]string, len(wems))
+	used := make(map[string]bool)
+	for i, wem := range wems {
+		id := wem.Descriptor.WemId
+		filename := fmt.Sprintf("%d%s", id, ext)
+		if name, ok := names.WemNameOf(id); ok {
+			filename = util.SanitizeFileName(name) + ext
+			if used[strings.ToLower(filename)] {
+				// Different wems may share an original name; keep both.
+				filename = fmt.Sprintf("%s_%d%s", util.SanitizeFileName(name), id,
+					ext)
+			}
+		}
+		used[strings.ToLower(filename)] = true
+		filenames[i] = filename
+	}
+	return filenames
+}
@@ -0,0 +1,299 @@
+package viewer
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+import (
+	"bnk"
+	"convert"
+	"pck"
+	"util"
+	"wwise"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The themes that can be chosen in the preferences.
+const (
+	systemTheme = "system"
+	lightTheme  = "light"
+	darkTheme   = "dark"
+)
+
+// The alignment, in bytes, that SoundBanks require of their wems. A preferred
+// alignment is only used for SoundBanks if it is a multiple of this.
+const soundBankAlignment = 16
+
+// The default volume, out of 100, of previews.
+const defaultPreviewVolume = 100
+
+// The name of the style used by the system theme, recorded before any other
+// theme is applied.
+var systemStyle string
+
+// preferredDir returns the directory that file dialogs should start in, as
+// chosen in the preferences under key, or the home directory of the user if
+// none was chosen.
+func preferredDir(key string) string {
+	if dir := loadString(key); dir != "" {
+		return dir
+	}
+	return util.UserHome()
+}
+
+// exportFormat returns the format that wems are converted to when exported, or
+// convert.UnknownFormat if they are exported as they are.
+func exportFormat() convert.Format {
+	return convert.ParseFormat(loadString(exportFormatKey))
+}
+
+// verifySaves returns true if saved files are re-opened and checked against
+// what was written to them.
+func verifySaves() bool {
+	return loadString(verifySavesKey) == "true"
+}
+
+// backupOriginals returns true if the file that was opened, or an original file
+// of the game, is copied to a backup before it is overwritten. This is done
+// unless it is turned off in the preferences.
+func backupOriginals() bool {
+	return loadString(backupKey) != "false"
+}
+
+// applyAlignment sets the alignment of the wems of ctn, following any that are
+// replaced, to the one chosen in the preferences.
+func applyAlignment(ctn wwise.Container) {
+	alignment := int64(loadInt(alignmentKey, 0))
+	if alignment <= 0 {
+		return
+	}
+	switch ctn := ctn.(type) {
+	case *bnk.File:
+		if alignment%soundBankAlignment == 0 {
+			ctn.WemAlignment = alignment
+		}
+	case *pck.File:
+		ctn.WemAlignment = alignment
+	}
+}
+
+// applyPreferences applies the preferences that take effect immediately.
+func (wv *WwiseViewerWindow) applyPreferences() {
+	applyTheme(loadString(themeKey))
+	wv.preview.SetVolume(loadInt(previewVolumeKey, defaultPreviewVolume))
+}
+
+// applyTheme sets the style and colors of the application to those of theme.
+func applyTheme(theme string) {
+	if systemStyle == "" {
+		systemStyle = widgets.QApplication_Style().ObjectName()
+	}
+	switch theme {
+	case lightTheme, darkTheme:
+		widgets.QApplication_SetStyle2("Fusion")
+	default:
+		widgets.QApplication_SetStyle2(systemStyle)
+	}
+	palette := widgets.QApplication_Style().StandardPalette()
+	if theme == darkTheme {
+		palette = darkPalette()
+	}
+	widgets.QApplication_SetPalette(palette, "")
+}
+
+func darkPalette() *gui.QPalette {
+	window := gui.NewQColor3(53, 53, 53, 255)
+	base := gui.NewQColor3(35, 35, 35, 255)
+	highlight := gui.NewQColor3(42, 130, 218, 255)
+	white := gui.NewQColor2(core.Qt__white)
+	disabled := gui.NewQColor2(core.Qt__gray)
+
+	p := gui.NewQPalette()
+	p.SetColor2(gui.QPalette__Window, window)
+	p.SetColor2(gui.QPalette__WindowText, white)
+	p.SetColor2(gui.QPalette__Base, base)
+	p.SetColor2(gui.QPalette__AlternateBase, window)
+	p.SetColor2(gui.QPalette__ToolTipBase, window)
+	p.SetColor2(gui.QPalette__ToolTipText, white)
+	p.SetColor2(gui.QPalette__Text, white)
+	p.SetColor2(gui.QPalette__Button, window)
+	p.SetColor2(gui.QPalette__ButtonText, white)
+	p.SetColor2(gui.QPalette__Link, highlight)
+	p.SetColor2(gui.QPalette__Highlight, highlight)
+	p.SetColor2(gui.QPalette__HighlightedText, gui.NewQColor2(core.Qt__black))
+	p.SetColor(gui.QPalette__Disabled, gui.QPalette__Text, disabled)
+	p.SetColor(gui.QPalette__Disabled, gui.QPalette__WindowText, disabled)
+	p.SetColor(gui.QPalette__Disabled, gui.QPalette__ButtonText, disabled)
+	return p
+}
+
+// selectData selects the item of combo whose data is value, if there is one.
+func selectData(combo *widgets.QComboBox, value string) {
+	for i := 0; i < combo.Count(); i++ {
+		if combo.ItemData(i, 0).ToString() == value {
+			combo.SetCurrentIndex(i)
+			return
+		}
+	}
+}
+
+func (wv *WwiseViewerWindow) setupPreferences() {
+	wv.actionPreferences = widgets.NewQAction2("&Preferences...", wv)
+	wv.actionPreferences.SetShortcuts2(gui.QKeySequence__Preferences)
+	wv.actionPreferences.SetMenuRole(widgets.QAction__PreferencesRole)
+	wv.actionPreferences.ConnectTriggered(func(checked bool) {
+		wv.showPreferences()
+	})
+}
+
+// showPreferences shows the preferences dialog, saving and applying the
+// preferences if it is accepted.
+func (wv *WwiseViewerWindow) showPreferences() {
+	dlg := widgets.NewQDialog(wv, 0)
+	dlg.SetWindowTitle("Preferences")
+	layout := widgets.NewQVBoxLayout2(dlg)
+
+	// pathField returns a field for a path, with a button that chooses it.
+	type chooser func(start string) string
+	pathField := func(path string,
+		choose chooser) (*widgets.QLineEdit, *widgets.QHBoxLayout) {
+		edit := widgets.NewQLineEdit2(path, nil)
+		edit.SetPlaceholderText("Default")
+		edit.SetClearButtonEnabled(true)
+		browse := widgets.NewQPushButton2("Browse...", nil)
+		browse.ConnectClicked(func(checked bool) {
+			start := edit.Text()
+			if start == "" {
+				start = util.UserHome()
+			}
+			if chosen := choose(start); chosen != "" {
+				edit.SetText(chosen)
+			}
+		})
+		row := widgets.NewQHBoxLayout()
+		row.AddWidget(edit, 1, 0)
+		row.AddWidget(browse, 0, 0)
+		return edit, row
+	}
+	chooseDir := func(start string) string {
+		opts := widgets.QFileDialog__ShowDirsOnly |
+			widgets.QFileDialog__DontResolveSymlinks
+		return widgets.QFileDialog_GetExistingDirectory(dlg, "Choose directory",
+			start, opts)
+	}
+
+	dirs := widgets.NewQGroupBox2("Default directories", nil)
+	dirsForm := widgets.NewQFormLayout(dirs)
+	editOpenDir, row := pathField(loadString(openDirKey), chooseDir)
+	dirsForm.AddRow4("Opening and saving:", row)
+	editReplaceDir, row := pathField(loadString(replaceDirKey), chooseDir)
+	dirsForm.AddRow4("Replacement wems:", row)
+	editExportDir, row := pathField(loadString(exportDirKey), chooseDir)
+	dirsForm.AddRow4("Exporting:", row)
+	layout.AddWidget(dirs, 0, 0)
+
+	files := widgets.NewQGroupBox2("Files", nil)
+	filesForm := widgets.NewQFormLayout(files)
+	spinAlignment := widgets.NewQSpinBox(nil)
+	spinAlignment.SetRange(0, 1<<20)
+	spinAlignment.SetSingleStep(soundBankAlignment)
+	spinAlignment.SetSuffix(" bytes")
+	spinAlignment.SetSpecialValueText("Default")
+	spinAlignment.SetValue(loadInt(alignmentKey, 0))
+	spinAlignment.SetToolTip("The alignment of the wems that follow a " +
+		"replaced wem, used for files opened from now on. SoundBanks only use " +
+		"multiples of 16. By default, SoundBanks keep the alignment that they " +
+		"were written with.")
+	filesForm.AddRow3("Wem alignment:", spinAlignment)
+	comboMode := widgets.NewQComboBox(nil)
+	comboMode.AddItem("Shift the wems that follow",
+		core.NewQVariant12(shiftOffsetsMode))
+	comboMode.AddItem("Pad in place", core.NewQVariant12(padInPlaceMode))
+	selectData(comboMode, loadString(packageModeKey))
+	comboMode.SetToolTip("Where replacements are placed in File Packages. " +
+		"Padding in place keeps every wem at its original offset, as long as " +
+		"each replacement fits in the space of the wem it replaces.")
+	filesForm.AddRow3("File Package replacements:", comboMode)
+	comboExport := widgets.NewQComboBox(nil)
+	comboExport.AddItem("Wem (unconverted)", core.NewQVariant12("wem"))
+	comboExport.AddItem("Ogg Vorbis", core.NewQVariant12("ogg"))
+	comboExport.AddItem("WAV", core.NewQVariant12("wav"))
+	selectData(comboExport, loadString(exportFormatKey))
+	filesForm.AddRow3("Export format:", comboExport)
+	editNames, row := pathField(loadString(namesPathKey),
+		func(start string) string {
+			return widgets.QFileDialog_GetOpenFileName(dlg,
+				"Open SoundbanksInfo or Wwise_IDs.h file", filepath.Dir(start),
+				nameFileFilters, "", 0)
+		})
+	editNames.SetPlaceholderText("None")
+	filesForm.AddRow4("Names file:", row)
+	checkboxVerify := widgets.NewQCheckBox2("Verify files after saving", nil)
+	checkboxVerify.SetChecked(verifySaves())
+	checkboxVerify.SetToolTip("Re-open each saved file, and check that it holds " +
+		"the same wems, sections and descriptors that were written.")
+	filesForm.AddRow3("", checkboxVerify)
+	checkboxBackup := widgets.NewQCheckBox2("Back up original files before "+
+		"overwriting them", nil)
+	checkboxBackup.SetChecked(backupOriginals())
+	checkboxBackup.SetToolTip("Copy the opened file, or a file within a " +
+		"nativePC or chunk directory, to a timestamped .bak file beside it " +
+		"before saving over it.")
+	filesForm.AddRow3("", checkboxBackup)
+	layout.AddWidget(files, 0, 0)
+
+	appearance := widgets.NewQGroupBox2("Appearance and preview", nil)
+	appearanceForm := widgets.NewQFormLayout(appearance)
+	comboTheme := widgets.NewQComboBox(nil)
+	comboTheme.AddItem("System", core.NewQVariant12(systemTheme))
+	comboTheme.AddItem("Light", core.NewQVariant12(lightTheme))
+	comboTheme.AddItem("Dark", core.NewQVariant12(darkTheme))
+	selectData(comboTheme, loadString(themeKey))
+	appearanceForm.AddRow3("Theme:", comboTheme)
+	sliderVolume := widgets.NewQSlider2(core.Qt__Horizontal, nil)
+	sliderVolume.SetRange(0, 100)
+	sliderVolume.SetValue(loadInt(previewVolumeKey, defaultPreviewVolume))
+	appearanceForm.AddRow3("Preview volume:", sliderVolume)
+	spinCache := widgets.NewQSpinBox(nil)
+	spinCache.SetRange(0, 1<<20)
+	spinCache.SetSingleStep(16)
+	spinCache.SetSuffix(" MB")
+	spinCache.SetValue(previewCacheLimit())
+	appearanceForm.AddRow3("Conversion cache size:", spinCache)
+	layout.AddWidget(appearance, 0, 0)
+
+	buttons := widgets.NewQDialogButtonBox3(
+		widgets.QDialogButtonBox__Ok|widgets.QDialogButtonBox__Cancel, nil)
+	buttons.ConnectAccepted(dlg.Accept)
+	buttons.ConnectRejected(dlg.Reject)
+	layout.AddWidget(buttons, 0, 0)
+
+	if dlg.Exec() != int(widgets.QDialog__Accepted) {
+		return
+	}
+	saveString(openDirKey, editOpenDir.Text())
+	saveString(replaceDirKey, editReplaceDir.Text())
+	saveString(exportDirKey, editExportDir.Text())
+	saveInt(alignmentKey, spinAlignment.Value())
+	saveString(exportFormatKey, comboExport.CurrentData(0).ToString())
+	saveString(packageModeKey, comboMode.CurrentData(0).ToString())
+	saveString(themeKey, comboTheme.CurrentData(0).ToString())
+	saveInt(previewVolumeKey, sliderVolume.Value())
+	saveInt(previewCacheLimitKey, spinCache.Value())
+	saveString(verifySavesKey, fmt.Sprintf("%t", checkboxVerify.IsChecked()))
+	saveString(backupKey, fmt.Sprintf("%t", checkboxBackup.IsChecked()))
+
+	names := editNames.Text()
+	if names != loadString(namesPathKey) {
+		saveString(namesPathKey, names)
+		if names == "" {
+			wv.table.SetNames(nil)
+		} else {
+			wv.loadNames(names)
+		}
+	}
+	wv.applyPreferences()
+}
@@ -0,0 +1,181 @@
+package viewer
+
+import (
+	"bnk"
+	"convert"
+	"wwise"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/multimedia"
+)
+
+// The default limit, in megabytes, of the size of the cache of converted wems.
+const defaultPreviewCacheLimit = 256
+
+// How often, in milliseconds, the position of playback is checked against the
+// end of the loop region.
+const loopCheckInterval = 10
+
+// The cache of converted wems, shared by previews and exports, and with the
+// command line tool.
+var conversionCache = convert.NewCache(convert.DefaultCacheDir(),
+	defaultPreviewCacheLimit<<20)
+
+// newConverter returns a Converter that caches converted wems in
+// conversionCache, within the size limit set by the user.
+func newConverter() *convert.Converter {
+	conversionCache.SetLimit(int64(previewCacheLimit()) << 20)
+	c := convert.NewConverter()
+	c.Cache = conversionCache
+	return c
+}
+
+// A previewPlayer converts wems to a playable format and plays them. Converted
+// wems are cached by the hash of their contents, so that replaying a wem does
+// not convert it again.
+type previewPlayer struct {
+	player    *multimedia.QMediaPlayer
+	converter *convert.Converter
+	// The path of the converted wem that is currently loaded, if any, and the
+	// function that allows it to be pruned from the cache once it is unloaded.
+	current string
+	release func()
+	// The number of times that the loop region will be repeated, or -1 if it is
+	// repeated until playback is stopped.
+	repeats int
+	// The region, in milliseconds, that is repeated when the wem loops. loopEnd
+	// is 0 if the whole wem is repeated.
+	loopStart, loopEnd int64
+	// Called whenever playback stops.
+	stopped func()
+}
+
+func newPreviewPlayer(parent core.QObject_ITF) *previewPlayer {
+	p := new(previewPlayer)
+	p.player = multimedia.NewQMediaPlayer(parent, 0)
+	p.converter = newConverter()
+
+	p.player.SetNotifyInterval(loopCheckInterval)
+	p.player.ConnectPositionChanged(func(position int64) {
+		if p.loopEnd > 0 && position >= p.loopEnd {
+			p.repeat()
+		}
+	})
+	p.player.ConnectStateChanged(func(state multimedia.QMediaPlayer__State) {
+		if state != multimedia.QMediaPlayer__StoppedState {
+			return
+		}
+		endOfMedia :=
+			p.player.MediaStatus() == multimedia.QMediaPlayer__EndOfMedia
+		if endOfMedia && p.repeat() {
+			return
+		}
+		if p.stopped != nil {
+			p.stopped()
+		}
+	})
+	return p
+}
+
+// repeat seeks back to the start of the loop region, returning false if the
+// region has already been repeated as many times as the wem loops.
+func (p *previewPlayer) repeat() bool {
+	if p.repeats == 0 {
+		return false
+	}
+	if p.repeats > 0 {
+		p.repeats--
+	}
+	p.player.SetPosition(p.loopStart)
+	p.player.Play()
+	return true
+}
+
+// Play converts wem, unless it has already been converted, and starts playing
+// it once, stopping any wem that is already playing.
+func (p *previewPlayer) Play(wem *wwise.Wem) error {
+	return p.PlayLooped(wem, bnk.LoopValue{})
+}
+
+// PlayLooped is like Play, but plays wem as many times as loop describes. If
+// the wem has loop points, only the region between them is repeated, as it is
+// in game.
+func (p *previewPlayer) PlayLooped(wem *wwise.Wem, loop bnk.LoopValue) error {
+	p.Stop()
+	format, err := wem.Format()
+	if err != nil {
+		return err
+	}
+	to, err := p.converter.PlayableFormat(format)
+	if err != nil {
+		return err
+	}
+	conversionCache.SetLimit(int64(previewCacheLimit()) << 20)
+	path, release, err := p.converter.Cached(wem, format, to)
+	if err != nil {
+		return err
+	}
+
+	if loop.Loops {
+		p.repeats = -1
+		if loop.Value != bnk.InfiniteLoops {
+			p.repeats = int(loop.Value) - 1
+		}
+		if format.HasLoopPoints && format.SampleRate != 0 {
+			rate := int64(format.SampleRate)
+			p.loopStart = int64(format.LoopStart) * 1000 / rate
+			p.loopEnd = int64(format.LoopEnd) * 1000 / rate
+		}
+	}
+
+	p.current, p.release = path, release
+	p.player.SetMedia(multimedia.NewQMediaContent2(
+		core.QUrl_FromLocalFile(path)), nil)
+	p.player.Play()
+	return nil
+}
+
+// ClearCache stops playback and removes every cached wem.
+func (p *previewPlayer) ClearCache() error {
+	p.Stop()
+	return conversionCache.Clear()
+}
+
+// Stop stops playback and unloads the wem that was playing.
+func (p *previewPlayer) Stop() {
+	p.repeats, p.loopStart, p.loopEnd = 0, 0, 0
+	p.player.Stop()
+	if p.current != "" {
+		// Release the file so that it can be pruned from the cache.
+		p.player.SetMedia(multimedia.NewQMediaContent(), nil)
+		p.release()
+		p.current, p.release = "", nil
+	}
+}
+
+// SetVolume sets the volume of playback, from 0 to 100.
+func (p *previewPlayer) SetVolume(volume int) {
+	p.player.SetVolume(volume)
+}
+
+// IsPlaying returns true if a wem is currently playing.
+func (p *previewPlayer) IsPlaying() bool {
+	return p.player.State() == multimedia.QMediaPlayer__PlayingState
+}
+
+// ConnectStopped calls f whenever playback stops, either because it was
+// stopped or because the wem has played as many times as it loops.
+func (p *previewPlayer) ConnectStopped(f func()) {
+	p.stopped = f
+}
+
+// Close stops playback. The cache is kept, so that wems played in this session
+// play instantly in the next.
+func (p *previewPlayer) Close() {
+	p.Stop()
+}
+
+// previewCacheLimit returns the size limit, in megabytes, of the cache of
+// converted wems.
+func previewCacheLimit() int {
+	return loadInt(previewCacheLimitKey, defaultPreviewCacheLimit)
+}
@@ -0,0 +1,118 @@
+package viewer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+	"wwise"
+)
+
+// The number of steps shown by a progress dialog. Progress is reported in bytes
+// or entries, which can exceed the range of an int, so it is scaled to this.
+const progressSteps = 1000
+
+// A dispatcher runs functions on the GUI thread on behalf of other goroutines.
+// Widgets may only be used from the GUI thread, so work done in the background
+// passes its results back through a dispatcher.
+type dispatcher struct {
+	core.QObject
+
+	_ func() `constructor:"init"`
+	// Emitted whenever functions are queued. As the dispatcher lives on the GUI
+	// thread, emitting this from any other thread queues the connection.
+	_ func() `signal:"queued"`
+
+	mu    sync.Mutex
+	queue []func()
+}
+
+func (d *dispatcher) init() {
+	d.ConnectQueued(d.runQueued)
+}
+
+// Run queues f to be run on the GUI thread. It may be called from any
+// goroutine.
+func (d *dispatcher) Run(f func()) {
+	d.mu.Lock()
+	d.queue = append(d.queue, f)
+	d.mu.Unlock()
+	d.Queued()
+}
+
+func (d *dispatcher) runQueued() {
+	d.mu.Lock()
+	queue := d.queue
+	d.queue = nil
+	d.mu.Unlock()
+	for _, f := range queue {
+		f()
+	}
+}
+
+// runInBackground runs work in a new goroutine while a modal progress dialog
+// showing label is open. work reports its progress to the ProgressFunc it is
+// given, and should stop once it returns false. The dialog shows that work is
+// busy until progress is first reported. Once work returns, the dialog is
+// closed and done is called on the GUI thread.
+//
+// work must not use any widgets; the window cannot be used until done is
+// called, so the container and table can be read, but not modified, by work.
+func (wv *WwiseViewerWindow) runInBackground(label string,
+	work func(progress wwise.ProgressFunc), done func()) {
+	dlg := widgets.NewQProgressDialog2(label, "Cancel", 0, 0, wv, 0)
+	dlg.SetWindowModality(core.Qt__WindowModal)
+	dlg.SetMinimumDuration(0)
+	dlg.SetAutoClose(false)
+	dlg.SetAutoReset(false)
+	var cancelled int32
+	dlg.ConnectCanceled(func() {
+		atomic.StoreInt32(&cancelled, 1)
+	})
+	dlg.Show()
+
+	last := -1
+	progress := func(done, total int64) bool {
+		if total > 0 {
+			// Only update the dialog when its value changes, as progress may be
+			// reported many thousands of times.
+			if value := int(done * progressSteps / total); value != last {
+				last = value
+				wv.dispatcher.Run(func() {
+					dlg.SetMaximum(progressSteps)
+					dlg.SetValue(value)
+				})
+			}
+		}
+		return atomic.LoadInt32(&cancelled) == 0
+	}
+	go func() {
+		work(progress)
+		wv.dispatcher.Run(func() {
+			dlg.Close()
+			dlg.DeleteLater()
+			done()
+		})
+	}()
+}
+
+// waitInBackground is like runInBackground, but returns only once done has
+// been called. Events continue to be processed while it waits, so the window
+// remains responsive.
+func (wv *WwiseViewerWindow) waitInBackground(label string,
+	work func(progress wwise.ProgressFunc), done func()) {
+	loop := core.NewQEventLoop(nil)
+	wv.runInBackground(label, work, func() {
+		done()
+		loop.Quit()
+	})
+	loop.Exec(0)
+	loop.DeleteLater()
+}
+
+func (wv *WwiseViewerWindow) showCancelledStatus(operation string) {
+	wv.StatusBar().ShowMessage(operation+" was cancelled.", 0)
+}
@@ -0,0 +1,122 @@
+package viewer
+
+import (
+	"path/filepath"
+)
+
+import (
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The number of recently opened files that are remembered.
+const maxRecentFiles = 10
+
+// addRecentFile moves path to the front of the recently opened files.
+func addRecentFile(path string) {
+	recent := []string{path}
+	for _, p := range loadStringList(recentFilesKey) {
+		if p != path && len(recent) < maxRecentFiles {
+			recent = append(recent, p)
+		}
+	}
+	saveStringList(recentFilesKey, recent)
+}
+
+// setPinned pins or unpins path. Pinned files are always listed first in the
+// recent files menu, and are never forgotten.
+func setPinned(path string, pinned bool) {
+	var list []string
+	for _, p := range loadStringList(pinnedFilesKey) {
+		if p != path {
+			list = append(list, p)
+		}
+	}
+	if pinned {
+		list = append(list, path)
+	}
+	saveStringList(pinnedFilesKey, list)
+}
+
+func isPinned(path string) bool {
+	for _, p := range loadStringList(pinnedFilesKey) {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (wv *WwiseViewerWindow) setupFileMenu() {
+	menu := wv.MenuBar().AddMenu2("&File")
+	menu.QWidget.AddAction(wv.actionOpen)
+	wv.menuRecent = menu.AddMenu2("Open &Recent")
+	wv.menuRecent.ConnectAboutToShow(wv.populateRecentMenu)
+	menu.QWidget.AddAction(wv.actionSave)
+	menu.AddSeparator()
+	menu.QWidget.AddAction(wv.actionExport)
+	menu.AddSeparator()
+	wv.setupLoadNames()
+	menu.QWidget.AddAction(wv.actionLoadNames)
+	menu.AddSeparator()
+	actionQuit := menu.AddAction("&Quit")
+	actionQuit.SetShortcuts2(gui.QKeySequence__Quit)
+	actionQuit.ConnectTriggered(func(checked bool) {
+		wv.Close()
+	})
+	wv.fileMenu = menu
+}
+
+// populateRecentMenu lists the pinned files, followed by the recently opened
+// files, in the recent files menu.
+func (wv *WwiseViewerWindow) populateRecentMenu() {
+	menu := wv.menuRecent
+	menu.Clear()
+
+	pinned := loadStringList(pinnedFilesKey)
+	for _, path := range pinned {
+		wv.addRecentAction(menu, path, "★ ")
+	}
+	if len(pinned) > 0 {
+		menu.AddSeparator()
+	}
+	recent := 0
+	for _, path := range loadStringList(recentFilesKey) {
+		if isPinned(path) {
+			continue
+		}
+		wv.addRecentAction(menu, path, "")
+		recent++
+	}
+	if recent > 0 {
+		menu.AddSeparator()
+	}
+
+	if wv.currPath != "" {
+		pinned := isPinned(wv.currPath)
+		text := "&Pin Current File"
+		if pinned {
+			text = "&Unpin Current File"
+		}
+		actionPin := menu.AddAction(text)
+		actionPin.ConnectTriggered(func(checked bool) {
+			setPinned(wv.currPath, !pinned)
+		})
+	}
+	actionClear := menu.AddAction("&Clear Recent Files")
+	actionClear.SetEnabled(recent > 0)
+	actionClear.ConnectTriggered(func(checked bool) {
+		saveStringList(recentFilesKey, nil)
+	})
+}
+
+func (wv *WwiseViewerWindow) addRecentAction(menu *widgets.QMenu, path string,
+	prefix string) {
+	action := menu.AddAction(prefix + filepath.Base(path))
+	action.SetToolTip(path)
+	action.SetStatusTip(path)
+	action.ConnectTriggered(func(checked bool) {
+		wv.Open(path)
+		wv.clearLoopValues()
+	})
+}
@@ -0,0 +1,182 @@
+package viewer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+import (
+	"bnk"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// A stagedHint records a change that was staged, but not saved, when the
+// viewer was last closed, so that it can be staged again.
+type stagedHint struct {
+	Index int `json:"index"`
+	// The path of the replacement wem, if the wem was replaced.
+	Replacement string `json:"replacement,omitempty"`
+	// The edited loop of the wem, if its loop was edited.
+	Loop *bnk.LoopValue `json:"loop,omitempty"`
+}
+
+// saveSession records the open file, the layout of the window and the changes
+// that have not been saved, so that they can be restored at the next launch.
+func (wv *WwiseViewerWindow) saveSession() {
+	saveString(sessionFileKey, wv.currPath)
+	saveString(sessionGeometryKey, encodeByteArray(wv.SaveGeometry()))
+	saveString(sessionStateKey, encodeByteArray(wv.SaveState(0)))
+
+	wv.recordColumnWidths()
+	saveJSON(sessionColumnsKey, wv.columnWidths)
+
+	var hints []stagedHint
+	if !wv.undoStack.IsClean() {
+		hints = wv.table.stagedHints()
+	}
+	saveJSON(sessionStagedKey, hints)
+}
+
+// RestoreWindowState restores the geometry of the window, and the layout of
+// its toolbars and docks, from the last session. It returns false if there is
+// no previous session.
+func (wv *WwiseViewerWindow) RestoreWindowState() bool {
+	geometry, ok := decodeByteArray(loadString(sessionGeometryKey))
+	if !ok || !wv.RestoreGeometry(geometry) {
+		return false
+	}
+	if state, ok := decodeByteArray(loadString(sessionStateKey)); ok {
+		wv.RestoreState(state, 0)
+	}
+	return true
+}
+
+// OfferSessionRestore asks whether to reopen the file that was open when the
+// viewer was last closed, along with any changes that were not saved.
+func (wv *WwiseViewerWindow) OfferSessionRestore() {
+	path := loadString(sessionFileKey)
+	if path == "" {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	var hints []stagedHint
+	loadJSON(sessionStagedKey, &hints)
+
+	msg := fmt.Sprintf("%s was open when this application was last closed.\n"+
+		"Do you want to reopen it?", filepath.Base(path))
+	if len(hints) > 0 {
+		msg += fmt.Sprintf("\n\n%d unsaved change(s) will be staged again.",
+			len(hints))
+	}
+	answer := widgets.QMessageBox_Question(wv, "Restore session", msg,
+		widgets.QMessageBox__Yes|widgets.QMessageBox__No, widgets.QMessageBox__Yes)
+	if answer != widgets.QMessageBox__Yes {
+		return
+	}
+	wv.openCtn(path, func() {
+		wv.restageHints(hints)
+	})
+}
+
+// restageHints stages the changes described by hints again.
+func (wv *WwiseViewerWindow) restageHints(hints []stagedHint) {
+	if len(hints) == 0 {
+		return
+	}
+	count := len(wv.table.GetContainer().Wems())
+	wv.undoStack.BeginMacro("Restore session")
+	for _, hint := range hints {
+		if hint.Index < 0 || hint.Index >= count {
+			wv.logConsole(consoleWarning, "Restore session", fmt.Sprintf("Could "+
+				"not stage a change to wem %d again, as the file no longer has it",
+				hint.Index+1))
+			continue
+		}
+		if hint.Replacement != "" {
+			wv.addReplacement(hint.Index, hint.Replacement)
+		}
+		if hint.Loop != nil {
+			loop := *hint.Loop
+			index := hint.Index
+			wv.stageChange("Update loop", index, func() {
+				wv.table.UpdateLoop(index, &loopWrapper{loop.Loops,
+					loop.Value == bnk.InfiniteLoops, loop.Value})
+			})
+		}
+	}
+	wv.undoStack.EndMacro()
+}
+
+// recordColumnWidths records the width of each shown column of the table, so
+// that it is kept when another model is loaded and in the next session. This
+// must be called before the table loads a new model.
+func (wv *WwiseViewerWindow) recordColumnWidths() {
+	if wv.table.GetContainer() == nil {
+		return
+	}
+	for i, title := range wv.table.ColumnTitles() {
+		if !wv.table.IsColumnHidden(i) && wv.table.ColumnWidth(i) > 0 {
+			wv.columnWidths[title] = wv.table.ColumnWidth(i)
+		}
+	}
+}
+
+// restoreColumnWidths sets the width of each column of the table to the width
+// it was last given.
+func (wv *WwiseViewerWindow) restoreColumnWidths() {
+	for i, title := range wv.table.ColumnTitles() {
+		if width, ok := wv.columnWidths[title]; ok && width > 0 {
+			wv.table.SetColumnWidth(i, width)
+		}
+	}
+}
+
+// stagedHints returns a hint for every wem with a staged change, in order of
+// wem index.
+func (t *WemTable) stagedHints() []stagedHint {
+	staged := make(map[int]*stagedHint)
+	hint := func(index int) *stagedHint {
+		if staged[index] == nil {
+			staged[index] = &stagedHint{Index: index}
+		}
+		return staged[index]
+	}
+	for index, w := range t.model.replacements {
+		if f, ok := w.replacement.Wem.(*os.File); ok {
+			hint(index).Replacement = f.Name()
+		}
+	}
+	if b, ok := t.model.ctn.(*bnk.File); ok {
+		for index := range t.model.loopEdits {
+			loop := b.LoopOf(index)
+			hint(index).Loop = &loop
+		}
+	}
+
+	var hints []stagedHint
+	for _, h := range staged {
+		hints = append(hints, *h)
+	}
+	sort.Slice(hints, func(i, j int) bool {
+		return hints[i].Index < hints[j].Index
+	})
+	return hints
+}
+
+func encodeByteArray(b *core.QByteArray) string {
+	return base64.StdEncoding.EncodeToString([]byte(b.ConstData()))
+}
+
+func decodeByteArray(s string) (*core.QByteArray, bool) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, false
+	}
+	return core.NewQByteArray2(string(b), len(b)), true
+}
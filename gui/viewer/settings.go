@@ -0,0 +1,110 @@
+package viewer
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+import (
+	"github.com/therecipe/qt/core"
+)
+
+// The organization and application names that settings are stored under.
+const (
+	settingsOrganization = "wwiseutil"
+	settingsApplication  = "Wwise Audio Utilities"
+)
+
+// The keys of persisted settings.
+const (
+	recentFilesKey = "files/recent"
+	pinnedFilesKey = "files/pinned"
+
+	sessionFileKey     = "session/file"
+	sessionGeometryKey = "session/geometry"
+	sessionStateKey    = "session/state"
+	sessionColumnsKey  = "session/columns"
+	sessionStagedKey   = "session/staged"
+	// The visibility of each column of the table, by title.
+	sessionVisibilityKey = "session/columnVisibility"
+
+	previewCacheLimitKey = "preview/cacheLimit"
+	previewVolumeKey     = "preview/volume"
+	previewLoopKey       = "preview/loop"
+
+	openDirKey      = "preferences/openDir"
+	replaceDirKey   = "preferences/replaceDir"
+	exportDirKey    = "preferences/exportDir"
+	alignmentKey    = "preferences/wemAlignment"
+	exportFormatKey = "preferences/exportFormat"
+	namesPathKey    = "preferences/names"
+	themeKey        = "preferences/theme"
+	packageModeKey  = "preferences/packageMode"
+	verifySavesKey  = "preferences/verifySaves"
+	backupKey       = "preferences/backupOriginals"
+
+	associationsOfferedKey = "associations/offered"
+)
+
+func newSettings() *core.QSettings {
+	return core.NewQSettings(settingsOrganization, settingsApplication, nil)
+}
+
+// loadString reads the string stored under key, returning "" if there is none.
+func loadString(key string) string {
+	return newSettings().Value(key, core.NewQVariant12("")).ToString()
+}
+
+// saveString stores value under key.
+func saveString(key string, value string) {
+	newSettings().SetValue(key, core.NewQVariant12(value))
+}
+
+// loadInt reads the integer stored under key, returning def if there is none.
+func loadInt(key string, def int) int {
+	value, err := strconv.Atoi(loadString(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// saveInt stores value under key.
+func saveInt(key string, value int) {
+	saveString(key, strconv.Itoa(value))
+}
+
+// loadStringList reads a list of strings stored under key, returning nil if
+// there is none.
+func loadStringList(key string) []string {
+	var list []string
+	if !loadJSON(key, &list) {
+		return nil
+	}
+	return list
+}
+
+// saveStringList stores list under key.
+func saveStringList(key string, list []string) {
+	saveJSON(key, list)
+}
+
+// loadJSON decodes the JSON document stored under key into v, returning false
+// if there is none or it is invalid.
+func loadJSON(key string, v interface{}) bool {
+	value := loadString(key)
+	if value == "" {
+		return false
+	}
+	return json.Unmarshal([]byte(value), v) == nil
+}
+
+// saveJSON stores v under key as a JSON document. Structured values are stored
+// as JSON so that they are read back identically on every platform.
+func saveJSON(key string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	saveString(key, string(b))
+}
@@ -0,0 +1,107 @@
+package viewer
+
+import (
+	"fmt"
+)
+
+import (
+	"bnk"
+	"pck"
+	"github.com/therecipe/qt/widgets"
+)
+
+// setupStatusBar adds the statistics of the shown container to the status bar,
+// beside the messages shown in it.
+func (wv *WwiseViewerWindow) setupStatusBar() {
+	wv.labelWemCount = widgets.NewQLabel(nil, 0)
+	wv.labelMediaSize = widgets.NewQLabel(nil, 0)
+	wv.labelMediaSize.SetToolTip("The total size of the wems, including " +
+		"staged replacements")
+	wv.labelVersion = widgets.NewQLabel(nil, 0)
+	wv.labelStaged = widgets.NewQLabel(nil, 0)
+	wv.labelStaged.SetToolTip("The number of wems with staged changes that " +
+		"have not been saved")
+	for _, label := range wv.statisticsLabels() {
+		wv.StatusBar().AddPermanentWidget(label, 0)
+	}
+	wv.undoStack.ConnectIndexChanged(func(idx int) {
+		wv.refreshStatusBar()
+	})
+	wv.refreshStatusBar()
+}
+
+func (wv *WwiseViewerWindow) statisticsLabels() []*widgets.QLabel {
+	return []*widgets.QLabel{wv.labelWemCount, wv.labelMediaSize,
+		wv.labelVersion, wv.labelStaged}
+}
+
+// refreshStatusBar updates the statistics in the status bar to match the shown
+// container and the changes staged to the open file.
+func (wv *WwiseViewerWindow) refreshStatusBar() {
+	ctn := wv.table.GetContainer()
+	if ctn == nil {
+		for _, label := range wv.statisticsLabels() {
+			label.Hide()
+		}
+		return
+	}
+
+	m := wv.table.model
+	var size int64
+	for i, wem := range ctn.Wems() {
+		if w, ok := m.replacements[i]; ok {
+			size += w.replacement.Length
+		} else {
+			size += int64(wem.Descriptor.Length)
+		}
+	}
+	wv.labelWemCount.SetText(fmt.Sprintf("%d wems", len(ctn.Wems())))
+	wv.labelMediaSize.SetText(byteSize(size))
+
+	wv.labelVersion.Hide()
+	switch ctn := ctn.(type) {
+	case *bnk.File:
+		if ctn.BankHeaderSection != nil {
+			wv.labelVersion.SetText(fmt.Sprintf("SoundBank v%d",
+				ctn.BankHeaderSection.Descriptor.Version))
+			wv.labelVersion.Show()
+		}
+	case *pck.File:
+		wv.labelVersion.SetText(fmt.Sprintf("File Package v%d",
+			ctn.Header.Version()))
+		wv.labelVersion.Show()
+	}
+
+	staged := 0
+	models := []*WemModel{m}
+	if wv.embedded != nil {
+		models = nil
+		for _, model := range wv.embedded.models {
+			models = append(models, model)
+		}
+	}
+	for _, model := range models {
+		staged += model.stagedChangeCount()
+	}
+	wv.labelStaged.SetText(fmt.Sprintf("%d staged", staged))
+
+	wv.labelWemCount.Show()
+	wv.labelMediaSize.Show()
+	wv.labelStaged.Show()
+}
+
+// byteSize returns n bytes as a human readable size, such as "1.5 MB".
+func byteSize(n int64) string {
+	const unit = 1 << 10
+	if n < unit {
+		return fmt.Sprintf("%d bytes", n)
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, s := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -2,6 +2,8 @@ package viewer
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 import (
@@ -15,9 +17,15 @@ import (
 
 type wemAccessor func(index int) string
 
+// A wemLess reports whether the wem at index a sorts before the wem at index b.
+type wemLess func(a, b int) bool
+
 type columnBinding struct {
 	title    string
 	accessor wemAccessor
+	// The order of the column when it is sorted. If this is nil, the column is
+	// sorted by the text of its cells.
+	less wemLess
 }
 
 type replacementWemWrapper struct {
@@ -34,6 +42,11 @@ type loopWrapper struct {
 type WemTable struct {
 	widgets.QTableView
 	model *WemModel
+	// The proxy between the model and the view, which filters the rows shown.
+	proxy  *core.QSortFilterProxyModel
+	filter *wemFilter
+	// The names loaded by the user, or nil if none have been loaded.
+	names *wwise.NameDatabase
 }
 
 type WemModel struct {
@@ -43,6 +56,24 @@ type WemModel struct {
 	ctn wwise.Container
 	// A mapping from wem index to the replacement wem.
 	replacements map[int]*replacementWemWrapper
+	// A mapping from wem index to the loop value the wem had before its loop was
+	// edited, for wems whose loops have been edited since the last save.
+	loopEdits map[int]bnk.LoopValue
+	// A mapping from wem index to the format of the wem, or nil if its format
+	// could not be read. Formats are read as they are first shown.
+	formats map[int]*wwise.WemFormat
+	// The names that the original names of wems are read from.
+	names *wwise.NameDatabase
+}
+
+// The title of the column of the names that wems were given in the Wwise
+// project, which are only known once names have been loaded.
+const originalNameColumn = "Original name"
+
+// The titles of the columns that are hidden until they are shown from the View
+// menu.
+var optionalColumns = []string{
+	originalNameColumn, "Duration", "Sample rate", "Channels", "Codec",
 }
 
 func NewTable() *WemTable {
@@ -50,10 +81,20 @@ func NewTable() *WemTable {
 
 	table.VerticalHeader().Hide()
 	table.SetSelectionBehavior(widgets.QAbstractItemView__SelectRows)
-	table.SetSelectionMode(widgets.QAbstractItemView__SingleSelection)
-	table.HorizontalHeader().SetSectionResizeMode(widgets.QHeaderView__Stretch)
+	table.SetSelectionMode(widgets.QAbstractItemView__ExtendedSelection)
+	// Columns can be resized by the user; the last fills any remaining space.
+	table.HorizontalHeader().SetSectionResizeMode(
+		widgets.QHeaderView__Interactive)
+	table.HorizontalHeader().SetStretchLastSection(true)
 	table.HorizontalHeader().SetHighlightSections(false)
 
+	table.proxy = core.NewQSortFilterProxyModel(nil)
+	table.proxy.ConnectFilterAcceptsRow(table.filterAcceptsRow)
+	table.proxy.ConnectLessThan(table.lessThan)
+	table.SetModel(table.proxy)
+	// Columns start sorted by name, which is the order of the wems in the file.
+	table.SetSortingEnabled(true)
+	table.SortByColumn(0, core.Qt__AscendingOrder)
 	table.LoadDefaultModel()
 
 	return table
@@ -62,50 +103,155 @@ func NewTable() *WemTable {
 func (t *WemTable) LoadDefaultModel() {
 	m := newModel()
 	m.bindings = []*columnBinding{
-		{"Name", empty},
-		{"Replacing with", empty},
-		{"Id", empty},
-		{"Size", empty},
-		{"File offset", empty},
-		{"Padding", empty},
-		{"Loops", empty},
+		{"Name", empty, nil},
+		{originalNameColumn, empty, nil},
+		{"Status", empty, nil},
+		{"Replacing with", empty, nil},
+		{"Id", empty, nil},
+		{"Size", empty, nil},
+		{"File offset", empty, nil},
+		{"Padding", empty, nil},
+		{"Loops", empty, nil},
+		{"Duration", empty, nil},
+		{"Sample rate", empty, nil},
+		{"Channels", empty, nil},
+		{"Codec", empty, nil},
 	}
 
-	t.model = m
-	t.SetModel(t.model)
+	t.setModel(m)
 }
 
 func (t *WemTable) LoadSoundBankModel(file *bnk.File) {
 	m := newModel()
 	m.ctn = file
 	m.bindings = []*columnBinding{
-		{"Name", m.defaultOr(m.wemName)},
-		{"Replacing with", m.defaultOr(m.wemReplacement)},
-		{"Id", m.defaultOr(m.wemId)},
-		{"Size", m.defaultOr(m.wemSize)},
-		{"File offset", m.defaultOr(m.wemOffset)},
-		{"Padding", m.defaultOr(m.wemPadding)},
-		{"Loops", m.defaultOr(m.wemLoops)},
+		{"Name", m.defaultOr(m.wemName), byIndex},
+		{originalNameColumn, m.defaultOr(m.wemOriginalName), nil},
+		{"Status", m.defaultOr(m.wemStatus), m.byStatus},
+		{"Replacing with", m.defaultOr(m.wemReplacement), m.byReplacement},
+		{"Id", m.defaultOr(m.wemId), m.byId},
+		{"Size", m.defaultOr(m.wemSize), m.bySize},
+		{"File offset", m.defaultOr(m.wemOffset), m.byOffset},
+		{"Padding", m.defaultOr(m.wemPadding), m.byPadding},
+		{"Loops", m.defaultOr(m.wemLoops), m.byLoop},
+		{"Duration", m.defaultOr(m.wemDuration), m.byDuration},
+		{"Sample rate", m.defaultOr(m.wemSampleRate), m.bySampleRate},
+		{"Channels", m.defaultOr(m.wemChannels), m.byChannels},
+		{"Codec", m.defaultOr(m.wemCodec), nil},
 	}
 
-	t.model = m
-	t.SetModel(t.model)
+	t.setModel(m)
 }
 
 func (t *WemTable) LoadFilePackageModel(file *pck.File) {
 	m := newModel()
 	m.ctn = file
 	m.bindings = []*columnBinding{
-		{"Name", m.defaultOr(m.wemName)},
-		{"Replacing with", m.defaultOr(m.wemReplacement)},
-		{"Id", m.defaultOr(m.wemId)},
-		{"Size", m.defaultOr(m.wemSize)},
-		{"File offset", m.defaultOr(m.wemOffset)},
-		{"Padding", m.defaultOr(m.wemPadding)},
+		{"Name", m.defaultOr(m.wemName), byIndex},
+		{originalNameColumn, m.defaultOr(m.wemOriginalName), nil},
+		{"Status", m.defaultOr(m.wemStatus), m.byStatus},
+		{"Replacing with", m.defaultOr(m.wemReplacement), m.byReplacement},
+		{"Id", m.defaultOr(m.wemId), m.byId},
+		{"Size", m.defaultOr(m.wemSize), m.bySize},
+		{"File offset", m.defaultOr(m.wemOffset), m.byOffset},
+		{"Padding", m.defaultOr(m.wemPadding), m.byPadding},
+		{"Duration", m.defaultOr(m.wemDuration), m.byDuration},
+		{"Sample rate", m.defaultOr(m.wemSampleRate), m.bySampleRate},
+		{"Channels", m.defaultOr(m.wemChannels), m.byChannels},
+		{"Codec", m.defaultOr(m.wemCodec), nil},
 	}
 
+	t.setModel(m)
+}
+
+func (t *WemTable) setModel(m *WemModel) {
+	m.names = t.names
 	t.model = m
-	t.SetModel(t.model)
+	t.proxy.SetSourceModel(m)
+}
+
+// SetNames sets the names shown for wems to those of db.
+func (t *WemTable) SetNames(db *wwise.NameDatabase) {
+	t.names = db
+	t.model.names = db
+	t.model.refreshAll()
+	t.proxy.InvalidateFilter()
+}
+
+// SetFilter narrows the rows shown to those that match the filter written in
+// query. An empty query shows every row.
+func (t *WemTable) SetFilter(query string) error {
+	f, err := parseWemFilter(query)
+	if err != nil {
+		return err
+	}
+	t.filter = f
+	t.proxy.InvalidateFilter()
+	return nil
+}
+
+func (t *WemTable) filterAcceptsRow(sourceRow int,
+	sourceParent *core.QModelIndex) bool {
+	m := t.model
+	if t.filter == nil || m.ctn == nil || sourceRow >= len(m.ctn.Wems()) {
+		return true
+	}
+	desc := m.ctn.Wems()[sourceRow].Descriptor
+	_, replaced := m.replacements[sourceRow]
+	name := m.wemName(sourceRow) + "\n" + m.wemOriginalName(sourceRow)
+	return t.filter.matches(desc.WemId, name, desc.Length, replaced)
+}
+
+func (t *WemTable) lessThan(left *core.QModelIndex,
+	right *core.QModelIndex) bool {
+	m := t.model
+	if m.ctn == nil || left.Column() >= len(m.bindings) {
+		return false
+	}
+	b := m.bindings[left.Column()]
+	if b.less == nil {
+		return b.accessor(left.Row()) < b.accessor(right.Row())
+	}
+	return b.less(left.Row(), right.Row())
+}
+
+// WemIndexAt returns the index of the wem shown at row of this table, which
+// may differ from row when the table is filtered.
+func (t *WemTable) WemIndexAt(row int) int {
+	return t.proxy.MapToSource(t.proxy.Index(row, 0, core.NewQModelIndex())).Row()
+}
+
+// SelectWem selects and scrolls to the row of the wem at index. The selection
+// is cleared if the wem is hidden by the filter.
+func (t *WemTable) SelectWem(index int) {
+	row := t.proxy.MapFromSource(t.model.Index(index, 0, core.NewQModelIndex()))
+	if !row.IsValid() {
+		t.ClearSelection()
+		return
+	}
+	t.SelectRow(row.Row())
+	t.ScrollTo(row, widgets.QAbstractItemView__EnsureVisible)
+}
+
+// WemIndexOfName returns the index of the wem whose id or name is name, or -1
+// if there is no such wem. Names are matched without regard to case.
+func (t *WemTable) WemIndexOfName(name string) int {
+	m := t.model
+	if m.ctn == nil {
+		return -1
+	}
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		if i := m.ctn.IndexOf(uint32(id)); i >= 0 {
+			return i
+		}
+	}
+	for i := range m.ctn.Wems() {
+		if strings.EqualFold(m.wemName(i), name) ||
+			strings.EqualFold(m.wemOriginalName(i), name) {
+			return i
+		}
+	}
+	return -1
 }
 
 func (t *WemTable) AddWemReplacement(name string, r *wwise.ReplacementWem) {
@@ -114,18 +260,41 @@ func (t *WemTable) AddWemReplacement(name string, r *wwise.ReplacementWem) {
 	t.refreshRow(r.WemIndex)
 }
 
+// loopValue returns the loop value described by r.
+func (r *loopWrapper) loopValue() bnk.LoopValue {
+	loop := bnk.LoopValue{}
+	if r.loops {
+		if r.infinity {
+			loop.Loops, loop.Value = true, 0
+		} else {
+			loop.Loops, loop.Value = true, r.value
+		}
+	}
+	return loop
+}
+
+// matches returns true if loop is the loop value described by r.
+func (r *loopWrapper) matches(loop bnk.LoopValue) bool {
+	want := r.loopValue()
+	return loop == want || (!loop.Loops && !want.Loops)
+}
+
 func (t *WemTable) UpdateLoop(wemIndex int, r *loopWrapper) {
 	switch ctn := t.model.ctn.(type) {
 	case *bnk.File:
-		loop := bnk.LoopValue{}
-		if r.loops {
-			if r.infinity {
-				loop.Loops, loop.Value = true, 0
-			} else {
-				loop.Loops, loop.Value = true, r.value
-			}
+		loop := r.loopValue()
+		old := ctn.LoopOf(wemIndex)
+		if err := ctn.ReplaceLoopOf(wemIndex, loop); err != nil {
+			t.refreshRow(wemIndex)
+			return
+		}
+		if _, ok := t.model.loopEdits[wemIndex]; !ok {
+			t.model.loopEdits[wemIndex] = old
+		}
+		if t.model.loopEdits[wemIndex] == ctn.LoopOf(wemIndex) {
+			// The loop has been set back to its original value.
+			delete(t.model.loopEdits, wemIndex)
 		}
-		ctn.ReplaceLoopOf(wemIndex, loop)
 		t.refreshRow(wemIndex)
 	default:
 		return
@@ -136,22 +305,43 @@ func (t *WemTable) UpdateLoop(wemIndex int, r *loopWrapper) {
 // Pending replacements are removed, and the table is refreshed. The number
 // of replacements commited is returned.
 func (t *WemTable) CommitReplacements() int {
-	var rs []*wwise.ReplacementWem
-	for _, w := range t.model.replacements {
-		rs = append(rs, w.replacement)
-	}
+	return t.model.commit()
+}
+
+// commit commits all changes staged in this model to its container, returning
+// the number of replacements committed.
+func (m *WemModel) commit() int {
+	rs := m.stagedReplacements()
 	count := len(rs)
-	t.model.ctn.ReplaceWems(rs...)
+	m.ctn.ReplaceWems(rs...)
 
-	// Clear all current replacements after committing them.
-	t.model.replacements = make(map[int]*replacementWemWrapper)
+	// Clear all current replacements after committing them. Loop edits are
+	// made to the container directly, so they are committed along with them.
+	m.replacements = make(map[int]*replacementWemWrapper)
+	m.loopEdits = make(map[int]bnk.LoopValue)
+	m.formats = make(map[int]*wwise.WemFormat)
 
 	// Update the viewmodel with new wem information.
-	rows := t.model.rowCount(nil)
-	cols := t.model.columnCount(nil)
+	m.refreshAll()
+	return count
+}
 
-	start := t.IndexAt(core.NewQPoint2(0, 0))
-	end := t.IndexAt(core.NewQPoint2(rows-1, cols-1))
+// stagedReplacements returns the replacements staged in this model.
+func (m *WemModel) stagedReplacements() []*wwise.ReplacementWem {
+	var rs []*wwise.ReplacementWem
+	for _, w := range m.replacements {
+		rs = append(rs, w.replacement)
+	}
+	return rs
+}
+
+// refreshAll redraws every cell of the table.
+func (m *WemModel) refreshAll() {
+	rows := m.rowCount(nil)
+	cols := m.columnCount(nil)
+
+	start := m.Index(0, 0, core.NewQModelIndex())
+	end := m.Index(rows-1, cols-1, core.NewQModelIndex())
 
 	var roles []int
 	for i := 0; i < rows; i++ {
@@ -160,18 +350,63 @@ func (t *WemTable) CommitReplacements() int {
 		}
 	}
 
-	t.DataChanged(start, end, roles)
+	m.DataChanged(start, end, roles)
+}
+
+// hasStagedChanges returns true if any wem of this model has a staged
+// replacement or loop edit.
+func (m *WemModel) hasStagedChanges() bool {
+	return len(m.replacements) > 0 || len(m.loopEdits) > 0
+}
+
+// stagedChangeCount returns the number of wems with staged changes.
+func (m *WemModel) stagedChangeCount() int {
+	count := len(m.replacements)
+	for index := range m.loopEdits {
+		if _, replaced := m.replacements[index]; !replaced {
+			count++
+		}
+	}
 	return count
 }
 
+// IsStaged returns true if the wem at index has a staged replacement or loop
+// edit.
+func (t *WemTable) IsStaged(index int) bool {
+	_, replaced := t.model.replacements[index]
+	_, looped := t.model.loopEdits[index]
+	return replaced || looped
+}
+
+// Revert discards the staged replacement and loop edit of the wem at index.
+func (t *WemTable) Revert(index int) {
+	delete(t.model.replacements, index)
+	if loop, ok := t.model.loopEdits[index]; ok {
+		if ctn, ok := t.model.ctn.(*bnk.File); ok {
+			ctn.ReplaceLoopOf(index, loop)
+		}
+		delete(t.model.loopEdits, index)
+	}
+	t.refreshRow(index)
+}
+
+// ColumnTitles returns the titles of the columns of this table, in order.
+func (t *WemTable) ColumnTitles() []string {
+	var titles []string
+	for _, b := range t.model.bindings {
+		titles = append(titles, b.title)
+	}
+	return titles
+}
+
 func (t *WemTable) GetContainer() wwise.Container {
 	return t.model.ctn
 }
 
 func (t *WemTable) refreshRow(row int) {
 	count := t.model.columnCount(nil)
-	start := t.IndexAt(core.NewQPoint2(row, 0))
-	end := t.IndexAt(core.NewQPoint2(row, count-1))
+	start := t.model.Index(row, 0, core.NewQModelIndex())
+	end := t.model.Index(row, count-1, core.NewQModelIndex())
 
 	var roles []int
 	for i := 0; i < count; i++ {
@@ -187,6 +422,8 @@ func (t *WemTable) refreshRow(row int) {
 func newModel() *WemModel {
 	model := new(WemModel)
 	model.replacements = make(map[int]*replacementWemWrapper)
+	model.loopEdits = make(map[int]bnk.LoopValue)
+	model.formats = make(map[int]*wwise.WemFormat)
 
 	model.ConnectRowCount(model.rowCount)
 	model.ConnectColumnCount(model.columnCount)
@@ -211,6 +448,27 @@ func (m *WemModel) wemName(index int) string {
 	return util.CanonicalWemName(index, len(m.ctn.Wems()))
 }
 
+// wemOriginalName returns the name of the wem at index in the Wwise project, or
+// an empty string if it is not known.
+func (m *WemModel) wemOriginalName(index int) string {
+	name, _ := m.names.WemNameOf(m.ctn.Wems()[index].Descriptor.WemId)
+	return name
+}
+
+func (m *WemModel) wemStatus(index int) string {
+	_, replaced := m.replacements[index]
+	_, looped := m.loopEdits[index]
+	switch {
+	case replaced && looped:
+		return "Replaced, loop edited"
+	case replaced:
+		return "Replaced"
+	case looped:
+		return "Loop edited"
+	}
+	return ""
+}
+
 func (m *WemModel) wemReplacement(index int) string {
 	r, ok := m.replacements[index]
 	if !ok {
@@ -239,20 +497,181 @@ func (m *WemModel) wemPadding(index int) string {
 }
 
 func (m *WemModel) wemLoops(index int) string {
-	str := "None"
 	switch ctn := m.ctn.(type) {
 	case *bnk.File:
+		if !ctn.CanLoop(index) {
+			return notLoopableText
+		}
+		return loopText(ctn.LoopOf(index))
+	}
+	return loopText(bnk.LoopValue{})
+}
+
+// The text of the loop column for wems that are not played by a sound object,
+// and so whose loop cannot be edited.
+const notLoopableText = "N/A"
+
+// loopText returns a human readable description of loop.
+func loopText(loop bnk.LoopValue) string {
+	switch {
+	case !loop.Loops:
+		return "None"
+	case loop.Value == bnk.InfiniteLoops:
+		return "Infinity"
+	}
+	return fmt.Sprintf("%d times", loop.Value)
+}
+
+func byIndex(a, b int) bool {
+	return a < b
+}
+
+// byReplacement sorts wems with a staged replacement first, by the name of
+// their replacement.
+func (m *WemModel) byReplacement(a, b int) bool {
+	ra, aok := m.replacements[a]
+	rb, bok := m.replacements[b]
+	if aok != bok {
+		return aok
+	}
+	if aok && ra.name != rb.name {
+		return ra.name < rb.name
+	}
+	return a < b
+}
+
+// byStatus sorts wems with staged changes first.
+func (m *WemModel) byStatus(a, b int) bool {
+	sa, sb := m.wemStatus(a), m.wemStatus(b)
+	if (sa == "") != (sb == "") {
+		return sa != ""
+	}
+	if sa != sb {
+		return sa < sb
+	}
+	return a < b
+}
+
+func (m *WemModel) byId(a, b int) bool {
+	wems := m.ctn.Wems()
+	return wems[a].Descriptor.WemId < wems[b].Descriptor.WemId
+}
+
+func (m *WemModel) bySize(a, b int) bool {
+	wems := m.ctn.Wems()
+	return wems[a].Descriptor.Length < wems[b].Descriptor.Length
+}
+
+func (m *WemModel) byOffset(a, b int) bool {
+	wems := m.ctn.Wems()
+	return wems[a].Descriptor.Offset < wems[b].Descriptor.Offset
+}
+
+func (m *WemModel) byPadding(a, b int) bool {
+	wems := m.ctn.Wems()
+	return wems[a].Padding.Size() < wems[b].Padding.Size()
+}
+
+// byLoop sorts wems that do not loop first, then by the number of times they
+// loop, with infinitely looping wems last.
+func (m *WemModel) byLoop(a, b int) bool {
+	rank := func(index int) uint64 {
+		ctn, ok := m.ctn.(*bnk.File)
+		if !ok || !ctn.CanLoop(index) {
+			return 0
+		}
 		loop := ctn.LoopOf(index)
-		if loop.Loops {
-			if loop.Value == bnk.InfiniteLoops {
-				str = "Infinity"
-			} else {
-				str = fmt.Sprintf("%d times", loop.Value)
-			}
+		switch {
+		case !loop.Loops:
+			return 1
+		case loop.Value == bnk.InfiniteLoops:
+			return ^uint64(0)
 		}
+		return uint64(loop.Value)
+	}
+	ra, rb := rank(a), rank(b)
+	if ra != rb {
+		return ra < rb
+	}
+	return a < b
+}
+
+// format returns the format of the wem at index, or nil if it cannot be read.
+func (m *WemModel) format(index int) *wwise.WemFormat {
+	f, ok := m.formats[index]
+	if !ok {
+		f, _ = m.ctn.Wems()[index].Format()
+		m.formats[index] = f
+	}
+	return f
+}
+
+func (m *WemModel) wemDuration(index int) string {
+	f := m.format(index)
+	if f == nil || f.Duration() == 0 {
+		return "Unknown"
+	}
+	return fmt.Sprintf("%.2fs", f.Duration().Seconds())
+}
+
+func (m *WemModel) wemSampleRate(index int) string {
+	f := m.format(index)
+	if f == nil {
+		return "Unknown"
 	}
+	return fmt.Sprintf("%d Hz", f.SampleRate)
+}
+
+func (m *WemModel) wemChannels(index int) string {
+	f := m.format(index)
+	if f == nil {
+		return "Unknown"
+	}
+	return fmt.Sprintf("%d", f.Channels)
+}
+
+func (m *WemModel) wemCodec(index int) string {
+	f := m.format(index)
+	if f == nil {
+		return "Unknown"
+	}
+	return f.CodecName()
+}
+
+// byFormat sorts wems by a property of their format, with wems whose format
+// cannot be read first.
+func (m *WemModel) byFormat(a, b int,
+	property func(f *wwise.WemFormat) int64) bool {
+	fa, fb := m.format(a), m.format(b)
+	va, vb := int64(-1), int64(-1)
+	if fa != nil {
+		va = property(fa)
+	}
+	if fb != nil {
+		vb = property(fb)
+	}
+	if va != vb {
+		return va < vb
+	}
+	return a < b
+}
+
+func (m *WemModel) byDuration(a, b int) bool {
+	return m.byFormat(a, b, func(f *wwise.WemFormat) int64 {
+		return int64(f.Duration())
+	})
+}
+
+func (m *WemModel) bySampleRate(a, b int) bool {
+	return m.byFormat(a, b, func(f *wwise.WemFormat) int64 {
+		return int64(f.SampleRate)
+	})
+}
 
-	return str
+func (m *WemModel) byChannels(a, b int) bool {
+	return m.byFormat(a, b, func(f *wwise.WemFormat) int64 {
+		return int64(f.Channels)
+	})
 }
 
 func (m *WemModel) rowCount(parent *core.QModelIndex) int {
@@ -0,0 +1,98 @@
+package viewer
+
+import (
+	"bnk"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// A rowState is the staged state of a single wem, as recorded before and after
+// a change so that the change can be undone.
+type rowState struct {
+	replacement *replacementWemWrapper
+	loop        bnk.LoopValue
+	// Whether the loop of the wem has been edited, and the loop it had before.
+	loopEdited   bool
+	originalLoop bnk.LoopValue
+}
+
+// rowState returns the staged state of the wem at index.
+func (t *WemTable) rowState(index int) rowState {
+	st := rowState{replacement: t.model.replacements[index]}
+	if ctn, ok := t.model.ctn.(*bnk.File); ok {
+		st.loop = ctn.LoopOf(index)
+	}
+	st.originalLoop, st.loopEdited = t.model.loopEdits[index]
+	return st
+}
+
+// restoreRowState sets the staged state of the wem at index to st.
+func (t *WemTable) restoreRowState(index int, st rowState) {
+	if st.replacement != nil {
+		t.model.replacements[index] = st.replacement
+	} else {
+		delete(t.model.replacements, index)
+	}
+	if ctn, ok := t.model.ctn.(*bnk.File); ok {
+		ctn.ReplaceLoopOf(index, st.loop)
+	}
+	if st.loopEdited {
+		t.model.loopEdits[index] = st.originalLoop
+	} else {
+		delete(t.model.loopEdits, index)
+	}
+	t.refreshRow(index)
+}
+
+// stageChange applies change, which modifies the staged state of the wem at
+// index, as a command on the undo stack with the description text.
+func (wv *WwiseViewerWindow) stageChange(text string, index int,
+	change func()) {
+	before := wv.table.rowState(index)
+	change()
+	after := wv.table.rowState(index)
+	logged := wv.newStagedChange(text, index, before, after)
+	wv.changeLog = append(wv.changeLog, logged)
+
+	// Undoing a change made in another view shows that view first.
+	model := wv.table.model
+	cmd := widgets.NewQUndoCommand2(text, nil)
+	cmd.ConnectUndo(func() {
+		wv.showModel(model)
+		wv.table.restoreRowState(index, before)
+		logged.applied = false
+		wv.refreshChangeLog()
+		wv.refreshSelectedLoop()
+	})
+	cmd.ConnectRedo(func() {
+		wv.showModel(model)
+		wv.table.restoreRowState(index, after)
+		logged.applied = true
+		wv.refreshChangeLog()
+		wv.refreshSelectedLoop()
+	})
+	// Pushing a command redoes it, which is harmless as the change has already
+	// been applied.
+	wv.undoStack.Push(cmd)
+}
+
+func (wv *WwiseViewerWindow) setupUndo(toolbar *widgets.QToolBar) {
+	wv.undoStack = widgets.NewQUndoStack(wv)
+	wv.actionUndo = wv.undoStack.CreateUndoAction(wv, "&Undo")
+	wv.actionUndo.SetShortcuts2(gui.QKeySequence__Undo)
+	wv.actionRedo = wv.undoStack.CreateRedoAction(wv, "&Redo")
+	wv.actionRedo.SetShortcuts2(gui.QKeySequence__Redo)
+	toolbar.QWidget.AddAction(wv.actionUndo)
+	toolbar.QWidget.AddAction(wv.actionRedo)
+}
+
+// refreshSelectedLoop updates the loop toolbar and details panel to show the
+// loop of the wem in the selected row, which may have been changed by an undo
+// or redo.
+func (wv *WwiseViewerWindow) refreshSelectedLoop() {
+	wv.refreshDetails()
+	row := wv.getSelectedRow()
+	if b, ok := wv.table.GetContainer().(*bnk.File); ok && row >= 0 {
+		wv.setLoopValues(b, row)
+	}
+}
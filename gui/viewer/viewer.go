@@ -1,18 +1,23 @@
 package viewer
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 import (
 	"bnk"
+	"convert"
 	"pck"
+	"session"
 	"util"
 	"wwise"
 	"github.com/therecipe/qt/core"
@@ -53,20 +58,116 @@ type WwiseViewerWindow struct {
 	actionOpen    *widgets.QAction
 	actionSave    *widgets.QAction
 	actionReplace *widgets.QAction
-	actionExport  *widgets.QAction
+	// Stages a replacement for every wem in a directory that is named after a
+	// wem in the container.
+	actionReplaceDir *widgets.QAction
+	actionExport     *widgets.QAction
+	actionPlay       *widgets.QAction
+	actionUndo       *widgets.QAction
+	actionRedo       *widgets.QAction
+	actionRevert     *widgets.QAction
+	actionFind       *widgets.QAction
+	actionGoTo       *widgets.QAction
+	actionLoadNames  *widgets.QAction
+	actionSetLoop    *widgets.QAction
+	actionCompare    *widgets.QAction
+	// Shows the preferences dialog.
+	actionPreferences *widgets.QAction
+	// Registers the viewer as the application that opens containers.
+	actionAssociate *widgets.QAction
+	// Whether previews repeat as many times as the wem loops.
+	actionPreviewLoop *widgets.QAction
+	// Applies the staged replacements to other containers.
+	actionBatch *widgets.QAction
+
+	// The history of staged changes, which is cleared whenever a file is opened
+	// or saved.
+	undoStack *widgets.QUndoStack
 
 	loopToolBar      *widgets.QToolBar
 	checkboxLoop     *widgets.QCheckBox
 	checkboxInfinity *widgets.QCheckBox
 	lineEditLoop     *widgets.QLineEdit
 
+	lineEditFilter *widgets.QLineEdit
+
+	// The statistics of the shown container, in the status bar.
+	labelWemCount  *widgets.QLabel
+	labelMediaSize *widgets.QLabel
+	labelVersion   *widgets.QLabel
+	labelStaged    *widgets.QLabel
+
+	bannerMismatch *widgets.QFrame
+	labelMismatch  *widgets.QLabel
+	// A description of each staged replacement whose format differs from the wem
+	// it replaces.
+	mismatches []string
+
+	fileMenu   *widgets.QMenu
+	menuRecent *widgets.QMenu
+	viewMenu   *widgets.QMenu
+	// A mapping from column title to whether the column is shown.
+	columnVisible map[string]bool
+	// A mapping from column title to the View menu action that toggles it.
+	columnActions map[string]*widgets.QAction
+	// A mapping from column title to the width the user last gave the column.
+	columnWidths map[string]int
+
+	dockHierarchy *widgets.QDockWidget
+	treeHierarchy *widgets.QTreeWidget
+	// A mapping from wem id to the items of the Sound objects that play it.
+	soundItems map[uint32][]*widgets.QTreeWidgetItem
+	// Set while the selection of the table and hierarchy tree are being
+	// synchronized, so that neither responds to the other's change.
+	syncingSelection bool
+
+	dockDetails *widgets.QDockWidget
+	treeDetails *widgets.QTreeWidget
+	// A mapping from wem id to the ids of the events that play the wem.
+	eventsOfWems map[uint32][]uint32
+
+	dockBanks *widgets.QDockWidget
+	listBanks *widgets.QListWidget
+	// The views of the open File Package and its embedded SoundBanks, or nil if
+	// the open file has no embedded SoundBanks.
+	embedded *embeddedBanks
+
+	dockChangeLog *widgets.QDockWidget
+	listChangeLog *widgets.QListWidget
+	// Every staged change made since the file was opened or saved, in order,
+	// including those that have been undone.
+	changeLog []*stagedChange
+
+	dockConsole *widgets.QDockWidget
+	listConsole *widgets.QListWidget
+	// The number of warnings and errors logged to the console since it was last
+	// cleared.
+	consoleWarnings int
+
+	dockHex        *widgets.QDockWidget
+	comboHexSource *widgets.QComboBox
+	textHex        *widgets.QPlainTextEdit
+	// The unknown sections of the open SoundBank, listed after the selected wem
+	// as sources of the hex preview.
+	hexSections []*bnk.UnknownSection
+
 	table               *WemTable
 	currSaveFileFilters string
+	// The path of the file that is open, or empty if no file is open.
+	currPath string
+	preview  *previewPlayer
+	// Passes the results of work done in the background back to the GUI thread.
+	dispatcher *dispatcher
+	// The temporary directories that wems dragged out of the table were
+	// exported to.
+	dragDirs []string
 }
 
 func New() *WwiseViewerWindow {
 	wv := new(WwiseViewerWindow)
-	wv.SetWindowTitle(core.QCoreApplication_ApplicationName())
+	wv.dispatcher = NewDispatcher(nil)
+	// The placeholder is replaced by an asterisk while there are unsaved changes.
+	wv.SetWindowTitle(core.QCoreApplication_ApplicationName() + "[*]")
 
 	tb := wv.AddToolBar3("Main Toolbar")
 	tb.SetToolButtonStyle(core.Qt__ToolButtonTextBesideIcon)
@@ -75,7 +176,22 @@ func New() *WwiseViewerWindow {
 	wv.setupOpen(tb)
 	wv.setupSave(tb)
 	wv.setupReplace(tb)
+	wv.setupReplaceDir(tb)
 	wv.setupExport(tb)
+	wv.setupPlay(tb)
+	tb.AddSeparator()
+	wv.setupUndo(tb)
+	wv.undoStack.ConnectCleanChanged(func(clean bool) {
+		wv.SetWindowModified(!clean)
+	})
+	wv.ConnectCloseEvent(func(event *gui.QCloseEvent) {
+		if wv.maybeSave() {
+			wv.saveSession()
+			event.Accept()
+		} else {
+			event.Ignore()
+		}
+	})
 
 	tb.AddSeparator()
 	wv.AddToolBarBreak(core.Qt__TopToolBarArea)
@@ -85,7 +201,34 @@ func New() *WwiseViewerWindow {
 
 	wv.table = NewTable()
 	wv.table.ConnectSelectionChanged(wv.onWemSelected)
-	wv.SetCentralWidget(wv.table)
+	wv.setupContextMenu()
+	wv.setupDragOut()
+	wv.setupFilter()
+	wv.setupMismatchBanner()
+
+	wv.setupFileMenu()
+	wv.setupEditMenu()
+	wv.setupViewMenu()
+	wv.setupHierarchyDock()
+	wv.setupHexDock()
+	wv.setupDetailsDock()
+	wv.setupChangeLogDock()
+	wv.setupBanksDock()
+	wv.setupConsoleDock()
+	wv.setupToolsMenu()
+	wv.setupHelpMenu()
+
+	central := widgets.NewQWidget(nil, 0)
+	layout := widgets.NewQVBoxLayout2(central)
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.AddWidget(wv.lineEditFilter, 0, 0)
+	layout.AddWidget(wv.bannerMismatch, 0, 0)
+	layout.AddWidget(wv.table, 0, 0)
+	wv.SetCentralWidget(central)
+	wv.setupStatusBar()
+
+	wv.applyPreferences()
+	wv.restoreNames()
 
 	wv.SetFocus2()
 	return wv
@@ -94,82 +237,307 @@ func New() *WwiseViewerWindow {
 func (wv *WwiseViewerWindow) setupOpen(toolbar *widgets.QToolBar) {
 	icon := gui.QIcon_FromTheme2("wwise-open", gui.NewQIcon5(rsrcPath+"/open.png"))
 	wv.actionOpen = widgets.NewQAction3(icon, "&Open", wv)
+	wv.actionOpen.SetShortcuts2(gui.QKeySequence__Open)
 	wv.actionOpen.ConnectTriggered(func(checked bool) {
-		home := util.UserHome()
+		start := preferredDir(openDirKey)
 		path := widgets.QFileDialog_GetOpenFileName(
-			wv, "Open file", home, supportedFileFilters, "", 0)
+			wv, "Open file", start, supportedFileFilters, "", 0)
 		if path != "" {
-			wv.openCtn(path)
-			wv.clearLoopValues()
+			wv.Open(path)
 		}
 	})
 	toolbar.QWidget.AddAction(wv.actionOpen)
 }
 
-func (wv *WwiseViewerWindow) openCtn(path string) {
-	switch t, ext := util.GetFileType(path); t {
-	case util.SoundBankFileType:
-		bnk, err := bnk.Open(path)
-		if err != nil {
-			wv.showOpenError(path, err)
-			return
-		}
-		wv.currSaveFileFilters = saveBnkFileFilters
-		wv.table.LoadSoundBankModel(bnk)
-	case util.FilePackageFileType:
-		pck, err := pck.Open(path)
-		if err != nil {
-			wv.showOpenError(path, err)
-			return
-		}
-		wv.currSaveFileFilters = savePckFileFilters
-		wv.table.LoadFilePackageModel(pck)
-	default:
+// Open opens the SoundBank or File Package at path, showing an error if it
+// cannot be opened.
+func (wv *WwiseViewerWindow) Open(path string) {
+	wv.openCtn(path, nil)
+}
+
+// openCtn opens the SoundBank or File Package at path in the background, then
+// calls opened, if it is not nil, once it has been loaded into the table.
+func (wv *WwiseViewerWindow) openCtn(path string, opened func()) {
+	if !wv.maybeSave() {
+		return
+	}
+	t, ext := util.GetFileType(path)
+	if t != util.SoundBankFileType && t != util.FilePackageFileType {
 		msg := fmt.Sprintf("%s(%s) is not a supported file format", path, ext)
 		wv.showOpenError(path, errors.New(msg))
 		return
 	}
 
+	var ctn wwise.Container
+	var err error
+	wv.runInBackground("Opening "+filepath.Base(path)+"...",
+		func(progress wwise.ProgressFunc) {
+			ctn, err = openWithProgress(path, t, progress)
+		}, func() {
+			switch {
+			case err == wwise.ErrCancelled:
+				wv.showCancelledStatus("Opening " + filepath.Base(path))
+			case err != nil:
+				wv.showOpenError(path, err)
+			default:
+				wv.loadCtn(path, ctn)
+				if opened != nil {
+					opened()
+				}
+			}
+		})
+}
+
+// openWithProgress opens the container of type t at path, reporting the number
+// of bytes parsed to progress. If progress returns false, opening stops and
+// wwise.ErrCancelled is returned.
+func openWithProgress(path string, t util.ContainerType,
+	progress wwise.ProgressFunc) (wwise.Container, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	report := func(p wwise.OpenProgress) {
+		if !progress(p.Parsed, p.Size) {
+			cancel()
+		}
+	}
+	var ctn wwise.Container
+	var err error
+	if t == util.SoundBankFileType {
+		var b *bnk.File
+		if b, err = bnk.OpenContext(ctx, path, report); err == nil {
+			ctn = b
+		}
+	} else {
+		var p *pck.File
+		if p, err = pck.OpenContext(ctx, path, report); err == nil {
+			ctn = p
+		}
+	}
+	if err == context.Canceled {
+		err = wwise.ErrCancelled
+	}
+	return ctn, err
+}
+
+// loadCtn shows ctn, which was opened from path, in the window.
+func (wv *WwiseViewerWindow) loadCtn(path string, ctn wwise.Container) {
+	applyAlignment(ctn)
+	wv.recordColumnWidths()
+	switch ctn := ctn.(type) {
+	case *bnk.File:
+		wv.currSaveFileFilters = saveBnkFileFilters
+		wv.logWarnings(path, ctn.Warnings())
+		wv.table.LoadSoundBankModel(ctn)
+		wv.loadHierarchy(ctn)
+		wv.loadHexSources(ctn)
+		wv.eventsOfWems = ctn.EventsOfWems()
+		wv.loadEmbeddedBanks(nil)
+	case *pck.File:
+		wv.currSaveFileFilters = savePckFileFilters
+		wv.logWarnings(path, ctn.Warnings())
+		wv.table.LoadFilePackageModel(ctn)
+		wv.loadHierarchy(nil)
+		wv.loadHexSources(nil)
+		wv.eventsOfWems = nil
+		wv.loadEmbeddedBanks(ctn)
+	}
+	wv.applyColumnVisibility()
+	wv.restoreColumnWidths()
+	wv.clearLoopValues()
+
+	wv.preview.Stop()
+	wv.undoStack.Clear()
+	wv.clearChangeLog()
+	wv.clearMismatches()
+	wv.currPath = path
+	addRecentFile(path)
 	wv.showFileOpenStatus(path)
+	// Placeholder containers with no wems are opened, and can be saved, but
+	// there is nothing within them to export, replace or go to.
+	hasWems := len(ctn.Wems()) > 0
+	if !hasWems {
+		wv.logConsole(consoleInfo, filepath.Base(path), "The file has no wems")
+	}
+	// Containers of versions that are not supported for replacements are
+	// opened read-only: their wems can be listed and exported, but not replaced.
+	canReplace := hasWems
+	if err := ctn.CheckVersion(wwise.EditWems); err != nil && hasWems {
+		wv.logConsole(consoleWarning, filepath.Base(path), err.Error())
+		canReplace = false
+	}
 	wv.actionSave.SetEnabled(true)
-	wv.actionExport.SetEnabled(true)
+	wv.actionExport.SetEnabled(hasWems)
+	wv.actionReplaceDir.SetEnabled(canReplace)
+	wv.actionCompare.SetEnabled(true)
+	wv.actionBatch.SetEnabled(true)
+	wv.actionGoTo.SetEnabled(hasWems)
+	wv.refreshStatusBar()
 }
 
 func (wv *WwiseViewerWindow) setupSave(toolbar *widgets.QToolBar) {
 	icon := gui.QIcon_FromTheme2("wwise-save", gui.NewQIcon5(rsrcPath+"/save.png"))
 	wv.actionSave = widgets.NewQAction3(icon, "&Save", wv)
 	wv.actionSave.SetEnabled(false)
+	wv.actionSave.SetShortcuts2(gui.QKeySequence__Save)
 	wv.actionSave.ConnectTriggered(func(checked bool) {
-		home := util.UserHome()
-		path := widgets.QFileDialog_GetSaveFileName(
-			wv, "Save file", home, wv.currSaveFileFilters, "", 0)
-		if path != "" {
-			wv.saveCtn(path)
-		}
+		wv.promptSave()
 	})
 	toolbar.QWidget.AddAction(wv.actionSave)
 }
 
-func (wv *WwiseViewerWindow) saveCtn(path string) {
-	outputFile, err := os.Create(path)
+// promptSave asks for a path to save the open file to, then saves it. It
+// returns true if the file was saved.
+func (wv *WwiseViewerWindow) promptSave() bool {
+	start := preferredDir(openDirKey)
+	path := widgets.QFileDialog_GetSaveFileName(
+		wv, "Save file", start, wv.currSaveFileFilters, "", 0)
+	if path == "" {
+		return false
+	}
+	return wv.saveCtn(path)
+}
+
+// maybeSave asks whether to save the staged changes, if there are any, before
+// they are discarded. It returns false if the changes should be kept, and the
+// operation that would discard them cancelled.
+func (wv *WwiseViewerWindow) maybeSave() bool {
+	if wv.undoStack.IsClean() {
+		return true
+	}
+	msg := fmt.Sprintf("%s has unsaved changes.\n"+
+		"Do you want to save your changes?", filepath.Base(wv.currPath))
+	answer := widgets.QMessageBox_Warning(wv, "Save changes", msg,
+		widgets.QMessageBox__Save|widgets.QMessageBox__Discard|
+			widgets.QMessageBox__Cancel, widgets.QMessageBox__Save)
+	switch answer {
+	case widgets.QMessageBox__Save:
+		return wv.promptSave()
+	case widgets.QMessageBox__Discard:
+		return true
+	}
+	return false
+}
+
+// saveCtn writes the open file, with all staged changes applied, to path. It
+// returns true if the file was saved.
+func (wv *WwiseViewerWindow) saveCtn(path string) bool {
+	if !wv.checkPackageFit(wv.rootModel()) {
+		return false
+	}
+	// Changes to embedded SoundBanks are written into the File Package before
+	// the File Package itself is committed.
+	count, err := wv.commitEmbeddedBanks()
 	if err != nil {
+		wv.undoStack.Clear()
+		wv.undoStack.ResetClean()
+		wv.clearChangeLog()
 		wv.showSaveError(path, err)
-		return
+		return false
 	}
-	count := wv.table.CommitReplacements()
-	ctn := wv.table.GetContainer()
+	root := wv.rootModel()
+	count += root.commit()
+	wv.undoStack.Clear()
+	wv.clearChangeLog()
+	ctn := root.ctn
 
-	total, err := ctn.WriteTo(outputFile)
+	var backup string
+	backupNeeded := backupOriginals() && util.NeedsBackup(path, wv.currPath)
+	var total, written int64
+	wv.waitInBackground("Saving "+filepath.Base(path)+"...",
+		func(progress wwise.ProgressFunc) {
+			if backupNeeded {
+				if backup, err = util.BackupFile(path, time.Now()); err != nil {
+					return
+				}
+			}
+			total, written, err = writeCtnFile(ctn, path, progress)
+		}, func() {})
 	if err != nil {
-		wv.showSaveError(path, err)
-		return
+		// The staged changes have been committed to the container, but they have
+		// not been saved.
+		wv.undoStack.ResetClean()
+		if err == wwise.ErrCancelled {
+			wv.showCancelledStatus("Saving " + filepath.Base(path))
+		} else {
+			wv.showSaveError(path, err)
+		}
+		return false
+	}
+	if verifySaves() && !wv.verifySave(ctn, path) {
+		return false
 	}
 
 	msg := fmt.Sprintf("Successfully saved %s.\n"+
 		"%d wems have been replaced.\n"+
-		"%d bytes have been written.", path, count, total)
+		"%d bytes have been written.", path, count, written)
+	if written != total {
+		msg += fmt.Sprintf("\nThe rest of its %d bytes were unchanged.", total)
+	}
+	if backup != "" {
+		msg += fmt.Sprintf("\nThe original file was backed up to %s.", backup)
+	}
 	widgets.QMessageBox_Information(wv, "Save successful", msg, 0, 0)
+	if util.SameFile(path, wv.currPath) {
+		// The data that the open file is read from may have moved.
+		wv.openCtn(path, nil)
+		return true
+	}
 	wv.showFileOpenStatus(path)
+	return true
+}
+
+// verifySave re-opens the file at path, to which ctn was just saved, and checks
+// that it holds what ctn does. It returns false, having shown why, if it does
+// not, or if verification was cancelled.
+func (wv *WwiseViewerWindow) verifySave(ctn wwise.Container,
+	path string) bool {
+	var err error
+	wv.waitInBackground("Verifying "+filepath.Base(path)+"...",
+		func(progress wwise.ProgressFunc) {
+			err = wwise.Verify(context.Background(), ctn, path,
+				session.OpenLike(ctn), progress)
+		}, func() {})
+	switch {
+	case err == wwise.ErrCancelled:
+		wv.showCancelledStatus("Verifying " + filepath.Base(path))
+		return false
+	case err != nil:
+		wv.showSaveError(path, err)
+		return false
+	}
+	return true
+}
+
+// writeCtnFile writes ctn to the file at path. An existing file is patched in
+// place, rewriting only the parts of it that change. Otherwise, or if the
+// changes move data within the file, ctn is written to a new file that then
+// replaces any existing one, so that the existing file can still be read from
+// while ctn is written. It returns the size of the written file and the number
+// of bytes written to it.
+func writeCtnFile(ctn wwise.Container, path string,
+	progress wwise.ProgressFunc) (total, written int64, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	switch {
+	case err == nil:
+		total, written, err = wwise.WriteInPlace(ctn, f, progress)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != wwise.ErrNotInPlace {
+			return total, written, err
+		}
+	case !os.IsNotExist(err):
+		return 0, 0, err
+	}
+
+	err = util.WriteFileAtomic(path, func(f *os.File) (err error) {
+		total, err = wwise.WriteWithProgress(ctn, f, progress)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, total, nil
 }
 
 func (wv *WwiseViewerWindow) setupReplace(toolbar *widgets.QToolBar) {
@@ -177,14 +545,15 @@ func (wv *WwiseViewerWindow) setupReplace(toolbar *widgets.QToolBar) {
 		gui.NewQIcon5(rsrcPath+"/replace.png"))
 	wv.actionReplace = widgets.NewQAction3(icon, "&Replace", wv)
 	wv.actionReplace.SetEnabled(false)
+	wv.actionReplace.SetShortcut(shortcut("Ctrl+R"))
 	wv.actionReplace.ConnectTriggered(func(checked bool) {
 		row := wv.getSelectedRow()
 		if row < 0 {
 			return
 		}
-		home := util.UserHome()
+		start := preferredDir(replaceDirKey)
 		path := widgets.QFileDialog_GetOpenFileName(
-			wv, "Open file", home, wemFileFilters, "", 0)
+			wv, "Open file", start, wemFileFilters, "", 0)
 		if path != "" {
 			wv.addReplacement(row, path)
 		}
@@ -193,18 +562,93 @@ func (wv *WwiseViewerWindow) setupReplace(toolbar *widgets.QToolBar) {
 }
 
 func (wv *WwiseViewerWindow) addReplacement(index int, path string) {
-	wem, err := os.Open(path)
+	err := wv.table.GetContainer().CheckVersion(wwise.EditWems)
 	if err != nil {
-		wv.showOpenError(path, err)
+		wv.logConsole(consoleWarning, "Replace", err.Error())
 		return
 	}
-	stat, err := wem.Stat()
+	// The replacement is read from the file only once the container is saved,
+	// so that staging many replacements holds none of them in memory or open.
+	wem, err := util.OpenLazy(path)
 	if err != nil {
 		wv.showOpenError(path, err)
 		return
 	}
-	r := &wwise.ReplacementWem{wem, index, stat.Size()}
-	wv.table.AddWemReplacement(stat.Name(), r)
+	name := filepath.Base(path)
+	r := &wwise.ReplacementWem{wem, index, wem.Size()}
+	wv.stageChange("Replace with "+name, index, func() {
+		wv.table.AddWemReplacement(name, r)
+	})
+	wv.checkReplacementFormat(name, r)
+}
+
+func (wv *WwiseViewerWindow) setupReplaceDir(toolbar *widgets.QToolBar) {
+	icon := gui.QIcon_FromTheme2("wwise-replace-dir",
+		gui.NewQIcon5(rsrcPath+"/replace.png"))
+	wv.actionReplaceDir = widgets.NewQAction3(icon, "Replace from &Folder...",
+		wv)
+	wv.actionReplaceDir.SetEnabled(false)
+	wv.actionReplaceDir.ConnectTriggered(func(checked bool) {
+		start := preferredDir(replaceDirKey)
+		opts := widgets.QFileDialog__ShowDirsOnly |
+			widgets.QFileDialog__DontResolveSymlinks
+		dir := widgets.QFileDialog_GetExistingDirectory(
+			wv, "Choose directory of replacement wems", start, opts)
+		if dir != "" {
+			wv.addReplacementsFromDir(dir)
+		}
+	})
+	toolbar.QWidget.AddAction(wv.actionReplaceDir)
+}
+
+// addReplacementsFromDir stages a replacement for every wem file in dir whose
+// name, without its extension, is the id or name of a wem in the container.
+// The matches are shown for confirmation before they are staged.
+func (wv *WwiseViewerWindow) addReplacementsFromDir(dir string) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		wv.showOpenError(dir, err)
+		return
+	}
+
+	matches := make(map[int]string)
+	var unmatched []string
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.EqualFold(filepath.Ext(fi.Name()), ".wem") {
+			continue
+		}
+		name := strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name()))
+		index := wv.table.WemIndexOfName(name)
+		if index < 0 {
+			unmatched = append(unmatched, fi.Name())
+			continue
+		}
+		matches[index] = filepath.Join(dir, fi.Name())
+	}
+	if len(matches) == 0 {
+		msg := fmt.Sprintf("None of the wem files in %s are named after the id "+
+			"or name of a wem in this file.", dir)
+		widgets.QMessageBox_Information(wv, "No replacements found", msg, 0, 0)
+		return
+	}
+
+	msg := fmt.Sprintf("%d wem file(s) in %s match a wem in this file.",
+		len(matches), dir)
+	if len(unmatched) > 0 {
+		msg += fmt.Sprintf("\n%d wem file(s) do not match, and will be "+
+			"skipped:\n%s", len(unmatched), strings.Join(unmatched, "\n"))
+	}
+	msg += "\n\nStage these replacements?"
+	answer := widgets.QMessageBox_Question(wv, "Replace from folder", msg,
+		widgets.QMessageBox__Yes|widgets.QMessageBox__No, widgets.QMessageBox__Yes)
+	if answer != widgets.QMessageBox__Yes {
+		return
+	}
+	wv.undoStack.BeginMacro("Replace from " + filepath.Base(dir))
+	for index, path := range matches {
+		wv.addReplacement(index, path)
+	}
+	wv.undoStack.EndMacro()
 }
 
 func (wv *WwiseViewerWindow) setupExport(toolbar *widgets.QToolBar) {
@@ -212,12 +656,13 @@ func (wv *WwiseViewerWindow) setupExport(toolbar *widgets.QToolBar) {
 		gui.NewQIcon5(rsrcPath+"/export.png"))
 	wv.actionExport = widgets.NewQAction3(icon, "&Export Wems", wv)
 	wv.actionExport.SetEnabled(false)
+	wv.actionExport.SetShortcut(shortcut("Ctrl+E"))
 	wv.actionExport.ConnectTriggered(func(checked bool) {
-		home := util.UserHome()
+		start := preferredDir(exportDirKey)
 		opts := widgets.QFileDialog__ShowDirsOnly |
 			widgets.QFileDialog__DontResolveSymlinks
 		dir := widgets.QFileDialog_GetExistingDirectory(
-			wv, "Choose directory to unpack into", home, opts)
+			wv, "Choose directory to unpack into", start, opts)
 		if dir != "" {
 			wv.exportCtn(dir)
 		}
@@ -225,6 +670,84 @@ func (wv *WwiseViewerWindow) setupExport(toolbar *widgets.QToolBar) {
 	toolbar.QWidget.AddAction(wv.actionExport)
 }
 
+func (wv *WwiseViewerWindow) setupPlay(toolbar *widgets.QToolBar) {
+	wv.preview = newPreviewPlayer(wv)
+	icon := wv.Style().StandardIcon(widgets.QStyle__SP_MediaPlay, nil, nil)
+	wv.actionPlay = widgets.NewQAction3(icon, "&Play", wv)
+	wv.actionPlay.SetEnabled(false)
+	wv.actionPlay.SetShortcut(shortcut("Space"))
+	wv.actionPlay.ConnectTriggered(func(checked bool) {
+		if wv.preview.IsPlaying() {
+			wv.preview.Stop()
+			return
+		}
+		row := wv.getSelectedRow()
+		if row < 0 {
+			return
+		}
+		ctn := wv.table.GetContainer()
+		wem := ctn.Wems()[row]
+		loop := bnk.LoopValue{}
+		if b, ok := ctn.(*bnk.File); ok && wv.actionPreviewLoop.IsChecked() {
+			loop = b.LoopOf(row)
+		}
+		if err := wv.preview.PlayLooped(wem, loop); err != nil {
+			wv.showPlayError(wem, err)
+			return
+		}
+		wv.actionPlay.SetText("&Stop")
+		wv.actionPlay.SetIcon(
+			wv.Style().StandardIcon(widgets.QStyle__SP_MediaStop, nil, nil))
+	})
+	wv.preview.ConnectStopped(func() {
+		wv.actionPlay.SetText("&Play")
+		wv.actionPlay.SetIcon(icon)
+	})
+	toolbar.QWidget.AddAction(wv.actionPlay)
+
+	wv.actionPreviewLoop = widgets.NewQAction2("Preview with &Loop", wv)
+	wv.actionPreviewLoop.SetCheckable(true)
+	wv.actionPreviewLoop.SetChecked(loadString(previewLoopKey) == "true")
+	wv.actionPreviewLoop.SetToolTip("Repeat previews of SoundBank wems as " +
+		"many times as they loop in game")
+	wv.actionPreviewLoop.ConnectToggled(func(checked bool) {
+		saveString(previewLoopKey, fmt.Sprintf("%t", checked))
+	})
+	toolbar.QWidget.AddAction(wv.actionPreviewLoop)
+}
+
+// Cleanup releases the resources held by the viewer, such as the media player
+// used for previews and the files exported by dragging wems.
+func (wv *WwiseViewerWindow) Cleanup() {
+	wv.preview.Close()
+	wv.removeDragDirs()
+}
+
+// revert discards the staged replacement and loop edit of the wem at index.
+func (wv *WwiseViewerWindow) revert(index int) {
+	wv.stageChange("Revert", index, func() {
+		wv.table.Revert(index)
+	})
+	wv.refreshSelectedLoop()
+	wv.actionRevert.SetEnabled(false)
+}
+
+func (wv *WwiseViewerWindow) setupFilter() {
+	wv.lineEditFilter = widgets.NewQLineEdit(wv)
+	wv.lineEditFilter.SetPlaceholderText("Filter by id or name, " +
+		"size:MIN-MAX (e.g. size:10k-1m), or replaced")
+	wv.lineEditFilter.SetClearButtonEnabled(true)
+	wv.lineEditFilter.ConnectTextChanged(func(text string) {
+		if err := wv.table.SetFilter(text); err != nil {
+			wv.lineEditFilter.SetToolTip(err.Error())
+			wv.lineEditFilter.SetStyleSheet("color: red")
+			return
+		}
+		wv.lineEditFilter.SetToolTip("")
+		wv.lineEditFilter.SetStyleSheet("")
+	})
+}
+
 func (wv *WwiseViewerWindow) setupLoopOptionsToolbar() {
 	ltb := widgets.NewQToolBar("Loop Toolbar", nil)
 	ltb.SetToolButtonStyle(core.Qt__ToolButtonTextOnly)
@@ -256,9 +779,15 @@ func (wv *WwiseViewerWindow) setupLoopOptionsToolbar() {
 	wv.lineEditLoop.SetMaximumWidth(90)
 	wv.lineEditLoop.SetMaxLength(10)
 
-	actionSetLoop := widgets.NewQAction2("&Update Loop", wv)
-	actionSetLoop.ConnectTriggered(func(checked bool) {
-		wemIndex := wv.getSelectedRow()
+	wv.actionSetLoop = widgets.NewQAction2("&Update Loop", wv)
+	wv.actionSetLoop.SetShortcut(shortcut("Ctrl+L"))
+	wv.actionSetLoop.ConnectTriggered(func(checked bool) {
+		indexes := wv.getSelectedRows()
+		// The shortcut is active even while the loop toolbar is disabled.
+		b, isSoundBank := wv.table.GetContainer().(*bnk.File)
+		if len(indexes) == 0 || !isSoundBank {
+			return
+		}
 		loops := wv.checkboxLoop.CheckState() == core.Qt__Checked
 		infinity := false
 		value, err := 0, error(nil)
@@ -274,13 +803,34 @@ func (wv *WwiseViewerWindow) setupLoopOptionsToolbar() {
 				}
 			}
 		}
-		wv.table.UpdateLoop(wemIndex, &loopWrapper{loops, infinity, uint32(value)})
+		loop := &loopWrapper{loops, infinity, uint32(value)}
+		if len(indexes) == 1 {
+			wv.stageChange("Update loop", indexes[0], func() {
+				wv.table.UpdateLoop(indexes[0], loop)
+			})
+			return
+		}
+
+		wv.undoStack.BeginMacro(fmt.Sprintf("Update loop of %d wems",
+			len(indexes)))
+		updated := 0
+		for _, wemIndex := range indexes {
+			wemIndex := wemIndex
+			wv.stageChange("Update loop", wemIndex, func() {
+				wv.table.UpdateLoop(wemIndex, loop)
+			})
+			if loop.matches(b.LoopOf(wemIndex)) {
+				updated++
+			}
+		}
+		wv.undoStack.EndMacro()
+		wv.showLoopUpdateSummary(updated, len(indexes))
 	})
 
 	ltb.AddWidget(wv.checkboxLoop)
 	ltb.AddWidget(wv.checkboxInfinity)
 	ltb.AddWidget(wv.lineEditLoop)
-	ltb.QWidget.AddAction(actionSetLoop)
+	ltb.QWidget.AddAction(wv.actionSetLoop)
 	ltb.AddSeparator()
 	ltb.SetEnabled(false)
 
@@ -314,29 +864,79 @@ func (wv *WwiseViewerWindow) setLoopValues(b *bnk.File, wemIndex int) {
 	}
 }
 
+// exportCtn writes every wem of the open file to dir in the background.
 func (wv *WwiseViewerWindow) exportCtn(dir string) {
-	total := int64(0)
-	ctn := wv.table.GetContainer()
-	for _, wem := range ctn.Wems() {
-		filename := fmt.Sprintf("%d.wem", wem.Descriptor.WemId);
-		f, err := os.Create(filepath.Join(dir, filename))
-		if err != nil {
-			wv.showExportError(filename, dir, err)
-			return
-		}
-		n, err := io.Copy(f, wem)
-		if err != nil {
+	wv.exportWems(dir, wv.table.GetContainer().Wems())
+}
+
+// exportWems writes each wem in wems to dir, named by its original name if
+// names have been loaded, or by its id otherwise. Wems are converted to the
+// export format chosen in the preferences, if any.
+func (wv *WwiseViewerWindow) exportWems(dir string, wems []*wwise.Wem) {
+	to := exportFormat()
+	filenames := exportFilenames(wems, wv.table.names, exportExtension(to))
+	count := len(wems)
+	var filename string
+	var total int64
+	var err error
+	wv.runInBackground("Exporting wems...", func(progress wwise.ProgressFunc) {
+		filename, total, err = writeWems(dir, wems, filenames, to, progress)
+	}, func() {
+		switch {
+		case err == wwise.ErrCancelled:
+			wv.showCancelledStatus("Exporting wems")
+		case err != nil:
 			wv.showExportError(filename, dir, err)
-			return
+		default:
+			msg := fmt.Sprintf("Successfully exported wems to %s.\n"+
+				"%d wems have been exported.\n"+
+				"%d bytes have been written.", dir, count, total)
+			widgets.QMessageBox_Information(wv, "Save successful", msg, 0, 0)
 		}
-		total += n
+	})
+}
+
+// exportExtension returns the file extension of wems exported in the format to.
+func exportExtension(to convert.Format) string {
+	if to != convert.UnknownFormat {
+		return to.Extension()
 	}
+	return ".wem"
+}
 
-	count := len(ctn.Wems())
-	msg := fmt.Sprintf("Successfully exported wems to %s.\n"+
-		"%d wems have been exported.\n"+
-		"%d bytes have been written.", dir, count, total)
-	widgets.QMessageBox_Information(wv, "Save successful", msg, 0, 0)
+// writeWems writes each of wems to the file in dir named by filenames,
+// several at once, converting them to the format to unless it is
+// convert.UnknownFormat. The name of the file that could not be written if an
+// error occurred, and the number of bytes written, are returned.
+func writeWems(dir string, wems []*wwise.Wem, filenames []string,
+	to convert.Format, progress wwise.ProgressFunc) (filename string,
+	total int64, err error) {
+	paths := make([]string, len(filenames))
+	for i, name := range filenames {
+		paths[i] = filepath.Join(dir, name)
+	}
+	exporter := &wwise.Exporter{StopOnError: true}
+	if to != convert.UnknownFormat {
+		converter := newConverter()
+		exporter.Export = func(wem *wwise.Wem, path string) (int64, error) {
+			format, err := wem.Format()
+			if err == nil {
+				err = converter.Convert(wem, format, to, path)
+			}
+			if err != nil {
+				return 0, err
+			}
+			if fi, err := os.Stat(util.LongPath(path)); err == nil {
+				return fi.Size(), nil
+			}
+			return 0, nil
+		}
+	}
+	res, err := exporter.Run(wems, paths, progress)
+	if xerr, ok := err.(*wwise.ExportError); ok {
+		return filenames[xerr.Index], res.Written, xerr.Err
+	}
+	return "", res.Written, err
 }
 
 func (wv *WwiseViewerWindow) onWemSelected(selected *core.QItemSelection,
@@ -350,18 +950,34 @@ func (wv *WwiseViewerWindow) onWemSelected(selected *core.QItemSelection,
 	wv.table.SelectionChanged(selected, deselected)
 	wv.table.ConnectSelectionChanged(wv.onWemSelected)
 
+	wv.refreshHexPreview()
+	wv.refreshDetails()
 	if len(selected.Indexes()) == 0 {
 		wv.actionReplace.SetEnabled(false)
+		wv.actionPlay.SetEnabled(false)
+		wv.actionRevert.SetEnabled(false)
 		return
 	}
 
 	wemIndex := wv.getSelectedRow()
+	wv.actionRevert.SetEnabled(wv.table.IsStaged(wemIndex))
+
+	canReplace := wv.table.GetContainer().CheckVersion(wwise.EditWems) == nil
+	wv.actionReplace.SetEnabled(canReplace)
+	wv.actionPlay.SetEnabled(true)
 
-	wv.actionReplace.SetEnabled(true)
+	wem := wv.table.GetContainer().Wems()[wemIndex]
+	wv.highlightSounds(wem.Descriptor.WemId)
 
 	switch bnk := wv.table.GetContainer().(type) {
 	case *bnk.File:
-		wv.loopToolBar.SetEnabled(true)
+		// Loops are only edited for the selected wems that are played by a sound
+		// object.
+		loopable := false
+		for _, index := range wv.getSelectedRows() {
+			loopable = loopable || bnk.CanLoop(index)
+		}
+		wv.loopToolBar.SetEnabled(loopable)
 		wv.setLoopValues(bnk, wemIndex)
 	}
 }
@@ -383,20 +999,46 @@ func (wv *WwiseViewerWindow) showOpenError(path string, err error) {
 	widgets.QMessageBox_Critical(wv, errorTitle, msg, 0, 0)
 }
 
+func (wv *WwiseViewerWindow) showPlayError(wem *wwise.Wem, err error) {
+	msg := fmt.Sprintf("Could not play wem %d:\n%s", wem.Descriptor.WemId, err)
+	widgets.QMessageBox_Critical(wv, errorTitle, msg, 0, 0)
+}
+
+func (wv *WwiseViewerWindow) showLoopUpdateSummary(updated, total int) {
+	msg := fmt.Sprintf("The loops of %d of %d wems have been updated.",
+		updated, total)
+	if updated < total {
+		msg += fmt.Sprintf("\n%d wems are not played by a sound object, so "+
+			"their loops could not be changed.", total-updated)
+	}
+	widgets.QMessageBox_Information(wv, "Loops updated", msg, 0, 0)
+}
+
 func (wv *WwiseViewerWindow) showLoopUpdateError(value string) {
 	msg := fmt.Sprintf("\"%s\" is not a valid looping value.\n "+
 		"The loop value must be an integer >= 2.", value)
 	widgets.QMessageBox_Critical(wv, errorTitle, msg, 0, 0)
 }
 
-// Returns the index of the selected row, or -1 if a row isn't selected.
+// Returns the indexes of the wems in the selected rows, in ascending order.
+func (wv *WwiseViewerWindow) getSelectedRows() []int {
+	var indexes []int
+	for _, row := range wv.table.SelectionModel().SelectedRows(0) {
+		indexes = append(indexes, wv.table.WemIndexAt(row.Row()))
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// Returns the index of the wem in the selected row, or -1 if a row isn't
+// selected.
 func (wv *WwiseViewerWindow) getSelectedRow() int {
 	selection := wv.table.SelectionModel()
 	indexes := selection.SelectedRows(0)
 	if len(indexes) == 0 {
 		return -1
 	}
-	return indexes[0].Row()
+	return wv.table.WemIndexAt(indexes[0].Row())
 }
 
 func (wv *WwiseViewerWindow) showFileOpenStatus(path string) {
@@ -0,0 +1,76 @@
+package pck
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+import (
+	"bnk"
+	"util"
+	"wwise"
+)
+
+// SoundBankIndexes returns the indexes of the entries of this File Package
+// that are embedded SoundBanks, rather than wems.
+func (pck *File) SoundBankIndexes() []int {
+	var indexes []int
+	for i, wem := range pck.wems {
+		r, ok := wem.Reader.(io.ReaderAt)
+		if ok && util.GetStreamType(r) == util.SoundBankFileType {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// Warnings returns a Warning for each embedded SoundBank of this File Package
+// that cannot be read, and for any empty entries that were out of order. Such
+// SoundBanks are written back unchanged.
+func (pck *File) Warnings() []wwise.Warning {
+	var warnings []wwise.Warning
+	if pck.moved > 0 {
+		warnings = append(warnings, wwise.Warning{wwise.SeverityCaution,
+			wwise.WarningMovedPlaceholder, fmt.Sprintf("%d empty entries had "+
+				"offsets out of order, and were moved to the entry that follows "+
+				"them", pck.moved)})
+	}
+	for _, index := range pck.SoundBankIndexes() {
+		if _, err := pck.SoundBank(index); err != nil {
+			warnings = append(warnings, wwise.Warning{wwise.SeverityProblem,
+				wwise.WarningUnreadableBank, fmt.Sprintf("The embedded SoundBank %d "+
+					"could not be read: %s", pck.wems[index].Descriptor.WemId, err)})
+		}
+	}
+	return warnings
+}
+
+// SoundBank opens the embedded SoundBank at index. The returned File reads
+// from this File Package, so it must not be used once this File Package is
+// closed.
+func (pck *File) SoundBank(index int) (*bnk.File, error) {
+	if index < 0 || index >= len(pck.wems) {
+		return nil, fmt.Errorf("%d is not a valid entry index", index)
+	}
+	wem := pck.wems[index]
+	r, ok := wem.Reader.(io.ReaderAt)
+	if !ok || util.GetStreamType(r) != util.SoundBankFileType {
+		return nil, errors.New("The entry is not an embedded SoundBank")
+	}
+	return bnk.NewFile(io.NewSectionReader(r, 0, int64(wem.Descriptor.Length)))
+}
+
+// ReplaceSoundBank replaces the embedded SoundBank at index with the SoundBank
+// of the specified length read from r.
+func (pck *File) ReplaceSoundBank(index int, r io.ReaderAt, length int64) error {
+	if index < 0 || index >= len(pck.wems) {
+		return fmt.Errorf("%d is not a valid entry index", index)
+	}
+	if util.GetStreamType(r) != util.SoundBankFileType {
+		return errors.New("The replacement is not a SoundBank")
+	}
+	pck.ReplaceWems(&wwise.ReplacementWem{Wem: r, WemIndex: index,
+		Length: length})
+	return nil
+}
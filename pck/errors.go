@@ -0,0 +1,21 @@
+package pck
+
+import (
+	"fmt"
+)
+
+// A WemOffsetError is returned when the data of an entry of a File Package does
+// not start at the offset given by its index, as each entry is expected to
+// follow the last.
+type WemOffsetError struct {
+	WemId uint32
+	// The offset given by the index of the entry, and the offset that it was
+	// found at.
+	Expected int64
+	Actual   int64
+}
+
+func (e *WemOffsetError) Error() string {
+	return fmt.Sprintf("Wem %d was expected to start at offset %d but instead "+
+		"started at offset %d", e.WemId, e.Expected, e.Actual)
+}
@@ -2,12 +2,10 @@
 package pck
 
 import (
+	"context"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
-	"math"
-	"os"
 	"strings"
 )
 
@@ -22,6 +20,11 @@ const HEADER_BYTES = 4 + 4 + 44 + 4
 // The number of bytes used to describe a single data index entry.
 const DATA_INDEX_BYTES = 4 + 4 + 4 + 4
 
+// The File Package versions whose header and index this package reads and
+// writes. Wems are only replaced within these, as the index of another version
+// may be laid out differently, and be written back wrongly.
+var supportedVersions = []uint32{1}
+
 // A File represents an open Wwise File Package.
 type File struct {
 	closer  io.Closer
@@ -29,6 +32,18 @@ type File struct {
 	Indexes []*DataIndex
 	Padding uint32
 	wems    []*wwise.Wem
+	// The index of the first wem with each id.
+	indexOf map[uint32]int
+	// The number of bytes that the wems following a replaced wem are aligned to,
+	// or 0 if they are not aligned.
+	WemAlignment int64
+	// Where replacements are placed within the File Package.
+	Mode ReplaceMode
+	// The number of placeholders, entries with no data, that were moved from an
+	// offset outside of the order of the entries.
+	moved int
+	// Whether any wem has been replaced.
+	replaced bool
 }
 
 // A Header represents a single Wwise File Package header.
@@ -52,54 +67,151 @@ type DataIndex struct {
 // NewFile creates a new File for access Wwise File Package files. The file is
 // expected to start at position 0 in the io.ReaderAt.
 func NewFile(r io.ReaderAt) (*File, error) {
+	return NewFileWithProgress(r, nil)
+}
+
+// NewFileWithProgress is like NewFile, but calls progress, if it is not nil,
+// as the entries of the File Package are read. If progress returns false,
+// reading stops and wwise.ErrCancelled is returned.
+func NewFileWithProgress(r io.ReaderAt,
+	progress wwise.ProgressFunc) (*File, error) {
+	return newFile(r, progress, nil)
+}
+
+// newFile is like NewFileWithProgress, but also reports its progress to m as
+// each entry is parsed.
+func newFile(r io.ReaderAt, progress wwise.ProgressFunc,
+	m *wwise.OpenMonitor) (*File, error) {
 	pck := new(File)
-	sr := io.NewSectionReader(r, 0, math.MaxInt64)
+	sr := io.NewSectionReader(r, 0, util.SizeOf(r))
+	parsed := func(section bool) error {
+		pos, _ := sr.Seek(0, io.SeekCurrent)
+		if section {
+			return m.SectionParsed(pos)
+		}
+		return m.Parsed(pos)
+	}
 
+	if err := m.Parsed(0); err != nil {
+		return nil, err
+	}
 	hdr, err := NewHeader(sr)
 	if err != nil {
-		return nil, err
+		return nil, wwise.WrapParseError(err, "The header", 0)
 	}
 	pck.Header = hdr
+	if err := parsed(true); err != nil {
+		return nil, err
+	}
+
+	// Each entry is read twice; once for its index, and once for its data.
+	total := 2 * int64(pck.Header.WemCount)
+	done := int64(0)
+	advance := func() error {
+		done++
+		if progress != nil && !progress(done, total) {
+			return wwise.ErrCancelled
+		}
+		return nil
+	}
+
+	// Every entry of the index has the same size, so the count cannot be trusted
+	// if the file is too short to hold that many.
+	pos, _ := sr.Seek(0, io.SeekCurrent)
+	indexEnd := pos + int64(pck.Header.WemCount)*(DATA_INDEX_BYTES+4)
+	if indexEnd > sr.Size() {
+		return nil, &wwise.BoundsError{fmt.Sprintf("The index of %d entries",
+			pck.Header.WemCount), indexEnd, sr.Size()}
+	}
 
 	// Read in the data index.
 	for i := uint32(0); i < pck.Header.WemCount; i++ {
+		idxStart, _ := sr.Seek(0, io.SeekCurrent)
 		idx, err := NewDataIndex(sr)
 		if err != nil {
-			return nil, err
+			return nil, wwise.WrapParseError(err, fmt.Sprintf("Index entry %d", i),
+				idxStart)
 		}
 		pck.Indexes = append(pck.Indexes, idx)
+		if err := advance(); err != nil {
+			return nil, err
+		}
+		if err := parsed(false); err != nil {
+			return nil, err
+		}
 	}
 
 	var padding uint32
+	pos, _ = sr.Seek(0, io.SeekCurrent)
 	err = binary.Read(sr, binary.LittleEndian, &padding)
 	if err != nil {
-		return nil, err
+		return nil, wwise.WrapParseError(err, "The padding of the index", pos)
 	}
 	pck.Padding = padding
+	if err := parsed(true); err != nil {
+		return nil, err
+	}
+
+	descs := make([]*wwise.WemDescriptor, len(pck.Indexes))
+	for i, idx := range pck.Indexes {
+		descs[i] = idx.Descriptor
+	}
+	dataStart, _ := sr.Seek(0, io.SeekCurrent)
+	pck.moved = wwise.PlacePlaceholders(descs, dataStart, sr.Size())
 
 	// Read in the data contained within this File Package
 	for i, idx := range pck.Indexes {
-		var nextOffset uint32
+		// Offsets are computed as 64 bit integers, as the last wem may end past
+		// the 4 GiB that its offset can address.
+		var nextOffset int64
 		if i+1 < len(pck.Indexes) {
 			// There is a subsequent wem, use it to find the next offset.
-			nextOffset = pck.Indexes[i+1].Descriptor.Offset
+			nextOffset = int64(pck.Indexes[i+1].Descriptor.Offset)
 		} else {
 			// This is the last wem, the next offset will be the end of the wem.
-			nextOffset = idx.Descriptor.Length + idx.Descriptor.Offset
+			nextOffset = int64(idx.Descriptor.Offset) + int64(idx.Descriptor.Length)
 		}
 
 		wem, err := newWem(sr, idx, nextOffset)
 		if err != nil {
-			return nil, err
+			return nil, wwise.WrapParseError(err, fmt.Sprintf("Entry %d", i),
+				int64(idx.Descriptor.Offset))
 		}
 		pck.wems = append(pck.wems, wem)
+		if err := advance(); err != nil {
+			return nil, err
+		}
+		if err := parsed(false); err != nil {
+			return nil, err
+		}
 	}
+	if err := parsed(true); err != nil {
+		return nil, err
+	}
+	pck.indexOf = wwise.IndexWems(pck.wems)
 
 	return pck, nil
 }
 
+// The suggestion made when the wems of a File Package would not fit within the
+// 32 bit offsets and lengths of its index.
+const overflowHint = "split the wems across several File Packages, each of " +
+	"which can address 4 GiB"
+
 // WriteTo writes the full contents of this File to the Writer specified by w.
+// It returns a *wwise.OverflowError, and writes nothing, if the wems would not
+// fit within the 32 bit offsets and lengths of the File Package, and a
+// *wwise.VersionError if wems were replaced in a version that does not support
+// it.
 func (pck *File) WriteTo(w io.Writer) (written int64, err error) {
+	if pck.replaced {
+		if err := pck.CheckVersion(wwise.EditWems); err != nil {
+			return 0, err
+		}
+	}
+	if err := wwise.CheckOffsets(pck, overflowHint); err != nil {
+		return 0, err
+	}
 	written, err = pck.Header.WriteTo(w)
 	if err != nil {
 		return
@@ -119,38 +231,70 @@ func (pck *File) WriteTo(w io.Writer) (written int64, err error) {
 	}
 	written += int64(4)
 
-	for _, wem := range pck.wems {
-		n, err := io.Copy(w, wem)
-		if err != nil {
-			return written, err
-		}
-		written += int64(n)
-		n, err = io.Copy(w, wem.Padding)
-		if err != nil {
-			return written, err
-		}
-		written += int64(n)
-	}
-
-	return written, nil
+	n, err := wwise.WriteWems(w, pck.wems)
+	return written + n, err
 }
 
-// Open opens the File at the specified path using os.Open and prepares it for
-// use as a Wwise File Package file.
+// Open opens the File at the specified path using util.OpenMapped and prepares
+// it for use as a Wwise File Package file.
 func Open(path string) (*File, error) {
-	f, err := os.Open(path)
+	return OpenWithProgress(path, nil)
+}
+
+// OpenWithProgress is like Open, but reports its progress to progress, as
+// NewFileWithProgress does.
+func OpenWithProgress(path string, progress wwise.ProgressFunc) (*File, error) {
+	return open(context.Background(), path, progress, nil)
+}
+
+// OpenContext is like Open, but calls progress, if it is not nil, as each
+// entry of the File Package is parsed. Parsing stops once ctx is done, and the
+// error of ctx is returned.
+func OpenContext(ctx context.Context, path string,
+	progress wwise.OpenProgressFunc) (*File, error) {
+	return open(ctx, path, nil, progress)
+}
+
+// open opens the File at path, reporting its progress to both progress and
+// openProgress, if they are not nil, until ctx is done.
+func open(ctx context.Context, path string, progress wwise.ProgressFunc,
+	openProgress wwise.OpenProgressFunc) (*File, error) {
+	f, err := util.OpenMapped(path)
 	if err != nil {
 		return nil, err
 	}
-	pck, err := NewFile(f)
+	m := wwise.NewOpenMonitor(ctx, f.Size(), openProgress)
+	cache := util.NewIndexCache(path, f)
+	pck, err := newFile(cache, progress, m)
 	if err != nil {
 		f.Close()
 		return nil, err
 	}
+	// The cache is only a convenience, so failing to write it is not an error.
+	cache.Save()
 	pck.closer = f
 	return pck, nil
 }
 
+// An OpenResult is the outcome of opening a File Package with OpenAsync.
+type OpenResult struct {
+	File *File
+	Err  error
+}
+
+// OpenAsync opens the File Package at path in a new goroutine, as OpenContext
+// does, and returns a channel that receives the result once it is opened.
+// progress is called from that goroutine.
+func OpenAsync(ctx context.Context, path string,
+	progress wwise.OpenProgressFunc) <-chan OpenResult {
+	c := make(chan OpenResult, 1)
+	go func() {
+		pck, err := OpenContext(ctx, path, progress)
+		c <- OpenResult{pck, err}
+	}()
+	return c
+}
+
 // Close closes the File
 // If the File was created using NewFile directly instead of Open,
 // Close has no effect.
@@ -168,13 +312,61 @@ func (pck *File) Wems() []*wwise.Wem {
 }
 
 func (pck *File) ReplaceWems(rs ...*wwise.ReplacementWem) {
-	wwise.ReplaceWems(pck, 0, rs...)
+	pck.replaced = pck.replaced || len(rs) > 0
+	rs = wwise.SortReplacements(rs)
+	if pck.Mode == PadInPlace {
+		rs = pck.replaceInPlace(rs)
+	}
+	wwise.ReplaceWems(pck, pck.WemAlignment, rs...)
+}
+
+// CheckVersion returns a *wwise.VersionError if this File Package does not
+// support edit. Wems, including embedded SoundBanks, are only replaced in the
+// supported versions. Only EditWems depends on the version; loops are stored
+// within SoundBanks, so they are never edited within a File Package itself.
+func (pck *File) CheckVersion(edit string) error {
+	if edit == wwise.EditWems && !containsVersion(supportedVersions,
+		pck.Header.Version()) {
+		return &wwise.VersionError{"File Package", pck.Header.Version(), edit,
+			supportedVersions}
+	}
+	return nil
+}
+
+func containsVersion(versions []uint32, version uint32) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
 }
 
 func (pck *File) DataStart() uint32 {
 	return 0
 }
 
+func (pck *File) IndexOf(id uint32) int {
+	if i, ok := pck.indexOf[id]; ok {
+		return i
+	}
+	return -1
+}
+
+func (pck *File) WemById(id uint32) *wwise.Wem {
+	if i := pck.IndexOf(id); i >= 0 {
+		return pck.wems[i]
+	}
+	return nil
+}
+
+func (pck *File) DescriptorById(id uint32) *wwise.WemDescriptor {
+	if wem := pck.WemById(id); wem != nil {
+		return wem.Descriptor
+	}
+	return nil
+}
+
 func (pck *File) String() string {
 	b := new(strings.Builder)
 
@@ -204,6 +396,12 @@ func NewHeader(sr util.ReadSeekerAt) (*Header, error) {
 	return hdr, nil
 }
 
+// Version returns the version of the File Package format, which is stored in
+// the first 4 bytes following the header length.
+func (hdr *Header) Version() uint32 {
+	return binary.LittleEndian.Uint32(hdr.Unknown[:4])
+}
+
 func (hdr *Header) WriteTo(w io.Writer) (written int64, err error) {
 	err = binary.Write(w, binary.LittleEndian, hdr)
 	if err != nil {
@@ -285,18 +483,25 @@ func (idx *DataIndex) WriteTo(w io.Writer) (written int64, err error) {
 }
 
 func newWem(sr util.ReadSeekerAt, idx *DataIndex,
-	nextOffset uint32) (*wwise.Wem, error) {
+	nextOffset int64) (*wwise.Wem, error) {
 	startOffset, _ := sr.Seek(0, io.SeekCurrent)
 	desc := idx.Descriptor
-	if uint32(startOffset) != desc.Offset {
-		msg := fmt.Sprintf("Wem %d was expected to start at offset %d "+
-			"but instead started at offset %d", desc.WemId, desc.Offset, startOffset)
-		return nil, errors.New(msg)
+	if startOffset != int64(desc.Offset) {
+		return nil, &WemOffsetError{desc.WemId, int64(desc.Offset), startOffset}
 	}
 
-	wemReader := util.NewResettingReader(sr, startOffset, int64(desc.Length))
 	wemEndOffset := startOffset + int64(desc.Length)
-	remaining := int64(nextOffset) - wemEndOffset
+	if wemEndOffset > sr.Size() {
+		return nil, &wwise.BoundsError{fmt.Sprintf("Wem %d", desc.WemId),
+			wemEndOffset, sr.Size()}
+	}
+	remaining := nextOffset - wemEndOffset
+	if remaining < 0 {
+		return nil, fmt.Errorf("Wem %d overlaps the entry that follows it",
+			desc.WemId)
+	}
+
+	wemReader := util.NewResettingReader(sr, startOffset, int64(desc.Length))
 
 	padding := util.NewResettingReader(&util.InfiniteReaderAt{0}, 0, remaining)
 	sr.Seek(int64(desc.Length)+remaining, io.SeekCurrent)
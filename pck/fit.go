@@ -0,0 +1,110 @@
+package pck
+
+import (
+	"util"
+	"wwise"
+)
+
+// A ReplaceMode determines where the wems of a File Package are placed when
+// some of them are replaced.
+type ReplaceMode int
+
+const (
+	// ShiftOffsets resizes the space taken up by each replaced wem to fit its
+	// replacement, moving every wem that follows it.
+	ShiftOffsets ReplaceMode = iota
+	// PadInPlace keeps each replacement at the offset of the wem it replaces,
+	// padding it to the size of the wem and its original padding, so that no
+	// other wem is moved. A replacement that is larger than this space is placed
+	// as it would be with ShiftOffsets.
+	PadInPlace
+)
+
+// A Fit describes how a set of replacements would fit within a File Package.
+type Fit struct {
+	// The indexes of the wems whose replacements are larger than the space taken
+	// up by the wem and its padding.
+	Overflows []int
+	// The number of wems that would be moved to a different offset.
+	Moved int
+	// The largest distance, in bytes, that any wem would be moved.
+	LargestShift int64
+}
+
+// SlotSize returns the number of bytes taken up by the wem at index, including
+// its padding. This is the largest replacement that can be made for the wem
+// without moving the wems that follow it.
+func (pck *File) SlotSize(index int) int64 {
+	wem := pck.wems[index]
+	return int64(wem.Descriptor.Length) + wem.Padding.Size()
+}
+
+// CheckFit returns how the replacements in rs would fit within this File
+// Package if they were replaced with mode, without replacing them.
+func (pck *File) CheckFit(mode ReplaceMode,
+	rs ...*wwise.ReplacementWem) *Fit {
+	sorted := wwise.SortReplacements(rs)
+
+	fit := new(Fit)
+	shift := int64(0)
+	next := 0
+	for i, wem := range pck.wems {
+		if shift != 0 {
+			fit.Moved++
+			if abs(shift) > fit.LargestShift {
+				fit.LargestShift = abs(shift)
+			}
+		}
+		if next >= len(sorted) || sorted[next].WemIndex != i {
+			continue
+		}
+		r := sorted[next]
+		next++
+
+		oldLength, padding := int64(wem.Descriptor.Length), wem.Padding.Size()
+		if r.Length > oldLength+padding {
+			fit.Overflows = append(fit.Overflows, i)
+		} else if mode == PadInPlace {
+			continue
+		}
+		if r.Length == oldLength {
+			continue
+		}
+		// Mirror the padding computed by wwise.ReplaceWems.
+		newPadding := padding
+		if pck.WemAlignment != 0 {
+			offset := int64(wem.Descriptor.Offset) + shift
+			newPadding = (pck.WemAlignment -
+				(offset+r.Length)%pck.WemAlignment) % pck.WemAlignment
+		}
+		shift += (r.Length - oldLength) + (newPadding - padding)
+	}
+	return fit
+}
+
+// replaceInPlace replaces the wems of rs that fit within the space taken up by
+// the wem they replace, returning the replacements that do not.
+func (pck *File) replaceInPlace(
+	rs []*wwise.ReplacementWem) []*wwise.ReplacementWem {
+	var overflows []*wwise.ReplacementWem
+	for _, r := range rs {
+		slot := pck.SlotSize(r.WemIndex)
+		if r.Length > slot {
+			overflows = append(overflows, r)
+			continue
+		}
+		wem := pck.wems[r.WemIndex]
+		wem.Reader = util.NewResettingReader(r.Wem, 0, r.Length)
+		wem.Descriptor.Length = uint32(r.Length)
+		wem.Padding =
+			util.NewResettingReader(&util.InfiniteReaderAt{0}, 0, slot-r.Length)
+	}
+	return overflows
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
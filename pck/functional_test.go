@@ -4,9 +4,18 @@ package pck
 // Large system tests for the bnk package.
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 import (
@@ -116,3 +125,816 @@ func rereadFile(t *testing.T, org *File) *File {
 
 	return ctn
 }
+
+func TestInjectedSoundBankCanBeExtracted(t *testing.T) {
+	util.SkipIfShort(t)
+
+	org, err := Open(filepath.Join(testDir, simpleFilePackage))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	defer org.Close()
+	if indexes := org.SoundBankIndexes(); len(indexes) != 0 {
+		t.Errorf("Expected no embedded SoundBanks, found %v", indexes)
+	}
+
+	bankPath := filepath.Join("..", "bnk", testDir, "simple.bnk")
+	bank, err := os.Open(bankPath)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	defer bank.Close()
+	stat, err := bank.Stat()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	err = org.ReplaceSoundBank(1, bank, stat.Size())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var b bytes.Buffer
+	_, err = org.WriteTo(&b)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	pck, err := NewFile(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	indexes := pck.SoundBankIndexes()
+	if len(indexes) != 1 || indexes[0] != 1 {
+		t.Errorf("Expected the SoundBank to be embedded at index 1, found %v",
+			indexes)
+		t.FailNow()
+	}
+	embedded, err := pck.SoundBank(1)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(embedded.Wems()) != 1 {
+		t.Errorf("Expected the embedded SoundBank to have 1 wem, found %d",
+			len(embedded.Wems()))
+	}
+}
+
+func TestOpenWithProgressCanBeCancelled(t *testing.T) {
+	path := filepath.Join(testDir, simpleFilePackage)
+	var last, total int64
+	pck, err := OpenWithProgress(path, func(done, n int64) bool {
+		last, total = done, n
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pck.Close()
+	if last != total || total != 2*int64(len(pck.Wems())) {
+		t.Errorf("Progress ended at %d of %d, want %d of %d", last, total,
+			2*len(pck.Wems()), 2*len(pck.Wems()))
+	}
+
+	_, err = OpenWithProgress(path, func(done, total int64) bool {
+		return false
+	})
+	if err != wwise.ErrCancelled {
+		t.Errorf("Expected %v when cancelled, got %v", wwise.ErrCancelled, err)
+	}
+}
+
+func TestWriteWithProgressIsEqual(t *testing.T) {
+	util.SkipIfShort(t)
+
+	pck, err := Open(filepath.Join(testDir, simpleFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pck.Close()
+	want := new(bytes.Buffer)
+	if _, err := pck.WriteTo(want); err != nil {
+		t.Fatal(err)
+	}
+
+	got := new(bytes.Buffer)
+	var last, total int64
+	_, err = wwise.WriteWithProgress(pck, got, func(done, n int64) bool {
+		last, total = done, n
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Error("Writing with progress did not produce the same File Package")
+	}
+	if last != total || total != int64(want.Len()) {
+		t.Errorf("Progress ended at %d of %d, want %d of %d", last, total,
+			want.Len(), want.Len())
+	}
+}
+
+func TestReplaceWemsPadInPlace(t *testing.T) {
+	pck, err := Open(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pck.Close()
+	var offsets []uint32
+	for _, wem := range pck.Wems() {
+		offsets = append(offsets, wem.Descriptor.Offset)
+	}
+
+	slot := pck.SlotSize(0)
+	smaller := &wwise.ReplacementWem{bytes.NewReader(make([]byte, slot/2)), 0,
+		slot / 2}
+	larger := &wwise.ReplacementWem{bytes.NewReader(make([]byte, slot+1)), 0,
+		slot + 1}
+	if fit := pck.CheckFit(ShiftOffsets, smaller); fit.Moved == 0 {
+		t.Error("Expected a smaller replacement to move the wems that follow it")
+	}
+	if fit := pck.CheckFit(PadInPlace, smaller); fit.Moved != 0 ||
+		len(fit.Overflows) != 0 {
+		t.Errorf("Expected a smaller replacement to fit in place, got %+v", fit)
+	}
+	fit := pck.CheckFit(PadInPlace, larger)
+	if len(fit.Overflows) != 1 || fit.Moved != len(pck.Wems())-1 {
+		t.Errorf("Expected a larger replacement to overflow, got %+v", fit)
+	}
+
+	pck.Mode = PadInPlace
+	pck.ReplaceWems(smaller)
+	reread := rereadFile(t, pck)
+	for i, wem := range reread.Wems() {
+		if wem.Descriptor.Offset != offsets[i] {
+			t.Errorf("Wem %d moved from offset %d to %d", i+1, offsets[i],
+				wem.Descriptor.Offset)
+		}
+	}
+	if got := reread.Wems()[0].Descriptor.Length; int64(got) != slot/2 {
+		t.Errorf("Expected the replaced wem to be %d bytes, got %d", slot/2, got)
+	}
+}
+
+func TestUnchangedWriteToFileIsEqual(t *testing.T) {
+	util.SkipIfShort(t)
+
+	path := filepath.Join(testDir, complexFilePackage)
+	pck, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pck.Close()
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The wems of an unchanged File Package are copied directly from its file.
+	f, err := ioutil.TempFile("", "wwiseutil-*.pck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	n, err := pck.WriteTo(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(want)) || !bytes.Equal(got, want) {
+		t.Error("Writing an unchanged File Package to a file did not produce " +
+			"the same File Package")
+	}
+}
+
+func BenchmarkWriteTo(b *testing.B) {
+	pck, err := Open(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pck.Close()
+	// Hide the ReadFrom method of ioutil.Discard, so that the copy buffer is
+	// used.
+	w := struct{ io.Writer }{ioutil.Discard}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := pck.WriteTo(w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestWriteInPlace(t *testing.T) {
+	original, err := ioutil.ReadFile(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ioutil.TempFile("", "wwiseutil-*.pck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	for _, mode := range []ReplaceMode{PadInPlace, ShiftOffsets} {
+		if err := ioutil.WriteFile(f.Name(), original, 0644); err != nil {
+			t.Fatal(err)
+		}
+		pck, err := Open(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pck.Mode = mode
+		slot := pck.SlotSize(0)
+		pck.ReplaceWems(&wwise.ReplacementWem{util.NewConstantReader(slot / 2),
+			0, slot / 2})
+		want := new(bytes.Buffer)
+		if _, err := pck.WriteTo(want); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := os.OpenFile(f.Name(), os.O_RDWR, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total, rewritten, err := wwise.WriteInPlace(pck, out, nil)
+		out.Close()
+		pck.Close()
+		got, rerr := ioutil.ReadFile(f.Name())
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+
+		if mode == ShiftOffsets {
+			// The wems that follow the replacement would be moved.
+			if err != wwise.ErrNotInPlace || !bytes.Equal(got, original) {
+				t.Errorf("Expected ErrNotInPlace and an unchanged file, got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != int64(want.Len()) || !bytes.Equal(got, want.Bytes()) {
+			t.Error("Writing in place did not produce the same File Package")
+		}
+		// Everything before the first wem is the header and the index.
+		if rewritten == 0 ||
+			rewritten > slot+int64(pck.Indexes[0].Descriptor.Offset) {
+			t.Errorf("Expected only the replaced wem and the index to be "+
+				"rewritten, but %d of %d bytes were", rewritten, total)
+		}
+	}
+}
+
+func TestIndexIsCachedBetweenOpens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wwiseutil-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cacheDir, minSize := util.IndexCacheDir, util.MinIndexCacheSize
+	util.IndexCacheDir, util.MinIndexCacheSize = filepath.Join(dir, "cache"), 0
+	defer func() {
+		util.IndexCacheDir, util.MinIndexCacheSize = cacheDir, minSize
+	}()
+
+	original, err := ioutil.ReadFile(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, complexFilePackage)
+	if err := ioutil.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Some platforms, such as js/wasm, only set modification times to the
+	// second, so the file is given one that can be set again exactly.
+	whole := time.Now().Truncate(time.Second)
+	if err := os.Chtimes(path, whole, whole); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		pck, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := new(bytes.Buffer)
+		if _, err := pck.WriteTo(got); err != nil {
+			t.Fatal(err)
+		}
+		pck.Close()
+		if !bytes.Equal(got.Bytes(), original) {
+			t.Errorf("Writing a File Package opened %d times was not equal", i+1)
+		}
+	}
+	caches, err := filepath.Glob(filepath.Join(util.IndexCacheDir, "*.cache"))
+	if err != nil || len(caches) != 1 {
+		t.Fatalf("Expected the index to be cached once, found %v", caches)
+	}
+
+	// Rename the "sfx" language of the file to "Sfx", without changing its size
+	// or modification time; the cache no longer applies, as the header changed.
+	renamed := append([]byte(nil), original...)
+	if !bytes.Equal(renamed[0x28:0x2e], []byte("s\x00f\x00x\x00")) {
+		t.Fatal("Expected the first language of the File Package to be sfx")
+	}
+	renamed[0x28] = 'S'
+	if err := ioutil.WriteFile(path, renamed, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, whole, whole); err != nil {
+		t.Fatal(err)
+	}
+	pck, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pck.Close()
+	got := new(bytes.Buffer)
+	if _, err := pck.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), renamed) {
+		t.Error("Expected a modified file not to be read from the cache")
+	}
+}
+
+func TestExporterWritesEveryWem(t *testing.T) {
+	pck, err := Open(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pck.Close()
+	dir, err := ioutil.TempDir("", "wwiseutil-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wems := pck.Wems()
+	paths := make([]string, len(wems))
+	for i := range wems {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d.wem", i))
+	}
+	exporter := &wwise.Exporter{Workers: 4}
+	last := int64(0)
+	res, err := exporter.Run(wems, paths, func(done, total int64) bool {
+		if done != last+1 || total != int64(len(wems)) {
+			t.Errorf("Expected progress %d of %d, got %d of %d", last+1,
+				len(wems), done, total)
+		}
+		last = done
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Exported != len(wems) || len(res.Errors) != 0 {
+		t.Errorf("Expected %d wems to be exported, got %d with %d error(s)",
+			len(wems), res.Exported, len(res.Errors))
+	}
+	written := int64(0)
+	for i, wem := range wems {
+		want, err := ioutil.ReadAll(wem.NewReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(paths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("The exported file of wem %d was not equal", i)
+		}
+		written += int64(len(want))
+	}
+	if res.Written != written {
+		t.Errorf("Expected %d bytes to be written, got %d", written, res.Written)
+	}
+
+	// Stopping after the first error leaves the remaining wems unexported.
+	exporter = &wwise.Exporter{Workers: 1, StopOnError: true}
+	paths[0] = filepath.Join(dir, "missing", "0.wem")
+	res, err = exporter.Run(wems, paths, nil)
+	if xerr, ok := err.(*wwise.ExportError); !ok || xerr.Index != 0 {
+		t.Errorf("Expected an ExportError for wem 0, got %v", err)
+	}
+	// The next wem may already have been handed to the worker.
+	if res.Exported > 1 {
+		t.Errorf("Expected the export to stop after the first error, but %d "+
+			"wems were exported", res.Exported)
+	}
+}
+
+func TestLargeReplacementIsStreamed(t *testing.T) {
+	util.SkipIfShort(t)
+	pck, err := Open(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pck.Close()
+
+	// A sparse file of several gigabytes, which takes no space on disk.
+	const size = 3 << 30
+	f, err := ioutil.TempFile("", "wwiseutil-*.wem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	pck.ReplaceWems(&wwise.ReplacementWem{f, 0, size})
+	wwise.AssertWriteIsStreamed(t, pck, size)
+}
+
+func TestOpenAsyncReportsProgress(t *testing.T) {
+	path := filepath.Join(testDir, complexFilePackage)
+	var last wwise.OpenProgress
+	res := <-OpenAsync(context.Background(), path,
+		func(p wwise.OpenProgress) {
+			last = p
+		})
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	defer res.File.Close()
+	// The header, the index and the entries.
+	if last.Parsed != last.Size || last.Sections != 3 {
+		t.Errorf("Expected %d bytes and 3 sections to be parsed, got %+v",
+			last.Size, last)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := OpenContext(ctx, path, nil); err != context.Canceled {
+		t.Errorf("Expected opening to be cancelled, got %v", err)
+	}
+}
+
+func TestOffsetsPastFourGiB(t *testing.T) {
+	util.SkipIfShort(t)
+	f, err := ioutil.TempFile("", "wwiseutil-*.pck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// A sparse File Package whose last wem starts just before 4 GiB, and ends
+	// past it.
+	const (
+		firstOffset  = HEADER_BYTES + 2*(DATA_INDEX_BYTES+4) + 4
+		firstLength  = 16
+		lastOffset   = 1<<32 - 256
+		lastLength   = 4096
+		packageBytes = lastOffset + lastLength
+	)
+	hdr := &Header{Identifier: [4]byte{'A', 'K', 'P', 'K'}, WemCount: 2,
+		Length: firstOffset - 8}
+	binary.LittleEndian.PutUint32(hdr.Unknown[:4], 1)
+	buf := new(bytes.Buffer)
+	hdr.WriteTo(buf)
+	for _, desc := range []*wwise.WemDescriptor{
+		{1, firstOffset, firstLength},
+		{2, lastOffset, lastLength},
+	} {
+		idx := &DataIndex{1, desc, 0}
+		idx.WriteTo(buf)
+	}
+	buf.Write(make([]byte, 4))
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	marker := []byte("the last wem")
+	if _, err := f.WriteAt(marker, lastOffset); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(packageBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	pck, err := NewFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wems := pck.Wems()
+	if size := wems[0].Padding.Size(); size != lastOffset-firstOffset-firstLength {
+		t.Errorf("Expected the first wem to be padded up to the last, but its "+
+			"padding is %d bytes", size)
+	}
+	if size := wems[1].Padding.Size(); size != 0 {
+		t.Errorf("Expected the last wem not to be padded, got %d bytes", size)
+	}
+	got := make([]byte, len(marker))
+	if _, err := io.ReadFull(wems[1].NewReader(), got); err != nil ||
+		!bytes.Equal(got, marker) {
+		t.Errorf("Expected the last wem to be read from past 4 GiB, got %q, %v",
+			got, err)
+	}
+	n, err := pck.WriteTo(ioutil.Discard)
+	if err != nil || n != packageBytes {
+		t.Errorf("Expected %d bytes to be written, got %d, %v", packageBytes, n,
+			err)
+	}
+
+	// A larger first wem would move the last past the offsets that can be
+	// addressed, which must be reported rather than overflow.
+	pck.ReplaceWems(&wwise.ReplacementWem{util.NewConstantReader(1024), 0,
+		1024})
+	_, err = pck.WriteTo(ioutil.Discard)
+	if oe, ok := err.(*wwise.OverflowError); !ok ||
+		oe.What != "The offset of wem 2" || oe.Value != lastOffset+1024-firstLength {
+		t.Errorf("Expected an OverflowError for a wem starting past 4 GiB, got %v",
+			err)
+	}
+}
+
+func TestWemsCanBeFoundById(t *testing.T) {
+	ctn, err := Open(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctn.Close()
+	wwise.AssertLookupsMatchWems(t, ctn)
+}
+
+func TestStagedReplacementIsReadWhenWritten(t *testing.T) {
+	org, err := Open(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer org.Close()
+	f, err := ioutil.TempFile("", "wwiseutil-*.wem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(bytes.Repeat([]byte{1}, 100))
+	f.Close()
+
+	lf, err := util.OpenLazy(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	org.ReplaceWems(&wwise.ReplacementWem{lf, 0, lf.Size()})
+	// The replacement is only read once the File Package is written, so changes
+	// made to it after it is staged are written.
+	want := bytes.Repeat([]byte{2}, 100)
+	if err := ioutil.WriteFile(f.Name(), want, 0644); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := org.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	written, err := NewFile(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(written.Wems()[0].NewReader())
+	if !bytes.Equal(got, want) {
+		t.Error("Expected the replacement to be read when the File Package was " +
+			"written")
+	}
+
+	// A replacement whose size changed after it was staged is not written.
+	if err := ioutil.WriteFile(f.Name(), want[:50], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := org.WriteTo(ioutil.Discard); err == nil {
+		t.Error("Expected an error for a replacement that changed size")
+	}
+}
+
+func TestPipedFilePackageSpillsToDisk(t *testing.T) {
+	defer func(threshold int64) { util.SpillThreshold = threshold }(
+		util.SpillThreshold)
+	util.SpillThreshold = 4096
+
+	f, err := os.Open(filepath.Join(testDir, complexFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Hide the random access methods of the file, as if it were a pipe.
+	r, err := util.NewSeekableReaderAt(struct{ io.Reader }{f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := r.(*util.SpillBuffer)
+	if !ok || !b.Spilled() {
+		t.Fatal("Expected a File Package larger than the threshold to be " +
+			"spilled to a temporary file")
+	}
+	defer b.Close()
+
+	pck, err := NewFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	wwise.AssertContainerEqualToFile(t, f, pck)
+}
+
+// FuzzNewFile checks that no File Package, however malformed, makes the parser
+// panic, or read or write more than the File Package holds. The corpus in
+// testdata/fuzz holds File Packages crafted to trip each of the bounds checks.
+func FuzzNewFile(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pck, err := NewFile(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		pck.Warnings()
+		n, err := pck.WriteTo(ioutil.Discard)
+		if err == nil && n > int64(len(data)) {
+			t.Errorf("Wrote %d bytes of a %d byte File Package", n, len(data))
+		}
+	})
+}
+
+func TestPlaceholderEntries(t *testing.T) {
+	le := func(vs ...uint32) []byte {
+		b := new(bytes.Buffer)
+		binary.Write(b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+	// Three entries, the second of which is a placeholder with an offset of 0.
+	start := uint32(HEADER_BYTES + 3*(DATA_INDEX_BYTES+4) + 4)
+	var data []byte
+	data = append(data, "AKPK"...)
+	data = append(data, le(0, 1)...)
+	data = append(data, make([]byte, 40)...)
+	data = append(data, le(3)...)
+	data = append(data, le(1, 0, 4, start, 0)...)
+	data = append(data, le(2, 0, 0, 0, 0)...)
+	data = append(data, le(3, 0, 4, start+4, 0)...)
+	data = append(data, le(0)...)
+	data = append(data, "AAAABBBB"...)
+
+	pck, err := NewFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := wwise.WemDescriptor{2, start + 4, 0}
+	if got := *pck.Wems()[1].Descriptor; got != want {
+		t.Errorf("Expected the placeholder to be %v but got %v", want, got)
+	}
+	if len(pck.Warnings()) != 1 {
+		t.Errorf("Expected a warning about the moved placeholder but got: %v",
+			pck.Warnings())
+	}
+
+	pck.ReplaceWems(&wwise.ReplacementWem{bytes.NewReader([]byte("CC")), 1, 2})
+	got := new(bytes.Buffer)
+	if _, err := pck.WriteTo(got); err != nil {
+		t.Fatal(err)
+	}
+	written, err := NewFile(bytes.NewReader(got.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := []string{"AAAA", "CC", "BBBB"}
+	for i, wem := range written.Wems() {
+		b, err := ioutil.ReadAll(wem.NewReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != contents[i] {
+			t.Errorf("Expected written entry %d to hold %q but got %q", i,
+				contents[i], b)
+		}
+	}
+}
+
+func TestWritesAreReproducible(t *testing.T) {
+	// The SHA-256 of the simple File Package with its second entry copied over
+	// its first, as written with each ReplaceMode.
+	golden := map[ReplaceMode]string{
+		ShiftOffsets: "102089c2f8d9ac11c84f61a3119d32511d888b838f24326139a3b13bab15d384",
+		PadInPlace:   "3052badad93758da3ab171a241d9551b1b2da12a3817c815cba2ac4b33cc8fad",
+	}
+	for mode, want := range golden {
+		var sums []string
+		for i := 0; i < 2; i++ {
+			pck, err := Open(filepath.Join(testDir, simpleFilePackage))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pck.Close()
+			pck.Mode = mode
+			wem, err := ioutil.ReadAll(pck.Wems()[1].NewReader())
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &wwise.ReplacementWem{bytes.NewReader(wem), 0, int64(len(wem))}
+			earlier := &wwise.ReplacementWem{bytes.NewReader(nil), 0, 0}
+			if i == 0 {
+				pck.ReplaceWems(r)
+			} else {
+				// A replacement that is overridden leaves no trace.
+				pck.ReplaceWems(earlier, r)
+			}
+
+			h := sha256.New()
+			if _, err := pck.WriteTo(h); err != nil {
+				t.Fatal(err)
+			}
+			sums = append(sums, hex.EncodeToString(h.Sum(nil)))
+
+			f, err := ioutil.TempFile("", "wwiseutil-*.pck")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			_, err = pck.WriteTo(f)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum := sha256.Sum256(b)
+			sums = append(sums, hex.EncodeToString(sum[:]))
+		}
+		for _, sum := range sums {
+			if sum != want {
+				t.Errorf("Expected the written File Package to have the SHA-256 %s "+
+					"with mode %d, but got %s", want, mode, sum)
+			}
+		}
+	}
+}
+
+func TestFilePackageWithoutWems(t *testing.T) {
+	hdr := &Header{Identifier: [4]byte{'A', 'K', 'P', 'K'}}
+	binary.LittleEndian.PutUint32(hdr.Unknown[:4], 1)
+	b := new(bytes.Buffer)
+	binary.Write(b, binary.LittleEndian, hdr)
+	// The padding that follows the index.
+	binary.Write(b, binary.LittleEndian, uint32(0))
+	data := b.Bytes()
+
+	pck, err := NewFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pck.Wems()) != 0 {
+		t.Errorf("Expected no wems but got %d", len(pck.Wems()))
+	}
+	_ = pck.String()
+	pck.ReplaceWems()
+	for _, mode := range []ReplaceMode{ShiftOffsets, PadInPlace} {
+		pck.Mode = mode
+		got := new(bytes.Buffer)
+		if _, err := pck.WriteTo(got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), data) {
+			t.Errorf("The written File Package differs from the one that was read "+
+				"with mode %d", mode)
+		}
+	}
+}
+
+func TestUnsupportedVersionIsReadOnly(t *testing.T) {
+	pck, err := Open(filepath.Join(testDir, simpleFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pck.Close()
+	binary.LittleEndian.PutUint32(pck.Header.Unknown[:4], 2)
+
+	// A File Package of an unsupported version can still be written unchanged.
+	if _, err := pck.WriteTo(ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	var verr *wwise.VersionError
+	if err := pck.CheckVersion(wwise.EditWems); !errors.As(err, &verr) ||
+		verr.Version != 2 {
+		t.Errorf("Expected a VersionError for version 2 but got: %v", err)
+	}
+
+	data := bytes.Repeat([]byte{0xAB}, 16)
+	pck.ReplaceWems(&wwise.ReplacementWem{bytes.NewReader(data), 0, 16})
+	n, err := pck.WriteTo(ioutil.Discard)
+	if !errors.As(err, &verr) || n != 0 {
+		t.Errorf("Expected a VersionError and nothing written but got %d bytes "+
+			"and: %v", n, err)
+	}
+}
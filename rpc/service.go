@@ -0,0 +1,528 @@
+// Package rpc implements the gRPC API of wwiseutil, defined by wwiseutil.proto,
+// so that tools written in any language can open, edit and save containers
+// through a typed contract rather than through the command line tool.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+import (
+	"bnk"
+	"convert"
+	"pck"
+	"session"
+	"util"
+	"wwise"
+)
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The largest number of bytes sent in a single Chunk, well below the limit on
+// the size of the messages that gRPC receives by default.
+const chunkBytes = 256 << 10
+
+// A Service implements the Containers service. Each opened container is kept
+// in a session, keyed by a token, until it is closed.
+//
+// As the service reads and writes files, every request must give the key of
+// the Service as "authorization: Bearer <key>" metadata, which the
+// interceptors of ServerOptions check; a grpc.Server that serves the Service
+// must be created with them. The paths that clients give are resolved within
+// Root.
+type Service struct {
+	UnimplementedContainersServer
+
+	// The secret that every request must give. NewService sets it to a random
+	// key; no key is required if it is empty.
+	Key string
+	// The directory that the paths given by clients, to open and save to, are
+	// resolved relative to, and must be within, after following symbolic
+	// links. Clients cannot give paths if it is empty.
+	Root string
+	// OpenFile opens the container at a path given by a client, stopping once
+	// ctx is done. If it is nil, a SoundBank or File Package is opened by the
+	// extension of the path.
+	OpenFile wwise.OpenFunc
+	// The converter used to send wems in other audio formats. Wems are only
+	// sent as they are if it is nil.
+	Converter *convert.Converter
+
+	sessions *session.Store
+}
+
+// NewService creates a Service with no open containers and a random key, that
+// accepts no paths from clients until its Root is set, opens containers from
+// paths by their extension and sends wems only as they are.
+func NewService() (*Service, error) {
+	key, err := session.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	return &Service{Key: key, sessions: session.NewStore()}, nil
+}
+
+// ServerOptions returns the options that a grpc.Server serving s must be
+// created with, which refuse every request that does not give the key of s.
+func (s *Service) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryInterceptor),
+		grpc.StreamInterceptor(s.streamInterceptor),
+	}
+}
+
+func (s *Service) unaryInterceptor(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Service) streamInterceptor(srv interface{}, stream grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// authorize returns an Unauthenticated error unless the metadata of ctx gives
+// the key of s.
+func (s *Service) authorize(ctx context.Context) error {
+	key := ""
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if strings.HasPrefix(auth, "Bearer ") {
+			key = strings.TrimPrefix(auth, "Bearer ")
+		}
+	}
+	if !session.CheckKey(key, s.Key) {
+		return status.Error(codes.Unauthenticated, "The key of this server is "+
+			"required, as authorization: Bearer metadata")
+	}
+	return nil
+}
+
+// resolve returns path, given by a client, resolved relative to the root of s,
+// or a PermissionDenied error if it is not within the root once symbolic links
+// are followed. An empty path is returned as it is, for the method to report.
+func (s *Service) resolve(path string) (string, error) {
+	path, err := session.Resolve(s.Root, path)
+	var rerr *session.RootError
+	switch {
+	case errors.As(err, &rerr):
+		return "", status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return "", status.Error(codes.Internal, err.Error())
+	}
+	return path, nil
+}
+
+// CloseAll closes every open container.
+func (s *Service) CloseAll() {
+	s.sessions.CloseAll()
+}
+
+// session returns the locked session of token, or a NotFound error if there is
+// no such session.
+func (s *Service) session(token string) (*session.Session, error) {
+	sess := s.sessions.Lock(token)
+	if sess == nil {
+		return nil, status.Errorf(codes.NotFound, "There is no open "+
+			"container with token %s", token)
+	}
+	return sess, nil
+}
+
+// wemIndex returns the index, starting at 0, of the wem at index within the
+// container of sess, or a NotFound error if there is no such wem.
+func wemIndex(sess *session.Session, index int32) (int, error) {
+	count := len(sess.Container.Wems())
+	if index < 1 || int(index) > count {
+		return 0, status.Errorf(codes.NotFound, "%d is not a valid wem index; "+
+			"the valid index range is %d to %d", index, 1, count)
+	}
+	return int(index) - 1, nil
+}
+
+// add keeps sess open, returning the response that gives its token.
+func (s *Service) add(sess *session.Session) (*OpenResponse, error) {
+	token, err := s.sessions.Add(sess)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &OpenResponse{Token: token,
+		Wems: int32(len(sess.Container.Wems()))}, nil
+}
+
+func (s *Service) Open(ctx context.Context,
+	req *OpenRequest) (*OpenResponse, error) {
+	path, err := s.resolve(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, status.Error(codes.InvalidArgument, "A path to open is "+
+			"required")
+	}
+	open := s.OpenFile
+	if open == nil {
+		open = session.OpenFile
+	}
+	ctn, err := open(ctx, path)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Could not parse .bnk "+
+			"or .pck file: %s", err)
+	}
+	return s.add(session.New(ctn, path))
+}
+
+// Upload opens the container sent by the client. It is buffered, spilling to a
+// temporary file if it is large, for as long as the session is open.
+func (s *Service) Upload(stream Containers_UploadServer) error {
+	b := util.NewSpillBuffer()
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			_, err = b.Write(chunk.Data)
+		}
+		if err != nil {
+			b.Close()
+			return err
+		}
+	}
+
+	sess, err := session.Parse(b)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Could not parse .bnk or "+
+			".pck file: %s", err)
+	}
+	res, err := s.add(sess)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(res)
+}
+
+func (s *Service) GetInfo(ctx context.Context,
+	req *ContainerRequest) (*ContainerInfo, error) {
+	sess, err := s.session(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Unlock()
+
+	info := &ContainerInfo{Wems: int32(len(sess.Container.Wems())),
+		Unsupported: make(map[string]string)}
+	switch ctn := sess.Container.(type) {
+	case *bnk.File:
+		info.Type, info.Version = "SoundBank", ctn.Version()
+		info.Loopable = int32(ctn.LoopableWemCount())
+	case *pck.File:
+		info.Type, info.Version = "File Package", ctn.Header.Version()
+		info.Unsupported[wwise.EditLoops] = "Loops are only edited within " +
+			"SoundBanks"
+	}
+	for _, edit := range []string{wwise.EditWems, wwise.EditLoops} {
+		if err := sess.Container.CheckVersion(edit); err != nil {
+			info.Unsupported[edit] = err.Error()
+		}
+	}
+	return info, nil
+}
+
+func (s *Service) ListWems(ctx context.Context,
+	req *ContainerRequest) (*ListWemsResponse, error) {
+	sess, err := s.session(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Unlock()
+
+	b, _ := sess.Container.(*bnk.File)
+	res := new(ListWemsResponse)
+	for i, wem := range sess.Container.Wems() {
+		desc := wem.Descriptor
+		w := &Wem{Index: int32(i + 1), Id: desc.WemId, Offset: desc.Offset,
+			Length: desc.Length, Replaced: sess.Replaced[i]}
+		if b != nil && b.CanLoop(i) {
+			w.Loop = newLoop(b.LoopOf(i))
+		}
+		res.Wems = append(res.Wems, w)
+	}
+	return res, nil
+}
+
+// DownloadWem sends a wem, converted to the requested audio format if one is
+// given.
+func (s *Service) DownloadWem(req *DownloadWemRequest,
+	stream Containers_DownloadWemServer) error {
+	sess, err := s.session(req.Token)
+	if err != nil {
+		return err
+	}
+	defer sess.Unlock()
+	index, err := wemIndex(sess, req.Index)
+	if err != nil {
+		return err
+	}
+
+	wem := sess.Container.Wems()[index]
+	w := &chunkWriter{stream}
+	if req.Format == "" {
+		_, err := util.Copy(w, wem.NewReader())
+		return err
+	}
+	to := convert.ParseFormat(req.Format)
+	if to == convert.UnknownFormat {
+		return status.Errorf(codes.InvalidArgument, "%s is not a supported "+
+			"audio format; use ogg or wav", req.Format)
+	}
+	if s.Converter == nil {
+		return status.Error(codes.Unimplemented, "This server does not convert "+
+			"wems")
+	}
+	format, err := wem.Format()
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Could not read the "+
+			"format of wem %d: %s", req.Index, err)
+	}
+	path, release, err := s.Converter.Converted(wem, format, to)
+	if err != nil {
+		return status.Errorf(codes.Internal, "Could not convert wem %d: %s",
+			req.Index, err)
+	}
+	defer release()
+	f, err := os.Open(path)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer f.Close()
+	_, err = util.Copy(w, f)
+	return err
+}
+
+// ReplaceWem replaces a wem with the data sent by the client. The data is
+// buffered, spilling to a temporary file if it is large, for as long as the
+// session is open. The session is only locked once every request is received.
+func (s *Service) ReplaceWem(stream Containers_ReplaceWemServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "No replacement was sent")
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.checkReplace(first); err != nil {
+		return err
+	}
+
+	b := util.NewSpillBuffer()
+	_, err = b.Write(first.Data)
+	for err == nil {
+		var req *ReplaceWemRequest
+		req, err = stream.Recv()
+		if err == nil {
+			_, err = b.Write(req.Data)
+		}
+	}
+	if err != io.EOF {
+		b.Close()
+		return err
+	}
+	if b.Size() == 0 {
+		b.Close()
+		return status.Error(codes.InvalidArgument, "The replacement wem is empty")
+	}
+
+	sess, err := s.session(first.Token)
+	if err != nil {
+		b.Close()
+		return err
+	}
+	defer sess.Unlock()
+	index, _ := wemIndex(sess, first.Index)
+	sess.Keep(b)
+	sess.Replace(index, b, b.Size())
+	return stream.SendAndClose(&ReplaceWemResponse{Length: b.Size()})
+}
+
+// checkReplace returns an error if the wem named by req cannot be replaced.
+func (s *Service) checkReplace(req *ReplaceWemRequest) error {
+	sess, err := s.session(req.Token)
+	if err != nil {
+		return err
+	}
+	defer sess.Unlock()
+	if _, err := wemIndex(sess, req.Index); err != nil {
+		return err
+	}
+	if err := sess.Container.CheckVersion(wwise.EditWems); err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return nil
+}
+
+// soundBank returns the container of sess if it is a SoundBank, or a
+// FailedPrecondition error if it is not, as only SoundBanks hold loops.
+func soundBank(sess *session.Session) (*bnk.File, error) {
+	b, ok := sess.Container.(*bnk.File)
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, "Loops are only "+
+			"edited within SoundBanks")
+	}
+	return b, nil
+}
+
+func (s *Service) GetLoop(ctx context.Context, req *WemRequest) (*Loop, error) {
+	sess, err := s.session(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Unlock()
+	index, err := wemIndex(sess, req.Index)
+	if err != nil {
+		return nil, err
+	}
+	b, err := soundBank(sess)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.CheckLoop(index); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return newLoop(b.LoopOf(index)), nil
+}
+
+// SetLoop sets the loop of a wem, returning the loop that it then has.
+func (s *Service) SetLoop(ctx context.Context,
+	req *SetLoopRequest) (*Loop, error) {
+	sess, err := s.session(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Unlock()
+	index, err := wemIndex(sess, req.Index)
+	if err != nil {
+		return nil, err
+	}
+	b, err := soundBank(sess)
+	if err != nil {
+		return nil, err
+	}
+	if req.Loop == nil {
+		return nil, status.Error(codes.InvalidArgument, "A loop is required")
+	}
+	loop := bnk.LoopValue{Loops: req.Loop.Loops}
+	if loop.Loops {
+		loop.Value = req.Loop.Value
+	}
+	if err := b.ReplaceLoopOf(index, loop); err != nil {
+		var lerr *bnk.LoopError
+		if errors.As(err, &lerr) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newLoop(b.LoopOf(index)), nil
+}
+
+func newLoop(loop bnk.LoopValue) *Loop {
+	return &Loop{Loops: loop.Loops, Value: loop.Value}
+}
+
+// Download sends the container, with every edit applied.
+func (s *Service) Download(req *ContainerRequest,
+	stream Containers_DownloadServer) error {
+	sess, err := s.session(req.Token)
+	if err != nil {
+		return err
+	}
+	defer sess.Unlock()
+	_, err = sess.Container.WriteTo(&chunkWriter{stream})
+	return err
+}
+
+// Save writes the container to a path within the root. An existing file is
+// overwritten, once it is backed up if it is an original file of the game, and
+// the written file is verified. The container cannot be saved over the file it
+// was opened from, as that file is still being read from.
+func (s *Service) Save(ctx context.Context,
+	req *SaveRequest) (*SaveResponse, error) {
+	path, err := s.resolve(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := s.session(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Unlock()
+	if path == "" {
+		return nil, status.Error(codes.InvalidArgument, "A path to save to is "+
+			"required")
+	}
+	saved, err := sess.Save(ctx, path, true)
+	var verr *wwise.VerifyError
+	switch {
+	case err == session.ErrSaveOverSource:
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case errors.As(err, &verr):
+		return nil, status.Error(codes.Internal, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "Could not write output "+
+			"file: %s", err)
+	}
+	return &SaveResponse{Path: path, Bytes: saved.Bytes}, nil
+}
+
+// Close closes a container once any request that is using it is done.
+func (s *Service) Close(ctx context.Context,
+	req *ContainerRequest) (*CloseResponse, error) {
+	if !s.sessions.Close(req.Token) {
+		return nil, status.Errorf(codes.NotFound, "There is no open container "+
+			"with token %s", req.Token)
+	}
+	return new(CloseResponse), nil
+}
+
+// A chunkSender is a stream that Chunks are sent to.
+type chunkSender interface {
+	Send(*Chunk) error
+}
+
+// A chunkWriter sends the data written to it as Chunks of at most chunkBytes.
+// The data is copied, as a message may still be read once it is sent.
+type chunkWriter struct {
+	stream chunkSender
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		if n > chunkBytes {
+			n = chunkBytes
+		}
+		data := append([]byte(nil), p[:n]...)
+		if err := w.stream.Send(&Chunk{Data: data}); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
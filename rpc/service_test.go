@@ -0,0 +1,264 @@
+// Package rpc implements the gRPC API of wwiseutil, defined by wwiseutil.proto,
+// so that tools written in any language can open, edit and save containers
+// through a typed contract rather than through the command line tool.
+package rpc
+
+// System tests of the Containers service, served over an in-memory connection.
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// The root directory of the services of the tests, and the containers within
+// it, relative to the root.
+const (
+	testRoot          = ".."
+	loopNoneSoundBank = "bnk/testdata/loop_none.bnk"
+	loop2SoundBank    = "bnk/testdata/loop_2.bnk"
+	simpleFilePackage = "pck/testdata/simple.pck"
+)
+
+// newClient serves a new Service whose root is the root of the repository,
+// returning it, a client of it, a context that gives its key and the function
+// that stops it.
+func newClient(t *testing.T) (*Service, ContainersClient, context.Context,
+	func()) {
+	svc, err := NewService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.Root = testRoot
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(svc.ServerOptions()...)
+	RegisterContainersServer(srv, svc)
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"authorization", "Bearer "+svc.Key)
+	return svc, NewContainersClient(conn), ctx, func() {
+		conn.Close()
+		srv.Stop()
+		svc.CloseAll()
+	}
+}
+
+// receive reads every Chunk of stream.
+func receive(t *testing.T, stream interface{ Recv() (*Chunk, error) }) []byte {
+	t.Helper()
+	b := new(bytes.Buffer)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return b.Bytes()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(chunk.Data)
+	}
+}
+
+func TestLoopEditMatchesSoundBank(t *testing.T) {
+	_, client, ctx, stop := newClient(t)
+	defer stop()
+
+	opened, err := client.Open(ctx, &OpenRequest{Path: loopNoneSoundBank})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := client.ListWems(ctx, &ContainerRequest{Token: opened.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Wems) == 0 || list.Wems[0].Loop == nil ||
+		list.Wems[0].Loop.Loops {
+		t.Fatal("Expected the first wem to have an editable loop of none")
+	}
+
+	loop, err := client.SetLoop(ctx, &SetLoopRequest{Token: opened.Token,
+		Index: 1, Loop: &Loop{Loops: true, Value: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loop.Loops || loop.Value != 2 {
+		t.Errorf("Expected a loop of 2 but got %v", loop)
+	}
+	stream, err := client.Download(ctx, &ContainerRequest{Token: opened.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := receive(t, stream)
+	expected, err := ioutil.ReadFile(filepath.Join(testRoot, loop2SoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Error("The downloaded SoundBank differs from", loop2SoundBank)
+	}
+
+	_, err = client.Close(ctx, &ContainerRequest{Token: opened.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.ListWems(ctx, &ContainerRequest{Token: opened.Token})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a closed container but got: %v", err)
+	}
+}
+
+func TestUploadAndReplaceWem(t *testing.T) {
+	_, client, ctx, stop := newClient(t)
+	defer stop()
+
+	data, err := ioutil.ReadFile(filepath.Join(testRoot, simpleFilePackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload, err := client.Upload(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(data) > 0 {
+		n := len(data)
+		if n > 1000 {
+			n = 1000
+		}
+		if err := upload.Send(&Chunk{Data: data[:n]}); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	opened, err := upload.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.GetInfo(ctx, &ContainerRequest{Token: opened.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Type != "File Package" || info.Wems != opened.Wems {
+		t.Errorf("Unexpected info %v", info)
+	}
+	_, err = client.GetLoop(ctx, &WemRequest{Token: opened.Token, Index: 1})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected loops not to be editable within a File Package but "+
+			"got: %v", err)
+	}
+
+	// Replacements larger than a single Chunk are sent in parts.
+	replacement := bytes.Repeat([]byte{0xAB}, chunkBytes+100)
+	replace, err := client.ReplaceWem(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := [][]byte{replacement[:100], replacement[100:]}
+	for i, part := range parts {
+		req := &ReplaceWemRequest{Data: part}
+		if i == 0 {
+			req.Token, req.Index = opened.Token, 1
+		}
+		if err := replace.Send(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	replaced, err := replace.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.Length != int64(len(replacement)) {
+		t.Errorf("Expected a length of %d but got %d", len(replacement),
+			replaced.Length)
+	}
+
+	stream, err := client.DownloadWem(ctx, &DownloadWemRequest{
+		Token: opened.Token, Index: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(receive(t, stream), replacement) {
+		t.Error("The downloaded wem differs from its replacement")
+	}
+	stream, err = client.DownloadWem(ctx, &DownloadWemRequest{
+		Token: opened.Token, Index: opened.Wems + 1})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an index past the last wem but got: %v",
+			err)
+	}
+}
+
+func TestRequestsAreRestricted(t *testing.T) {
+	svc, client, ctx, stop := newClient(t)
+	defer stop()
+
+	// Requests without the key are refused, whether unary or streamed.
+	noKey := context.Background()
+	wrongKey := metadata.AppendToOutgoingContext(noKey, "authorization",
+		"Bearer wrong")
+	for _, c := range []context.Context{noKey, wrongKey} {
+		_, err := client.Open(c, &OpenRequest{Path: loopNoneSoundBank})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated but got: %v", err)
+		}
+		upload, err := client.Upload(c)
+		if err == nil {
+			_, err = upload.CloseAndRecv()
+		}
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated for an upload but got: %v", err)
+		}
+	}
+
+	// Paths outside the root are refused, both to open and to save to.
+	outside := filepath.Join(t.TempDir(), "out.bnk")
+	_, err := client.Open(ctx, &OpenRequest{Path: "../" + loopNoneSoundBank})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied to open outside the root but got: "+
+			"%v", err)
+	}
+	opened, err := client.Open(ctx, &OpenRequest{Path: loopNoneSoundBank})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Save(ctx, &SaveRequest{Token: opened.Token, Path: outside})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied to save outside the root but got: "+
+			"%v", err)
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written outside the root: %v", err)
+	}
+
+	// No paths are accepted without a root.
+	svc.Root = ""
+	_, err = client.Open(ctx, &OpenRequest{Path: loopNoneSoundBank})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied without a root but got: %v", err)
+	}
+}
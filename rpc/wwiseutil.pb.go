@@ -0,0 +1,1064 @@
+// The gRPC API of wwiseutil, which opens, edits and saves SoundBanks and File
+// Packages on behalf of tools written in any language.
+//
+// The Go code of this package is generated from this file with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative wwiseutil.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: wwiseutil.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OpenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenRequest) Reset() {
+	*x = OpenRequest{}
+	mi := &file_wwiseutil_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenRequest) ProtoMessage() {}
+
+func (x *OpenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenRequest.ProtoReflect.Descriptor instead.
+func (*OpenRequest) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OpenRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type OpenResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The token that identifies the container in other requests.
+	Token         string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Wems          int32  `protobuf:"varint,2,opt,name=wems,proto3" json:"wems,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenResponse) Reset() {
+	*x = OpenResponse{}
+	mi := &file_wwiseutil_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenResponse) ProtoMessage() {}
+
+func (x *OpenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenResponse.ProtoReflect.Descriptor instead.
+func (*OpenResponse) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OpenResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *OpenResponse) GetWems() int32 {
+	if x != nil {
+		return x.Wems
+	}
+	return 0
+}
+
+// A Chunk is part of a file that is too large to send in a single message.
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_wwiseutil_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ContainerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContainerRequest) Reset() {
+	*x = ContainerRequest{}
+	mi := &file_wwiseutil_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContainerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContainerRequest) ProtoMessage() {}
+
+func (x *ContainerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContainerRequest.ProtoReflect.Descriptor instead.
+func (*ContainerRequest) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ContainerRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type ContainerInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Either "SoundBank" or "File Package".
+	Type    string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Version uint32 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Wems    int32  `protobuf:"varint,3,opt,name=wems,proto3" json:"wems,omitempty"`
+	// The number of wems whose loop can be edited.
+	Loopable int32 `protobuf:"varint,4,opt,name=loopable,proto3" json:"loopable,omitempty"`
+	// The edits that the container does not support, such as "replace wems",
+	// with the reason why.
+	Unsupported   map[string]string `protobuf:"bytes,5,rep,name=unsupported,proto3" json:"unsupported,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContainerInfo) Reset() {
+	*x = ContainerInfo{}
+	mi := &file_wwiseutil_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContainerInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContainerInfo) ProtoMessage() {}
+
+func (x *ContainerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContainerInfo.ProtoReflect.Descriptor instead.
+func (*ContainerInfo) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ContainerInfo) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ContainerInfo) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ContainerInfo) GetWems() int32 {
+	if x != nil {
+		return x.Wems
+	}
+	return 0
+}
+
+func (x *ContainerInfo) GetLoopable() int32 {
+	if x != nil {
+		return x.Loopable
+	}
+	return 0
+}
+
+func (x *ContainerInfo) GetUnsupported() map[string]string {
+	if x != nil {
+		return x.Unsupported
+	}
+	return nil
+}
+
+type Wem struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Index    int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Id       uint32                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Offset   uint32                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length   uint32                 `protobuf:"varint,4,opt,name=length,proto3" json:"length,omitempty"`
+	Replaced bool                   `protobuf:"varint,5,opt,name=replaced,proto3" json:"replaced,omitempty"`
+	// The loop of the wem, if it can be edited.
+	Loop          *Loop `protobuf:"bytes,6,opt,name=loop,proto3" json:"loop,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Wem) Reset() {
+	*x = Wem{}
+	mi := &file_wwiseutil_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Wem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wem) ProtoMessage() {}
+
+func (x *Wem) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wem.ProtoReflect.Descriptor instead.
+func (*Wem) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Wem) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Wem) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Wem) GetOffset() uint32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Wem) GetLength() uint32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *Wem) GetReplaced() bool {
+	if x != nil {
+		return x.Replaced
+	}
+	return false
+}
+
+func (x *Wem) GetLoop() *Loop {
+	if x != nil {
+		return x.Loop
+	}
+	return nil
+}
+
+type ListWemsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wems          []*Wem                 `protobuf:"bytes,1,rep,name=wems,proto3" json:"wems,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWemsResponse) Reset() {
+	*x = ListWemsResponse{}
+	mi := &file_wwiseutil_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWemsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWemsResponse) ProtoMessage() {}
+
+func (x *ListWemsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWemsResponse.ProtoReflect.Descriptor instead.
+func (*ListWemsResponse) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListWemsResponse) GetWems() []*Wem {
+	if x != nil {
+		return x.Wems
+	}
+	return nil
+}
+
+type WemRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Index         int32                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WemRequest) Reset() {
+	*x = WemRequest{}
+	mi := &file_wwiseutil_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WemRequest) ProtoMessage() {}
+
+func (x *WemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WemRequest.ProtoReflect.Descriptor instead.
+func (*WemRequest) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WemRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *WemRequest) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type DownloadWemRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Token string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Index int32                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	// The audio format, either "ogg" or "wav", to convert the wem to. The wem
+	// is sent as it is if the format is empty.
+	Format        string `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadWemRequest) Reset() {
+	*x = DownloadWemRequest{}
+	mi := &file_wwiseutil_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadWemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadWemRequest) ProtoMessage() {}
+
+func (x *DownloadWemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadWemRequest.ProtoReflect.Descriptor instead.
+func (*DownloadWemRequest) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DownloadWemRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *DownloadWemRequest) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *DownloadWemRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type ReplaceWemRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The token and index are only read from the first request.
+	Token         string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Index         int32  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Data          []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplaceWemRequest) Reset() {
+	*x = ReplaceWemRequest{}
+	mi := &file_wwiseutil_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplaceWemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplaceWemRequest) ProtoMessage() {}
+
+func (x *ReplaceWemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplaceWemRequest.ProtoReflect.Descriptor instead.
+func (*ReplaceWemRequest) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReplaceWemRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ReplaceWemRequest) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ReplaceWemRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ReplaceWemResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The length of the replacement wem.
+	Length        int64 `protobuf:"varint,1,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplaceWemResponse) Reset() {
+	*x = ReplaceWemResponse{}
+	mi := &file_wwiseutil_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplaceWemResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplaceWemResponse) ProtoMessage() {}
+
+func (x *ReplaceWemResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplaceWemResponse.ProtoReflect.Descriptor instead.
+func (*ReplaceWemResponse) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReplaceWemResponse) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type Loop struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Loops bool                   `protobuf:"varint,1,opt,name=loops,proto3" json:"loops,omitempty"`
+	// The number of times the wem is played, where 0 plays it forever. It is
+	// ignored if the wem does not loop.
+	Value         uint32 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Loop) Reset() {
+	*x = Loop{}
+	mi := &file_wwiseutil_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Loop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Loop) ProtoMessage() {}
+
+func (x *Loop) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Loop.ProtoReflect.Descriptor instead.
+func (*Loop) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Loop) GetLoops() bool {
+	if x != nil {
+		return x.Loops
+	}
+	return false
+}
+
+func (x *Loop) GetValue() uint32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type SetLoopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Index         int32                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Loop          *Loop                  `protobuf:"bytes,3,opt,name=loop,proto3" json:"loop,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLoopRequest) Reset() {
+	*x = SetLoopRequest{}
+	mi := &file_wwiseutil_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLoopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLoopRequest) ProtoMessage() {}
+
+func (x *SetLoopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLoopRequest.ProtoReflect.Descriptor instead.
+func (*SetLoopRequest) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetLoopRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *SetLoopRequest) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *SetLoopRequest) GetLoop() *Loop {
+	if x != nil {
+		return x.Loop
+	}
+	return nil
+}
+
+type SaveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveRequest) Reset() {
+	*x = SaveRequest{}
+	mi := &file_wwiseutil_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveRequest) ProtoMessage() {}
+
+func (x *SaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveRequest.ProtoReflect.Descriptor instead.
+func (*SaveRequest) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SaveRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *SaveRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type SaveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Bytes         int64                  `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveResponse) Reset() {
+	*x = SaveResponse{}
+	mi := &file_wwiseutil_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveResponse) ProtoMessage() {}
+
+func (x *SaveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveResponse.ProtoReflect.Descriptor instead.
+func (*SaveResponse) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SaveResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SaveResponse) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+type CloseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseResponse) Reset() {
+	*x = CloseResponse{}
+	mi := &file_wwiseutil_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseResponse) ProtoMessage() {}
+
+func (x *CloseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wwiseutil_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseResponse.ProtoReflect.Descriptor instead.
+func (*CloseResponse) Descriptor() ([]byte, []int) {
+	return file_wwiseutil_proto_rawDescGZIP(), []int{15}
+}
+
+var File_wwiseutil_proto protoreflect.FileDescriptor
+
+const file_wwiseutil_proto_rawDesc = "" +
+	"\n" +
+	"\x0fwwiseutil.proto\x12\twwiseutil\"!\n" +
+	"\vOpenRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"8\n" +
+	"\fOpenResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x12\n" +
+	"\x04wems\x18\x02 \x01(\x05R\x04wems\"\x1b\n" +
+	"\x05Chunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"(\n" +
+	"\x10ContainerRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"\xfa\x01\n" +
+	"\rContainerInfo\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\rR\aversion\x12\x12\n" +
+	"\x04wems\x18\x03 \x01(\x05R\x04wems\x12\x1a\n" +
+	"\bloopable\x18\x04 \x01(\x05R\bloopable\x12K\n" +
+	"\vunsupported\x18\x05 \x03(\v2).wwiseutil.ContainerInfo.UnsupportedEntryR\vunsupported\x1a>\n" +
+	"\x10UnsupportedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9c\x01\n" +
+	"\x03Wem\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\rR\x02id\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\rR\x06offset\x12\x16\n" +
+	"\x06length\x18\x04 \x01(\rR\x06length\x12\x1a\n" +
+	"\breplaced\x18\x05 \x01(\bR\breplaced\x12#\n" +
+	"\x04loop\x18\x06 \x01(\v2\x0f.wwiseutil.LoopR\x04loop\"6\n" +
+	"\x10ListWemsResponse\x12\"\n" +
+	"\x04wems\x18\x01 \x03(\v2\x0e.wwiseutil.WemR\x04wems\"8\n" +
+	"\n" +
+	"WemRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x05R\x05index\"X\n" +
+	"\x12DownloadWemRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x05R\x05index\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\"S\n" +
+	"\x11ReplaceWemRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\",\n" +
+	"\x12ReplaceWemResponse\x12\x16\n" +
+	"\x06length\x18\x01 \x01(\x03R\x06length\"2\n" +
+	"\x04Loop\x12\x14\n" +
+	"\x05loops\x18\x01 \x01(\bR\x05loops\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value\"a\n" +
+	"\x0eSetLoopRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x05R\x05index\x12#\n" +
+	"\x04loop\x18\x03 \x01(\v2\x0f.wwiseutil.LoopR\x04loop\"7\n" +
+	"\vSaveRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"8\n" +
+	"\fSaveResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05bytes\x18\x02 \x01(\x03R\x05bytes\"\x0f\n" +
+	"\rCloseResponse2\xb3\x05\n" +
+	"\n" +
+	"Containers\x127\n" +
+	"\x04Open\x12\x16.wwiseutil.OpenRequest\x1a\x17.wwiseutil.OpenResponse\x125\n" +
+	"\x06Upload\x12\x10.wwiseutil.Chunk\x1a\x17.wwiseutil.OpenResponse(\x01\x12@\n" +
+	"\aGetInfo\x12\x1b.wwiseutil.ContainerRequest\x1a\x18.wwiseutil.ContainerInfo\x12D\n" +
+	"\bListWems\x12\x1b.wwiseutil.ContainerRequest\x1a\x1b.wwiseutil.ListWemsResponse\x12@\n" +
+	"\vDownloadWem\x12\x1d.wwiseutil.DownloadWemRequest\x1a\x10.wwiseutil.Chunk0\x01\x12K\n" +
+	"\n" +
+	"ReplaceWem\x12\x1c.wwiseutil.ReplaceWemRequest\x1a\x1d.wwiseutil.ReplaceWemResponse(\x01\x121\n" +
+	"\aGetLoop\x12\x15.wwiseutil.WemRequest\x1a\x0f.wwiseutil.Loop\x125\n" +
+	"\aSetLoop\x12\x19.wwiseutil.SetLoopRequest\x1a\x0f.wwiseutil.Loop\x12;\n" +
+	"\bDownload\x12\x1b.wwiseutil.ContainerRequest\x1a\x10.wwiseutil.Chunk0\x01\x127\n" +
+	"\x04Save\x12\x16.wwiseutil.SaveRequest\x1a\x17.wwiseutil.SaveResponse\x12>\n" +
+	"\x05Close\x12\x1b.wwiseutil.ContainerRequest\x1a\x18.wwiseutil.CloseResponseB%Z#github.com/hpxro7/wwiseutil/rpc;rpcb\x06proto3"
+
+var (
+	file_wwiseutil_proto_rawDescOnce sync.Once
+	file_wwiseutil_proto_rawDescData []byte
+)
+
+func file_wwiseutil_proto_rawDescGZIP() []byte {
+	file_wwiseutil_proto_rawDescOnce.Do(func() {
+		file_wwiseutil_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_wwiseutil_proto_rawDesc), len(file_wwiseutil_proto_rawDesc)))
+	})
+	return file_wwiseutil_proto_rawDescData
+}
+
+var file_wwiseutil_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_wwiseutil_proto_goTypes = []any{
+	(*OpenRequest)(nil),        // 0: wwiseutil.OpenRequest
+	(*OpenResponse)(nil),       // 1: wwiseutil.OpenResponse
+	(*Chunk)(nil),              // 2: wwiseutil.Chunk
+	(*ContainerRequest)(nil),   // 3: wwiseutil.ContainerRequest
+	(*ContainerInfo)(nil),      // 4: wwiseutil.ContainerInfo
+	(*Wem)(nil),                // 5: wwiseutil.Wem
+	(*ListWemsResponse)(nil),   // 6: wwiseutil.ListWemsResponse
+	(*WemRequest)(nil),         // 7: wwiseutil.WemRequest
+	(*DownloadWemRequest)(nil), // 8: wwiseutil.DownloadWemRequest
+	(*ReplaceWemRequest)(nil),  // 9: wwiseutil.ReplaceWemRequest
+	(*ReplaceWemResponse)(nil), // 10: wwiseutil.ReplaceWemResponse
+	(*Loop)(nil),               // 11: wwiseutil.Loop
+	(*SetLoopRequest)(nil),     // 12: wwiseutil.SetLoopRequest
+	(*SaveRequest)(nil),        // 13: wwiseutil.SaveRequest
+	(*SaveResponse)(nil),       // 14: wwiseutil.SaveResponse
+	(*CloseResponse)(nil),      // 15: wwiseutil.CloseResponse
+	nil,                        // 16: wwiseutil.ContainerInfo.UnsupportedEntry
+}
+var file_wwiseutil_proto_depIdxs = []int32{
+	16, // 0: wwiseutil.ContainerInfo.unsupported:type_name -> wwiseutil.ContainerInfo.UnsupportedEntry
+	11, // 1: wwiseutil.Wem.loop:type_name -> wwiseutil.Loop
+	5,  // 2: wwiseutil.ListWemsResponse.wems:type_name -> wwiseutil.Wem
+	11, // 3: wwiseutil.SetLoopRequest.loop:type_name -> wwiseutil.Loop
+	0,  // 4: wwiseutil.Containers.Open:input_type -> wwiseutil.OpenRequest
+	2,  // 5: wwiseutil.Containers.Upload:input_type -> wwiseutil.Chunk
+	3,  // 6: wwiseutil.Containers.GetInfo:input_type -> wwiseutil.ContainerRequest
+	3,  // 7: wwiseutil.Containers.ListWems:input_type -> wwiseutil.ContainerRequest
+	8,  // 8: wwiseutil.Containers.DownloadWem:input_type -> wwiseutil.DownloadWemRequest
+	9,  // 9: wwiseutil.Containers.ReplaceWem:input_type -> wwiseutil.ReplaceWemRequest
+	7,  // 10: wwiseutil.Containers.GetLoop:input_type -> wwiseutil.WemRequest
+	12, // 11: wwiseutil.Containers.SetLoop:input_type -> wwiseutil.SetLoopRequest
+	3,  // 12: wwiseutil.Containers.Download:input_type -> wwiseutil.ContainerRequest
+	13, // 13: wwiseutil.Containers.Save:input_type -> wwiseutil.SaveRequest
+	3,  // 14: wwiseutil.Containers.Close:input_type -> wwiseutil.ContainerRequest
+	1,  // 15: wwiseutil.Containers.Open:output_type -> wwiseutil.OpenResponse
+	1,  // 16: wwiseutil.Containers.Upload:output_type -> wwiseutil.OpenResponse
+	4,  // 17: wwiseutil.Containers.GetInfo:output_type -> wwiseutil.ContainerInfo
+	6,  // 18: wwiseutil.Containers.ListWems:output_type -> wwiseutil.ListWemsResponse
+	2,  // 19: wwiseutil.Containers.DownloadWem:output_type -> wwiseutil.Chunk
+	10, // 20: wwiseutil.Containers.ReplaceWem:output_type -> wwiseutil.ReplaceWemResponse
+	11, // 21: wwiseutil.Containers.GetLoop:output_type -> wwiseutil.Loop
+	11, // 22: wwiseutil.Containers.SetLoop:output_type -> wwiseutil.Loop
+	2,  // 23: wwiseutil.Containers.Download:output_type -> wwiseutil.Chunk
+	14, // 24: wwiseutil.Containers.Save:output_type -> wwiseutil.SaveResponse
+	15, // 25: wwiseutil.Containers.Close:output_type -> wwiseutil.CloseResponse
+	15, // [15:26] is the sub-list for method output_type
+	4,  // [4:15] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_wwiseutil_proto_init() }
+func file_wwiseutil_proto_init() {
+	if File_wwiseutil_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_wwiseutil_proto_rawDesc), len(file_wwiseutil_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wwiseutil_proto_goTypes,
+		DependencyIndexes: file_wwiseutil_proto_depIdxs,
+		MessageInfos:      file_wwiseutil_proto_msgTypes,
+	}.Build()
+	File_wwiseutil_proto = out.File
+	file_wwiseutil_proto_goTypes = nil
+	file_wwiseutil_proto_depIdxs = nil
+}
@@ -0,0 +1,538 @@
+// The gRPC API of wwiseutil, which opens, edits and saves SoundBanks and File
+// Packages on behalf of tools written in any language.
+//
+// The Go code of this package is generated from this file with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative wwiseutil.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: wwiseutil.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Containers_Open_FullMethodName        = "/wwiseutil.Containers/Open"
+	Containers_Upload_FullMethodName      = "/wwiseutil.Containers/Upload"
+	Containers_GetInfo_FullMethodName     = "/wwiseutil.Containers/GetInfo"
+	Containers_ListWems_FullMethodName    = "/wwiseutil.Containers/ListWems"
+	Containers_DownloadWem_FullMethodName = "/wwiseutil.Containers/DownloadWem"
+	Containers_ReplaceWem_FullMethodName  = "/wwiseutil.Containers/ReplaceWem"
+	Containers_GetLoop_FullMethodName     = "/wwiseutil.Containers/GetLoop"
+	Containers_SetLoop_FullMethodName     = "/wwiseutil.Containers/SetLoop"
+	Containers_Download_FullMethodName    = "/wwiseutil.Containers/Download"
+	Containers_Save_FullMethodName        = "/wwiseutil.Containers/Save"
+	Containers_Close_FullMethodName       = "/wwiseutil.Containers/Close"
+)
+
+// ContainersClient is the client API for Containers service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Containers opens SoundBanks and File Packages, and keeps each open,
+// identified by a token, until it is closed. Wem indexes start at 1, as they
+// do elsewhere. Every request must give the key of the server as
+// "authorization: Bearer <key>" metadata.
+type ContainersClient interface {
+	// Opens the container at a path within the root directory of the server.
+	Open(ctx context.Context, in *OpenRequest, opts ...grpc.CallOption) (*OpenResponse, error)
+	// Opens the container whose contents are sent by the client, in order.
+	Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, OpenResponse], error)
+	// Describes a container, and the edits that it does not support.
+	GetInfo(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*ContainerInfo, error)
+	// Lists the wems of a container.
+	ListWems(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*ListWemsResponse, error)
+	// Sends the wem at an index, converted to an audio format if one is given.
+	DownloadWem(ctx context.Context, in *DownloadWemRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error)
+	// Replaces the wem at an index with the data of every request sent. The
+	// container and wem are named by the first request.
+	ReplaceWem(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ReplaceWemRequest, ReplaceWemResponse], error)
+	// Gets the loop of the wem at an index.
+	GetLoop(ctx context.Context, in *WemRequest, opts ...grpc.CallOption) (*Loop, error)
+	// Sets the loop of the wem at an index.
+	SetLoop(ctx context.Context, in *SetLoopRequest, opts ...grpc.CallOption) (*Loop, error)
+	// Sends the container, with every edit applied.
+	Download(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error)
+	// Writes the container, with every edit applied, to a path within the root
+	// directory of the server.
+	Save(ctx context.Context, in *SaveRequest, opts ...grpc.CallOption) (*SaveResponse, error)
+	// Closes a container.
+	Close(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*CloseResponse, error)
+}
+
+type containersClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewContainersClient(cc grpc.ClientConnInterface) ContainersClient {
+	return &containersClient{cc}
+}
+
+func (c *containersClient) Open(ctx context.Context, in *OpenRequest, opts ...grpc.CallOption) (*OpenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OpenResponse)
+	err := c.cc.Invoke(ctx, Containers_Open_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containersClient) Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, OpenResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Containers_ServiceDesc.Streams[0], Containers_Upload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Chunk, OpenResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Containers_UploadClient = grpc.ClientStreamingClient[Chunk, OpenResponse]
+
+func (c *containersClient) GetInfo(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*ContainerInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ContainerInfo)
+	err := c.cc.Invoke(ctx, Containers_GetInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containersClient) ListWems(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*ListWemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWemsResponse)
+	err := c.cc.Invoke(ctx, Containers_ListWems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containersClient) DownloadWem(ctx context.Context, in *DownloadWemRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Containers_ServiceDesc.Streams[1], Containers_DownloadWem_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadWemRequest, Chunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Containers_DownloadWemClient = grpc.ServerStreamingClient[Chunk]
+
+func (c *containersClient) ReplaceWem(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ReplaceWemRequest, ReplaceWemResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Containers_ServiceDesc.Streams[2], Containers_ReplaceWem_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReplaceWemRequest, ReplaceWemResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Containers_ReplaceWemClient = grpc.ClientStreamingClient[ReplaceWemRequest, ReplaceWemResponse]
+
+func (c *containersClient) GetLoop(ctx context.Context, in *WemRequest, opts ...grpc.CallOption) (*Loop, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Loop)
+	err := c.cc.Invoke(ctx, Containers_GetLoop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containersClient) SetLoop(ctx context.Context, in *SetLoopRequest, opts ...grpc.CallOption) (*Loop, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Loop)
+	err := c.cc.Invoke(ctx, Containers_SetLoop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containersClient) Download(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Containers_ServiceDesc.Streams[3], Containers_Download_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ContainerRequest, Chunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Containers_DownloadClient = grpc.ServerStreamingClient[Chunk]
+
+func (c *containersClient) Save(ctx context.Context, in *SaveRequest, opts ...grpc.CallOption) (*SaveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SaveResponse)
+	err := c.cc.Invoke(ctx, Containers_Save_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containersClient) Close(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*CloseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseResponse)
+	err := c.cc.Invoke(ctx, Containers_Close_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ContainersServer is the server API for Containers service.
+// All implementations must embed UnimplementedContainersServer
+// for forward compatibility.
+//
+// Containers opens SoundBanks and File Packages, and keeps each open,
+// identified by a token, until it is closed. Wem indexes start at 1, as they
+// do elsewhere. Every request must give the key of the server as
+// "authorization: Bearer <key>" metadata.
+type ContainersServer interface {
+	// Opens the container at a path within the root directory of the server.
+	Open(context.Context, *OpenRequest) (*OpenResponse, error)
+	// Opens the container whose contents are sent by the client, in order.
+	Upload(grpc.ClientStreamingServer[Chunk, OpenResponse]) error
+	// Describes a container, and the edits that it does not support.
+	GetInfo(context.Context, *ContainerRequest) (*ContainerInfo, error)
+	// Lists the wems of a container.
+	ListWems(context.Context, *ContainerRequest) (*ListWemsResponse, error)
+	// Sends the wem at an index, converted to an audio format if one is given.
+	DownloadWem(*DownloadWemRequest, grpc.ServerStreamingServer[Chunk]) error
+	// Replaces the wem at an index with the data of every request sent. The
+	// container and wem are named by the first request.
+	ReplaceWem(grpc.ClientStreamingServer[ReplaceWemRequest, ReplaceWemResponse]) error
+	// Gets the loop of the wem at an index.
+	GetLoop(context.Context, *WemRequest) (*Loop, error)
+	// Sets the loop of the wem at an index.
+	SetLoop(context.Context, *SetLoopRequest) (*Loop, error)
+	// Sends the container, with every edit applied.
+	Download(*ContainerRequest, grpc.ServerStreamingServer[Chunk]) error
+	// Writes the container, with every edit applied, to a path within the root
+	// directory of the server.
+	Save(context.Context, *SaveRequest) (*SaveResponse, error)
+	// Closes a container.
+	Close(context.Context, *ContainerRequest) (*CloseResponse, error)
+	mustEmbedUnimplementedContainersServer()
+}
+
+// UnimplementedContainersServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedContainersServer struct{}
+
+func (UnimplementedContainersServer) Open(context.Context, *OpenRequest) (*OpenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Open not implemented")
+}
+func (UnimplementedContainersServer) Upload(grpc.ClientStreamingServer[Chunk, OpenResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Upload not implemented")
+}
+func (UnimplementedContainersServer) GetInfo(context.Context, *ContainerRequest) (*ContainerInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
+func (UnimplementedContainersServer) ListWems(context.Context, *ContainerRequest) (*ListWemsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWems not implemented")
+}
+func (UnimplementedContainersServer) DownloadWem(*DownloadWemRequest, grpc.ServerStreamingServer[Chunk]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadWem not implemented")
+}
+func (UnimplementedContainersServer) ReplaceWem(grpc.ClientStreamingServer[ReplaceWemRequest, ReplaceWemResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ReplaceWem not implemented")
+}
+func (UnimplementedContainersServer) GetLoop(context.Context, *WemRequest) (*Loop, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLoop not implemented")
+}
+func (UnimplementedContainersServer) SetLoop(context.Context, *SetLoopRequest) (*Loop, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLoop not implemented")
+}
+func (UnimplementedContainersServer) Download(*ContainerRequest, grpc.ServerStreamingServer[Chunk]) error {
+	return status.Errorf(codes.Unimplemented, "method Download not implemented")
+}
+func (UnimplementedContainersServer) Save(context.Context, *SaveRequest) (*SaveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Save not implemented")
+}
+func (UnimplementedContainersServer) Close(context.Context, *ContainerRequest) (*CloseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Close not implemented")
+}
+func (UnimplementedContainersServer) mustEmbedUnimplementedContainersServer() {}
+func (UnimplementedContainersServer) testEmbeddedByValue()                    {}
+
+// UnsafeContainersServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ContainersServer will
+// result in compilation errors.
+type UnsafeContainersServer interface {
+	mustEmbedUnimplementedContainersServer()
+}
+
+func RegisterContainersServer(s grpc.ServiceRegistrar, srv ContainersServer) {
+	// If the following call pancis, it indicates UnimplementedContainersServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Containers_ServiceDesc, srv)
+}
+
+func _Containers_Open_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainersServer).Open(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Containers_Open_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainersServer).Open(ctx, req.(*OpenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Containers_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ContainersServer).Upload(&grpc.GenericServerStream[Chunk, OpenResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Containers_UploadServer = grpc.ClientStreamingServer[Chunk, OpenResponse]
+
+func _Containers_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainersServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Containers_GetInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainersServer).GetInfo(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Containers_ListWems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainersServer).ListWems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Containers_ListWems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainersServer).ListWems(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Containers_DownloadWem_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadWemRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ContainersServer).DownloadWem(m, &grpc.GenericServerStream[DownloadWemRequest, Chunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Containers_DownloadWemServer = grpc.ServerStreamingServer[Chunk]
+
+func _Containers_ReplaceWem_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ContainersServer).ReplaceWem(&grpc.GenericServerStream[ReplaceWemRequest, ReplaceWemResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Containers_ReplaceWemServer = grpc.ClientStreamingServer[ReplaceWemRequest, ReplaceWemResponse]
+
+func _Containers_GetLoop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainersServer).GetLoop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Containers_GetLoop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainersServer).GetLoop(ctx, req.(*WemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Containers_SetLoop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLoopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainersServer).SetLoop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Containers_SetLoop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainersServer).SetLoop(ctx, req.(*SetLoopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Containers_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ContainerRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ContainersServer).Download(m, &grpc.GenericServerStream[ContainerRequest, Chunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Containers_DownloadServer = grpc.ServerStreamingServer[Chunk]
+
+func _Containers_Save_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainersServer).Save(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Containers_Save_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainersServer).Save(ctx, req.(*SaveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Containers_Close_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainersServer).Close(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Containers_Close_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainersServer).Close(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Containers_ServiceDesc is the grpc.ServiceDesc for Containers service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Containers_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wwiseutil.Containers",
+	HandlerType: (*ContainersServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Open",
+			Handler:    _Containers_Open_Handler,
+		},
+		{
+			MethodName: "GetInfo",
+			Handler:    _Containers_GetInfo_Handler,
+		},
+		{
+			MethodName: "ListWems",
+			Handler:    _Containers_ListWems_Handler,
+		},
+		{
+			MethodName: "GetLoop",
+			Handler:    _Containers_GetLoop_Handler,
+		},
+		{
+			MethodName: "SetLoop",
+			Handler:    _Containers_SetLoop_Handler,
+		},
+		{
+			MethodName: "Save",
+			Handler:    _Containers_Save_Handler,
+		},
+		{
+			MethodName: "Close",
+			Handler:    _Containers_Close_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Upload",
+			Handler:       _Containers_Upload_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadWem",
+			Handler:       _Containers_DownloadWem_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ReplaceWem",
+			Handler:       _Containers_ReplaceWem_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Download",
+			Handler:       _Containers_Download_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "wwiseutil.proto",
+}
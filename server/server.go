@@ -0,0 +1,617 @@
+// Package server implements an HTTP API over SoundBanks and File Packages, so
+// that other tools, such as web based mod tools, can open, edit and save
+// containers without reading their formats themselves.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+import (
+	"bnk"
+	"convert"
+	"pck"
+	"session"
+	"util"
+	"wwise"
+)
+
+// The path that every endpoint of a Server is found beneath.
+const Path = "/containers"
+
+// The extension of wems sent or exported as they are.
+const wemExtension = ".wem"
+
+// The content types of the audio formats that wems are converted to.
+var contentTypes = map[convert.Format]string{
+	convert.OggFormat: "audio/ogg",
+	convert.WavFormat: "audio/wav",
+}
+
+// A Server serves the HTTP API. Each opened container is kept in a session,
+// keyed by a token, until it is closed. The endpoints, relative to Path, are:
+//
+//	POST   /                        Opens the container in the request body,
+//	                                or at the path query parameter.
+//	GET    /{token}                 Downloads the container, with all edits
+//	                                applied.
+//	DELETE /{token}                 Closes a container.
+//	GET    /{token}/info            Describes the container, and the edits
+//	                                that it does not support.
+//	POST   /{token}/save            Writes the container to the path query
+//	                                parameter, overwriting an existing file
+//	                                only if the overwrite query parameter is
+//	                                true.
+//	POST   /{token}/export          Writes every wem to the dir query
+//	                                parameter, converted to the format query
+//	                                parameter if it is given.
+//	GET    /{token}/wems            Lists the wems of a container.
+//	GET    /{token}/wems/{i}        Downloads the wem at index i, converted to
+//	                                the format query parameter if it is given.
+//	PUT    /{token}/wems/{i}        Replaces the wem at index i with the
+//	                                request body.
+//	GET    /{token}/wems/{i}/loop   Gets the loop of the wem at index i.
+//	PUT    /{token}/wems/{i}/loop   Sets the loop of the wem at index i to the
+//	                                loop in the request body.
+//
+// Wem indexes start at 1, as they do elsewhere. Descriptions are sent and
+// received as JSON, and failures are sent as a JSON object whose error member
+// describes the failure.
+//
+// As the API reads and writes files, every request must give the key of the
+// Server, either as an "Authorization: Bearer <key>" header or as the key query
+// parameter, and must name a loopback host, such as localhost, in its Host
+// header, so that a web page cannot reach the API by rebinding a domain name to
+// a loopback address. The paths that clients give are resolved within Root.
+type Server struct {
+	// The secret that every request must give. New sets it to a random key; no
+	// key is required if it is empty.
+	Key string
+	// The directory that the paths given by clients, to open, save and export
+	// to, are resolved relative to, and must be within, after following
+	// symbolic links. Clients cannot give paths if it is empty.
+	Root string
+	// Open opens the container at a path given by a client, stopping once ctx
+	// is done. If it is nil, a SoundBank or File Package is opened by the
+	// extension of the path.
+	Open wwise.OpenFunc
+	// The converter used to send and export wems in other audio formats. Wems
+	// are only sent as they are if it is nil.
+	Converter *convert.Converter
+
+	sessions *session.Store
+}
+
+// A wemInfo describes a single wem of an open container.
+type wemInfo struct {
+	Index    int    `json:"index"`
+	Id       uint32 `json:"id"`
+	Offset   uint32 `json:"offset"`
+	Length   uint32 `json:"length"`
+	Replaced bool   `json:"replaced"`
+	// The loop of the wem, if it can be edited.
+	Loop *loopInfo `json:"loop,omitempty"`
+}
+
+// A loopInfo is the loop of a wem, as it is sent and received.
+type loopInfo struct {
+	Loops bool `json:"loops"`
+	// The number of times the wem is played, where 0 plays it forever. It is
+	// ignored if the wem does not loop.
+	Value uint32 `json:"value"`
+}
+
+// A containerInfo describes an open container.
+type containerInfo struct {
+	// Either "SoundBank" or "File Package".
+	Type    string `json:"type"`
+	Version uint32 `json:"version"`
+	Wems    int    `json:"wems"`
+	// The number of wems whose loop can be edited.
+	Loopable int `json:"loopable"`
+	// The edits that the container does not support, such as "replace wems",
+	// with the reason why.
+	Unsupported map[string]string `json:"unsupported"`
+}
+
+// An exportResult summarizes the wems written by an export.
+type exportResult struct {
+	Dir      string   `json:"dir"`
+	Exported int      `json:"exported"`
+	Bytes    int64    `json:"bytes"`
+	Errors   []string `json:"errors"`
+}
+
+// New creates a Server with no open containers and a random key, that accepts
+// no paths from clients until its Root is set, opens containers from paths by
+// their extension and sends wems only as they are.
+func New() (*Server, error) {
+	key, err := session.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	return &Server{Key: key, sessions: session.NewStore()}, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackHost(r.Host) {
+		httpError(w, http.StatusForbidden, "Requests must be sent to a loopback "+
+			"host, such as localhost, not %s", r.Host)
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		httpError(w, http.StatusUnauthorized, "The key of this server is "+
+			"required, as an Authorization: Bearer header or the key query "+
+			"parameter")
+		return
+	}
+	parts := strings.Split(strings.Trim(
+		strings.TrimPrefix(r.URL.Path, Path), "/"), "/")
+	if parts[0] == "" {
+		if r.Method != http.MethodPost {
+			httpError(w, http.StatusMethodNotAllowed, "Use POST to open a container")
+			return
+		}
+		s.open(w, r)
+		return
+	}
+
+	sess := s.sessions.Lock(parts[0])
+	if sess == nil {
+		httpError(w, http.StatusNotFound, "There is no open container with "+
+			"token %s", parts[0])
+		return
+	}
+	defer sess.Unlock()
+
+	query := r.URL.Query()
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		download(w, sess)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		s.sessions.Remove(parts[0])
+		sess.Close()
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "info" && r.Method == http.MethodGet:
+		info(w, sess)
+	case len(parts) == 2 && parts[1] == "save" && r.Method == http.MethodPost:
+		if path, ok := s.resolve(w, query.Get("path")); ok {
+			save(w, r, sess, path)
+		}
+	case len(parts) == 2 && parts[1] == "export" && r.Method == http.MethodPost:
+		if dir, ok := s.resolve(w, query.Get("dir")); ok {
+			s.export(w, sess, dir, query.Get("format"))
+		}
+	case len(parts) == 2 && parts[1] == "wems" && r.Method == http.MethodGet:
+		list(w, sess)
+	case (len(parts) == 3 || len(parts) == 4 && parts[3] == "loop") &&
+		parts[1] == "wems":
+		index, err := strconv.Atoi(parts[2])
+		count := len(sess.Container.Wems())
+		if err != nil || index < 1 || index > count {
+			httpError(w, http.StatusNotFound, "%s is not a valid wem index; the "+
+				"valid index range is %d to %d", parts[2], 1, count)
+			return
+		}
+		switch {
+		case len(parts) == 4 && r.Method == http.MethodGet:
+			loop(w, sess, index-1)
+		case len(parts) == 4 && r.Method == http.MethodPut:
+			replaceLoop(w, r, sess, index-1)
+		case len(parts) == 4:
+			httpError(w, http.StatusMethodNotAllowed, "Use GET or PUT for a loop")
+		case r.Method == http.MethodGet:
+			s.downloadWem(w, sess, index-1, query.Get("format"))
+		case r.Method == http.MethodPut:
+			replace(w, r, sess, index-1)
+		default:
+			httpError(w, http.StatusMethodNotAllowed, "Use GET or PUT for a wem")
+		}
+	default:
+		httpError(w, http.StatusNotFound, "Unknown endpoint %s %s", r.Method,
+			r.URL.Path)
+	}
+}
+
+// isLoopbackHost returns true if host, the Host header of a request, names a
+// loopback address, either as localhost or as an IP address.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authorized returns true if r gives the key of this server.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.Key == "" {
+		return true
+	}
+	key := r.URL.Query().Get("key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return session.CheckKey(key, s.Key)
+}
+
+// resolve returns path, given by a client, resolved relative to the root of
+// this server. It sends an error and returns false if path is not within the
+// root once symbolic links are followed. An empty path is returned as it is,
+// for the endpoint to report.
+func (s *Server) resolve(w http.ResponseWriter, path string) (string, bool) {
+	path, err := session.Resolve(s.Root, path)
+	var rerr *session.RootError
+	switch {
+	case errors.As(err, &rerr):
+		httpError(w, http.StatusForbidden, "%s", err)
+		return "", false
+	case err != nil:
+		httpError(w, http.StatusInternalServerError, "%s", err)
+		return "", false
+	}
+	return path, true
+}
+
+// Close closes every open container.
+func (s *Server) Close() {
+	s.sessions.CloseAll()
+}
+
+// open opens the container at the path query parameter, or else the container
+// sent as the request body, and starts a session for it.
+func (s *Server) open(w http.ResponseWriter, r *http.Request) {
+	path, ok := s.resolve(w, r.URL.Query().Get("path"))
+	if !ok {
+		return
+	}
+	var sess *session.Session
+	var err error
+	if path != "" {
+		open := s.Open
+		if open == nil {
+			open = session.OpenFile
+		}
+		var ctn wwise.Container
+		if ctn, err = open(r.Context(), path); err == nil {
+			sess = session.New(ctn, path)
+		}
+	} else {
+		sess, err = session.Read(r.Body)
+	}
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "Could not parse .bnk or .pck "+
+			"file: %s", err)
+		return
+	}
+	token, err := s.sessions.Add(sess)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token": token,
+		"wems":  len(sess.Container.Wems()),
+	})
+}
+
+func info(w http.ResponseWriter, sess *session.Session) {
+	info := &containerInfo{Wems: len(sess.Container.Wems()),
+		Unsupported: make(map[string]string)}
+	switch ctn := sess.Container.(type) {
+	case *bnk.File:
+		info.Type, info.Version = "SoundBank", ctn.Version()
+		info.Loopable = ctn.LoopableWemCount()
+	case *pck.File:
+		info.Type, info.Version = "File Package", ctn.Header.Version()
+		info.Unsupported[wwise.EditLoops] = "Loops are only edited within " +
+			"SoundBanks"
+	}
+	for _, edit := range []string{wwise.EditWems, wwise.EditLoops} {
+		if err := sess.Container.CheckVersion(edit); err != nil {
+			info.Unsupported[edit] = err.Error()
+		}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func list(w http.ResponseWriter, sess *session.Session) {
+	b, _ := sess.Container.(*bnk.File)
+	wems := []*wemInfo{}
+	for i, wem := range sess.Container.Wems() {
+		desc := wem.Descriptor
+		info := &wemInfo{i + 1, desc.WemId, desc.Offset, desc.Length,
+			sess.Replaced[i], nil}
+		if b != nil && b.CanLoop(i) {
+			loop := b.LoopOf(i)
+			info.Loop = &loopInfo{loop.Loops, loop.Value}
+		}
+		wems = append(wems, info)
+	}
+	writeJSON(w, http.StatusOK, wems)
+}
+
+// downloadWem sends the wem at index, converted to the format named by
+// formatName, or as it is if formatName is empty.
+func (s *Server) downloadWem(w http.ResponseWriter, sess *session.Session,
+	index int, formatName string) {
+	wem := sess.Container.Wems()[index]
+	if formatName != "" {
+		to, ok := s.checkFormat(w, formatName)
+		if ok {
+			s.downloadConverted(w, wem, index, to)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length",
+		strconv.FormatUint(uint64(wem.Descriptor.Length), 10))
+	setFileName(w, wem, wemExtension)
+	if _, err := util.Copy(w, wem.NewReader()); err != nil {
+		log.Printf("Could not send wem %d: %s", index+1, err)
+	}
+}
+
+// checkFormat returns the audio format named by name, or sends an error and
+// returns false if wems cannot be converted to it.
+func (s *Server) checkFormat(w http.ResponseWriter,
+	name string) (convert.Format, bool) {
+	to := convert.ParseFormat(name)
+	if to == convert.UnknownFormat {
+		httpError(w, http.StatusBadRequest, "%s is not a supported audio "+
+			"format; use ogg or wav", name)
+		return to, false
+	}
+	if s.Converter == nil {
+		httpError(w, http.StatusNotImplemented, "This server does not convert "+
+			"wems")
+		return to, false
+	}
+	return to, true
+}
+
+// downloadConverted converts the wem at index to the audio format given by to,
+// and sends the result.
+func (s *Server) downloadConverted(w http.ResponseWriter, wem *wwise.Wem,
+	index int, to convert.Format) {
+	format, err := wem.Format()
+	if err != nil {
+		httpError(w, http.StatusUnprocessableEntity, "Could not read the format "+
+			"of wem %d: %s", index+1, err)
+		return
+	}
+	path, release, err := s.Converter.Converted(wem, format, to)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "Could not convert wem %d: "+
+			"%s", index+1, err)
+		return
+	}
+	defer release()
+	f, err := os.Open(path)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypes[to])
+	w.Header().Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+	setFileName(w, wem, to.Extension())
+	if _, err := util.Copy(w, f); err != nil {
+		log.Printf("Could not send wem %d: %s", index+1, err)
+	}
+}
+
+// setFileName names the file that a wem is sent as, by its id and ext.
+func setFileName(w http.ResponseWriter, wem *wwise.Wem, ext string) {
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=\"%d%s\"", wem.Descriptor.WemId, ext))
+}
+
+// replace replaces the wem at index with the request body. The body is
+// buffered, spilling to a temporary file if it is large, for as long as the
+// session is open.
+func replace(w http.ResponseWriter, r *http.Request, sess *session.Session,
+	index int) {
+	if err := sess.Container.CheckVersion(wwise.EditWems); err != nil {
+		httpError(w, http.StatusConflict, "%s", err)
+		return
+	}
+	b := util.NewSpillBuffer()
+	n, err := util.Copy(b, r.Body)
+	if err != nil || n == 0 {
+		b.Close()
+		if err != nil {
+			httpError(w, http.StatusBadRequest, "Could not read replacement: %s",
+				err)
+		} else {
+			httpError(w, http.StatusBadRequest, "The replacement wem is empty")
+		}
+		return
+	}
+	sess.Keep(b)
+	sess.Replace(index, b, n)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// soundBank returns the container of sess if it is a SoundBank, or sends an
+// error and returns nil if it is not, as only SoundBanks hold loops.
+func soundBank(w http.ResponseWriter, sess *session.Session) *bnk.File {
+	b, ok := sess.Container.(*bnk.File)
+	if !ok {
+		httpError(w, http.StatusConflict, "Loops are only edited within "+
+			"SoundBanks")
+	}
+	return b
+}
+
+func loop(w http.ResponseWriter, sess *session.Session, index int) {
+	b := soundBank(w, sess)
+	if b == nil {
+		return
+	}
+	if err := b.CheckLoop(index); err != nil {
+		httpError(w, http.StatusConflict, "%s", err)
+		return
+	}
+	loop := b.LoopOf(index)
+	writeJSON(w, http.StatusOK, &loopInfo{loop.Loops, loop.Value})
+}
+
+// replaceLoop sets the loop of the wem at index to the loop described by the
+// request body.
+func replaceLoop(w http.ResponseWriter, r *http.Request,
+	sess *session.Session, index int) {
+	b := soundBank(w, sess)
+	if b == nil {
+		return
+	}
+	loop := new(loopInfo)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(loop); err != nil {
+		httpError(w, http.StatusBadRequest, "Could not read loop: %s", err)
+		return
+	}
+	value := bnk.LoopValue{Loops: loop.Loops}
+	if loop.Loops {
+		value.Value = loop.Value
+	}
+	if err := b.ReplaceLoopOf(index, value); err != nil {
+		var lerr *bnk.LoopError
+		if errors.As(err, &lerr) {
+			httpError(w, http.StatusConflict, "%s", err)
+		} else {
+			httpError(w, http.StatusInternalServerError, "%s", err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func download(w http.ResponseWriter, sess *session.Session) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := sess.Container.WriteTo(w); err != nil {
+		log.Printf("Could not send container: %s", err)
+	}
+}
+
+// save writes the container of sess to path, which is only overwritten if the
+// overwrite query parameter is true. The container cannot be saved over the
+// file it was opened from, as that file is still being read from.
+func save(w http.ResponseWriter, r *http.Request, sess *session.Session,
+	path string) {
+	if path == "" {
+		httpError(w, http.StatusBadRequest, "A path to save to is required")
+		return
+	}
+	overwrite := r.URL.Query().Get("overwrite") == "true"
+	saved, err := sess.Save(r.Context(), path, overwrite)
+	var verr *wwise.VerifyError
+	switch {
+	case err == session.ErrSaveOverSource || err == session.ErrExists:
+		httpError(w, http.StatusConflict, "%s", err)
+		return
+	case errors.As(err, &verr):
+		httpError(w, http.StatusInternalServerError, "%s", err)
+		return
+	case err != nil:
+		httpError(w, http.StatusInternalServerError, "Could not write output "+
+			"file: %s", err)
+		return
+	}
+	res := map[string]interface{}{"path": path, "bytes": saved.Bytes}
+	if saved.Backup != "" {
+		res["backup"] = saved.Backup
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// export writes every wem of the session to a file within dir, named by its id,
+// converted to the format named by formatName, or as it is if formatName is
+// empty.
+func (s *Server) export(w http.ResponseWriter, sess *session.Session,
+	dir string, formatName string) {
+	if dir == "" {
+		httpError(w, http.StatusBadRequest, "A directory to export to is "+
+			"required")
+		return
+	}
+	exporter := new(wwise.Exporter)
+	ext := wemExtension
+	if formatName != "" {
+		to, ok := s.checkFormat(w, formatName)
+		if !ok {
+			return
+		}
+		ext = to.Extension()
+		exporter.Export = func(wem *wwise.Wem, path string) (int64, error) {
+			return s.exportConverted(wem, to, path)
+		}
+	}
+	if err := os.MkdirAll(util.LongPath(dir), os.ModePerm); err != nil {
+		httpError(w, http.StatusInternalServerError, "Could not create output "+
+			"directory: %s", err)
+		return
+	}
+
+	wems := sess.Container.Wems()
+	paths := make([]string, len(wems))
+	for i, wem := range wems {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d%s", wem.Descriptor.WemId,
+			ext))
+	}
+	res, _ := exporter.Run(wems, paths, nil)
+	result := &exportResult{dir, res.Exported, res.Written, []string{}}
+	for _, xerr := range res.Errors {
+		result.Errors = append(result.Errors, xerr.Error())
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// exportConverted converts wem to the audio format given by to, writing it to
+// the file at path. It returns the size of the file written.
+func (s *Server) exportConverted(wem *wwise.Wem, to convert.Format,
+	path string) (int64, error) {
+	format, err := wem.Format()
+	if err != nil {
+		return 0, err
+	}
+	if err := s.Converter.Convert(wem, format, to, path); err != nil {
+		return 0, err
+	}
+	fi, err := os.Stat(util.LongPath(path))
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func httpError(w http.ResponseWriter, status int, format string,
+	v ...interface{}) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, v...)})
+}
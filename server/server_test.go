@@ -0,0 +1,292 @@
+// Package server implements an HTTP API over SoundBanks and File Packages, so
+// that other tools, such as web based mod tools, can open, edit and save
+// containers without reading their formats themselves.
+package server
+
+// System tests of the endpoints of the server package.
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+import (
+	"bnk"
+)
+
+// The root directory of the servers of the tests, and the containers within
+// it, relative to the root.
+const (
+	testRoot          = ".."
+	loopNoneSoundBank = "bnk/testdata/loop_none.bnk"
+	loop2SoundBank    = "bnk/testdata/loop_2.bnk"
+	simpleFilePackage = "pck/testdata/simple.pck"
+)
+
+// newTestServer starts a Server whose root is root.
+func newTestServer(t *testing.T, root string) (*Server, *httptest.Server) {
+	s, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Root = root
+	return s, httptest.NewServer(s)
+}
+
+// do sends a request to ts, with the key of its Server, failing t unless the
+// response has status. The body of the response is decoded into v if it is not
+// nil, and returned.
+func do(t *testing.T, ts *httptest.Server, method, path string, body io.Reader,
+	status int, v interface{}) []byte {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+Path+path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+ts.Config.Handler.(*Server).Key)
+	return send(t, req, status, v)
+}
+
+// send sends req, failing t unless the response has status. The body of the
+// response is decoded into v if it is not nil, and returned.
+func send(t *testing.T, req *http.Request, status int,
+	v interface{}) []byte {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != status {
+		t.Fatalf("Expected status %d from %s %s but got %d: %s", status,
+			req.Method, req.URL.Path, resp.StatusCode, data)
+	}
+	if v != nil {
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return data
+}
+
+// openPath opens the container at path on ts, returning its token.
+func openPath(t *testing.T, ts *httptest.Server, path string) string {
+	t.Helper()
+	var opened struct {
+		Token string `json:"token"`
+	}
+	do(t, ts, http.MethodPost, "?path="+url.QueryEscape(path), nil,
+		http.StatusCreated, &opened)
+	return opened.Token
+}
+
+func TestLoopEditMatchesSoundBank(t *testing.T) {
+	s, ts := newTestServer(t, testRoot)
+	defer s.Close()
+	defer ts.Close()
+
+	token := openPath(t, ts, loopNoneSoundBank)
+	var wems []*wemInfo
+	do(t, ts, http.MethodGet, "/"+token+"/wems", nil, http.StatusOK, &wems)
+	if len(wems) == 0 || wems[0].Loop == nil || wems[0].Loop.Loops {
+		t.Fatalf("Expected the first wem to have an editable loop of none")
+	}
+
+	do(t, ts, http.MethodPut, "/"+token+"/wems/1/loop",
+		bytes.NewReader([]byte(`{"loops": true, "value": 2}`)),
+		http.StatusNoContent, nil)
+	loop := new(loopInfo)
+	do(t, ts, http.MethodGet, "/"+token+"/wems/1/loop", nil, http.StatusOK, loop)
+	if *loop != (loopInfo{true, 2}) {
+		t.Errorf("Expected a loop of 2 but got %+v", *loop)
+	}
+	do(t, ts, http.MethodPut, "/"+token+"/wems/1/loop",
+		bytes.NewReader([]byte(`{"times": 2}`)), http.StatusBadRequest, nil)
+
+	got := do(t, ts, http.MethodGet, "/"+token, nil, http.StatusOK, nil)
+	expected, err := ioutil.ReadFile(filepath.Join(testRoot, loop2SoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Error("The downloaded SoundBank differs from", loop2SoundBank)
+	}
+}
+
+func TestReplaceExportAndSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, ts := newTestServer(t, dir)
+	defer s.Close()
+	defer ts.Close()
+
+	data, err := ioutil.ReadFile(filepath.Join(testRoot, loopNoneSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opened struct {
+		Token string `json:"token"`
+		Wems  int    `json:"wems"`
+	}
+	do(t, ts, http.MethodPost, "", bytes.NewReader(data), http.StatusCreated,
+		&opened)
+	token := opened.Token
+
+	replacement := bytes.Repeat([]byte{0xAB}, 100)
+	do(t, ts, http.MethodPut, "/"+token+"/wems/1",
+		bytes.NewReader(replacement), http.StatusNoContent, nil)
+	got := do(t, ts, http.MethodGet, "/"+token+"/wems/1", nil, http.StatusOK,
+		nil)
+	if !bytes.Equal(got, replacement) {
+		t.Error("The downloaded wem differs from its replacement")
+	}
+	do(t, ts, http.MethodGet, "/"+token+"/wems/1?format=wav", nil,
+		http.StatusNotImplemented, nil)
+	do(t, ts, http.MethodGet, "/"+token+"/wems/0", nil, http.StatusNotFound, nil)
+
+	result := new(exportResult)
+	do(t, ts, http.MethodPost, "/"+token+"/export?dir=wems", nil,
+		http.StatusOK, result)
+	if result.Exported != opened.Wems || len(result.Errors) != 0 {
+		t.Errorf("Expected %d wems to be exported but got %+v", opened.Wems,
+			result)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "wems")); err != nil {
+		t.Errorf("Expected the wems to be exported within the root: %s", err)
+	}
+
+	path := filepath.Join(dir, "saved.bnk")
+	do(t, ts, http.MethodPost, "/"+token+"/save?path="+url.QueryEscape(path),
+		nil, http.StatusOK, nil)
+	saved, err := bnk.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+	wem, err := ioutil.ReadAll(saved.Wems()[0].NewReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(wem, replacement) {
+		t.Error("The saved SoundBank does not hold the replacement")
+	}
+
+	// An existing file is only overwritten when asked to, and is backed up first
+	// if it is an original file of the game.
+	do(t, ts, http.MethodPost, "/"+token+"/save?path="+url.QueryEscape(path),
+		nil, http.StatusConflict, nil)
+	game := filepath.Join(dir, "nativePC", "saved.bnk")
+	if err := os.Mkdir(filepath.Dir(game), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(game, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	var overwritten struct {
+		Backup string `json:"backup"`
+	}
+	do(t, ts, http.MethodPost, "/"+token+"/save?overwrite=true&path="+
+		url.QueryEscape(game), nil, http.StatusOK, &overwritten)
+	backup, err := ioutil.ReadFile(overwritten.Backup)
+	if err != nil {
+		t.Fatalf("Expected the overwritten file to be backed up: %s", err)
+	}
+	if !bytes.Equal(backup, data) {
+		t.Error("The backup differs from the overwritten file")
+	}
+
+	do(t, ts, http.MethodDelete, "/"+token, nil, http.StatusNoContent, nil)
+	do(t, ts, http.MethodGet, "/"+token+"/wems", nil, http.StatusNotFound, nil)
+}
+
+func TestFilePackageInfo(t *testing.T) {
+	s, ts := newTestServer(t, testRoot)
+	defer s.Close()
+	defer ts.Close()
+
+	token := openPath(t, ts, simpleFilePackage)
+	info := new(containerInfo)
+	do(t, ts, http.MethodGet, "/"+token+"/info", nil, http.StatusOK, info)
+	if info.Type != "File Package" || info.Wems == 0 {
+		t.Errorf("Unexpected info %+v", info)
+	}
+	if _, ok := info.Unsupported["edit loops"]; !ok {
+		t.Error("Expected loops not to be editable within a File Package")
+	}
+	do(t, ts, http.MethodGet, "/"+token+"/wems/1/loop", nil,
+		http.StatusConflict, nil)
+}
+
+func TestRequestsAreRestricted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, ts := newTestServer(t, dir)
+	defer s.Close()
+	defer ts.Close()
+
+	// Without the key, or with the wrong one.
+	req, err := http.NewRequest(http.MethodPost, ts.URL+Path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send(t, req, http.StatusUnauthorized, nil)
+	req.Header.Set("Authorization", "Bearer "+s.Key+"0")
+	send(t, req, http.StatusUnauthorized, nil)
+
+	// The key may also be given as a query parameter.
+	req, err = http.NewRequest(http.MethodGet, ts.URL+Path+"/missing?key="+
+		s.Key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send(t, req, http.StatusNotFound, nil)
+
+	// A domain name that has been rebound to a loopback address.
+	req.Host = "attacker.example:80"
+	send(t, req, http.StatusForbidden, nil)
+
+	// Paths outside of the root, including through a symbolic link.
+	outside := filepath.Join(testRoot, loopNoneSoundBank)
+	abs, err := filepath.Abs(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{abs, "../other.bnk"} {
+		do(t, ts, http.MethodPost, "?path="+url.QueryEscape(path), nil,
+			http.StatusForbidden, nil)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(filepath.Dir(abs), link); err == nil {
+		do(t, ts, http.MethodPost, "?path="+url.QueryEscape("link/"+
+			filepath.Base(abs)), nil, http.StatusForbidden, nil)
+	}
+
+	// Containers sent in the request body are still accepted without a root.
+	s.Root = ""
+	data, err := ioutil.ReadFile(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	do(t, ts, http.MethodPost, "", bytes.NewReader(data), http.StatusCreated,
+		nil)
+	do(t, ts, http.MethodPost, "?path="+url.QueryEscape(abs), nil,
+		http.StatusForbidden, nil)
+}
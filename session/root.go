@@ -0,0 +1,76 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// A RootError is returned when a path given by a client is refused, as no root
+// directory is set, or as the path is not within it.
+type RootError struct {
+	Path string
+	// The root directory, which is empty if none is set.
+	Root string
+}
+
+func (e *RootError) Error() string {
+	if e.Root == "" {
+		return "This server does not accept paths"
+	}
+	return fmt.Sprintf("%s is not within the root directory of this server",
+		e.Path)
+}
+
+// Resolve returns path, given by a client, resolved relative to root. It
+// returns a *RootError if root is empty, or if path is not within root once
+// symbolic links are followed. An empty path is returned as it is, for the
+// caller to report.
+func Resolve(root, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	if root == "" {
+		return "", &RootError{Path: path}
+	}
+	abs, err := filepath.Abs(root)
+	if err == nil {
+		abs, err = filepath.EvalSymlinks(abs)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Could not resolve the root directory: %s", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(abs, path)
+	}
+	path = filepath.Clean(path)
+	if !within(abs, evalExisting(path)) {
+		return "", &RootError{Path: path, Root: root}
+	}
+	return path, nil
+}
+
+// evalExisting returns path with the symbolic links of its longest existing
+// prefix followed, as the rest of it, which is yet to be created, has none.
+func evalExisting(path string) string {
+	rest := ""
+	for {
+		if eval, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(eval, rest)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, rest)
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
+
+// within returns true if path is dir, or is within it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator)) &&
+		!filepath.IsAbs(rel)
+}
@@ -0,0 +1,297 @@
+// Package session keeps containers open between the requests of the clients
+// of the APIs of wwiseutil, such as those of the server and rpc packages, along
+// with the replacements that the clients send to them.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+import (
+	"bnk"
+	"pck"
+	"util"
+	"wwise"
+)
+
+// The error returned when a container is saved over the file it was opened
+// from, which is still being read from.
+var ErrSaveOverSource = errors.New("Cannot save over the file that the " +
+	"container was opened from")
+
+// The error returned when a container is saved over an existing file without
+// being asked to overwrite it.
+var ErrExists = errors.New("The file already exists; ask to overwrite it to " +
+	"save over it")
+
+// A Session is a single open container, along with the replacements sent to
+// it. A Session is only used while it is locked, by the Store that it is kept
+// in.
+type Session struct {
+	mu        sync.Mutex
+	Container wwise.Container
+	// The path the container was opened from, if it was opened from a path.
+	Path string
+	// The indexes, starting at 0, of the wems that have been replaced.
+	Replaced map[int]bool
+	// The buffers of the container and replacements sent by the client, which
+	// are released once the session is closed.
+	buffers []*util.SpillBuffer
+	closed  bool
+}
+
+// New creates a Session for ctn, opened from path, or from data sent by a
+// client if path is empty.
+func New(ctn wwise.Container, path string) *Session {
+	return &Session{Container: ctn, Path: path, Replaced: make(map[int]bool)}
+}
+
+// Keep keeps b until the session is closed, then closes it.
+func (sess *Session) Keep(b *util.SpillBuffer) {
+	sess.buffers = append(sess.buffers, b)
+}
+
+// Replace replaces the wem at index, starting at 0, with the length bytes of
+// r.
+func (sess *Session) Replace(index int, r io.ReaderAt, length int64) {
+	sess.Container.ReplaceWems(&wwise.ReplacementWem{Wem: r, WemIndex: index,
+		Length: length})
+	sess.Replaced[index] = true
+}
+
+// A Saved describes a container written by Session.Save.
+type Saved struct {
+	// The number of bytes written.
+	Bytes int64
+	// The path of the backup of the file that was overwritten, or empty if none
+	// was made.
+	Backup string
+}
+
+// Save writes the container, with every edit applied, to path, as the command
+// line tool writes its output: an existing file is only overwritten if
+// overwrite is true, and is first backed up beside itself if it is an original
+// file of the game, as given by util.NeedsBackup. The written file is then
+// re-opened and verified, stopping once ctx is done.
+//
+// ErrSaveOverSource is returned if path is the file that the container was
+// opened from, ErrExists if path exists and overwrite is false, and a
+// *wwise.VerifyError if the written file does not hold what the container does.
+func (sess *Session) Save(ctx context.Context, path string,
+	overwrite bool) (*Saved, error) {
+	if sess.Path != "" && util.SameFile(path, sess.Path) {
+		return nil, ErrSaveOverSource
+	}
+	saved := new(Saved)
+	if _, err := os.Stat(path); err == nil {
+		if !overwrite {
+			return nil, ErrExists
+		}
+		if util.NeedsBackup(path, sess.Path) {
+			if saved.Backup, err = util.BackupFile(path, time.Now()); err != nil {
+				return nil, fmt.Errorf("Could not back up \"%s\": %s", path, err)
+			}
+		}
+	}
+	err := util.WriteFileAtomic(path, func(f *os.File) (err error) {
+		saved.Bytes, err = sess.Container.WriteTo(f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = wwise.Verify(ctx, sess.Container, path, OpenLike(sess.Container), nil)
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// Unlock unlocks a session returned by Store.Lock.
+func (sess *Session) Unlock() {
+	sess.mu.Unlock()
+}
+
+// Close closes the container of a locked session, and releases the buffers
+// that it keeps. The session cannot be locked again.
+func (sess *Session) Close() {
+	if sess.closed {
+		return
+	}
+	sess.closed = true
+	sess.Container.Close()
+	for _, b := range sess.buffers {
+		b.Close()
+	}
+}
+
+// A Store keeps open sessions, keyed by the tokens or handles that clients
+// name them by.
+type Store struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewStore creates a Store with no sessions.
+func NewStore() *Store {
+	return &Store{sessions: make(map[string]*Session)}
+}
+
+// Add keeps sess under a new random token, which it returns. The session is
+// closed if no token could be created.
+func (s *Store) Add(sess *Session) (string, error) {
+	token, err := NewToken()
+	if err != nil {
+		sess.Close()
+		return "", err
+	}
+	s.Put(token, sess)
+	return token, nil
+}
+
+// Put keeps sess under key.
+func (s *Store) Put(key string, sess *Session) {
+	s.mu.Lock()
+	s.sessions[key] = sess
+	s.mu.Unlock()
+}
+
+// Lock returns the session kept under key once it is locked, waiting for any
+// request that is using it to be done. It returns nil if there is no such
+// session, or if it was closed while waiting.
+func (s *Store) Lock(key string) *Session {
+	s.mu.Lock()
+	sess := s.sessions[key]
+	s.mu.Unlock()
+	if sess == nil {
+		return nil
+	}
+	sess.mu.Lock()
+	if sess.closed {
+		sess.mu.Unlock()
+		return nil
+	}
+	return sess
+}
+
+// Remove stops keeping the session under key, returning it, or nil if there is
+// no such session. The session is not closed.
+func (s *Store) Remove(key string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.sessions[key]
+	delete(s.sessions, key)
+	return sess
+}
+
+// Close removes the session under key, and closes it once any request that is
+// using it is done. It returns false if there is no such session.
+func (s *Store) Close(key string) bool {
+	sess := s.Remove(key)
+	if sess == nil {
+		return false
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.Close()
+	return true
+}
+
+// CloseAll closes every session, each once any request that is using it is
+// done.
+func (s *Store) CloseAll() {
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]*Session)
+	s.mu.Unlock()
+	for _, sess := range sessions {
+		sess.mu.Lock()
+		sess.Close()
+		sess.mu.Unlock()
+	}
+}
+
+// OpenFile opens the SoundBank or File Package at path, by its extension,
+// stopping once ctx is done.
+func OpenFile(ctx context.Context, path string) (wwise.Container, error) {
+	switch t, ext := util.GetFileType(path); t {
+	case util.SoundBankFileType:
+		return bnk.OpenContext(ctx, path, nil)
+	case util.FilePackageFileType:
+		return pck.OpenContext(ctx, path, nil)
+	default:
+		return nil, fmt.Errorf("%s, is not a supported input file type", ext)
+	}
+}
+
+// OpenLike returns the function that opens files of the same type as ctn,
+// whatever their extension.
+func OpenLike(ctn wwise.Container) wwise.OpenFunc {
+	if _, ok := ctn.(*bnk.File); ok {
+		return func(ctx context.Context, path string) (wwise.Container, error) {
+			return bnk.OpenContext(ctx, path, nil)
+		}
+	}
+	return func(ctx context.Context, path string) (wwise.Container, error) {
+		return pck.OpenContext(ctx, path, nil)
+	}
+}
+
+// Read reads a container sent by a client from r into a new buffer, returning
+// a Session for it that keeps the buffer.
+func Read(r io.Reader) (*Session, error) {
+	b := util.NewSpillBuffer()
+	if _, err := util.Copy(b, r); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse parses the container sent by a client that b holds, by its leading
+// identifier, returning a Session for it that keeps b. b is closed if it does
+// not hold a container.
+func Parse(b *util.SpillBuffer) (*Session, error) {
+	var ctn wwise.Container
+	var err error
+	switch util.GetStreamType(b) {
+	case util.SoundBankFileType:
+		ctn, err = bnk.NewFile(b)
+	case util.FilePackageFileType:
+		ctn, err = pck.NewFile(b)
+	default:
+		err = errors.New("The data sent is not a SoundBank or File Package")
+	}
+	if err != nil {
+		b.Close()
+		return nil, err
+	}
+	sess := New(ctn, "")
+	sess.Keep(b)
+	return sess, nil
+}
+
+// NewToken returns a random token, or key, that cannot be guessed.
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CheckKey returns true if given is key, or if key is empty. The comparison
+// takes the same time wherever the two differ.
+func CheckKey(given, key string) bool {
+	return key == "" || subtle.ConstantTimeCompare([]byte(given),
+		[]byte(key)) == 1
+}
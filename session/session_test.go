@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+const loopNoneSoundBank = "../bnk/testdata/loop_none.bnk"
+
+func TestStoreClose(t *testing.T) {
+	ctn, err := OpenFile(context.Background(), loopNoneSoundBank)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore()
+	token, err := s.Add(New(ctn, loopNoneSoundBank))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := s.Lock(token)
+	if sess == nil {
+		t.Fatal("Expected the session to be kept under its token")
+	}
+	ctx := context.Background()
+	_, err = sess.Save(ctx, loopNoneSoundBank, true)
+	if err != ErrSaveOverSource {
+		t.Errorf("Expected saving over the source to fail but got: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "out.bnk")
+	if _, err := sess.Save(ctx, out, false); err != nil {
+		t.Error(err)
+	}
+	if _, err := sess.Save(ctx, out, false); err != ErrExists {
+		t.Errorf("Expected saving over an existing file to fail but got: %v",
+			err)
+	}
+	if _, err := sess.Save(ctx, out, true); err != nil {
+		t.Error(err)
+	}
+
+	// A request waiting for the session finds it closed once it is unlocked.
+	closed := make(chan bool)
+	go func() { closed <- s.Close(token) }()
+	sess.Unlock()
+	if !<-closed {
+		t.Error("Expected the session to be closed")
+	}
+	if s.Lock(token) != nil {
+		t.Error("Expected no session once it is closed")
+	}
+	if s.Close(token) {
+		t.Error("Expected a closed session not to be closed again")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	if _, err := Resolve("", "a.bnk"); err == nil {
+		t.Error("Expected paths to be refused without a root")
+	}
+	path, err := Resolve(root, "a.bnk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if abs, _ := filepath.EvalSymlinks(root); filepath.Dir(path) != abs {
+		t.Errorf("Expected %s to be within %s", path, abs)
+	}
+	for _, p := range []string{"../a.bnk", filepath.Dir(root)} {
+		if _, err := Resolve(root, p); err == nil {
+			t.Errorf("Expected %s to be refused", p)
+		} else if _, ok := err.(*RootError); !ok {
+			t.Errorf("Expected a RootError for %s but got: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic calls write with a temporary file beside path, and renames it
+// over path only once write and every step after it succeed. Until then, any
+// existing file at path is left as it was, so a save that fails part of the way
+// through, or a file at path that is still being read from, is never
+// truncated. The temporary file is removed on failure.
+//
+// The file takes the permissions of the file it replaces, or 0644 if there is
+// no file at path. If path is a symbolic link, the file it links to is
+// replaced. Files that cannot be replaced, such as devices and named pipes,
+// are written to directly.
+func WriteFileAtomic(path string, write func(f *os.File) error) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		if !info.Mode().IsRegular() {
+			return writeFileDirect(path, write)
+		}
+		mode = info.Mode().Perm()
+		if target, err := filepath.EvalSymlinks(path); err == nil {
+			path = target
+		}
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+
+		"-*")
+	if err != nil {
+		return err
+	}
+	err = write(tmp)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// writeFileDirect calls write with the file at path, opened for writing.
+func writeFileDirect(path string, write func(f *os.File) error) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The layout of the timestamp in the names of backups.
+const backupTimeLayout = "20060102-150405"
+
+// The extension of backups.
+const BackupExtension = ".bak"
+
+// GamePath returns the path of path relative to the root of the game data that
+// it is within, which is the innermost nativePC or chunk directory, with
+// slashes as separators. It returns false if path is not within game data.
+func GamePath(path string) (string, bool) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		dir := strings.ToLower(parts[i])
+		if dir == "nativepc" || strings.HasPrefix(dir, "chunk") {
+			return strings.Join(parts[i+1:], "/"), true
+		}
+	}
+	return "", false
+}
+
+// NeedsBackup returns true if the existing file at path should be backed up
+// before it is overwritten by a container edited from source: either path is
+// source itself, or it is within game data, where it is likely an original
+// file of the game.
+func NeedsBackup(path, source string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if si, err := os.Stat(source); err == nil && os.SameFile(info, si) {
+		return true
+	}
+	_, ok := GamePath(path)
+	return ok
+}
+
+// BackupFile copies the file at path to a new file beside it, named by path, the
+// time now and BackupExtension, such as simple.bnk.20060102-150405.bak. If a
+// backup of that name already exists, a number is added to the name. It
+// returns the path of the backup.
+func BackupFile(path string, now time.Time) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	base := path + "." + now.Format(backupTimeLayout)
+	backup := base + BackupExtension
+	var dst *os.File
+	for i := 2; ; i++ {
+		dst, err = os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+			info.Mode().Perm())
+		if !os.IsExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s-%d%s", base, i, BackupExtension)
+	}
+	if err != nil {
+		return "", err
+	}
+	_, err = Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(backup)
+		return "", err
+	}
+	return backup, nil
+}
@@ -0,0 +1,297 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IndexCacheDir is the directory that the indexes of large containers are
+// cached in, so that opening them again does not read their indexes from the
+// file. Caching is disabled if it is empty, as it is by default; applications
+// opt in by setting it, usually to DefaultIndexCacheDir.
+var IndexCacheDir string
+
+// MaxIndexCacheDirSize is the total size of the cache files that are kept in
+// IndexCacheDir. Once it is exceeded, the least recently used are removed.
+var MaxIndexCacheDirSize int64 = 256 << 20
+
+// MinIndexCacheSize is the size of the smallest file whose index is cached.
+// Smaller files are read quickly enough without a cache.
+var MinIndexCacheSize int64 = 64 << 20
+
+// The largest number of bytes that an IndexCache records. A container whose
+// index is larger than this is not cached.
+const maxIndexCacheBytes = 16 << 20
+
+// The number of bytes at the start of a file, holding the header and the
+// section table or index of a container, whose hash is compared with the
+// cache, so that a file rewritten with the same size and modification time
+// does not use a stale cache.
+const indexCacheCheckBytes = 64 << 10
+
+// DefaultIndexCacheDir returns the directory in the user cache directory that
+// indexes are cached in, or an empty string if there is no user cache
+// directory.
+func DefaultIndexCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "wwiseutil", "index")
+}
+
+// An IndexCache is an io.ReaderAt over a container file that records the parts
+// of the file that are read while the container is parsed, which are its
+// indexes and headers. Once saved, the same parts are read from the cache when
+// the file is next opened, rather than from the file, for as long as the file
+// is not modified.
+//
+// Once the cache has been saved, or was loaded, its ranges no longer change, so
+// reads, such as those of wems, take no lock.
+type IndexCache struct {
+	r    io.ReaderAt
+	path string
+	key  indexCacheKey
+	// Nonzero, accessed atomically, once the ranges no longer change.
+	done uint32
+
+	mu sync.Mutex
+	// The parts of the file that have been recorded or loaded, in order of their
+	// offset.
+	ranges    []*cachedRange
+	recorded  int64
+	recording bool
+	loaded    bool
+}
+
+// An indexCacheKey identifies the version of a file that a cache was recorded
+// from.
+type indexCacheKey struct {
+	Path    string
+	Size    int64
+	ModTime int64
+	// The hash of the first indexCacheCheckBytes of the file.
+	Head [sha256.Size]byte
+}
+
+// A cachedRange is a part of a file, starting at Offset.
+type cachedRange struct {
+	Offset int64
+	Data   []byte
+}
+
+// The contents of a cache file.
+type indexCacheFile struct {
+	Key    indexCacheKey
+	Ranges []*cachedRange
+}
+
+// NewIndexCache returns an IndexCache that reads the file at path from r. If
+// the index of the file has been cached, it is loaded; otherwise, reads are
+// recorded until Save is called. If the file is too small to be cached, or
+// caching is disabled, every read is passed to r.
+func NewIndexCache(path string, r io.ReaderAt) *IndexCache {
+	c := &IndexCache{r: r, done: 1}
+	if IndexCacheDir == "" {
+		return c
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < MinIndexCacheSize {
+		return c
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return c
+	}
+	head := make([]byte, indexCacheCheckBytes)
+	if info.Size() < indexCacheCheckBytes {
+		head = head[:info.Size()]
+	}
+	if n, _ := r.ReadAt(head, 0); n < len(head) {
+		return c
+	}
+	sum := sha256.Sum256([]byte(abs))
+	c.path = filepath.Join(IndexCacheDir, hex.EncodeToString(sum[:])+".cache")
+	c.key = indexCacheKey{abs, info.Size(), info.ModTime().UnixNano(),
+		sha256.Sum256(head)}
+	c.loaded = c.load()
+	c.recording = !c.loaded
+	if c.recording {
+		c.done = 0
+	}
+	return c
+}
+
+// load reads the cache of this file, returning false if there is none for the
+// current version of the file.
+func (c *IndexCache) load() bool {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	var cf indexCacheFile
+	if err := gob.NewDecoder(f).Decode(&cf); err != nil || cf.Key != c.key {
+		return false
+	}
+	c.ranges = cf.Ranges
+	// The modification time of a cache file is when it was last used.
+	now := time.Now()
+	os.Chtimes(c.path, now, now)
+	return true
+}
+
+// Loaded returns true if the index of the file was read from the cache.
+func (c *IndexCache) Loaded() bool {
+	return c.loaded
+}
+
+// Size returns the size of the file, as SizeOf does for the reader that the
+// cache was created with.
+func (c *IndexCache) Size() int64 {
+	return SizeOf(c.r)
+}
+
+func (c *IndexCache) ReadAt(p []byte, off int64) (int, error) {
+	if atomic.LoadUint32(&c.done) != 0 {
+		if data := c.lookup(off, int64(len(p))); data != nil {
+			return copy(p, data), nil
+		}
+		return c.r.ReadAt(p, off)
+	}
+
+	c.mu.Lock()
+	if data := c.lookup(off, int64(len(p))); data != nil {
+		c.mu.Unlock()
+		return copy(p, data), nil
+	}
+	recording := c.recording
+	c.mu.Unlock()
+
+	n, err := c.r.ReadAt(p, off)
+	if recording && n > 0 {
+		c.record(off, p[:n])
+	}
+	return n, err
+}
+
+// lookup returns the cached bytes from off to off + n, or nil if they are not
+// all cached.
+func (c *IndexCache) lookup(off, n int64) []byte {
+	i := sort.Search(len(c.ranges), func(i int) bool {
+		return c.ranges[i].Offset+int64(len(c.ranges[i].Data)) > off
+	})
+	if i == len(c.ranges) || c.ranges[i].Offset > off {
+		return nil
+	}
+	rg := c.ranges[i]
+	start := off - rg.Offset
+	if start+n > int64(len(rg.Data)) {
+		return nil
+	}
+	return rg.Data[start : start+n]
+}
+
+// record adds the bytes p, read from off, to the cache. Parsing reads a file
+// mostly in order, so a read that directly follows the last one extends it.
+func (c *IndexCache) record(off int64, p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.recording {
+		return
+	}
+	c.recorded += int64(len(p))
+	if c.recorded > maxIndexCacheBytes {
+		c.recording, c.ranges = false, nil
+		return
+	}
+	if n := len(c.ranges); n > 0 {
+		last := c.ranges[n-1]
+		if end := last.Offset + int64(len(last.Data)); end == off {
+			last.Data = append(last.Data, p...)
+			return
+		} else if end > off {
+			// Keep the ranges in order; overlapping reads are not recorded.
+			return
+		}
+	}
+	data := append([]byte(nil), p...)
+	c.ranges = append(c.ranges, &cachedRange{off, data})
+}
+
+// Save stops recording reads and, if the index of the file was not loaded from
+// the cache, writes what was recorded to the cache, then removes the least
+// recently used caches beyond MaxIndexCacheDirSize.
+func (c *IndexCache) Save() error {
+	c.mu.Lock()
+	recording := c.recording
+	c.recording = false
+	atomic.StoreUint32(&c.done, 1)
+	c.mu.Unlock()
+	if !recording || len(c.ranges) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(IndexCacheDir, os.ModePerm); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a cache is never read while it
+	// is only partly written.
+	tmp, err := ioutil.TempFile(IndexCacheDir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = gob.NewEncoder(tmp).Encode(&indexCacheFile{c.key, c.ranges})
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return err
+	}
+	pruneIndexCache(c.path)
+	return nil
+}
+
+// pruneIndexCache removes the least recently used cache files from
+// IndexCacheDir until they are within MaxIndexCacheDirSize, keeping the cache
+// file at keep, which has just been written.
+func pruneIndexCache(keep string) {
+	fis, err := ioutil.ReadDir(IndexCacheDir)
+	if err != nil {
+		return
+	}
+	var caches []os.FileInfo
+	size := int64(0)
+	for _, fi := range fis {
+		if strings.HasSuffix(fi.Name(), ".cache") {
+			caches = append(caches, fi)
+			size += fi.Size()
+		}
+	}
+	sort.Slice(caches, func(i, j int) bool {
+		return caches[i].ModTime().Before(caches[j].ModTime())
+	})
+	for _, fi := range caches {
+		if size <= MaxIndexCacheDirSize {
+			return
+		}
+		path := filepath.Join(IndexCacheDir, fi.Name())
+		if path != keep && os.Remove(path) == nil {
+			size -= fi.Size()
+		}
+	}
+}
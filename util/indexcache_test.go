@@ -0,0 +1,112 @@
+package util
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// withIndexCacheDir caches every file in a new temporary directory until the
+// returned function is called.
+func withIndexCacheDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "wwiseutil-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cacheDir, minSize := IndexCacheDir, MinIndexCacheSize
+	IndexCacheDir, MinIndexCacheSize = dir, 0
+	return func() {
+		IndexCacheDir, MinIndexCacheSize = cacheDir, minSize
+		os.RemoveAll(dir)
+	}
+}
+
+// openIndexCache reads the first n bytes of the file at path through a new
+// IndexCache, which is then saved.
+func openIndexCache(t *testing.T, path string, n int) (*IndexCache, []byte) {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c := NewIndexCache(path, f)
+	p := make([]byte, n)
+	if _, err := c.ReadAt(p, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return c, p
+}
+
+func TestIndexCacheIsLoadedUntilTheHeadChanges(t *testing.T) {
+	defer withIndexCacheDir(t)()
+	path, data := writeTempFile(t, indexCacheCheckBytes+1024)
+	defer os.Remove(path)
+	whole := time.Now().Truncate(time.Second)
+	if err := os.Chtimes(path, whole, whole); err != nil {
+		t.Fatal(err)
+	}
+
+	if c, _ := openIndexCache(t, path, 512); c.Loaded() {
+		t.Error("Expected the first open not to be loaded from the cache")
+	}
+	if c, p := openIndexCache(t, path, 512); !c.Loaded() {
+		t.Error("Expected an unchanged file to be loaded from the cache")
+	} else if !bytes.Equal(p, data[:512]) {
+		t.Error("Read the wrong bytes from the cache")
+	}
+
+	// Change a byte within the head without changing the size or modification
+	// time of the file.
+	data[100]++
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, whole, whole); err != nil {
+		t.Fatal(err)
+	}
+	if c, p := openIndexCache(t, path, 512); c.Loaded() {
+		t.Error("Expected a file whose head changed not to be loaded from the " +
+			"cache")
+	} else if !bytes.Equal(p, data[:512]) {
+		t.Error("Read stale bytes from the cache")
+	}
+}
+
+func TestIndexCacheRemovesLeastRecentlyUsed(t *testing.T) {
+	defer withIndexCacheDir(t)()
+	maxSize := MaxIndexCacheDirSize
+	defer func() { MaxIndexCacheDirSize = maxSize }()
+	MaxIndexCacheDirSize = 3 << 10
+
+	// Each cache is a little over 1 KiB, so only two fit.
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path, _ := writeTempFile(t, 2048)
+		defer os.Remove(path)
+		paths = append(paths, path)
+		openIndexCache(t, path, 1024)
+		// Give each cache a distinct time of last use.
+		caches, _ := filepath.Glob(filepath.Join(IndexCacheDir, "*.cache"))
+		for _, cache := range caches {
+			fi, err := os.Stat(cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			older := fi.ModTime().Add(-time.Minute)
+			os.Chtimes(cache, older, older)
+		}
+	}
+	caches, _ := filepath.Glob(filepath.Join(IndexCacheDir, "*.cache"))
+	if len(caches) != 2 {
+		t.Fatalf("Expected 2 caches to be kept, found %d", len(caches))
+	}
+	if c, _ := openIndexCache(t, paths[0], 1024); c.Loaded() {
+		t.Error("Expected the least recently used cache to be removed")
+	}
+}
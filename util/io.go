@@ -2,15 +2,79 @@
 package util
 
 import (
+	"bytes"
 	"io"
+	"math"
+	"sync"
+	"sync/atomic"
 )
 
+// AutoBufferSize, given to SetCopyBufferSize, makes Copy choose the size of
+// the buffer for each copy from the amount of data being copied.
+const AutoBufferSize = 0
+
+// The range of buffer sizes that are chosen automatically. Large copies, such
+// as the wems of a File Package, are read in larger blocks, which is much faster
+// from hard drives and network shares, but small copies do not need more than
+// the smallest buffer.
+const (
+	minAutoBufferSize = 64 << 10
+	maxAutoBufferSize = 4 << 20
+)
+
+// The number of reads that an automatically sized buffer copies a reader in,
+// until the buffer reaches its largest size.
+const autoBufferReads = 16
+
+// The size of the buffers used by Copy, or AutoBufferSize.
+var copyBufferSize int64 = AutoBufferSize
+
+// The buffers used by Copy, shared so that copying many small readers, such as
+// the wems of a container, does not allocate a new buffer for each. It maps a
+// buffer size to a *sync.Pool of buffers of that size.
+var copyBuffers sync.Map
+
+// SetCopyBufferSize sets the size in bytes of the buffers used by Copy, and so
+// by every write and export of a container. The best size depends on where the
+// data is read from and written to. A size of AutoBufferSize, the default,
+// chooses a size for each copy.
+func SetCopyBufferSize(size int) {
+	if size < 0 {
+		size = AutoBufferSize
+	}
+	atomic.StoreInt64(&copyBufferSize, int64(size))
+}
+
+// CopyBufferSize returns the size in bytes of the buffers used by Copy, or
+// AutoBufferSize if it is chosen for each copy.
+func CopyBufferSize() int {
+	return int(atomic.LoadInt64(&copyBufferSize))
+}
+
+// bufferSize returns the size of the buffer to copy src with.
+func bufferSize(src io.Reader) int {
+	if size := CopyBufferSize(); size != AutoBufferSize {
+		return size
+	}
+	size := minAutoBufferSize
+	if s, ok := src.(interface{ Size() int64 }); ok {
+		for int64(size)*autoBufferReads < s.Size() && size < maxAutoBufferSize {
+			size *= 2
+		}
+	}
+	return size
+}
+
 type ReadSeekerAt interface {
 	io.ReadSeeker
 	io.ReaderAt
 	Size() int64
 }
 
+// A ResettingReader is an io.SectionReader that seeks back to its start once
+// all of it has been read, so that it can be read again. Its position is shared
+// by everything that reads it; use NewIndependentReader to read it from several
+// goroutines at once.
 type ResettingReader struct {
 	*io.SectionReader
 }
@@ -27,6 +91,21 @@ func (r *ResettingReader) Read(p []byte) (n int, err error) {
 	return
 }
 
+// NewIndependentReader returns a reader over all of the data of r that reads
+// it with ReadAt, at an offset of its own. Reading it neither changes nor
+// depends on the position of r, so any number of independent readers, over the
+// same or different parts of a file, can be read from different goroutines at
+// once. If r does not support random access, r itself is returned.
+func NewIndependentReader(r io.Reader) io.Reader {
+	if ra, ok := r.(interface {
+		io.ReaderAt
+		Size() int64
+	}); ok {
+		return io.NewSectionReader(ra, 0, ra.Size())
+	}
+	return r
+}
+
 // A utility ReaderAt that emits an infinite stream of a specific value.
 type InfiniteReaderAt struct {
 	// The value that this padding writer will write.
@@ -46,3 +125,73 @@ func (r *InfiniteReaderAt) ReadAt(p []byte, off int64) (int, error) {
 func NewConstantReader(size int64) io.ReaderAt {
 	return io.NewSectionReader(&InfiniteReaderAt{'A'}, 0, size)
 }
+
+// NewSeekableReaderAt returns r as an io.ReaderAt if it supports random access.
+// Otherwise, such as when r is a pipe, all of r is buffered into a SpillBuffer,
+// which is returned; it should be closed once it is no longer read.
+func NewSeekableReaderAt(r io.Reader) (io.ReaderAt, error) {
+	if ra, ok := r.(io.ReaderAt); ok {
+		if s, ok := r.(io.Seeker); ok {
+			if _, err := s.Seek(0, io.SeekCurrent); err == nil {
+				return ra, nil
+			}
+		}
+	}
+	b := NewSpillBuffer()
+	if _, err := Copy(b, r); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// SizeOf returns the size of r, if it reports one with a Size method.
+// Otherwise, the size of r is unknown, and math.MaxInt64 is returned, so that r
+// is read until it ends.
+func SizeOf(r io.ReaderAt) int64 {
+	if s, ok := r.(interface{ Size() int64 }); ok {
+		return s.Size()
+	}
+	return math.MaxInt64
+}
+
+// The largest buffer that ReadFull allocates before anything is read into it.
+const maxReadFullBuffer = 1 << 20
+
+// ReadFull reads exactly n bytes from r, returning io.ErrUnexpectedEOF if r
+// ends first. Unlike io.ReadFull, the buffer that the bytes are read into grows
+// as they are read, so a length read from a corrupt file cannot make it
+// allocate much more memory than r holds.
+func ReadFull(r io.Reader, n int64) ([]byte, error) {
+	size := n
+	if size > maxReadFullBuffer {
+		size = maxReadFullBuffer
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	read, err := buf.ReadFrom(io.LimitReader(r, n))
+	if err != nil {
+		return nil, err
+	}
+	if read < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), nil
+}
+
+// Copy is like io.Copy, but copies through a buffer taken from a pool shared by
+// every caller, instead of allocating one for each copy.
+func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	size := bufferSize(src)
+	pool, ok := copyBuffers.Load(size)
+	if !ok {
+		pool, _ = copyBuffers.LoadOrStore(size, &sync.Pool{
+			New: func() interface{} {
+				b := make([]byte, size)
+				return &b
+			},
+		})
+	}
+	buf := pool.(*sync.Pool).Get().(*[]byte)
+	defer pool.(*sync.Pool).Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
@@ -0,0 +1,99 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// A LazyFile is an io.ReaderAt over a file that is opened only while it is
+// being read. Staging a replacement as a LazyFile holds neither its contents
+// in memory nor a handle to it open, so any number of replacements can be
+// staged; their data is read from disk only once the container is written.
+//
+// The file is opened for each read, and is shared by the reads made at once,
+// so it is held open only while it is being read. It is an error for the file
+// to change size once the LazyFile is created.
+type LazyFile struct {
+	path string
+	size int64
+
+	mu      sync.Mutex
+	f       *os.File
+	readers int
+}
+
+// OpenLazy returns a LazyFile over the file at path. The file is only checked
+// to exist; it is not kept open.
+func OpenLazy(path string) (*LazyFile, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	return &LazyFile{path: path, size: fi.Size()}, nil
+}
+
+// Name returns the path of the file.
+func (lf *LazyFile) Name() string {
+	return lf.path
+}
+
+// Size returns the size of the file when the LazyFile was created.
+func (lf *LazyFile) Size() int64 {
+	return lf.size
+}
+
+func (lf *LazyFile) ReadAt(p []byte, off int64) (int, error) {
+	f, err := lf.acquire()
+	if err != nil {
+		return 0, err
+	}
+	n, err := f.ReadAt(p, off)
+	lf.release()
+	return n, err
+}
+
+// acquire opens the file, unless it is already open, for a read.
+func (lf *LazyFile) acquire() (*os.File, error) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if lf.f == nil {
+		f, err := os.Open(lf.path)
+		if err != nil {
+			return nil, err
+		}
+		fi, err := f.Stat()
+		if err == nil && fi.Size() != lf.size {
+			err = fmt.Errorf("%s changed size from %d to %d bytes since it was "+
+				"staged", lf.path, lf.size, fi.Size())
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		lf.f = f
+	}
+	lf.readers++
+	return lf.f, nil
+}
+
+// release ends a read, closing the file if no other read is in progress.
+func (lf *LazyFile) release() {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	lf.readers--
+	if lf.readers == 0 {
+		lf.f.Close()
+		lf.f = nil
+	}
+}
+
+// Close has no effect, as the file is only open while it is being read. It
+// allows a LazyFile to be used wherever a replacement is closed once it is no
+// longer staged.
+func (lf *LazyFile) Close() error {
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package util
+
+// LongPath returns path as it is, as only Windows limits paths to MAX_PATH.
+func LongPath(path string) string {
+	return path
+}
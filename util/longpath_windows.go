@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package util
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// The length of the longest path that Windows opens without the \\?\ prefix.
+// Paths are limited to MAX_PATH, 260 characters, and directories to 12 fewer,
+// so that there is room for a file with an 8.3 name within them.
+const maxShortPathLength = 247
+
+// LongPath returns path in a form that Windows can open even if it is longer
+// than MAX_PATH. Once the absolute path is longer than that, it is returned
+// with the \\?\ prefix, which lifts the limit, or as \\?\UNC\server\share if it
+// is on a network share. Other paths are returned as they are.
+func LongPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	// The length in bytes is never less than the length in UTF-16 code units,
+	// which Windows counts, so non-ASCII paths are prefixed early, if at all.
+	if err != nil || len(abs) <= maxShortPathLength {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
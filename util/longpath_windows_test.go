@@ -0,0 +1,42 @@
+//go:build windows
+// +build windows
+
+package util
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	long := `C:\` + strings.Repeat(`directory\`, 30) + "music.wem"
+	share := `\\server\share\` + strings.Repeat(`directory\`, 30) + "music.wem"
+	cases := []struct {
+		path, want string
+	}{
+		{`C:\music.wem`, `C:\music.wem`},
+		{long, `\\?\` + long},
+		{share, `\\?\UNC\` + share[2:]},
+		{`\\?\` + long, `\\?\` + long},
+		{`\\.\pipe\wwiseutil`, `\\.\pipe\wwiseutil`},
+	}
+	for _, c := range cases {
+		if got := LongPath(c.path); got != c.want {
+			t.Errorf("LongPath(%q) = %q, expected %q", c.path, got, c.want)
+		}
+	}
+
+	// A relative path is made absolute only once it is too long.
+	if got := LongPath("music.wem"); got != "music.wem" {
+		t.Errorf("Expected a short relative path to be kept, got %q", got)
+	}
+	rel := strings.Repeat(`directory\`, 30) + "music.wem"
+	abs, err := filepath.Abs(rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := LongPath(rel); got != `\\?\`+abs && got != `\\?\UNC\`+abs[2:] {
+		t.Errorf("Expected a long relative path to be made absolute, got %q", got)
+	}
+}
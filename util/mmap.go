@@ -0,0 +1,145 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// The smallest file that OpenMapped memory maps. Smaller files are read
+// directly, as mapping them costs more than the reads it saves.
+const minMappedSize = 1 << 20
+
+var errMmapUnsupported = errors.New("Memory mapping is not supported")
+
+// openMappings holds the files whose contents are mapped by an open
+// MappedFile, so that a file is not truncated beneath its mapping.
+var openMappings = struct {
+	sync.Mutex
+	files map[*MappedFile]os.FileInfo
+}{files: make(map[*MappedFile]os.FileInfo)}
+
+// A MappedFile is a file opened for reading whose contents are memory mapped,
+// where the platform supports it, so that reading from it does not take a
+// system call for every read. Reading from it once it is closed returns
+// os.ErrClosed.
+//
+// The mapping is private, but some platforms, Linux among them, still show
+// later writes to the file through it, just as reading the file directly
+// would.
+type MappedFile struct {
+	ReadSeekerAt
+	file *os.File
+	m    *mappedReader
+}
+
+// A mappedReader reads from mapped memory until it is unmapped, after which
+// every read returns os.ErrClosed rather than touching the unmapped memory.
+type mappedReader struct {
+	mu     sync.RWMutex
+	r      *bytes.Reader
+	unmap  func() error
+	closed bool
+}
+
+func (m *mappedReader) Read(p []byte) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return 0, os.ErrClosed
+	}
+	return m.r.Read(p)
+}
+
+func (m *mappedReader) ReadAt(p []byte, off int64) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return 0, os.ErrClosed
+	}
+	return m.r.ReadAt(p, off)
+}
+
+func (m *mappedReader) Seek(offset int64, whence int) (int64, error) {
+	return m.r.Seek(offset, whence)
+}
+
+func (m *mappedReader) Size() int64 {
+	return m.r.Size()
+}
+
+// close unmaps the memory once every read in progress has finished.
+func (m *mappedReader) close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	return m.unmap()
+}
+
+// OpenMapped opens the file at path for reading, memory mapping it if it is
+// large enough to benefit. If it cannot be mapped, it is read directly
+// instead.
+func OpenMapped(path string) (*MappedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	size := fi.Size()
+
+	mf := &MappedFile{file: f}
+	if size >= minMappedSize && int64(int(size)) == size {
+		if data, unmap, err := mmap(f, int(size)); err == nil {
+			mf.m = &mappedReader{r: bytes.NewReader(data), unmap: unmap}
+			mf.ReadSeekerAt = mf.m
+			openMappings.Lock()
+			openMappings.files[mf] = fi
+			openMappings.Unlock()
+			return mf, nil
+		}
+	}
+	mf.ReadSeekerAt = io.NewSectionReader(f, 0, size)
+	return mf, nil
+}
+
+// IsMapped returns true if the contents of this file are memory mapped.
+func (mf *MappedFile) IsMapped() bool {
+	return mf.m != nil
+}
+
+// IsMappedFile returns true if the file described by fi is memory mapped by a
+// MappedFile that is still open.
+func IsMappedFile(fi os.FileInfo) bool {
+	openMappings.Lock()
+	defer openMappings.Unlock()
+	for _, other := range openMappings.files {
+		if os.SameFile(fi, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close unmaps the contents of this file, if they are mapped, and closes it.
+func (mf *MappedFile) Close() error {
+	var err error
+	if mf.m != nil {
+		openMappings.Lock()
+		delete(openMappings.files, mf)
+		openMappings.Unlock()
+		err = mf.m.close()
+	}
+	if cerr := mf.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package util
+
+import (
+	"os"
+)
+
+// mmap always fails, as memory mapping is not supported on this platform.
+func mmap(f *os.File, size int) ([]byte, func() error, error) {
+	return nil, nil, errMmapUnsupported
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// writeTempFile writes size bytes to a new temporary file, returning its path
+// and contents.
+func writeTempFile(t *testing.T, size int) (string, []byte) {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	f, err := ioutil.TempFile("", "wwiseutil-*.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name(), data
+}
+
+func TestMappedFileRead(t *testing.T) {
+	for _, size := range []int{minMappedSize / 2, minMappedSize + 3} {
+		path, data := writeTempFile(t, size)
+		defer os.Remove(path)
+		mf, err := OpenMapped(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, 16)
+		if _, err := mf.ReadAt(got, int64(size-16)); err != nil {
+			t.Error(err)
+		} else if !bytes.Equal(got, data[size-16:]) {
+			t.Errorf("Read the wrong bytes from a file of %d bytes", size)
+		}
+		if mf.Size() != int64(size) {
+			t.Errorf("Expected a size of %d, got %d", size, mf.Size())
+		}
+		mf.Close()
+	}
+}
+
+func TestMappedFileReadAfterClose(t *testing.T) {
+	path, _ := writeTempFile(t, minMappedSize)
+	defer os.Remove(path)
+	mf, err := OpenMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mf.IsMapped() {
+		t.Skip("Memory mapping is not supported")
+	}
+	if err := mf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 16)
+	if _, err := mf.ReadAt(p, 0); err != os.ErrClosed {
+		t.Errorf("Expected os.ErrClosed from ReadAt, got %v", err)
+	}
+	if _, err := mf.Read(p); err != os.ErrClosed {
+		t.Errorf("Expected os.ErrClosed from Read, got %v", err)
+	}
+}
+
+func TestIsMappedFile(t *testing.T) {
+	path, _ := writeTempFile(t, minMappedSize)
+	defer os.Remove(path)
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mf, err := OpenMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mf.IsMapped() {
+		mf.Close()
+		t.Skip("Memory mapping is not supported")
+	}
+	if !IsMappedFile(fi) {
+		t.Error("Expected an open mapping to be reported")
+	}
+	mf.Close()
+	if IsMappedFile(fi) {
+		t.Error("Expected a closed mapping not to be reported")
+	}
+}
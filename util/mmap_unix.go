@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package util
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the first size bytes of f into memory for reading, returning the
+// mapped bytes and a function that unmaps them. The mapping is private, so that
+// it is never written back to f.
+func mmap(f *os.File, size int) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ,
+		syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package util
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"unsafe"
+)
+
+// mmap maps the first size bytes of f into memory for reading, returning the
+// mapped bytes and a function that unmaps them.
+func mmap(f *os.File, size int) ([]byte, func() error, error) {
+	h, err := syscall.CreateFileMapping(syscall.Handle(f.Fd()), nil,
+		syscall.PAGE_READONLY, uint32(int64(size)>>32), uint32(size), nil)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("CreateFileMapping", err)
+	}
+	addr, err := syscall.MapViewOfFile(h, syscall.FILE_MAP_READ, 0, 0,
+		uintptr(size))
+	if err != nil {
+		syscall.CloseHandle(h)
+		return nil, nil, os.NewSyscallError("MapViewOfFile", err)
+	}
+	var data []byte
+	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&data))
+	hdr.Data, hdr.Len, hdr.Cap = addr, size, size
+	unmap := func() error {
+		err := syscall.UnmapViewOfFile(addr)
+		if cerr := syscall.CloseHandle(h); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	return data, unmap, nil
+}
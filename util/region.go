@@ -0,0 +1,50 @@
+package util
+
+import (
+	"io"
+	"os"
+)
+
+// A FileRegion is a contiguous range of bytes within a file.
+type FileRegion struct {
+	File   *os.File
+	Offset int64
+	Length int64
+}
+
+// RegionOf returns the region of a file that r reads, if r reads directly from
+// an *os.File or a MappedFile through any number of io.SectionReaders,
+// ResettingReaders and IndexCaches. The second value is false if r reads from
+// anything else.
+func RegionOf(r io.Reader) (FileRegion, bool) {
+	region := FileRegion{Length: -1}
+	var cur interface{} = r
+	for {
+		switch v := cur.(type) {
+		case *ResettingReader:
+			cur = v.SectionReader
+		case *io.SectionReader:
+			outer, off, n := v.Outer()
+			if region.Length < 0 {
+				region.Length = n
+			} else if region.Offset+region.Length > n {
+				// The inner reader reads past the end of this one.
+				return FileRegion{}, false
+			}
+			region.Offset += off
+			cur = outer
+		case *IndexCache:
+			cur = v.r
+		case *MappedFile:
+			cur = v.file
+		case *os.File:
+			if region.Length < 0 {
+				return FileRegion{}, false
+			}
+			region.File = v
+			return region, true
+		default:
+			return FileRegion{}, false
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package util
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// The default value of SpillThreshold.
+const DefaultSpillThreshold = 256 << 20
+
+// SpillThreshold is the number of bytes that a SpillBuffer holds in memory.
+// Once more is written to it, its contents are moved to a temporary file.
+var SpillThreshold int64 = DefaultSpillThreshold
+
+// The temporary files of the SpillBuffers that have not been closed.
+var spillFiles = struct {
+	sync.Mutex
+	files map[*os.File]bool
+}{files: make(map[*os.File]bool)}
+
+// A SpillBuffer buffers data that must be read back at any position, such as a
+// container read from a pipe, or a SoundBank written before it is embedded in
+// a File Package. Data is held in memory until it grows past SpillThreshold,
+// and is then spilled to a temporary file, so that buffering a container of
+// many gigabytes needs little memory.
+//
+// A SpillBuffer is written to, and then read from once every write is done. It
+// should be closed once it is no longer read, which removes its temporary file.
+type SpillBuffer struct {
+	threshold int64
+	mem       []byte
+	file      *os.File
+	size      int64
+}
+
+// NewSpillBuffer creates an empty SpillBuffer that spills to a temporary file
+// once it grows past the current SpillThreshold.
+func NewSpillBuffer() *SpillBuffer {
+	return &SpillBuffer{threshold: SpillThreshold}
+}
+
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.size+int64(len(p)) > b.threshold {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+	if b.file != nil {
+		n, err := b.file.WriteAt(p, b.size)
+		b.size += int64(n)
+		return n, err
+	}
+	b.mem = append(b.mem, p...)
+	b.size += int64(len(p))
+	return len(p), nil
+}
+
+// spill moves the contents of b from memory to a temporary file.
+func (b *SpillBuffer) spill() error {
+	f, err := ioutil.TempFile("", "wwiseutil-spill-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b.mem); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	spillFiles.Lock()
+	spillFiles.files[f] = true
+	spillFiles.Unlock()
+	b.file, b.mem = f, nil
+	return nil
+}
+
+func (b *SpillBuffer) ReadAt(p []byte, off int64) (int, error) {
+	if off >= b.size {
+		return 0, io.EOF
+	}
+	if b.file != nil {
+		if rest := b.size - off; int64(len(p)) > rest {
+			n, err := b.file.ReadAt(p[:rest], off)
+			if err == nil {
+				err = io.EOF
+			}
+			return n, err
+		}
+		return b.file.ReadAt(p, off)
+	}
+	n := copy(p, b.mem[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Size returns the number of bytes written to b.
+func (b *SpillBuffer) Size() int64 {
+	return b.size
+}
+
+// Spilled returns true if the contents of b are held in a temporary file,
+// rather than in memory.
+func (b *SpillBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// Close releases the contents of b, removing its temporary file if it has one.
+func (b *SpillBuffer) Close() error {
+	b.mem = nil
+	if b.file == nil {
+		return nil
+	}
+	spillFiles.Lock()
+	delete(spillFiles.files, b.file)
+	spillFiles.Unlock()
+	err := removeSpillFile(b.file)
+	b.file = nil
+	return err
+}
+
+// RemoveSpillFiles removes the temporary files of every SpillBuffer that has
+// not been closed. It should be called before a program exits, as buffers that
+// are read until then are often never closed.
+func RemoveSpillFiles() {
+	spillFiles.Lock()
+	defer spillFiles.Unlock()
+	for f := range spillFiles.files {
+		removeSpillFile(f)
+		delete(spillFiles.files, f)
+	}
+}
+
+func removeSpillFile(f *os.File) error {
+	err := f.Close()
+	if rerr := os.Remove(f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -3,13 +3,18 @@ package util
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
+// The version of wwiseutil.
+const Version = "1.0"
+
 type ContainerType int
 
 const (
@@ -18,9 +23,32 @@ const (
 	FilePackageFileType ContainerType = iota
 )
 
+// The characters that cannot be used in a file name on Windows, which are a
+// superset of those disallowed on POSIX systems.
+const invalidFileNameChars = `<>:"/\|?*`
+
+// The names of devices that Windows reserves, which cannot be used as the name
+// of a file, whatever its extension.
+var reservedFileNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM0": true, "COM1": true, "COM2": true, "COM3": true, "COM4": true,
+	"COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT0": true, "LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true,
+	"LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// The length in bytes past which a sanitized file name is cut short. File
+// systems limit names to 255 bytes or UTF-16 code units, and this leaves room
+// for the suffix and extension that are added to a name once it is sanitized.
+const maxFileNameBytes = 200
+
 var soundBankExtensions = []string{".nbnk", ".bnk"}
 var filePackageExtensions = []string{".npck", ".pck"}
 
+// The identifiers found at the very start of each container type.
+var soundBankMagic = [4]byte{'B', 'K', 'H', 'D'}
+var filePackageMagic = [4]byte{'A', 'K', 'P', 'K'}
+
 // UserHome returns the platform-specific path to the user's home directory.
 func UserHome() string {
 	if runtime.GOOS == "windows" {
@@ -60,6 +88,68 @@ func GetFileType(path string) (t ContainerType, ext string) {
 	return UnknownFileType, ext
 }
 
+// GetStreamType determines what the file type of the container starting at
+// position 0 in r is, based off of its leading identifier.
+func GetStreamType(r io.ReaderAt) ContainerType {
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return UnknownFileType
+	}
+	switch magic {
+	case soundBankMagic:
+		return SoundBankFileType
+	case filePackageMagic:
+		return FilePackageFileType
+	}
+	return UnknownFileType
+}
+
+// SameFile returns true if the paths a and b name the same existing file.
+func SameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
+
+// SanitizeFileName replaces any characters in name that are not allowed in a
+// file name on common platforms, and any bytes that are not valid UTF-8, with
+// an underscore. Other characters, such as those of localized names, are kept.
+// Names that Windows reserves for devices, such as CON or LPT1.wem, have an
+// underscore added after the device name, and names too long for common file
+// systems are cut short.
+func SanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || r == utf8.RuneError ||
+			strings.ContainsRune(invalidFileNameChars, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > maxFileNameBytes {
+		end := maxFileNameBytes
+		for !utf8.RuneStart(name[end]) {
+			end--
+		}
+		name = name[:end]
+	}
+	// Windows does not allow names that end with a space or period.
+	name = strings.TrimRight(name, " .")
+	if name == "" {
+		return "_"
+	}
+	device := name
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		device = name[:i]
+	}
+	if reservedFileNames[strings.ToUpper(strings.TrimRight(device, " "))] {
+		name = device + "_" + name[len(device):]
+	}
+	return name
+}
+
 func contains(sources []string, target string) bool {
 	for _, s := range sources {
 		if s == target {
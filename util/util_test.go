@@ -0,0 +1,42 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	// "é" is two bytes, so a name of them is cut within one at 200 bytes unless
+	// the cut is moved back to the start of a character.
+	long := "a" + strings.Repeat("é", 150)
+	cases := []struct {
+		name, want string
+	}{
+		{"music.wem", "music.wem"},
+		{"ボイス_01.wem", "ボイス_01.wem"},
+		{"a<b>c:d\"e/f\\g|h?i*j.wem", "a_b_c_d_e_f_g_h_i_j.wem"},
+		{"tab\there.wem", "tab_here.wem"},
+		{"CON", "CON_"},
+		{"con", "con_"},
+		{"lpt1.wem", "lpt1_.wem"},
+		{"NUL .txt", "NUL _.txt"},
+		{"COM10.wem", "COM10.wem"},
+		{"CONSOLE.wem", "CONSOLE.wem"},
+		{"name. . ", "name"},
+		{"...", "_"},
+		{"", "_"},
+		{"bad\xffutf8\xc3.wem", "bad_utf8_.wem"},
+		{long, "a" + strings.Repeat("é", 99)},
+	}
+	for _, c := range cases {
+		got := SanitizeFileName(c.name)
+		if got != c.want {
+			t.Errorf("SanitizeFileName(%q) = %q, expected %q", c.name, got, c.want)
+		}
+		if !utf8.ValidString(got) || len(got) > maxFileNameBytes {
+			t.Errorf("SanitizeFileName(%q) = %q, which is not a valid name", c.name,
+				got)
+		}
+	}
+}
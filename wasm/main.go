@@ -0,0 +1,171 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command wasm exposes the SoundBank and File Package parsers to JavaScript, so
+// that wems can be listed and extracted within a browser. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o wwiseutil.wasm wasm
+//
+// and load wwiseutil.wasm with the wasm_exec.js of the Go release it was built
+// with. Once running, it defines a global wwiseutil object with the functions:
+//
+//	open(data)            Opens the SoundBank or File Package held by the
+//	                      Uint8Array data, returning an object with its handle,
+//	                      type, version and wems.
+//	list(handle)          Returns the wems of a container, each with its index,
+//	                      id, offset and length.
+//	extract(handle, i)    Returns the wem at index i as a Uint8Array.
+//	close(handle)         Releases a container.
+//
+// Wem indexes start at 1, as they do elsewhere. A function that fails returns
+// an object whose error member describes the failure.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"syscall/js"
+)
+
+import (
+	"bnk"
+	"pck"
+	"util"
+	"wwise"
+)
+
+// The containers that are open, by their handles. JavaScript calls the
+// functions of this package one at a time, so they are not locked.
+var (
+	containers = make(map[int]wwise.Container)
+	nextHandle = 1
+)
+
+func main() {
+	api := map[string]interface{}{
+		"open":    js.FuncOf(open),
+		"list":    js.FuncOf(list),
+		"extract": js.FuncOf(extract),
+		"close":   js.FuncOf(closeContainer),
+	}
+	js.Global().Set("wwiseutil", js.ValueOf(api))
+	// The functions can only be called for as long as the program runs.
+	select {}
+}
+
+// jsError returns the object that a function returns when it fails.
+func jsError(format string, v ...interface{}) interface{} {
+	return map[string]interface{}{"error": fmt.Sprintf(format, v...)}
+}
+
+// open opens the container held by a Uint8Array. The bytes are copied, and read
+// from memory, so that nothing is read from a file system.
+func open(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || !args[0].InstanceOf(js.Global().Get("Uint8Array")) {
+		return jsError("open expects a Uint8Array")
+	}
+	data := make([]byte, args[0].Length())
+	js.CopyBytesToGo(data, args[0])
+	r := bytes.NewReader(data)
+
+	var ctn wwise.Container
+	var err error
+	typ := ""
+	version := uint32(0)
+	switch util.GetStreamType(r) {
+	case util.SoundBankFileType:
+		var b *bnk.File
+		b, err = bnk.NewFile(r)
+		if err == nil {
+			ctn, typ, version = b, "SoundBank", b.Version()
+		}
+	case util.FilePackageFileType:
+		var p *pck.File
+		p, err = pck.NewFile(r)
+		if err == nil {
+			ctn, typ, version = p, "File Package", p.Header.Version()
+		}
+	default:
+		err = fmt.Errorf("The data is not a SoundBank or File Package")
+	}
+	if err != nil {
+		return jsError("Could not parse .bnk or .pck file: %s", err)
+	}
+
+	handle := nextHandle
+	nextHandle++
+	containers[handle] = ctn
+	return map[string]interface{}{
+		"handle":  handle,
+		"type":    typ,
+		"version": version,
+		"wems":    wems(ctn),
+	}
+}
+
+// lookup returns the container of the handle given by args[0].
+func lookup(args []js.Value) (wwise.Container, error) {
+	if len(args) == 0 || args[0].Type() != js.TypeNumber {
+		return nil, fmt.Errorf("A handle is required")
+	}
+	ctn, ok := containers[args[0].Int()]
+	if !ok {
+		return nil, fmt.Errorf("There is no open container with handle %d",
+			args[0].Int())
+	}
+	return ctn, nil
+}
+
+func list(this js.Value, args []js.Value) interface{} {
+	ctn, err := lookup(args)
+	if err != nil {
+		return jsError("%s", err)
+	}
+	return wems(ctn)
+}
+
+// wems describes the wems of ctn.
+func wems(ctn wwise.Container) []interface{} {
+	var list []interface{}
+	for i, wem := range ctn.Wems() {
+		desc := wem.Descriptor
+		list = append(list, map[string]interface{}{
+			"index":  i + 1,
+			"id":     desc.WemId,
+			"offset": desc.Offset,
+			"length": desc.Length,
+		})
+	}
+	return list
+}
+
+func extract(this js.Value, args []js.Value) interface{} {
+	ctn, err := lookup(args)
+	if err != nil {
+		return jsError("%s", err)
+	}
+	count := len(ctn.Wems())
+	if len(args) != 2 || args[1].Type() != js.TypeNumber ||
+		args[1].Int() < 1 || args[1].Int() > count {
+		return jsError("extract expects a wem index from %d to %d", 1, count)
+	}
+	wem := ctn.Wems()[args[1].Int()-1]
+	data := make([]byte, wem.Descriptor.Length)
+	if _, err := io.ReadFull(wem.NewReader(), data); err != nil {
+		return jsError("Could not read wem %d: %s", args[1].Int(), err)
+	}
+	arr := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(arr, data)
+	return arr
+}
+
+func closeContainer(this js.Value, args []js.Value) interface{} {
+	ctn, err := lookup(args)
+	if err != nil {
+		return jsError("%s", err)
+	}
+	ctn.Close()
+	delete(containers, args[0].Int())
+	return nil
+}
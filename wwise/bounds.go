@@ -0,0 +1,45 @@
+package wwise
+
+import (
+	"fmt"
+)
+
+// A BoundsError is returned when a container describes a part of itself, such
+// as a section, an index or a wem, as extending past the end of the file or of
+// the part that holds it. Such containers are corrupt, or were crafted to make
+// the parser read or allocate more than the file holds, so they are rejected
+// before anything is read from the part.
+type BoundsError struct {
+	// A description of the part, such as "The DIDX section".
+	What string
+	// The offset that the part would end at, and the offset that it must end by.
+	End   int64
+	Limit int64
+}
+
+func (e *BoundsError) Error() string {
+	return fmt.Sprintf("%s would end at offset %d, but must end by offset %d",
+		e.What, e.End, e.Limit)
+}
+
+// An OverflowError is returned when a container would be written with an
+// offset or length larger than the 32 bit field that holds it, as can happen
+// once wems are replaced by much larger ones. The container is not written,
+// rather than written with a value that has wrapped around.
+type OverflowError struct {
+	// A description of the value, such as "The offset of wem 1234".
+	What  string
+	Value int64
+	// A suggestion of how the wems can be stored within the limits of the
+	// format, such as streaming them from a File Package.
+	Hint string
+}
+
+func (e *OverflowError) Error() string {
+	msg := fmt.Sprintf("%s would be %d, past the 4 GiB that its 32 bit field "+
+		"can hold", e.What, e.Value)
+	if e.Hint != "" {
+		msg += "; " + e.Hint
+	}
+	return msg
+}
@@ -5,6 +5,7 @@ package wwise
 import (
 	"fmt"
 	"io"
+	"math"
 	"sort"
 )
 
@@ -13,6 +14,9 @@ import (
 )
 
 type Container interface {
+	// WriteTo streams the container to w. Wems are copied through buffers of a
+	// bounded size, as chosen by util.CopyBufferSize, so the memory used to write
+	// a container does not grow with the size of its wems or of the container.
 	io.WriterTo
 	io.Closer
 	fmt.Stringer
@@ -30,6 +34,27 @@ type Container interface {
 	// begins. DataStart() + WemDescriptor.Length gives you the true offset of a
 	// wem in a file.
 	DataStart() uint32
+
+	// IndexOf returns the index of the wem with the specified id, or -1 if there
+	// is no such wem. If several wems have the id, the first is used. Wems are
+	// found in constant time, rather than by searching Wems().
+	IndexOf(id uint32) int
+
+	// WemById returns the wem with the specified id, or nil if there is no such
+	// wem.
+	WemById(id uint32) *Wem
+
+	// DescriptorById returns the descriptor of the wem with the specified id, or
+	// nil if there is no such wem.
+	DescriptorById(id uint32) *WemDescriptor
+
+	// Warnings returns the findings about this container that do not stop it
+	// from being read and written, in the order they were found.
+	Warnings() []Warning
+
+	// CheckVersion returns a *VersionError if the format version of this
+	// container does not support edit, such as EditWems, or nil if it does.
+	CheckVersion(edit string) error
 }
 
 // A Wem represents a single sound entity contained within a SoundBank file.
@@ -42,6 +67,13 @@ type Wem struct {
 	Padding util.ReadSeekerAt
 }
 
+// NewReader returns a reader over the data of this wem with a position of its
+// own, so that this wem, and the other wems of its container, can be read by
+// several goroutines at once.
+func (wem *Wem) NewReader() io.Reader {
+	return util.NewIndependentReader(wem.Reader)
+}
+
 // A WemDescriptor represents the location of a single wem entity within the
 // SoundBank DATA section.
 type WemDescriptor struct {
@@ -53,6 +85,43 @@ type WemDescriptor struct {
 	Length uint32
 }
 
+// PlacePlaceholders moves each of descs that has a length of 0, which some
+// tools write as a placeholder with an arbitrary offset, to the offset of the
+// next wem with data, or the end of the last one, if its own offset is not
+// between the wems on either side of it. descs are given in the order their
+// wems are stored, between the offsets start and end. Placeholders that are
+// already in order are left where they are. It returns the number of
+// placeholders that were moved.
+//
+// Once moved, a placeholder takes up no space between the wems around it, so
+// the padding of each wem can be found from the offset of the one after it.
+func PlacePlaceholders(descs []*WemDescriptor, start, end int64) int {
+	moved := 0
+	prevEnd := start
+	for i, desc := range descs {
+		if desc.Length != 0 {
+			prevEnd = int64(desc.Offset) + int64(desc.Length)
+			continue
+		}
+		// The offset of the next wem with data, and where the placeholder is
+		// moved to if it is out of order.
+		next, to := end, prevEnd
+		for _, d := range descs[i+1:] {
+			if d.Length != 0 {
+				next, to = int64(d.Offset), int64(d.Offset)
+				break
+			}
+		}
+		off := int64(desc.Offset)
+		if (off < prevEnd || off > next) && to >= prevEnd && to <= math.MaxUint32 {
+			desc.Offset = uint32(to)
+			moved++
+		}
+		prevEnd = int64(desc.Offset)
+	}
+	return moved
+}
+
 // A ReplacementWem defines a wem to be replaced into an original SoundBank File.
 type ReplacementWem struct {
 	// The reader pointing to the contents of the new wem.
@@ -72,17 +141,37 @@ type ByWemIndex struct {
 	ReplacementWems
 }
 
+// SortReplacements returns the replacements of rs in ascending order of their
+// WemIndex. If several replace the same wem, only the last of them in rs is
+// kept, so that the result, and the container it is replaced into, depends
+// only on the order of rs and never on how a sort orders equal indexes.
+func SortReplacements(rs []*ReplacementWem) []*ReplacementWem {
+	last := make(map[int]int)
+	for i, r := range rs {
+		last[r.WemIndex] = i
+	}
+	sorted := make([]*ReplacementWem, 0, len(last))
+	for i, r := range rs {
+		if last[r.WemIndex] == i {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Sort(ByWemIndex{sorted})
+	return sorted
+}
+
 // ReplaceWems replaces the wems of ctn with all the replacements in rs. The
 // ctv is updated to match the new expected lengths and offsets. The amount
 // of additional space taken up by the new wems is returned. This should be
 // used to update the headers of any container as appropriate. If alignment is
 // a non-zero number, padding will be added to the end of wems so that they are
-// aligned with (offset will be divisible by) this number.
+// aligned with (offset will be divisible by) this number. If several
+// replacements replace the same wem, the last of them is used.
 func ReplaceWems(ctn Container, alignment int64, rs ...*ReplacementWem) int64 {
 	// Ammending offsets in case of a surplus in a single pass, in O(n) time, as
 	// opposed to O(n^2), requires that the replacements happen in the order
 	// that their wem will appear in the file; sorting them by index achives this.
-	sort.Sort(ByWemIndex{rs})
+	rs = SortReplacements(rs)
 	// Surplus is the number of bytes a wem offset needs to be increased by or
 	// decreased by because of a change in a previous wem's size.
 	surplus := int64(0)
@@ -98,7 +187,8 @@ func ReplaceWems(ctn Container, alignment int64, rs ...*ReplacementWem) int64 {
 				// Compute the new amount of padding needed to align the next offset
 				// (true end of this wem section) with alignment bytes.
 				padding =
-					(alignment - (int64(wem.Descriptor.Offset)+newLength)%alignment)
+					(alignment - (int64(wem.Descriptor.Offset)+newLength)%alignment) %
+						alignment
 			}
 			// Update the new surplus after changing this wem.
 			// Subsequent wem's will need to have their offsets aligned with the end
@@ -134,6 +224,84 @@ func ReplaceWems(ctn Container, alignment int64, rs ...*ReplacementWem) int64 {
 	return surplus
 }
 
+// CheckOffsets returns an *OverflowError, suggesting hint, if a wem of ctn
+// would start, or be longer, than the 32 bit offsets and lengths of its format
+// can hold, as can happen once a wem is replaced by a larger one. Positions are
+// computed as 64 bit integers from the sizes of the wems, so that a container
+// is never written with an offset that has overflowed.
+func CheckOffsets(ctn Container, hint string) error {
+	wems := ctn.Wems()
+	if len(wems) == 0 {
+		return nil
+	}
+	off := int64(wems[0].Descriptor.Offset)
+	for _, wem := range wems {
+		if off > math.MaxUint32 {
+			return &OverflowError{fmt.Sprintf("The offset of wem %d",
+				wem.Descriptor.WemId), off, hint}
+		}
+		size := wemSize(wem)
+		if size > math.MaxUint32 {
+			return &OverflowError{fmt.Sprintf("The length of wem %d",
+				wem.Descriptor.WemId), size, hint}
+		}
+		off += size
+		if wem.Padding != nil {
+			off += wem.Padding.Size()
+		}
+	}
+	return nil
+}
+
+// DataEnd returns the offset, relative to the DataStart of ctn, at which the
+// last of its wems, and its padding, ends. It is computed as a 64 bit integer
+// from the sizes of the wems, rather than from their offsets.
+func DataEnd(ctn Container) int64 {
+	wems := ctn.Wems()
+	if len(wems) == 0 {
+		return 0
+	}
+	end := int64(wems[0].Descriptor.Offset)
+	for _, wem := range wems {
+		end += wemSize(wem)
+		if wem.Padding != nil {
+			end += wem.Padding.Size()
+		}
+	}
+	return end
+}
+
+// IndexWems returns a map from the id of each of wems to its index, for
+// implementing Container.IndexOf. If several wems have the same id, the first
+// is used.
+func IndexWems(wems []*Wem) map[uint32]int {
+	indexOf := make(map[uint32]int, len(wems))
+	for i := len(wems) - 1; i >= 0; i-- {
+		indexOf[wems[i].Descriptor.WemId] = i
+	}
+	return indexOf
+}
+
+// MatchReplacements returns copies of the replacements in rs, which replace
+// wems of from, that replace the wems with the same ids in to instead. The
+// ids of the replaced wems that to does not have are returned as well, in the
+// order of rs.
+func MatchReplacements(from, to Container,
+	rs []*ReplacementWem) ([]*ReplacementWem, []uint32) {
+	var matched []*ReplacementWem
+	var missing []uint32
+	for _, r := range rs {
+		id := from.Wems()[r.WemIndex].Descriptor.WemId
+		index := to.IndexOf(id)
+		if index < 0 {
+			missing = append(missing, id)
+			continue
+		}
+		matched = append(matched, &ReplacementWem{r.Wem, index, r.Length})
+	}
+	return matched, missing
+}
+
 func (rs ReplacementWems) Len() int {
 	return len(rs)
 }
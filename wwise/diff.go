@@ -0,0 +1,87 @@
+package wwise
+
+import (
+	"crypto/sha256"
+	"sort"
+)
+
+import (
+	"util"
+)
+
+// A WemDiff lists the ids of the wems that differ between two containers.
+type WemDiff struct {
+	// The wems that are only in the new container.
+	Added []uint32
+	// The wems that are only in the old container.
+	Removed []uint32
+	// The wems that are in both containers, with different contents.
+	Changed []uint32
+}
+
+// Empty returns true if the two containers have identical wems.
+func (d *WemDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffWems compares the wems of old and updated, matching them by id and
+// comparing their contents. The ids of each list are in ascending order.
+func DiffWems(old, updated Container) (*WemDiff, error) {
+	oldSums, err := WemChecksums(old)
+	if err != nil {
+		return nil, err
+	}
+	newSums, err := WemChecksums(updated)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(WemDiff)
+	for id, sum := range newSums {
+		oldSum, ok := oldSums[id]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, id)
+		case oldSum != sum:
+			d.Changed = append(d.Changed, id)
+		}
+	}
+	for id := range oldSums {
+		if _, ok := newSums[id]; !ok {
+			d.Removed = append(d.Removed, id)
+		}
+	}
+	sortIds(d.Added)
+	sortIds(d.Removed)
+	sortIds(d.Changed)
+	return d, nil
+}
+
+// WemChecksums returns the SHA-256 of the contents of every wem of ctn, by wem
+// id.
+func WemChecksums(ctn Container) (map[uint32][sha256.Size]byte, error) {
+	sums := make(map[uint32][sha256.Size]byte)
+	for _, wem := range ctn.Wems() {
+		var sum [sha256.Size]byte
+		b, err := checksum(wem)
+		if err != nil {
+			return nil, err
+		}
+		copy(sum[:], b)
+		sums[wem.Descriptor.WemId] = sum
+	}
+	return sums, nil
+}
+
+// checksum returns the SHA-256 of the contents of wem.
+func checksum(wem *Wem) ([]byte, error) {
+	h := sha256.New()
+	if _, err := util.Copy(h, wem.NewReader()); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func sortIds(ids []uint32) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
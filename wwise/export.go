@@ -0,0 +1,141 @@
+package wwise
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+import (
+	"util"
+)
+
+// An ExportFunc writes wem to the file at path, returning the number of bytes
+// written. It may be called by several goroutines at once.
+type ExportFunc func(wem *Wem, path string) (int64, error)
+
+// ExportWem is an ExportFunc that writes wem to the file at path as it is. path
+// may be longer than MAX_PATH on Windows.
+func ExportWem(wem *Wem, path string) (int64, error) {
+	f, err := os.Create(util.LongPath(path))
+	if err != nil {
+		return 0, err
+	}
+	n, err := util.Copy(f, wem.NewReader())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// An ExportError records a wem that could not be exported.
+type ExportError struct {
+	// The index of the wem within the wems being exported, and the path it was
+	// being written to.
+	Index int
+	Path  string
+	Err   error
+}
+
+func (e *ExportError) Error() string {
+	return fmt.Sprintf("Could not write %s: %s", e.Path, e.Err)
+}
+
+// An ExportResult summarizes the wems written by an Exporter.
+type ExportResult struct {
+	// The number of wems that were exported, and the number of bytes written.
+	Exported int
+	Written  int64
+	// The wems that could not be exported, in the order they were given.
+	Errors []*ExportError
+}
+
+// An Exporter writes wems to files, several at once. Each wem is read through
+// its own reader, so wems of the same container are exported in parallel.
+type Exporter struct {
+	// The number of wems exported at once. If it is less than 1, one wem is
+	// exported for each CPU.
+	Workers int
+	// The function that writes each wem. If it is nil, ExportWem is used.
+	Export ExportFunc
+	// Whether to stop once a wem cannot be exported. Otherwise, the remaining
+	// wems are exported, and every error is recorded in the result.
+	StopOnError bool
+}
+
+// Run exports each of wems to the corresponding path of paths. progress, if it
+// is not nil, is called with the number of wems exported as each one finishes;
+// it is never called by more than one goroutine at once.
+//
+// Run returns ErrCancelled if progress cancelled the export, or, if StopOnError
+// is set, the *ExportError of the first wem that could not be exported. Wems
+// that were being exported at the time are finished, and included in the
+// result, before Run returns.
+func (e *Exporter) Run(wems []*Wem, paths []string,
+	progress ProgressFunc) (*ExportResult, error) {
+	export := e.Export
+	if export == nil {
+		export = ExportWem
+	}
+	workers := e.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(wems) {
+		workers = len(wems)
+	}
+
+	res := new(ExportResult)
+	var mu sync.Mutex
+	var stopped error
+	done, total := int64(0), int64(len(wems))
+	finish := func(i int, n int64, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			xerr := &ExportError{i, paths[i], err}
+			res.Errors = append(res.Errors, xerr)
+			if e.StopOnError && stopped == nil {
+				stopped = xerr
+			}
+		} else {
+			res.Exported++
+			res.Written += n
+		}
+		done++
+		if progress != nil && !progress(done, total) && stopped == nil {
+			stopped = ErrCancelled
+		}
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				n, err := export(wems[i], paths[i])
+				finish(i, n, err)
+			}
+		}()
+	}
+	for i := range wems {
+		mu.Lock()
+		stop := stopped != nil
+		mu.Unlock()
+		if stop {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	sort.Slice(res.Errors, func(i, j int) bool {
+		return res.Errors[i].Index < res.Errors[j].Index
+	})
+	return res, stopped
+}
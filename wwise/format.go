@@ -0,0 +1,267 @@
+// Package wwise implements access and modification iterfaces and functions to
+// common WWise container formats.
+package wwise
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The codecs that a wem can be encoded with, as identified by the format tag of
+// its fmt chunk.
+const (
+	CodecPCM        = 0x0001
+	CodecADPCM      = 0x0002
+	CodecExtensible = 0xFFFE
+	CodecVorbis     = 0xFFFF
+	CodecOpus       = 0x3041
+	CodecWwiseOpus  = 0x3040
+	CodecAAC        = 0xAAC0
+)
+
+// The number of samples stored in each channel of a Wwise IMA ADPCM block.
+const adpcmSamplesPerBlock = 64
+
+// The offset of the sample count within the fmt chunk of a Vorbis wem that
+// stores its vorb data within the fmt chunk.
+const vorbisSampleCountOffset = 0x18
+
+// ErrEmptyWem is returned when the format of a wem with no data is read. Such
+// wems are placeholders that some SoundBanks and File Packages include.
+var ErrEmptyWem = errors.New("The wem is empty; it is a placeholder with no " +
+	"audio")
+
+var riffId = [4]byte{'R', 'I', 'F', 'F'}
+var waveId = [4]byte{'W', 'A', 'V', 'E'}
+var fmtChunkId = [4]byte{'f', 'm', 't', ' '}
+var dataChunkId = [4]byte{'d', 'a', 't', 'a'}
+var vorbChunkId = [4]byte{'v', 'o', 'r', 'b'}
+var smplChunkId = [4]byte{'s', 'm', 'p', 'l'}
+
+// The offset of the loop count within a smpl chunk, and of the first loop,
+// whose start and end follow its cue point id and type.
+const (
+	smplLoopCountOffset = 0x1C
+	smplFirstLoopOffset = 0x24
+)
+
+var codecNames = map[uint16]string{
+	CodecPCM:        "PCM",
+	CodecADPCM:      "ADPCM",
+	CodecExtensible: "PCM",
+	CodecVorbis:     "Vorbis",
+	CodecOpus:       "Opus",
+	CodecWwiseOpus:  "Opus",
+	CodecAAC:        "AAC",
+}
+
+// A WemFormat describes how the audio of a wem is encoded, as read from its
+// RIFF header.
+type WemFormat struct {
+	Codec             uint16
+	Channels          uint16
+	SampleRate        uint32
+	AvgBytesPerSecond uint32
+	BlockAlign        uint16
+	BitsPerSample     uint16
+	// The number of samples in each channel, if it is stored in the header. This
+	// is 0 if it is not known.
+	SampleCount uint32
+	// The offset of the audio data from the start of the wem.
+	DataOffset int64
+	// The length in bytes of the audio data.
+	DataLength uint32
+	// Whether the wem has loop points, and the first and last samples of the
+	// region that is repeated when it loops.
+	HasLoopPoints bool
+	LoopStart     uint32
+	LoopEnd       uint32
+}
+
+// The leading fields of a fmt chunk that are common to every codec.
+type fmtChunk struct {
+	Codec             uint16
+	Channels          uint16
+	SampleRate        uint32
+	AvgBytesPerSecond uint32
+	BlockAlign        uint16
+	BitsPerSample     uint16
+}
+
+type chunkHeader struct {
+	Identifier [4]byte
+	Length     uint32
+}
+
+// ReadWemFormat reads the format of the wem of length size that starts at
+// position 0 in r.
+// ErrEmptyWem is returned if size is 0.
+func ReadWemFormat(r io.ReaderAt, size int64) (*WemFormat, error) {
+	if size == 0 {
+		return nil, ErrEmptyWem
+	}
+	sr := io.NewSectionReader(r, 0, size)
+	var riff struct {
+		chunkHeader
+		Form [4]byte
+	}
+	err := binary.Read(sr, binary.LittleEndian, &riff)
+	if err != nil {
+		return nil, err
+	}
+	if riff.Identifier != riffId || riff.Form != waveId {
+		return nil, errors.New("The wem does not have a RIFF WAVE header")
+	}
+
+	f := new(WemFormat)
+	foundFmt := false
+	offset := int64(12)
+	for offset < size {
+		hdr := chunkHeader{}
+		err := binary.Read(io.NewSectionReader(r, offset, 8), binary.LittleEndian,
+			&hdr)
+		if err != nil {
+			break
+		}
+		data := io.NewSectionReader(r, offset+8, int64(hdr.Length))
+
+		switch hdr.Identifier {
+		case fmtChunkId:
+			c := fmtChunk{}
+			err = binary.Read(data, binary.LittleEndian, &c)
+			if err != nil {
+				return nil, err
+			}
+			f.Codec, f.Channels, f.SampleRate = c.Codec, c.Channels, c.SampleRate
+			f.AvgBytesPerSecond, f.BlockAlign = c.AvgBytesPerSecond, c.BlockAlign
+			f.BitsPerSample = c.BitsPerSample
+			if c.Codec == CodecVorbis && hdr.Length > vorbisSampleCountOffset+4 {
+				f.SampleCount, err = readUint32At(data, vorbisSampleCountOffset)
+				if err != nil {
+					return nil, err
+				}
+			}
+			foundFmt = true
+		case vorbChunkId:
+			f.SampleCount, err = readUint32At(data, 0)
+			if err != nil {
+				return nil, err
+			}
+		case smplChunkId:
+			if hdr.Length < smplFirstLoopOffset+16 {
+				break
+			}
+			count, err := readUint32At(data, smplLoopCountOffset)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				f.LoopStart, err = readUint32At(data, smplFirstLoopOffset+8)
+				if err != nil {
+					return nil, err
+				}
+				f.LoopEnd, err = readUint32At(data, smplFirstLoopOffset+12)
+				if err != nil {
+					return nil, err
+				}
+				f.HasLoopPoints = true
+			}
+		case dataChunkId:
+			f.DataOffset, f.DataLength = offset+8, hdr.Length
+		}
+		// Chunks are aligned to an even number of bytes.
+		offset += 8 + int64(hdr.Length) + int64(hdr.Length&1)
+	}
+
+	if !foundFmt {
+		return nil, errors.New("The wem does not have a fmt chunk")
+	}
+	return f, nil
+}
+
+func readUint32At(r io.ReaderAt, off int64) (uint32, error) {
+	var b [4]byte
+	_, err := r.ReadAt(b[:], off)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+// Format reads the format of this wem from its RIFF header.
+func (wem *Wem) Format() (*WemFormat, error) {
+	r, ok := wem.Reader.(io.ReaderAt)
+	if !ok {
+		return nil, errors.New("The wem does not support random access")
+	}
+	return ReadWemFormat(r, int64(wem.Descriptor.Length))
+}
+
+// CodecName returns a human readable name for the codec of this format.
+func (f *WemFormat) CodecName() string {
+	if name, ok := codecNames[f.Codec]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (0x%04X)", f.Codec)
+}
+
+// Samples returns the number of samples in each channel. If the sample count
+// is not stored in the header, it is computed from the length of the audio
+// data where the codec allows.
+func (f *WemFormat) Samples() uint32 {
+	if f.SampleCount != 0 {
+		return f.SampleCount
+	}
+	switch f.Codec {
+	case CodecPCM, CodecExtensible:
+		if f.BlockAlign != 0 {
+			return f.DataLength / uint32(f.BlockAlign)
+		}
+	case CodecADPCM:
+		if f.BlockAlign != 0 {
+			return f.DataLength / uint32(f.BlockAlign) * adpcmSamplesPerBlock
+		}
+	}
+	return 0
+}
+
+// Duration returns the length of the audio, or 0 if it cannot be determined.
+func (f *WemFormat) Duration() time.Duration {
+	if f.SampleRate == 0 {
+		return 0
+	}
+	if samples := f.Samples(); samples != 0 {
+		return time.Duration(samples) * time.Second / time.Duration(f.SampleRate)
+	}
+	if f.AvgBytesPerSecond != 0 {
+		// Estimate the duration of variable bitrate codecs by their average
+		// bitrate.
+		return time.Duration(f.DataLength) * time.Second /
+			time.Duration(f.AvgBytesPerSecond)
+	}
+	return 0
+}
+
+// Mismatches returns a description of each way in which the format of a
+// replacement, given by other, differs from this format in its codec, channel
+// count or sample rate. Games often fail to play a replacement that differs in
+// any of these.
+func (f *WemFormat) Mismatches(other *WemFormat) []string {
+	var ms []string
+	if codecNames[f.Codec] != codecNames[other.Codec] {
+		ms = append(ms, fmt.Sprintf("codec %s instead of %s", other.CodecName(),
+			f.CodecName()))
+	}
+	if f.Channels != other.Channels {
+		ms = append(ms, fmt.Sprintf("%d channel(s) instead of %d",
+			other.Channels, f.Channels))
+	}
+	if f.SampleRate != other.SampleRate {
+		ms = append(ms, fmt.Sprintf("%d Hz instead of %d Hz", other.SampleRate,
+			f.SampleRate))
+	}
+	return ms
+}
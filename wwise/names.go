@@ -0,0 +1,246 @@
+// Package wwise implements access and modification iterfaces and functions to
+// common WWise container formats.
+package wwise
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A NameDatabase maps the numeric ids used within Wwise containers to the
+// friendly names given to them in the Wwise project.
+type NameDatabase struct {
+	// A mapping from the id of any object (a bank, event or wem) to its name.
+	names map[uint32]string
+	// A mapping from wem id to the short name of the bank that includes it.
+	bankOf map[uint32]string
+}
+
+// The subset of a SoundbanksInfo.xml file, generated by Wwise alongside its
+// SoundBanks, that is needed to resolve names.
+type soundBanksInfo struct {
+	SoundBanks    []soundBankInfo `xml:"SoundBanks>SoundBank"`
+	StreamedFiles []fileInfo      `xml:"StreamedFiles>File"`
+}
+
+type soundBankInfo struct {
+	Id              uint32      `xml:"Id,attr"`
+	ShortName       string      `xml:"ShortName"`
+	Events          []eventInfo `xml:"IncludedEvents>Event"`
+	MemoryFiles     []fileInfo  `xml:"IncludedMemoryFiles>File"`
+	StreamedFiles   []fileInfo  `xml:"ReferencedStreamedFiles>File"`
+	PrefetchedFiles []fileInfo  `xml:"IncludedPrefetchFiles>File"`
+}
+
+type eventInfo struct {
+	Id   uint32 `xml:"Id,attr"`
+	Name string `xml:"Name,attr"`
+}
+
+type fileInfo struct {
+	Id        uint32 `xml:"Id,attr"`
+	ShortName string `xml:"ShortName"`
+}
+
+// NewNameDatabase creates a new, empty NameDatabase.
+func NewNameDatabase() *NameDatabase {
+	return &NameDatabase{make(map[uint32]string), make(map[uint32]string)}
+}
+
+// ReadSoundBanksInfo reads a NameDatabase from the contents of a
+// SoundbanksInfo.xml file.
+func ReadSoundBanksInfo(r io.Reader) (*NameDatabase, error) {
+	info := new(soundBanksInfo)
+	err := xml.NewDecoder(r).Decode(info)
+	if err != nil {
+		return nil, err
+	}
+
+	db := NewNameDatabase()
+	for _, f := range info.StreamedFiles {
+		db.names[f.Id] = f.ShortName
+	}
+	for _, bank := range info.SoundBanks {
+		db.names[bank.Id] = bank.ShortName
+		for _, e := range bank.Events {
+			db.names[e.Id] = e.Name
+		}
+
+		var files []fileInfo
+		files = append(files, bank.MemoryFiles...)
+		files = append(files, bank.StreamedFiles...)
+		files = append(files, bank.PrefetchedFiles...)
+		for _, f := range files {
+			if f.ShortName != "" {
+				db.names[f.Id] = f.ShortName
+			}
+			db.bankOf[f.Id] = bank.ShortName
+		}
+	}
+	return db, nil
+}
+
+// The subset of a SoundbanksInfo.json file that is needed to resolve names.
+// Newer versions of Wwise list the wems of a bank as Media, rather than by how
+// they are stored.
+type soundBanksInfoJSON struct {
+	SoundBanksInfo struct {
+		SoundBanks []struct {
+			Id              jsonId          `json:"Id"`
+			ShortName       string          `json:"ShortName"`
+			Events          []eventInfoJSON `json:"IncludedEvents"`
+			Media           []fileInfoJSON  `json:"Media"`
+			MemoryFiles     []fileInfoJSON  `json:"IncludedMemoryFiles"`
+			StreamedFiles   []fileInfoJSON  `json:"ReferencedStreamedFiles"`
+			PrefetchedFiles []fileInfoJSON  `json:"IncludedPrefetchFiles"`
+		} `json:"SoundBanks"`
+		StreamedFiles []fileInfoJSON `json:"StreamedFiles"`
+	} `json:"SoundBanksInfo"`
+}
+
+type eventInfoJSON struct {
+	Id   jsonId `json:"Id"`
+	Name string `json:"Name"`
+}
+
+type fileInfoJSON struct {
+	Id        jsonId `json:"Id"`
+	ShortName string `json:"ShortName"`
+}
+
+// A jsonId is an id within a SoundbanksInfo.json file, which Wwise writes as a
+// string of digits.
+type jsonId uint32
+
+func (id *jsonId) UnmarshalJSON(b []byte) error {
+	n, err := strconv.ParseUint(strings.Trim(string(b), `"`), 10, 32)
+	if err != nil {
+		return err
+	}
+	*id = jsonId(n)
+	return nil
+}
+
+// ReadSoundBanksInfoJSON reads a NameDatabase from the contents of a
+// SoundbanksInfo.json file.
+func ReadSoundBanksInfoJSON(r io.Reader) (*NameDatabase, error) {
+	info := new(soundBanksInfoJSON)
+	err := json.NewDecoder(r).Decode(info)
+	if err != nil {
+		return nil, err
+	}
+
+	db := NewNameDatabase()
+	for _, f := range info.SoundBanksInfo.StreamedFiles {
+		db.names[uint32(f.Id)] = f.ShortName
+	}
+	for _, bank := range info.SoundBanksInfo.SoundBanks {
+		db.names[uint32(bank.Id)] = bank.ShortName
+		for _, e := range bank.Events {
+			db.names[uint32(e.Id)] = e.Name
+		}
+
+		var files []fileInfoJSON
+		files = append(files, bank.Media...)
+		files = append(files, bank.MemoryFiles...)
+		files = append(files, bank.StreamedFiles...)
+		files = append(files, bank.PrefetchedFiles...)
+		for _, f := range files {
+			if f.ShortName != "" {
+				db.names[uint32(f.Id)] = f.ShortName
+			}
+			db.bankOf[uint32(f.Id)] = bank.ShortName
+		}
+	}
+	return db, nil
+}
+
+// A declaration of an id within a Wwise_IDs.h file, such as
+// "static const AkUniqueID PLAY_FOOTSTEP = 1234567890U;".
+var wwiseIdPattern = regexp.MustCompile(
+	`static\s+const\s+AkUniqueID\s+(\w+)\s*=\s*(\d+)U?\s*;`)
+
+// ReadWwiseIds reads a NameDatabase from the contents of a Wwise_IDs.h file.
+// These files name banks, events and other objects, but not wems.
+func ReadWwiseIds(r io.Reader) (*NameDatabase, error) {
+	db := NewNameDatabase()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := wwiseIdPattern.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		id, err := strconv.ParseUint(m[2], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		db.names[uint32(id)] = m[1]
+	}
+	return db, scanner.Err()
+}
+
+// OpenNameDatabase reads a NameDatabase from the file at the specified path,
+// which may be a SoundbanksInfo.xml or SoundbanksInfo.json file, or a
+// Wwise_IDs.h file. The format is chosen by the extension of the file.
+func OpenNameDatabase(path string) (*NameDatabase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ReadSoundBanksInfoJSON(f)
+	case ".h":
+		return ReadWwiseIds(f)
+	}
+	return ReadSoundBanksInfo(f)
+}
+
+// OpenSoundBanksInfo reads a NameDatabase from the SoundbanksInfo.xml file at
+// the specified path.
+func OpenSoundBanksInfo(path string) (*NameDatabase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSoundBanksInfo(f)
+}
+
+// NameOf returns the name of the object with the specified id. ok is false if
+// the id has no known name.
+func (db *NameDatabase) NameOf(id uint32) (name string, ok bool) {
+	if db == nil {
+		return "", false
+	}
+	name, ok = db.names[id]
+	return
+}
+
+// WemNameOf returns the name of the wem with the specified id, without its
+// original extension. ok is false if the wem has no known name.
+func (db *NameDatabase) WemNameOf(id uint32) (name string, ok bool) {
+	name, ok = db.NameOf(id)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)), true
+}
+
+// BankOf returns the short name of the bank that includes the wem with the
+// specified id. ok is false if the bank is not known.
+func (db *NameDatabase) BankOf(id uint32) (bank string, ok bool) {
+	if db == nil {
+		return "", false
+	}
+	bank, ok = db.bankOf[id]
+	return
+}
@@ -0,0 +1,55 @@
+package wwise
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// An OpenFunc opens the container at path, stopping once ctx is done.
+type OpenFunc func(ctx context.Context, path string) (Container, error)
+
+// OpenAll opens each of paths with open, up to workers at once, and calls each
+// with the index of every path, and the container opened from it or the error
+// that prevented it from being opened, in which case the container is nil. If
+// workers is less than 1, one container is opened for each CPU. Parsing a
+// container is mostly bound by the CPU, so opening many at once keeps the disk
+// busy rather than a single core.
+//
+// each is called by several goroutines at once, as each container is opened,
+// so a container may be used while others are still being parsed. It must
+// close the container once it is done with it. Every path is given to open
+// with ctx, so once ctx is done the remaining containers fail to open, and are
+// passed to each with the error. OpenAll returns once every call to each has
+// returned.
+func OpenAll(ctx context.Context, paths []string, workers int, open OpenFunc,
+	each func(i int, ctn Container, err error)) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				ctn, err := open(ctx, paths[i])
+				if err != nil {
+					// open may return a nil pointer of the type of its container.
+					ctn = nil
+				}
+				each(i, ctn, err)
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
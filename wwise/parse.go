@@ -0,0 +1,44 @@
+package wwise
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// A ParseError records where in a container parsing failed, so that a
+// container that cannot be read can be understood from the error alone.
+type ParseError struct {
+	// A description of the part being parsed, such as "HIRC object 217", and
+	// the offset from the start of the container at which the part begins.
+	Part   string
+	Offset int64
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	msg := e.Err.Error()
+	if e.Err == io.EOF || e.Err == io.ErrUnexpectedEOF {
+		msg = "short read"
+	}
+	return fmt.Sprintf("%s at 0x%X: %s", e.Part, e.Offset, msg)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// WrapParseError returns err as a *ParseError of the part described by part,
+// which begins at offset. err is returned as it is if it is nil, if it is
+// already a *ParseError of a part within this one, or if it records that
+// parsing was cancelled rather than that it failed.
+func WrapParseError(err error, part string, offset int64) error {
+	switch err {
+	case nil, ErrCancelled, context.Canceled, context.DeadlineExceeded:
+		return err
+	}
+	if _, ok := err.(*ParseError); ok {
+		return err
+	}
+	return &ParseError{part, offset, err}
+}
@@ -0,0 +1,200 @@
+package wwise
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+import (
+	"util"
+)
+
+// The size of the blocks that WriteInPlace compares with the existing contents
+// of the file before rewriting them.
+const patchBlockSize = 256 << 10
+
+// ErrNotInPlace is returned by WriteInPlace when writing the container would
+// move data that is read from the file being written over, or would shorten a
+// file that is memory mapped.
+var ErrNotInPlace = errors.New("The changes move data within the file, so " +
+	"they cannot be written in place")
+
+// A patchRecorder records what a container writes without writing it; the
+// wems as pieces, which are read once they are written, and everything else as
+// chunks of bytes, which are small enough to be kept in memory.
+type patchRecorder struct {
+	pos    int64
+	chunks []*patchChunk
+	pieces []*piece
+}
+
+// A patchChunk is a contiguous run of bytes written by a container at off.
+type patchChunk struct {
+	off  int64
+	data []byte
+}
+
+func (rec *patchRecorder) Write(p []byte) (int, error) {
+	if n := len(rec.chunks); n > 0 {
+		last := rec.chunks[n-1]
+		if last.off+int64(len(last.data)) == rec.pos {
+			last.data = append(last.data, p...)
+			rec.pos += int64(len(p))
+			return len(p), nil
+		}
+	}
+	data := append([]byte(nil), p...)
+	rec.chunks = append(rec.chunks, &patchChunk{rec.pos, data})
+	rec.pos += int64(len(p))
+	return len(p), nil
+}
+
+// addWems records wems, and their padding, as pieces written at the current
+// position.
+func (rec *patchRecorder) addWems(wems []*Wem) (int64, error) {
+	start := rec.pos
+	rec.pieces = append(rec.pieces, splitPieces(wems, start)...)
+	for _, wem := range wems {
+		rec.pos += wemSize(wem)
+		if wem.Padding != nil {
+			rec.pos += wem.Padding.Size()
+		}
+	}
+	return rec.pos - start, nil
+}
+
+// WriteInPlace writes ctn over the existing file f, which must be open for
+// reading and writing, rewriting only the parts of f whose bytes change. Wems
+// that ctn reads from f at the position they are written to are not read at
+// all, so saving a container over the file it was opened from costs little more
+// than writing the wems that were replaced. Every other wem is compared with
+// the bytes already in f, block by block.
+//
+// ErrNotInPlace is returned, and f is left unchanged, if a wem that ctn reads
+// from f would be written at a different position, or if f would be shortened
+// while an open util.MappedFile maps it, as reading the mapping past the new
+// end of f would then fault. Data that ctn reads from f
+// may have been overwritten once WriteInPlace returns, so ctn should be opened
+// again from f before it is used further.
+//
+// progress, if it is not nil, is called as each wem is written. Writing can not
+// be cancelled, as that would leave f only partly written, so the value that
+// progress returns is ignored. WriteInPlace returns the size of the written
+// container, and the number of bytes of f that were rewritten.
+func WriteInPlace(ctn Container, f *os.File,
+	progress ProgressFunc) (total, rewritten int64, err error) {
+	rec := new(patchRecorder)
+	total, err = ctn.WriteTo(rec)
+	if err != nil {
+		return 0, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Pieces read from f at their own position are already in place. Any other
+	// piece read from f would be overwritten before, or while, it is read.
+	same := make(map[*os.File]bool)
+	isTarget := func(src *os.File) bool {
+		if is, ok := same[src]; ok {
+			return is
+		}
+		other, err := src.Stat()
+		same[src] = err == nil && os.SameFile(info, other)
+		return same[src]
+	}
+	var pending []*piece
+	for _, p := range rec.pieces {
+		if p.inFile && isTarget(p.region.File) {
+			if p.region.Offset != p.off {
+				return 0, 0, ErrNotInPlace
+			}
+			continue
+		}
+		pending = append(pending, p)
+	}
+
+	if total < info.Size() && util.IsMappedFile(info) {
+		return 0, 0, ErrNotInPlace
+	}
+	if info.Size() != total {
+		if err := f.Truncate(total); err != nil {
+			return 0, 0, err
+		}
+	}
+	block, existing := make([]byte, patchBlockSize), make([]byte, patchBlockSize)
+	for _, c := range rec.chunks {
+		n, err := patchAt(f, c.off, bytes.NewReader(c.data), block, existing)
+		rewritten += n
+		if err != nil {
+			return total, rewritten, err
+		}
+	}
+	for i, p := range pending {
+		var r io.Reader = util.NewIndependentReader(p.r)
+		if p.inFile {
+			r = io.NewSectionReader(p.region.File, p.region.Offset, p.region.Length)
+		}
+		lr := &countingReader{r: r}
+		n, err := patchAt(f, p.off, lr, block, existing)
+		rewritten += n
+		if err != nil {
+			return total, rewritten, err
+		}
+		if lr.n != p.size {
+			return total, rewritten, fmt.Errorf("Wem %d was %d bytes long, but %d "+
+				"bytes were expected", p.wem.Descriptor.WemId, lr.n, p.size)
+		}
+		if progress != nil {
+			progress(int64(i+1), int64(len(pending)))
+		}
+	}
+	return total, rewritten, nil
+}
+
+// patchAt writes the bytes read from r to f at off, in blocks, rewriting only
+// the blocks that differ from those already in f. block and existing, of the
+// same length, are used to hold each block of r and of f. It returns the number
+// of bytes rewritten.
+func patchAt(f *os.File, off int64, r io.Reader, block,
+	existing []byte) (rewritten int64, err error) {
+	for {
+		n, rerr := io.ReadFull(r, block)
+		if n > 0 {
+			m, err := f.ReadAt(existing[:n], off)
+			if err != nil && err != io.EOF {
+				return rewritten, err
+			}
+			if m < n || !bytes.Equal(existing[:n], block[:n]) {
+				if _, err := f.WriteAt(block[:n], off); err != nil {
+					return rewritten, err
+				}
+				rewritten += int64(n)
+			}
+			off += int64(n)
+		}
+		switch rerr {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			return rewritten, nil
+		default:
+			return rewritten, rerr
+		}
+	}
+}
+
+// A countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
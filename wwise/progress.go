@@ -0,0 +1,183 @@
+package wwise
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// A ProgressFunc is called as a long running operation advances, with the
+// amount of work that has been done and the total amount of work. Returning
+// false cancels the operation.
+type ProgressFunc func(done, total int64) bool
+
+// ErrCancelled is returned by an operation that was cancelled by its
+// ProgressFunc.
+var ErrCancelled = errors.New("The operation was cancelled")
+
+// A progressWriter reports the number of bytes written through it.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	return n, pw.advance(int64(n), err)
+}
+
+// advance records that n more bytes have been written, reporting the progress
+// unless err is not nil. It returns err, or ErrCancelled if writing should
+// stop.
+func (pw *progressWriter) advance(n int64, err error) error {
+	pw.written += n
+	done := pw.written
+	if done > pw.total {
+		done = pw.total
+	}
+	if err == nil && !pw.progress(done, pw.total) {
+		err = ErrCancelled
+	}
+	return err
+}
+
+// A progressWriterAt is a progressWriter for a destination that can be written
+// to at any position, which may be written to by several goroutines at once.
+// Progress is only ever reported by one goroutine at a time.
+type progressWriterAt struct {
+	mu sync.Mutex
+	pw *progressWriter
+	w  WriteSeekerAt
+}
+
+func (pw *progressWriterAt) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	return pw.pw.Write(p)
+}
+
+func (pw *progressWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := pw.w.WriteAt(p, off)
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	return n, pw.pw.advance(int64(n), err)
+}
+
+func (pw *progressWriterAt) Seek(offset int64, whence int) (int64, error) {
+	return pw.w.Seek(offset, whence)
+}
+
+func (pw *progressWriterAt) file() *os.File {
+	f, _ := pw.w.(*os.File)
+	return f
+}
+
+func (pw *progressWriterAt) copied(n int64) error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	return pw.pw.advance(n, nil)
+}
+
+// WriteWithProgress writes the full contents of ctn to w, calling progress as
+// the data of its wems is written. If progress returns false, writing stops
+// and ErrCancelled is returned. The bytes already written to w are not
+// removed.
+func WriteWithProgress(ctn Container, w io.Writer,
+	progress ProgressFunc) (int64, error) {
+	if progress == nil {
+		return ctn.WriteTo(w)
+	}
+	// The end of the last wem and its padding is close enough to the size of
+	// the container for reporting progress, without reading any of its data.
+	total := int64(0)
+	for _, wem := range ctn.Wems() {
+		desc := wem.Descriptor
+		end := int64(ctn.DataStart()) + int64(desc.Offset) + int64(desc.Length)
+		if wem.Padding != nil {
+			end += wem.Padding.Size()
+		}
+		if end > total {
+			total = end
+		}
+	}
+	pw := &progressWriter{w: w, total: total, progress: progress}
+	var dst io.Writer = pw
+	if ws, ok := w.(WriteSeekerAt); ok {
+		// Keep the destination able to have wems written to it concurrently.
+		dst = &progressWriterAt{pw: pw, w: ws}
+	}
+	n, err := ctn.WriteTo(dst)
+	if err == nil {
+		progress(total, total)
+	}
+	return n, err
+}
+
+// An OpenProgress describes how much of a container has been parsed.
+type OpenProgress struct {
+	// The number of bytes of the file that have been parsed, and the size of the
+	// file, or 0 if it is not known. The data of wems is never read while
+	// parsing; it counts as parsed once it has been passed over.
+	Parsed int64
+	Size   int64
+	// The number of sections that have been parsed. A File Package is parsed as
+	// three sections: its header, its index, and its entries.
+	Sections int
+}
+
+// An OpenProgressFunc is called as a container is parsed.
+type OpenProgressFunc func(p OpenProgress)
+
+// An OpenMonitor reports the progress of parsing a container, and stops the
+// parsing once its context is done. The methods of a nil *OpenMonitor do
+// nothing, so that containers can be parsed without one.
+type OpenMonitor struct {
+	ctx      context.Context
+	progress OpenProgressFunc
+	status   OpenProgress
+}
+
+// NewOpenMonitor returns an OpenMonitor for parsing a file of the specified
+// size, which reports to progress, if it is not nil, until ctx is done.
+func NewOpenMonitor(ctx context.Context, size int64,
+	progress OpenProgressFunc) *OpenMonitor {
+	return &OpenMonitor{ctx: ctx, progress: progress,
+		status: OpenProgress{Size: size}}
+}
+
+// Parsed records that the file has been parsed up to offset. It returns the
+// error of the context of m once it is done, in which case parsing should stop.
+func (m *OpenMonitor) Parsed(offset int64) error {
+	if m == nil {
+		return nil
+	}
+	return m.report(offset, 0)
+}
+
+// SectionParsed records that a section, which ends at offset, has been parsed.
+// It returns an error as Parsed does.
+func (m *OpenMonitor) SectionParsed(offset int64) error {
+	if m == nil {
+		return nil
+	}
+	return m.report(offset, 1)
+}
+
+func (m *OpenMonitor) report(offset int64, sections int) error {
+	if err := m.ctx.Err(); err != nil {
+		return err
+	}
+	if m.status.Size > 0 && offset > m.status.Size {
+		offset = m.status.Size
+	}
+	m.status.Parsed = offset
+	m.status.Sections += sections
+	if m.progress != nil {
+		m.progress(m.status)
+	}
+	return nil
+}
@@ -5,8 +5,12 @@ package wwise
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"io"
+	"io/ioutil"
 	"os"
+	"runtime"
+	"sync"
 	"testing"
 )
 
@@ -187,3 +191,106 @@ func (rts replacementTest) Expand(org Container) []*ReplacementWem {
 	}
 	return rs
 }
+
+// The most memory that writing a container may allocate, however large its
+// wems are.
+const maxWriteAllocation = 64 << 20
+
+// A discardSeeker is a WriteSeekerAt that discards everything written to it.
+type discardSeeker struct {
+	mu  sync.Mutex
+	pos int64
+}
+
+func (d *discardSeeker) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pos += int64(len(p))
+	return len(p), nil
+}
+
+func (d *discardSeeker) WriteAt(p []byte, off int64) (int, error) {
+	return len(p), nil
+}
+
+func (d *discardSeeker) Seek(offset int64, whence int) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+		d.pos = offset
+	case io.SeekCurrent:
+		d.pos += offset
+	default:
+		return 0, errors.New("The end of a discardSeeker is unknown")
+	}
+	return d.pos, nil
+}
+
+// AssertWriteIsStreamed writes ctn, which is at least min bytes long, both
+// sequentially and to a destination that can be written to at any position,
+// and fails if either write allocated memory in proportion to the size of ctn,
+// rather than a bounded amount for its buffers.
+func AssertWriteIsStreamed(t *testing.T, ctn Container, min int64) {
+	writers := []struct {
+		name string
+		w    io.Writer
+	}{
+		// Hide the ReadFrom method of ioutil.Discard, so that wems are copied
+		// through buffers.
+		{"sequential", struct{ io.Writer }{ioutil.Discard}},
+		{"at positions", new(discardSeeker)},
+	}
+	for _, w := range writers {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		n, err := ctn.WriteTo(w.w)
+		runtime.ReadMemStats(&after)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n < min {
+			t.Errorf("Writing %s wrote %d bytes, but at least %d were expected",
+				w.name, n, min)
+		}
+		alloc := after.TotalAlloc - before.TotalAlloc
+		if alloc > maxWriteAllocation {
+			t.Errorf("Writing %d bytes %s allocated %d bytes, more than the "+
+				"bound of %d", n, w.name, alloc, maxWriteAllocation)
+		}
+	}
+}
+
+// AssertLookupsMatchWems fails if finding the wems of ctn by their ids gives a
+// different result than searching Wems() for the first wem with each id.
+func AssertLookupsMatchWems(t *testing.T, ctn Container) {
+	wems := ctn.Wems()
+	first := make(map[uint32]int)
+	missing := uint32(1)
+	for i, wem := range wems {
+		id := wem.Descriptor.WemId
+		if _, ok := first[id]; !ok {
+			first[id] = i
+		}
+		if id >= missing {
+			missing = id + 1
+		}
+	}
+	for id, i := range first {
+		if got := ctn.IndexOf(id); got != i {
+			t.Errorf("Expected wem %d to be at index %d, got %d", id, i, got)
+		}
+		if wem := ctn.WemById(id); wem != wems[i] {
+			t.Errorf("WemById(%d) did not return the wem at index %d", id, i)
+		}
+		if desc := ctn.DescriptorById(id); desc != wems[i].Descriptor {
+			t.Errorf("DescriptorById(%d) did not return the descriptor of the wem "+
+				"at index %d", id, i)
+		}
+	}
+	if ctn.IndexOf(missing) != -1 || ctn.WemById(missing) != nil ||
+		ctn.DescriptorById(missing) != nil {
+		t.Errorf("Expected wem %d not to be found", missing)
+	}
+}
@@ -0,0 +1,78 @@
+package wwise
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// A VerifyError is returned by Verify when the container written to a file
+// does not match the container that it was written from.
+type VerifyError struct {
+	Path string
+	// A description of how the written container differs, such as "it holds 3
+	// wems, rather than 4".
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("The file written to %s failed verification: %s", e.Path,
+		e.Reason)
+}
+
+// Verify re-opens the file at path, to which ctn was just written, with open,
+// and checks that it holds what ctn does: the same number of wems, each with
+// the same id and contents, and the same sections and descriptors, as given by
+// the String of each container. A save that is corrupt is found at once,
+// rather than once the file is loaded by the game.
+//
+// progress, if it is not nil, is called as the contents of each wem are
+// compared; if it returns false, ErrCancelled is returned. A *VerifyError is
+// returned if the written container differs from ctn.
+func Verify(ctx context.Context, ctn Container, path string, open OpenFunc,
+	progress ProgressFunc) error {
+	written, err := open(ctx, path)
+	if err != nil {
+		return &VerifyError{path, fmt.Sprintf("it could not be re-opened: %s",
+			err)}
+	}
+	defer written.Close()
+
+	want, got := ctn.Wems(), written.Wems()
+	if len(got) != len(want) {
+		return &VerifyError{path, fmt.Sprintf("it holds %d wems, rather than %d",
+			len(got), len(want))}
+	}
+	for i, wem := range want {
+		id, gotId := wem.Descriptor.WemId, got[i].Descriptor.WemId
+		if gotId != id {
+			return &VerifyError{path, fmt.Sprintf("the wem at index %d has id %d, "+
+				"rather than %d", i, gotId, id)}
+		}
+	}
+	if ctn.String() != written.String() {
+		return &VerifyError{path, "its sections or wem descriptors differ from " +
+			"those that were written"}
+	}
+
+	total := int64(len(want))
+	for i, wem := range want {
+		sum, err := checksum(wem)
+		if err != nil {
+			return err
+		}
+		gotSum, err := checksum(got[i])
+		if err != nil {
+			return &VerifyError{path, fmt.Sprintf("wem %d could not be read: %s",
+				wem.Descriptor.WemId, err)}
+		}
+		if !bytes.Equal(sum, gotSum) {
+			return &VerifyError{path, fmt.Sprintf("the contents of wem %d differ "+
+				"from those that were written", wem.Descriptor.WemId)}
+		}
+		if progress != nil && !progress(int64(i+1), total) {
+			return ErrCancelled
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package wwise
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The edits of a container whose support depends on its format version.
+const (
+	EditWems  = "replace wems"
+	EditLoops = "edit loops"
+)
+
+// A VersionError is returned when an edit is requested of a container whose
+// format version does not support it, rather than writing a container that
+// the game may misread. The container can still be read.
+type VersionError struct {
+	// The type of the container, such as "SoundBank", and its version.
+	Container string
+	Version   uint32
+	// The edit that was requested, such as EditLoops, and the versions that
+	// support it.
+	Edit      string
+	Supported []uint32
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s version %d is not supported to %s; the supported "+
+		"versions are %s. The %s can still be opened, and its wems listed and "+
+		"exported", e.Container, e.Version, e.Edit,
+		FormatVersions(e.Supported), e.Container)
+}
+
+// FormatVersions returns a list of versions for messages, such as "120 and
+// 132".
+func FormatVersions(versions []uint32) string {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = fmt.Sprintf("%d", v)
+	}
+	if len(parts) < 2 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " +
+		parts[len(parts)-1]
+}
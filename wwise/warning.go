@@ -0,0 +1,78 @@
+package wwise
+
+import (
+	"fmt"
+)
+
+// A Severity ranks how much a Warning may matter to edits of a container.
+type Severity int
+
+const (
+	// SeverityNotice is a part of the container that is kept as it was read,
+	// and does not affect edits.
+	SeverityNotice Severity = iota
+	// SeverityCaution is a part of the container that was read leniently, or
+	// that this package does not fully understand. Edits should be verified in
+	// game.
+	SeverityCaution
+	// SeverityProblem is an inconsistency within the container, which may make
+	// the game fail to load it whether or not it is edited.
+	SeverityProblem
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityNotice:
+		return "notice"
+	case SeverityCaution:
+		return "caution"
+	case SeverityProblem:
+		return "problem"
+	}
+	return fmt.Sprintf("severity %d", int(s))
+}
+
+// The kinds of Warning, which stay the same between releases so that
+// warnings can be filtered, unlike their messages.
+const (
+	WarningUntestedVersion  = "untested-version"
+	WarningUnknownSection   = "unknown-section"
+	WarningLengthMismatch   = "length-mismatch"
+	WarningTrailingData     = "trailing-data"
+	WarningMovedPlaceholder = "moved-placeholder"
+	WarningOverlap          = "overlap"
+	WarningOutOfOrder       = "out-of-order"
+	WarningRepeatedId       = "repeated-id"
+	WarningUnknownObject    = "unknown-object"
+	WarningUndecodedObject  = "undecoded-object"
+	WarningHierarchy        = "hierarchy"
+	WarningUnreadableBank   = "unreadable-bank"
+)
+
+// A Warning is a finding about a container that does not stop it from being
+// read and written, such as a section that is not decoded, a part that was
+// read leniently, or a value that is inconsistent with the rest of the
+// container.
+type Warning struct {
+	Severity Severity
+	// The kind of finding, one of the Warning constants, such as
+	// WarningUnknownSection.
+	Kind    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Severity, w.Message)
+}
+
+// MaxSeverity returns the highest severity of warnings, or -1 if there are
+// none.
+func MaxSeverity(warnings []Warning) Severity {
+	max := Severity(-1)
+	for _, w := range warnings {
+		if w.Severity > max {
+			max = w.Severity
+		}
+	}
+	return max
+}
@@ -0,0 +1,313 @@
+package wwise
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+import (
+	"util"
+)
+
+// The largest number of wems that WriteWems copies at once.
+const writeWorkers = 4
+
+// The largest block of a file that is copied directly at once, so that the
+// progress of copying large regions can be reported.
+const regionBlockSize = 64 << 20
+
+// A WriteSeekerAt is a destination that can be written to at any position,
+// such as an *os.File.
+type WriteSeekerAt interface {
+	io.Writer
+	io.WriterAt
+	io.Seeker
+}
+
+// An offsetWriter writes sequentially to an io.WriterAt, starting at off.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.WriteAt(p, ow.off)
+	ow.off += int64(n)
+	return n, err
+}
+
+// WriteWems writes each of wems, followed by its padding, to w in order. If w
+// is a WriteSeekerAt, the wems are copied by several workers at once, each
+// writing to the position of its wem, and w is left seeked to the end of the
+// last wem. This is much faster when wems are read from different files, as
+// replacements are. If w is also an *os.File, wems that are unchanged are
+// copied directly from the file they were read from, without passing through
+// memory where the platform allows.
+func WriteWems(w io.Writer, wems []*Wem) (written int64, err error) {
+	if rec, ok := w.(*patchRecorder); ok {
+		return rec.addWems(wems)
+	}
+	if ws, ok := w.(WriteSeekerAt); ok {
+		// Destinations that cannot seek, such as pipes, are written to
+		// sequentially.
+		if start, err := ws.Seek(0, io.SeekCurrent); err == nil {
+			return writeWemsAt(ws, start, wems)
+		}
+	}
+	for _, wem := range wems {
+		n, err := util.Copy(w, wem.NewReader())
+		if err != nil {
+			return written, err
+		}
+		written += n
+		if wem.Padding == nil {
+			continue
+		}
+		n, err = util.Copy(w, util.NewIndependentReader(wem.Padding))
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+func writeWemsAt(w WriteSeekerAt, start int64, wems []*Wem) (int64, error) {
+	pieces := splitPieces(wems, start)
+	end := start
+	if n := len(pieces); n > 0 {
+		end = pieces[n-1].off + pieces[n-1].size
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	jobs := make(chan *piece)
+	var wg sync.WaitGroup
+	for worker := 0; worker < writeWorkers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pw := newPieceWriter(w)
+			defer pw.close()
+			for p := range jobs {
+				if failed() {
+					continue
+				}
+				if err := pw.write(p); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, p := range pieces {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	if _, err := w.Seek(end, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - start, nil
+}
+
+// A piece is a part of the wems of a container that is written at once; either
+// the data of a wem or its padding. Consecutive pieces that are read from
+// adjacent regions of the same file are merged, so that unchanged wems are
+// copied in as few large blocks as possible.
+type piece struct {
+	r io.Reader
+	// The wem that this piece begins with.
+	wem *Wem
+	// The size of this piece, and its position within the destination.
+	size int64
+	off  int64
+	// The region of a file that this piece is read from, if inFile is true.
+	region util.FileRegion
+	inFile bool
+}
+
+// splitPieces splits wems, and their padding, into the pieces that they are
+// written as, starting at position start in the destination.
+func splitPieces(wems []*Wem, start int64) []*piece {
+	var pieces []*piece
+	off := start
+	add := func(wem *Wem, r io.Reader, size int64) {
+		region, inFile := util.RegionOf(r)
+		inFile = inFile && region.Length == size
+		if n := len(pieces); n > 0 && inFile {
+			last := pieces[n-1]
+			if last.inFile && last.region.File == region.File &&
+				last.region.Offset+last.region.Length == region.Offset {
+				last.region.Length += size
+				last.size += size
+				off += size
+				return
+			}
+		}
+		pieces = append(pieces, &piece{r, wem, size, off, region, inFile})
+		off += size
+	}
+	for _, wem := range wems {
+		add(wem, wem.Reader, wemSize(wem))
+		if wem.Padding != nil && wem.Padding.Size() > 0 {
+			add(wem, wem.Padding, wem.Padding.Size())
+		}
+	}
+	return pieces
+}
+
+// A fileWriter is a destination that writes to a file, into which data may be
+// copied directly from other files. copied is called with the number of bytes
+// each time that data is copied.
+type fileWriter interface {
+	file() *os.File
+	copied(n int64) error
+}
+
+// A pieceWriter writes pieces to their positions within a destination. Each
+// worker of writeWemsAt uses its own pieceWriter.
+type pieceWriter struct {
+	w io.WriterAt
+	// A separate handle to the destination, if it is a file, whose offset is
+	// used to copy regions of other files into it directly, and the function to
+	// call once they are copied.
+	dst    *os.File
+	copied func(n int64) error
+	// Separate handles to the files that regions are copied from, opened on
+	// first use. A file maps to nil if it could not be opened again.
+	srcs map[*os.File]*os.File
+}
+
+func newPieceWriter(w io.WriterAt) *pieceWriter {
+	pw := &pieceWriter{w: w, srcs: make(map[*os.File]*os.File)}
+	switch w := w.(type) {
+	case *os.File:
+		pw.dst = reopen(w, os.O_WRONLY)
+	case fileWriter:
+		if f := w.file(); f != nil {
+			pw.dst, pw.copied = reopen(f, os.O_WRONLY), w.copied
+		}
+	}
+	return pw
+}
+
+// reopen opens the file f again with the specified flags, so that the new
+// handle has its own offset. It returns nil if f cannot be opened again.
+func reopen(f *os.File, flag int) *os.File {
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+	g, err := os.OpenFile(f.Name(), flag, 0)
+	if err != nil {
+		return nil
+	}
+	// The file may have been renamed or removed since it was opened.
+	if other, err := g.Stat(); err != nil || !os.SameFile(info, other) {
+		g.Close()
+		return nil
+	}
+	return g
+}
+
+func (pw *pieceWriter) write(p *piece) error {
+	var n int64
+	var err error
+	switch src := pw.source(p); {
+	case src != nil:
+		n, err = pw.copyFile(src, p)
+	case p.inFile:
+		r := io.NewSectionReader(p.region.File, p.region.Offset, p.region.Length)
+		n, err = util.Copy(&offsetWriter{pw.w, p.off}, r)
+	default:
+		n, err = util.Copy(&offsetWriter{pw.w, p.off},
+			util.NewIndependentReader(p.r))
+	}
+	if err != nil {
+		return err
+	}
+	if n != p.size {
+		return fmt.Errorf("Wem %d was %d bytes long, but %d bytes were expected",
+			p.wem.Descriptor.WemId, n, p.size)
+	}
+	return nil
+}
+
+// source returns the handle to copy p from directly, or nil if it cannot be.
+func (pw *pieceWriter) source(p *piece) *os.File {
+	if !p.inFile || pw.dst == nil {
+		return nil
+	}
+	src, ok := pw.srcs[p.region.File]
+	if !ok {
+		src = reopen(p.region.File, os.O_RDONLY)
+		pw.srcs[p.region.File] = src
+	}
+	return src
+}
+
+// copyFile copies the region of p from src to the destination, in blocks of at
+// most regionBlockSize bytes. The copy is made by the operating system where
+// it supports it.
+func (pw *pieceWriter) copyFile(src *os.File, p *piece) (int64, error) {
+	if _, err := pw.dst.Seek(p.off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := src.Seek(p.region.Offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	written := int64(0)
+	for written < p.size {
+		block := p.size - written
+		if block > regionBlockSize {
+			block = regionBlockSize
+		}
+		n, err := pw.dst.ReadFrom(io.LimitReader(src, block))
+		written += n
+		if err == nil && pw.copied != nil {
+			err = pw.copied(n)
+		}
+		if err != nil {
+			return written, err
+		}
+		if n < block {
+			break
+		}
+	}
+	return written, nil
+}
+
+func (pw *pieceWriter) close() {
+	if pw.dst != nil {
+		pw.dst.Close()
+	}
+	for _, src := range pw.srcs {
+		if src != nil {
+			src.Close()
+		}
+	}
+}
+
+// wemSize returns the number of bytes that will be read from the reader of
+// wem.
+func wemSize(wem *Wem) int64 {
+	if s, ok := wem.Reader.(interface{ Size() int64 }); ok {
+		return s.Size()
+	}
+	return int64(wem.Descriptor.Length)
+}